@@ -44,6 +44,33 @@ describe 'otel-collector-windows' do
     let(:properties) { { 'limits' => { 'memory_mib' => '512' } } }
     let(:rendered) { JSON.load(template.render(properties)) }
 
+    it 'points the collector to the rendered instance spec' do
+      expect(rendered['processes'][0]['env']['OTELCOL_BOSH_SPEC_FILE']).to eq('/var/vcap/jobs/otel-collector-windows/config/bosh/spec.json')
+    end
+
+    it 'points the collector to the allow list to apply to configuration fragments' do
+      expect(rendered['processes'][0]['env']['OTELCOL_CF_ALLOW_LIST_FILE']).to eq('/var/vcap/jobs/otel-collector-windows/config/allow_list.yml')
+    end
+
+    describe 'config_fragments' do
+      it 'only loads the config by default' do
+        expect(rendered['processes'][0]['args']).to eq(['--config', '/var/vcap/jobs/otel-collector-windows/config/config.yml'])
+      end
+
+      context 'when config_fragments is set' do
+        before do
+          properties['config_fragments'] = '/var/vcap/jobs/*/config/otel-collector.d/*.yml'
+        end
+
+        it 'loads the fragments of co-located jobs before the config' do
+          expect(rendered['processes'][0]['args']).to eq([
+            '--config', 'fragments:/var/vcap/jobs/*/config/otel-collector.d/*.yml',
+            '--config', '/var/vcap/jobs/otel-collector-windows/config/config.yml'
+          ])
+        end
+      end
+    end
+
     describe 'limits' do
       describe 'memory' do
         context 'when not provided' do
@@ -12,11 +12,142 @@ describe 'otel-collector' do
 
   it_behaves_like 'common config.yml'
 
+  describe 'monit' do
+    let(:spec) { job.instance_variable_get(:@spec) }
+    let(:job_path) { job.instance_variable_get(:@job_path) }
+    let(:template) { Bosh::Template::Test::Template.new(spec, File.join(job_path, 'monit')) }
+    let(:properties) { { 'config' => { 'extensions' => { 'health' => {} }, 'service' => { 'extensions' => [] } } } }
+    let(:rendered) { template.render(properties) }
+
+    it 'only checks the pid without the health extension' do
+      expect(rendered).to include('check process otel-collector')
+      expect(rendered).not_to include('if failed')
+    end
+
+    context 'when the health extension is enabled' do
+      before do
+        properties['config']['service']['extensions'] = ['health']
+      end
+
+      it 'restarts the collector when it is not ready' do
+        expect(rendered).to include('if failed host 127.0.0.1 port 13133 protocol http request "/ready" for 3 cycles then restart')
+      end
+
+      it 'only alerts when the collector is unhealthy' do
+        expect(rendered).to include('if failed host 127.0.0.1 port 13133 protocol http request "/health" for 3 cycles then alert')
+        expect(rendered).not_to include('"/health" for 3 cycles then restart')
+      end
+
+      context 'with a custom endpoint and paths' do
+        before do
+          properties['config']['extensions']['health'] = { 'endpoint' => '[::1]:8080', 'path' => '/healthz', 'readiness_path' => '/readyz' }
+        end
+
+        it 'checks the configured endpoint' do
+          expect(rendered).to include('if failed host ::1 port 8080 protocol http request "/readyz" for 3 cycles then restart')
+          expect(rendered).to include('if failed host ::1 port 8080 protocol http request "/healthz" for 3 cycles then alert')
+        end
+      end
+    end
+
+    context 'when the config is a string' do
+      let(:properties) { { 'config' => "extensions:\n  health/monit:\nservice:\n  extensions: [health/monit]\n" } }
+
+      it 'checks the health endpoint' do
+        expect(rendered).to include('if failed host 127.0.0.1 port 13133 protocol http request "/ready"')
+        expect(rendered).to include('if failed host 127.0.0.1 port 13133 protocol http request "/health"')
+      end
+    end
+  end
+
   describe 'config/bpm.yml' do
     let(:template) { job.template('config/bpm.yml') }
     let(:properties) { { 'limits' => { 'memory_mib' => '512', 'cpu' => '1' } } }
     let(:rendered) { YAML.safe_load(template.render(properties)) }
 
+    it 'mounts the ephemeral disk for the disk storage extension' do
+      expect(rendered['processes'][0]['ephemeral_disk']).to be(true)
+    end
+
+    it 'points the collector to the secrets to interpolate' do
+      expect(rendered['processes'][0]['env']['OTELCOL_CF_SECRETS_FILE']).to eq('/var/vcap/jobs/otel-collector/config/secrets.yml')
+    end
+
+    it 'points the collector to the rendered instance spec, as BPM does not mount /var/vcap/bosh' do
+      expect(rendered['processes'][0]['env']['OTELCOL_BOSH_SPEC_FILE']).to eq('/var/vcap/jobs/otel-collector/config/bosh/spec.json')
+    end
+
+    it 'mounts the directory with the stemcell version read-only, as BPM does not mount /var/vcap/bosh' do
+      expect(rendered['processes'][0]['unrestricted_volumes']).to include({ 'path' => '/var/vcap/bosh/etc', 'mount_only' => true })
+    end
+
+    it 'points the collector to the allow list to apply to configuration fragments' do
+      expect(rendered['processes'][0]['env']['OTELCOL_CF_ALLOW_LIST_FILE']).to eq('/var/vcap/jobs/otel-collector/config/allow_list.yml')
+    end
+
+    describe 'config_fragments' do
+      it 'only loads the config by default' do
+        expect(rendered['processes'][0]['args']).to eq(['--config', '/var/vcap/jobs/otel-collector/config/config.yml'])
+      end
+
+      it 'mounts no fragment directories by default' do
+        expect(rendered['processes'][0]['unrestricted_volumes']).to eq([{ 'path' => '/var/vcap/bosh/etc', 'mount_only' => true }])
+      end
+
+      context 'when config_fragments is set' do
+        before do
+          properties['config_fragments'] = '/var/vcap/jobs/*/config/otel-collector.d/*.yml'
+        end
+
+        it 'loads the fragments of co-located jobs before the config' do
+          expect(rendered['processes'][0]['args']).to eq([
+            '--config', 'fragments:/var/vcap/jobs/*/config/otel-collector.d/*.yml',
+            '--config', '/var/vcap/jobs/otel-collector/config/config.yml'
+          ])
+        end
+
+        it 'mounts the directories of the fragments read-only' do
+          expect(rendered['processes'][0]['unrestricted_volumes']).to eq([
+            { 'path' => '/var/vcap/bosh/etc', 'mount_only' => true },
+            { 'path' => '/var/vcap/jobs/*/config/otel-collector.d' }
+          ])
+        end
+      end
+    end
+
+    describe 'credhub' do
+      it 'does not connect to CredHub by default' do
+        expect(rendered['processes'][0]['env'].keys).not_to include(a_string_starting_with('CREDHUB_'))
+      end
+
+      context 'when credhub.url is set' do
+        before do
+          properties['credhub'] = {
+            'url' => 'https://credhub.service.cf.internal:8844',
+            'ca_cert' => 'ca-pem',
+            'tls' => { 'cert' => 'cert-pem', 'key' => 'key-pem' }
+          }
+        end
+
+        it 'passes the connection to the CredHub provider' do
+          expect(rendered['processes'][0]['env']).to include(
+            'CREDHUB_SERVER' => 'https://credhub.service.cf.internal:8844',
+            'CREDHUB_CA_CERT' => '/var/vcap/jobs/otel-collector/config/certs/credhub-ca.crt',
+            'CREDHUB_CLIENT_CERT' => '/var/vcap/jobs/otel-collector/config/certs/credhub-client.crt',
+            'CREDHUB_CLIENT_KEY' => '/var/vcap/jobs/otel-collector/config/certs/credhub-client.key'
+          )
+          expect(rendered['processes'][0]['env']).not_to have_key('CREDHUB_CLIENT')
+        end
+
+        it 'passes the UAA client when one is set' do
+          properties['credhub']['uaa_client'] = 'otel-collector'
+          properties['credhub']['uaa_client_secret'] = 'uaa-secret'
+
+          expect(rendered['processes'][0]['env']).to include('CREDHUB_CLIENT' => 'otel-collector', 'CREDHUB_SECRET' => 'uaa-secret')
+        end
+      end
+    end
+
     describe 'limits' do
       describe 'memory' do
         context 'when not provided' do
@@ -3,6 +3,7 @@
 require 'rspec'
 require 'bosh/template/test'
 require 'yaml'
+require 'json'
 
 shared_examples_for 'common config.yml' do
   describe 'config/config.yml' do
@@ -213,7 +214,9 @@ shared_examples_for 'common config.yml' do
                     'client_ca_file' => "#{config_path}/certs/otel-collector-ca.crt",
                     'cert_file' => "#{config_path}/certs/otel-collector.crt",
                     'key_file' => "#{config_path}/certs/otel-collector.key",
-                    'min_version' => '1.3'
+                    'min_version' => '1.3',
+                    'reload_interval' => '1m',
+                    'client_ca_file_reload' => true
                   }
                 }
               }
@@ -274,6 +277,106 @@ shared_examples_for 'common config.yml' do
             expect(builtin_otlp_receiver['protocols']['grpc']['endpoint']).to eq('0.0.0.0:9100')
           end
         end
+
+        it 'does not authenticate senders by default' do
+          expect(builtin_otlp_receiver['protocols']['grpc']).not_to have_key('auth')
+          expect(rendered['extensions'].keys).to eq(['pprof'])
+          expect(rendered['service']['pipelines']['traces']['processors']).to eq(['batch'])
+        end
+
+        context 'when ingress.grpc.allowed_identities is set' do
+          before do
+            properties['ingress'] = {
+              'grpc' => {
+                'allowed_identities' => ['forwarder-agent.service.cf.internal'],
+                'source_ids' => { 'forwarder-agent.service.cf.internal' => ['gorouter'] }
+              }
+            }
+          end
+
+          it 'authenticates senders by their client certificates' do
+            expect(builtin_otlp_receiver['protocols']['grpc']['auth']).to eq({ 'authenticator' => 'clientcertauth/cf-internal-local' })
+            expect(rendered['extensions']['clientcertauth/cf-internal-local']).to eq({ 'allowed_identities' => ['forwarder-agent.service.cf.internal'] })
+            expect(rendered['service']['extensions']).to eq(['pprof', 'clientcertauth/cf-internal-local'])
+          end
+
+          it 'adds the sender identity first in every pipeline' do
+            expect(rendered['processors']['senderidentity/cf-internal-local']).to eq({ 'source_ids' => { 'forwarder-agent.service.cf.internal' => ['gorouter'] } })
+            expect(rendered['service']['pipelines']['traces']['processors']).to eq(['senderidentity/cf-internal-local', 'batch'])
+            expect(rendered['service']['pipelines']['metrics']['processors']).to eq(['senderidentity/cf-internal-local', 'batch'])
+            expect(rendered['service']['pipelines']['logs']['processors']).to eq(['senderidentity/cf-internal-local', 'batch'])
+          end
+
+          context 'when a pipeline also receives from a connector' do
+            before do
+              config['connectors'] = { 'redmetrics' => nil }
+              config['service']['pipelines']['traces']['exporters'] = ['otlp', 'redmetrics']
+              config['service']['pipelines']['metrics']['receivers'] = ['otlp/placeholder', 'redmetrics']
+            end
+
+            it 'errors as the senders of the internal receiver could not be checked' do
+              expect { rendered }.to raise_error(/Pipeline metrics receives from connectors \["redmetrics"\] next to the internal receiver, which is not supported when ingress.grpc.allowed_identities is set/)
+            end
+          end
+
+          context 'when a pipeline only receives from a connector' do
+            before do
+              config['connectors'] = { 'redmetrics' => nil }
+              config['service']['pipelines']['traces']['exporters'] = ['otlp', 'redmetrics']
+              config['service']['pipelines']['metrics']['receivers'] = ['redmetrics']
+            end
+
+            it 'does not check the sender identity in it' do
+              expect(rendered['service']['pipelines']['metrics']['receivers']).to eq(['redmetrics'])
+              expect(rendered['service']['pipelines']['metrics']['processors']).to eq(['batch'])
+              expect(rendered['service']['pipelines']['traces']['processors']).to eq(['senderidentity/cf-internal-local', 'batch'])
+            end
+          end
+        end
+
+        context 'when ingress.apps.enabled is true' do
+          let(:apps_receiver) { rendered['receivers']['otlp/cf-internal-apps'] }
+
+          before do
+            properties['ingress'] = { 'apps' => { 'enabled' => true } }
+            config['service']['pipelines']['metrics/foo'] = {
+              'receivers' => ['otlp/placeholder'],
+              'processors' => ['batch'],
+              'exporters' => ['otlp']
+            }
+          end
+
+          it 'receives from apps authenticated by their instance identity' do
+            expect(apps_receiver['protocols']['grpc']['endpoint']).to eq('0.0.0.0:9101')
+            expect(apps_receiver['protocols']['grpc']['tls']['client_ca_file']).to eq("#{config_path}/certs/instance-identity-ca.crt")
+            expect(apps_receiver['protocols']['grpc']['auth']).to eq({ 'authenticator' => 'instanceidentityauth/cf-internal-apps' })
+            expect(rendered['extensions']['instanceidentityauth/cf-internal-apps']).to eq({ 'ca_file' => "#{config_path}/certs/instance-identity-ca.crt" })
+            expect(rendered['service']['extensions']).to eq(['pprof', 'instanceidentityauth/cf-internal-apps'])
+          end
+
+          it 'copies every pipeline for the telemetry of apps' do
+            expect(rendered['service']['pipelines']['metrics/cf-internal-apps']).to eq(
+              {
+                'receivers' => ['otlp/cf-internal-apps'],
+                'processors' => ['senderidentity/cf-internal-apps', 'batch'],
+                'exporters' => ['otlp']
+              }
+            )
+            expect(rendered['service']['pipelines']['metrics/foo-cf-internal-apps']['receivers']).to eq(['otlp/cf-internal-apps'])
+            expect(rendered['service']['pipelines']['metrics']['receivers']).to eq(['otlp/cf-internal-local'])
+          end
+
+          context 'when ingress.grpc.allowed_identities is also set' do
+            before do
+              properties['ingress']['grpc'] = { 'allowed_identities' => ['forwarder-agent'] }
+            end
+
+            it 'only adds the sender identity of apps to the copies' do
+              expect(rendered['service']['pipelines']['logs']['processors']).to eq(['senderidentity/cf-internal-local', 'batch'])
+              expect(rendered['service']['pipelines']['logs/cf-internal-apps']['processors']).to eq(['senderidentity/cf-internal-apps', 'batch'])
+            end
+          end
+        end
       end
     end
 
@@ -309,8 +412,8 @@ shared_examples_for 'common config.yml' do
           config['processors']['batch/cf-internal-foo'] = nil
         end
 
-        it 'raises an error' do
-          expect { rendered }.to raise_error(/Processors cannot be defined under cf-internal namespace/)
+        it 'leaves rejecting it to the collector' do
+          expect(rendered['processors']).to have_key('batch/cf-internal-foo')
         end
       end
 
@@ -402,8 +505,8 @@ shared_examples_for 'common config.yml' do
             'endpoint' => '203.0.113.10:4317'
           }
         end
-        it 'raises an error' do
-          expect { rendered }.to raise_error(/Exporters cannot be defined under cf-internal namespace/)
+        it 'leaves rejecting it to the collector' do
+          expect(rendered['exporters']).to have_key('otlp/cf-internal-foo')
         end
       end
     end
@@ -449,6 +552,90 @@ shared_examples_for 'common config.yml' do
         config['extensions']['unavailable'] = nil
         expect { rendered }.to raise_error(/The following configured extensions are not included in this OpenTelemetry Collector distribution: \["unavailable"\]/)
       end
+
+      context 'when an extension uses the reserved namespace' do
+        before do
+          config['extensions']['pprof/cf-internal-foo'] = nil
+        end
+
+        it 'leaves rejecting it to the collector' do
+          expect(rendered['extensions']).to have_key('pprof/cf-internal-foo')
+        end
+      end
+
+      it 'only adds components in the reserved namespace the collector allows' do
+        properties['ingress'] = { 'grpc' => { 'allowed_identities' => ['forwarder-agent'] }, 'apps' => { 'enabled' => true } }
+        allowed = YAML.load_file(File.join(release_dir, 'src/components/converter/cfconverter/internal_components.yaml'))
+
+        %w[exporters processors extensions].each do |kind|
+          rendered.fetch(kind, {}).keys.select { |id| id.include?('/cf-internal') }.each do |id|
+            expect(allowed.fetch(kind, [])).to include(id), "#{id} is not allowed by the cfconverter"
+          end
+        end
+      end
+    end
+
+    describe 'connectors' do
+      before do
+        config['connectors'] = { 'redmetrics' => nil }
+        config['service']['pipelines']['traces']['exporters'] = ['otlp', 'redmetrics']
+        config['service']['pipelines']['metrics']['receivers'] = ['otlp/placeholder', 'redmetrics']
+      end
+
+      it 'list of available connectors matches builder source of truth' do
+        config['connectors']['unavailable'] = nil
+
+        builder_config = YAML.load_file(File.join(release_dir, "src/otel-collector-builder/config.yaml"))
+        connector_gomods = builder_config.fetch('connectors').map {|entry| entry.fetch('gomod').split(" ")[0]}
+        connector_names = connector_gomods.map do |gomod|
+          YAML.load_file(File.join(release_dir, "src/otel-collector/vendor", gomod, "metadata.yaml")).fetch('type')
+        end
+        formatted_names = connector_names.sort.map {|name| "\"#{name}\"" }.join(", ")
+
+        expect { rendered }.to raise_error do |error|
+          expect(error.message).to include("Available: [#{formatted_names}]")
+        end
+      end
+
+      it 'includes the configured connectors in the config' do
+        expect(rendered['connectors']).to eq(config['connectors'])
+      end
+
+      it 'keeps connectors as receivers next to the internal receiver' do
+        expect(rendered['service']['pipelines']['metrics']['receivers']).to eq(['otlp/cf-internal-local', 'redmetrics'])
+        expect(rendered['service']['pipelines']['traces']['exporters']).to eq(['otlp', 'redmetrics'])
+      end
+
+      context 'when a pipeline only receives from connectors' do
+        before do
+          config['connectors']['attributerouting'] = { 'default_pipeline' => 'logs' }
+          config['service']['pipelines']['logs']['exporters'] = ['attributerouting']
+          config['service']['pipelines']['logs/isoseg'] = {
+            'receivers' => ['attributerouting'],
+            'exporters' => ['otlp']
+          }
+        end
+
+        it 'does not add the internal receiver to it' do
+          expect(rendered['service']['pipelines']['logs/isoseg']['receivers']).to eq(['attributerouting'])
+          expect(rendered['service']['pipelines']['logs']['receivers']).to eq(['otlp/cf-internal-local'])
+        end
+      end
+
+      it 'errors when a configured connector is not allowed' do
+        properties['allow_list'] = {'connectors' => []}
+        expect { rendered }.to raise_error(/The following configured connectors are not allowed: \["redmetrics"\]/)
+      end
+
+      it 'errors when an unrecognized connector is in allow list' do
+        properties['allow_list'] = {'connectors' => ['redmetrics', 'unrecognized-connector']}
+        expect { rendered }.to raise_error(/The following connectors specified in the allow list are not included in this OpenTelemetry Collector distribution: \["unrecognized-connector"\]/)
+      end
+
+      it 'errors when an unavailable connector is configured' do
+        config['connectors']['unavailable'] = nil
+        expect { rendered }.to raise_error(/The following configured connectors are not included in this OpenTelemetry Collector distribution: \["unavailable"\]/)
+      end
     end
 
     describe 'internal telemetry' do
@@ -602,161 +789,81 @@ shared_examples_for 'common config.yml' do
     end
 
     describe 'secret interpolation' do
-      let(:config) do
-        {
-          'exporters' => {
-            'otlp' => {
-              'endpoint' => 'otelcol:4317',
-              'tls' => {
-                'cert_pem' => '{{ .test-secret.cert }}',
-                'key_pem' => '{{ .test-secret.key }}',
-                'ca_pem' => '{{ .test-secret.ca }}'
-              },
-              'headers' => {
-                'auth' => '{{ .anothersecret.secret }}'
-              }
-            },
-            'prometheus/test' => {
-              'tags' => [
-                '{{ .anothersecret.secret }}'
-              ]
-            }
-          },
-          'service' => {
-            'pipelines' => {
-              'traces' => {
-                'exporters' => ['otlp']
-              },
-              'metrics' => {
-                'exporters' => ['otlp']
-              }
-            }
-          }
-        }
+      let(:secrets) { [{ 'name' => 'test-secret', 'cert' => 'foo', 'key' => 'bar' }, { 'name' => 'anothersecret', 'secret' => 'foobarbaz' }] }
+      let(:properties) { { 'config' => config, 'secrets' => secrets } }
+
+      before do
+        config['exporters']['otlp']['tls'] = { 'cert_pem' => '{{ .test-secret.cert }}' }
       end
-      let(:properties) do
-        {
-          'config' => YAML.dump(config),
-          'secrets' => [
-            {
-              'name' => 'test-secret',
-              'cert' => '-----BEGIN CERTIFICATE-----
-MIIE4jCCAsqgAwIBAgIUO/DRqVeXUmewgpy33MkQpe0ME7YwDQYJKoZIhvcNAQEL
-BQAwgZkxCzAJBgNVBAYTAlVTMRMwEQYDVQQIDApDYWxpZm9ybmlhMRYwFAYDVQQH
-DA1TYW4gRnJhbmNpc2NvMQwwCgYDVQQKDANNQVAxDzANBgNVBAsMBlZNd2FyZTEV
-MBMGA1UEAwwMVG9vbHNtaXRoc0NBMScwJQYJKoZIhvcNAQkBFhhjZi10b29sc21p
-dGhzQHdtd2FyZS5jb20wHhcNMjQwODI3MjEzMDU3WhcNMjYwODI4MjEzMDU3WjAy
-MQswCQYDVQQGEwJVUzEQMA4GA1UECgwHUGl2b3RhbDERMA8GA1UEAwwIYmxhaC5j
-b20wggEiMA0GCSqGSIb3DQEBAQUAA4IBDwAwggEKAoIBAQDT0qMGluiM2jrZ0k/3
-YjSy6/55NJttugG+RjfWXIPTti3ySHBgf5oOhgE1w/TMH8vQC1QBXSi3erw+WlZV
-GW7pSs1AwPiTDJWlCmsyabY3En5+V+yFTI7CtA5uxC8Yo6szfHxk+RlZUcE8S7vd
-0Lty0hahK0q+cNLqDfWDJ4jgJWKkoT9yGKSF+LLoUpJXqzI7d0soevzAolXEGb6X
-O8ORQDYbT/onCwq9MKb4jRVE+KYT2+ajdKI0MPR4/3JA8/o2O4BNTf6MOnSFKWLe
-CYXdtcqaDE2GqK3OUnlH2Tv2lS+1KCGq9800MfXJ/ln7kuetPBz7MelR6Ph9SWqk
-Ev3NAgMBAAGjgYcwgYQwHQYDVR0OBBYEFPF+Zo5VBV/ZCDQk02HBER1j5WDtMB8G
-A1UdIwQYMBaAFMGM2idsRltlr/D2KjmlZE2sdFgVMB0GA1UdJQQWMBQGCCsGAQUF
-BwMCBggrBgEFBQcDATAOBgNVHQ8BAf8EBAMCBaAwEwYDVR0RBAwwCoIIYmxhaC5j
-b20wDQYJKoZIhvcNAQELBQADggIBALkKkStBbqSJmhAgXsfxMyX+ksuf0iKchP14
-/PIq9srwy6S6urc+9ajp7qNDvM+xaj8w2poUF4CPPVS7RqiRf5wJr2ZJDq0lcXbU
-M+qqKth+6VkOPUsOP+5b6j/aUoo1zTxqiP6q2bJ2igujHfSJ4H3JenD2VogqzrDS
-hNU0m4vupB79dlqPUWkkhkyQ+83GMLWzgwatmjj11jBeOPHNXZJikUODxvwVqscZ
-iYYdVzzSqVJCxinwk1eGvGXeGsSR4EBsLpF9g18L57PPT8OfDHM7KnBdwhSFkLuU
-gtd7i3u9NSScr7g3beQIBEi+ho/FR/pPcU453ilECsza3esMKAubr1nE6Be3tlhL
-EZpwAdkj3lZVnAMcXyNo20mgYK7yVoVa+rS4E9oyTcldjqBUvFnFtqbB70h5ZZ/v
-71uRB07WqE6zdvslcHtgWls5mM4APKhxjuszmY4GgEEQ7SJObQSzC53avPhlu+TB
-3EWIdIjpvyNSEsC6yIVQrKJ6ejcqV9+OVPFQyHQ2yzyBDVSVVU6EqYFUJy3zmHp+
-mm95ZMr9Q04nwi5//MNW7Yuw7XmjFtTlN6ybHrc82jNWDJx5GvZkHj0Qmg6TMYu2
-hqmaUsNEA27fgk2HRuHUOJ+2EFFlCVZMLR7vN/JVE/LhZ2CdzoyMOkH0vtKophTg
-HqBTRxft
------END CERTIFICATE-----',
-              'key' => 'bar',
-              'ca' => 'baz'
-            },
-            {
-              'name' => 'anothersecret',
-              'secret' => 'foobarbaz'
-            }
-          ]
-        }
+
+      it 'leaves the template variables to the collector' do
+        expect(rendered['exporters']['otlp']['tls']['cert_pem']).to eq('{{ .test-secret.cert }}')
       end
 
-      it 'interpolates the config and renders it successfully' do
-        expect(rendered['exporters']['otlp']['tls']['cert_pem']).to eq("-----BEGIN CERTIFICATE-----\nMIIE4jCCAsqgAwIBAgIUO/DRqVeXUmewgpy33MkQpe0ME7YwDQYJKoZIhvcNAQEL\nBQAwgZkxCzAJBgNVBAYTAlVTMRMwEQYDVQQIDApDYWxpZm9ybmlhMRYwFAYDVQQH\nDA1TYW4gRnJhbmNpc2NvMQwwCgYDVQQKDANNQVAxDzANBgNVBAsMBlZNd2FyZTEV\nMBMGA1UEAwwMVG9vbHNtaXRoc0NBMScwJQYJKoZIhvcNAQkBFhhjZi10b29sc21p\ndGhzQHdtd2FyZS5jb20wHhcNMjQwODI3MjEzMDU3WhcNMjYwODI4MjEzMDU3WjAy\nMQswCQYDVQQGEwJVUzEQMA4GA1UECgwHUGl2b3RhbDERMA8GA1UEAwwIYmxhaC5j\nb20wggEiMA0GCSqGSIb3DQEBAQUAA4IBDwAwggEKAoIBAQDT0qMGluiM2jrZ0k/3\nYjSy6/55NJttugG+RjfWXIPTti3ySHBgf5oOhgE1w/TMH8vQC1QBXSi3erw+WlZV\nGW7pSs1AwPiTDJWlCmsyabY3En5+V+yFTI7CtA5uxC8Yo6szfHxk+RlZUcE8S7vd\n0Lty0hahK0q+cNLqDfWDJ4jgJWKkoT9yGKSF+LLoUpJXqzI7d0soevzAolXEGb6X\nO8ORQDYbT/onCwq9MKb4jRVE+KYT2+ajdKI0MPR4/3JA8/o2O4BNTf6MOnSFKWLe\nCYXdtcqaDE2GqK3OUnlH2Tv2lS+1KCGq9800MfXJ/ln7kuetPBz7MelR6Ph9SWqk\nEv3NAgMBAAGjgYcwgYQwHQYDVR0OBBYEFPF+Zo5VBV/ZCDQk02HBER1j5WDtMB8G\nA1UdIwQYMBaAFMGM2idsRltlr/D2KjmlZE2sdFgVMB0GA1UdJQQWMBQGCCsGAQUF\nBwMCBggrBgEFBQcDATAOBgNVHQ8BAf8EBAMCBaAwEwYDVR0RBAwwCoIIYmxhaC5j\nb20wDQYJKoZIhvcNAQELBQADggIBALkKkStBbqSJmhAgXsfxMyX+ksuf0iKchP14\n/PIq9srwy6S6urc+9ajp7qNDvM+xaj8w2poUF4CPPVS7RqiRf5wJr2ZJDq0lcXbU\nM+qqKth+6VkOPUsOP+5b6j/aUoo1zTxqiP6q2bJ2igujHfSJ4H3JenD2VogqzrDS\nhNU0m4vupB79dlqPUWkkhkyQ+83GMLWzgwatmjj11jBeOPHNXZJikUODxvwVqscZ\niYYdVzzSqVJCxinwk1eGvGXeGsSR4EBsLpF9g18L57PPT8OfDHM7KnBdwhSFkLuU\ngtd7i3u9NSScr7g3beQIBEi+ho/FR/pPcU453ilECsza3esMKAubr1nE6Be3tlhL\nEZpwAdkj3lZVnAMcXyNo20mgYK7yVoVa+rS4E9oyTcldjqBUvFnFtqbB70h5ZZ/v\n71uRB07WqE6zdvslcHtgWls5mM4APKhxjuszmY4GgEEQ7SJObQSzC53avPhlu+TB\n3EWIdIjpvyNSEsC6yIVQrKJ6ejcqV9+OVPFQyHQ2yzyBDVSVVU6EqYFUJy3zmHp+\nmm95ZMr9Q04nwi5//MNW7Yuw7XmjFtTlN6ybHrc82jNWDJx5GvZkHj0Qmg6TMYu2\nhqmaUsNEA27fgk2HRuHUOJ+2EFFlCVZMLR7vN/JVE/LhZ2CdzoyMOkH0vtKophTg\nHqBTRxft\n-----END CERTIFICATE-----")
-        expect(rendered['exporters']['otlp']['tls']['key_pem']).to eq('bar')
-        expect(rendered['exporters']['otlp']['tls']['ca_pem']).to eq('baz')
-        expect(rendered['exporters']['otlp']['headers']['auth']).to eq('foobarbaz')
-        expect(rendered['exporters']['prometheus/test']['tags'][0]).to eq('foobarbaz')
+      it 'leaves checking for unused secrets to the collector' do
+        expect { rendered }.not_to raise_error
       end
 
-      context 'when no secrets exist for template variables' do
-        before do
-          properties['secrets'][0]['key'] = ''
-          properties['secrets'][0]['ca'] = nil
-          properties['secrets'].delete_at(1)
-        end
+      describe 'config/secrets.yml' do
+        let(:secrets_template) { job.template('config/secrets.yml') }
 
-        it 'does not interpolate those template variables' do
-          expect(rendered['exporters']['otlp']['tls']['cert_pem']).to eq("-----BEGIN CERTIFICATE-----\nMIIE4jCCAsqgAwIBAgIUO/DRqVeXUmewgpy33MkQpe0ME7YwDQYJKoZIhvcNAQEL\nBQAwgZkxCzAJBgNVBAYTAlVTMRMwEQYDVQQIDApDYWxpZm9ybmlhMRYwFAYDVQQH\nDA1TYW4gRnJhbmNpc2NvMQwwCgYDVQQKDANNQVAxDzANBgNVBAsMBlZNd2FyZTEV\nMBMGA1UEAwwMVG9vbHNtaXRoc0NBMScwJQYJKoZIhvcNAQkBFhhjZi10b29sc21p\ndGhzQHdtd2FyZS5jb20wHhcNMjQwODI3MjEzMDU3WhcNMjYwODI4MjEzMDU3WjAy\nMQswCQYDVQQGEwJVUzEQMA4GA1UECgwHUGl2b3RhbDERMA8GA1UEAwwIYmxhaC5j\nb20wggEiMA0GCSqGSIb3DQEBAQUAA4IBDwAwggEKAoIBAQDT0qMGluiM2jrZ0k/3\nYjSy6/55NJttugG+RjfWXIPTti3ySHBgf5oOhgE1w/TMH8vQC1QBXSi3erw+WlZV\nGW7pSs1AwPiTDJWlCmsyabY3En5+V+yFTI7CtA5uxC8Yo6szfHxk+RlZUcE8S7vd\n0Lty0hahK0q+cNLqDfWDJ4jgJWKkoT9yGKSF+LLoUpJXqzI7d0soevzAolXEGb6X\nO8ORQDYbT/onCwq9MKb4jRVE+KYT2+ajdKI0MPR4/3JA8/o2O4BNTf6MOnSFKWLe\nCYXdtcqaDE2GqK3OUnlH2Tv2lS+1KCGq9800MfXJ/ln7kuetPBz7MelR6Ph9SWqk\nEv3NAgMBAAGjgYcwgYQwHQYDVR0OBBYEFPF+Zo5VBV/ZCDQk02HBER1j5WDtMB8G\nA1UdIwQYMBaAFMGM2idsRltlr/D2KjmlZE2sdFgVMB0GA1UdJQQWMBQGCCsGAQUF\nBwMCBggrBgEFBQcDATAOBgNVHQ8BAf8EBAMCBaAwEwYDVR0RBAwwCoIIYmxhaC5j\nb20wDQYJKoZIhvcNAQELBQADggIBALkKkStBbqSJmhAgXsfxMyX+ksuf0iKchP14\n/PIq9srwy6S6urc+9ajp7qNDvM+xaj8w2poUF4CPPVS7RqiRf5wJr2ZJDq0lcXbU\nM+qqKth+6VkOPUsOP+5b6j/aUoo1zTxqiP6q2bJ2igujHfSJ4H3JenD2VogqzrDS\nhNU0m4vupB79dlqPUWkkhkyQ+83GMLWzgwatmjj11jBeOPHNXZJikUODxvwVqscZ\niYYdVzzSqVJCxinwk1eGvGXeGsSR4EBsLpF9g18L57PPT8OfDHM7KnBdwhSFkLuU\ngtd7i3u9NSScr7g3beQIBEi+ho/FR/pPcU453ilECsza3esMKAubr1nE6Be3tlhL\nEZpwAdkj3lZVnAMcXyNo20mgYK7yVoVa+rS4E9oyTcldjqBUvFnFtqbB70h5ZZ/v\n71uRB07WqE6zdvslcHtgWls5mM4APKhxjuszmY4GgEEQ7SJObQSzC53avPhlu+TB\n3EWIdIjpvyNSEsC6yIVQrKJ6ejcqV9+OVPFQyHQ2yzyBDVSVVU6EqYFUJy3zmHp+\nmm95ZMr9Q04nwi5//MNW7Yuw7XmjFtTlN6ybHrc82jNWDJx5GvZkHj0Qmg6TMYu2\nhqmaUsNEA27fgk2HRuHUOJ+2EFFlCVZMLR7vN/JVE/LhZ2CdzoyMOkH0vtKophTg\nHqBTRxft\n-----END CERTIFICATE-----")
-          expect(rendered['exporters']['otlp']['tls']['key_pem']).to eq('{{ .test-secret.key }}')
-          expect(rendered['exporters']['otlp']['tls']['ca_pem']).to eq('{{ .test-secret.ca }}')
-          expect(rendered['exporters']['otlp']['headers']['auth']).to eq('{{ .anothersecret.secret }}')
-          expect(rendered['exporters']['prometheus/test']['tags'][0]).to eq('{{ .anothersecret.secret }}')
+        it 'holds the secrets for the collector to interpolate' do
+          expect(YAML.safe_load(secrets_template.render(properties))).to eq(secrets)
         end
-      end
 
-      context 'when no template variables exist for a secret' do
-        before do
-          config['exporters']['otlp'].delete('headers')
-          config['exporters'].delete('prometheus/test')
+        it 'accepts the secrets as YAML' do
+          properties['secrets'] = YAML.dump(secrets)
+          expect(YAML.safe_load(secrets_template.render(properties))).to eq(secrets)
         end
 
-        it 'raises an error' do
-          expect { rendered }.to raise_error(/The following secrets are unused: \['anothersecret.secret'\]/)
+        it 'has no secrets by default' do
+          properties.delete('secrets')
+          expect(YAML.safe_load(secrets_template.render(properties))).to eq([])
         end
       end
+    end
 
-      context 'when template variables uses differing amounts of space separation' do
-        before do
-          config['exporters']['otlp']['tls']['cert_pem'] = '{{.test-secret.cert}}'
-          config['exporters']['otlp']['tls']['key_pem'] = '{{        .test-secret.key}}'
-          config['exporters']['otlp']['tls']['ca_pem'] = '{{   .test-secret.ca     }}'
-        end
+    describe 'config/allow_list.yml' do
+      let(:allow_list_template) { job.template('config/allow_list.yml') }
 
-        it 'interpolates the config and renders it successfully' do
-          expect(rendered['exporters']['otlp']['tls']['cert_pem']).to eq("-----BEGIN CERTIFICATE-----\nMIIE4jCCAsqgAwIBAgIUO/DRqVeXUmewgpy33MkQpe0ME7YwDQYJKoZIhvcNAQEL\nBQAwgZkxCzAJBgNVBAYTAlVTMRMwEQYDVQQIDApDYWxpZm9ybmlhMRYwFAYDVQQH\nDA1TYW4gRnJhbmNpc2NvMQwwCgYDVQQKDANNQVAxDzANBgNVBAsMBlZNd2FyZTEV\nMBMGA1UEAwwMVG9vbHNtaXRoc0NBMScwJQYJKoZIhvcNAQkBFhhjZi10b29sc21p\ndGhzQHdtd2FyZS5jb20wHhcNMjQwODI3MjEzMDU3WhcNMjYwODI4MjEzMDU3WjAy\nMQswCQYDVQQGEwJVUzEQMA4GA1UECgwHUGl2b3RhbDERMA8GA1UEAwwIYmxhaC5j\nb20wggEiMA0GCSqGSIb3DQEBAQUAA4IBDwAwggEKAoIBAQDT0qMGluiM2jrZ0k/3\nYjSy6/55NJttugG+RjfWXIPTti3ySHBgf5oOhgE1w/TMH8vQC1QBXSi3erw+WlZV\nGW7pSs1AwPiTDJWlCmsyabY3En5+V+yFTI7CtA5uxC8Yo6szfHxk+RlZUcE8S7vd\n0Lty0hahK0q+cNLqDfWDJ4jgJWKkoT9yGKSF+LLoUpJXqzI7d0soevzAolXEGb6X\nO8ORQDYbT/onCwq9MKb4jRVE+KYT2+ajdKI0MPR4/3JA8/o2O4BNTf6MOnSFKWLe\nCYXdtcqaDE2GqK3OUnlH2Tv2lS+1KCGq9800MfXJ/ln7kuetPBz7MelR6Ph9SWqk\nEv3NAgMBAAGjgYcwgYQwHQYDVR0OBBYEFPF+Zo5VBV/ZCDQk02HBER1j5WDtMB8G\nA1UdIwQYMBaAFMGM2idsRltlr/D2KjmlZE2sdFgVMB0GA1UdJQQWMBQGCCsGAQUF\nBwMCBggrBgEFBQcDATAOBgNVHQ8BAf8EBAMCBaAwEwYDVR0RBAwwCoIIYmxhaC5j\nb20wDQYJKoZIhvcNAQELBQADggIBALkKkStBbqSJmhAgXsfxMyX+ksuf0iKchP14\n/PIq9srwy6S6urc+9ajp7qNDvM+xaj8w2poUF4CPPVS7RqiRf5wJr2ZJDq0lcXbU\nM+qqKth+6VkOPUsOP+5b6j/aUoo1zTxqiP6q2bJ2igujHfSJ4H3JenD2VogqzrDS\nhNU0m4vupB79dlqPUWkkhkyQ+83GMLWzgwatmjj11jBeOPHNXZJikUODxvwVqscZ\niYYdVzzSqVJCxinwk1eGvGXeGsSR4EBsLpF9g18L57PPT8OfDHM7KnBdwhSFkLuU\ngtd7i3u9NSScr7g3beQIBEi+ho/FR/pPcU453ilECsza3esMKAubr1nE6Be3tlhL\nEZpwAdkj3lZVnAMcXyNo20mgYK7yVoVa+rS4E9oyTcldjqBUvFnFtqbB70h5ZZ/v\n71uRB07WqE6zdvslcHtgWls5mM4APKhxjuszmY4GgEEQ7SJObQSzC53avPhlu+TB\n3EWIdIjpvyNSEsC6yIVQrKJ6ejcqV9+OVPFQyHQ2yzyBDVSVVU6EqYFUJy3zmHp+\nmm95ZMr9Q04nwi5//MNW7Yuw7XmjFtTlN6ybHrc82jNWDJx5GvZkHj0Qmg6TMYu2\nhqmaUsNEA27fgk2HRuHUOJ+2EFFlCVZMLR7vN/JVE/LhZ2CdzoyMOkH0vtKophTg\nHqBTRxft\n-----END CERTIFICATE-----")
-          expect(rendered['exporters']['otlp']['tls']['key_pem']).to eq('bar')
-          expect(rendered['exporters']['otlp']['tls']['ca_pem']).to eq('baz')
-          expect(rendered['exporters']['otlp']['headers']['auth']).to eq('foobarbaz')
-          expect(rendered['exporters']['prometheus/test']['tags'][0]).to eq('foobarbaz')
-        end
+      it 'holds the allow list for the collector to apply to configuration fragments' do
+        properties = { 'allow_list' => { 'processors' => ['batch'], 'exporters' => [] } }
+        expect(YAML.safe_load(allow_list_template.render(properties))).to eq(
+          'processors' => ['batch'],
+          'exporters' => []
+        )
       end
 
-      context 'when template variables are not formatted correctly' do
-        before do
-          config['exporters']['otlp']['tls']['cert_pem'] = '{{test-secret.cert}}'
-          config['exporters']['otlp']['tls']['key_pem'] = '{{ .test-secret }}'
-          config['exporters']['otlp']['tls']['ca_pem'] = "{{\n .test-secret.ca \n}}"
-        end
+      it 'accepts the allow list as YAML' do
+        properties = { 'allow_list' => { 'processors' => "['batch']", 'connectors' => '' } }
+        expect(YAML.safe_load(allow_list_template.render(properties))).to eq(
+          'processors' => ['batch'],
+          'connectors' => []
+        )
+      end
 
-        it 'does not match secrets to those variables' do
-          expect { rendered }.to raise_error(/The following secrets are unused: \['test-secret.cert', 'test-secret.key', 'test-secret.ca'\]/)
-        end
+      it 'allows all components by default' do
+        expect(YAML.safe_load(allow_list_template.render({}))).to eq({})
       end
+    end
 
-      context 'when template variables are not quoted' do
-        before do
-          properties['config'] = '
-exporters:
-  otlp/other:
-    endpoint: otelcol:4317
-    headers:
-      auth: {{ .test.secret }}'
-          properties['secrets'] = [{'name' => 'test', 'secret' => 'mysecret'}]
-        end
+    describe 'config/bosh/spec.json' do
+      let(:spec_template) { job.template('config/bosh/spec.json') }
+      let(:instance) do
+        Bosh::Template::Test::InstanceSpec.new(
+          deployment: 'cf', name: 'router', id: 'abc-123', index: 2, az: 'z1', ip: '10.0.1.12'
+        )
+      end
 
-        it 'does not match secrets to those variables' do
-          expect { rendered }.to raise_error(/The following secrets are unused: \['test.secret'\]/)
-        end
+      it 'holds the instance spec for the collector' do
+        expect(JSON.parse(spec_template.render({}, spec: instance))).to eq(
+          'deployment' => 'cf',
+          'name' => 'router',
+          'id' => 'abc-123',
+          'index' => 2,
+          'az' => 'z1',
+          'ip' => '10.0.1.12'
+        )
       end
     end
   end
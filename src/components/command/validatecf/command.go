@@ -0,0 +1,70 @@
+// Package validatecf implements the validate-cf command, which applies the
+// policy the BOSH jobs apply to their config property when they are rendered
+// to a configuration file, so that operators can check their manifests
+// before deploying them.
+package validatecf
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"go.opentelemetry.io/collector/otelcol"
+	"go.yaml.in/yaml/v3"
+)
+
+// NewCommand creates the validate-cf command for the collector with the
+// settings set.
+func NewCommand(set otelcol.CollectorSettings) *cobra.Command {
+	var configPath, allowListPath string
+	cmd := &cobra.Command{
+		Use:   "validate-cf",
+		Short: "Validates the config property of the BOSH jobs against their policy",
+		Long: "Validates the config property of the otel-collector and otel-collector-windows BOSH jobs, " +
+			"and optionally their allow_list property, against the policy the jobs apply when they are rendered, " +
+			"and validates the configuration of every component with the factories of this collector distribution. " +
+			"Every violation is printed with its path.",
+		Args:         cobra.ExactArgs(0),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			var cfg map[string]any
+			if err := readYAML(configPath, &cfg); err != nil {
+				return err
+			}
+			var allowList AllowList
+			if allowListPath != "" {
+				if err := readYAML(allowListPath, &allowList); err != nil {
+					return err
+				}
+			}
+			factories, err := set.Factories()
+			if err != nil {
+				return fmt.Errorf("failed to initialize factories: %w", err)
+			}
+
+			violations := Validate(cfg, allowList, factories)
+			for _, v := range violations {
+				fmt.Fprintln(cmd.OutOrStdout(), v)
+			}
+			if len(violations) > 0 {
+				return fmt.Errorf("the config violates the policy of the BOSH jobs %d time(s)", len(violations))
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&configPath, "config", "", "file with the config property")
+	cmd.Flags().StringVar(&allowListPath, "allow-list", "", "file with the allow_list property")
+	_ = cmd.MarkFlagRequired("config")
+	return cmd
+}
+
+func readYAML(path string, v any) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("unable to read %v: %w", path, err)
+	}
+	if err := yaml.Unmarshal(content, v); err != nil {
+		return fmt.Errorf("unable to parse %v: %w", path, err)
+	}
+	return nil
+}
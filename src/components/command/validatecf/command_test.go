@@ -0,0 +1,94 @@
+package validatecf_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+
+	"code.cloudfoundry.org/otel-collector-release/src/components/command/validatecf"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.opentelemetry.io/collector/otelcol"
+)
+
+var _ = Describe("validate-cf command", func() {
+	var (
+		dir           string
+		configPath    string
+		allowListPath string
+	)
+
+	BeforeEach(func() {
+		dir = GinkgoT().TempDir()
+		configPath = filepath.Join(dir, "config.yml")
+		allowListPath = filepath.Join(dir, "allow_list.yml")
+		write(configPath, `
+exporters:
+  otlp:
+    endpoint: otelcol.example.com:4317
+  prometheus/main:
+    endpoint: 127.0.0.1:8889
+service:
+  pipelines:
+    metrics:
+      exporters: [otlp, prometheus/main]
+`)
+		write(allowListPath, `
+exporters: [otlp]
+`)
+	})
+
+	run := func(args ...string) (string, error) {
+		cmd := validatecf.NewCommand(otelcol.CollectorSettings{
+			Factories: func() (otelcol.Factories, error) { return testFactories(), nil },
+		})
+		out := new(bytes.Buffer)
+		cmd.SetOut(out)
+		cmd.SetErr(new(bytes.Buffer))
+		cmd.SetArgs(args)
+		err := cmd.Execute()
+		return out.String(), err
+	}
+
+	It("prints every violation", func() {
+		out, err := run("--config", configPath, "--allow-list", allowListPath)
+
+		Expect(err).To(MatchError("the config violates the policy of the BOSH jobs 2 time(s)"))
+		Expect(out).To(Equal(
+			"exporters::prometheus/main::endpoint: Cannot define prometheus exporter listening on port 8889 (reserved for BBS API port)\n" +
+				"exporters::prometheus/main: \"prometheus\" is not allowed, allowed are [otlp]\n",
+		))
+	})
+
+	It("allows every component without an allow list", func() {
+		write(configPath, `
+exporters:
+  otlp:
+    endpoint: otelcol.example.com:4317
+service:
+  pipelines:
+    metrics:
+      exporters: [otlp]
+`)
+
+		out, err := run("--config", configPath)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out).To(BeEmpty())
+	})
+
+	It("requires a config", func() {
+		_, err := run()
+		Expect(err).To(MatchError(ContainSubstring(`required flag(s) "config" not set`)))
+	})
+
+	It("fails when the config can't be parsed", func() {
+		write(configPath, "exporters: [")
+		_, err := run("--config", configPath)
+		Expect(err).To(MatchError(ContainSubstring("unable to parse")))
+	})
+})
+
+func write(path, content string) {
+	Expect(os.WriteFile(path, []byte(content), 0o600)).To(Succeed())
+}
@@ -0,0 +1,47 @@
+package validatecf
+
+import (
+	"slices"
+
+	"code.cloudfoundry.org/otel-collector-release/src/components/provider/credhubprovider"
+	"github.com/spf13/cobra"
+	"go.opentelemetry.io/collector/confmap"
+	"go.opentelemetry.io/collector/otelcol"
+)
+
+const printInitialConfig = "print-initial-config"
+
+// Extend makes the changes to the collector command cmd created with set
+// that the builder can't generate, so that the generated main only needs to
+// call it: it adds the validate-cf command, and has print-initial-config
+// resolve CredHub references to a placeholder, so that it neither prints
+// credentials nor connects to CredHub.
+func Extend(cmd *cobra.Command, set otelcol.CollectorSettings) {
+	redacted := otelcol.NewCommand(withCredHubRedacted(set))
+	for _, sub := range redacted.Commands() {
+		if sub.Name() != printInitialConfig {
+			continue
+		}
+		for _, c := range cmd.Commands() {
+			if c.Name() == printInitialConfig {
+				cmd.RemoveCommand(c)
+			}
+		}
+		// The command reads the flags it was created with, so it keeps
+		// working when moved.
+		redacted.RemoveCommand(sub)
+		cmd.AddCommand(sub)
+	}
+	cmd.AddCommand(NewCommand(set))
+}
+
+func withCredHubRedacted(set otelcol.CollectorSettings) otelcol.CollectorSettings {
+	factories := slices.Clone(set.ConfigProviderSettings.ResolverSettings.ProviderFactories)
+	for i, f := range factories {
+		if f.Create(confmap.ProviderSettings{}).Scheme() == "credhub" {
+			factories[i] = credhubprovider.NewFactory(credhubprovider.WithRedaction(true))
+		}
+	}
+	set.ConfigProviderSettings.ResolverSettings.ProviderFactories = factories
+	return set
+}
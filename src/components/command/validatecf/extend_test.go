@@ -0,0 +1,80 @@
+package validatecf_test
+
+import (
+	"context"
+	"io"
+	"os"
+
+	"code.cloudfoundry.org/otel-collector-release/src/components/command/validatecf"
+	"code.cloudfoundry.org/otel-collector-release/src/components/provider/credhubprovider"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/cobra"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/confmap"
+	"go.opentelemetry.io/collector/confmap/provider/envprovider"
+	"go.opentelemetry.io/collector/featuregate"
+	"go.opentelemetry.io/collector/otelcol"
+)
+
+var _ = Describe("Extend", func() {
+	newCommand := func() *cobra.Command {
+		set := otelcol.CollectorSettings{
+			BuildInfo: component.BuildInfo{Command: "otelcol-cf"},
+			Factories: func() (otelcol.Factories, error) { return testFactories(), nil },
+			ConfigProviderSettings: otelcol.ConfigProviderSettings{
+				ResolverSettings: confmap.ResolverSettings{
+					ProviderFactories: []confmap.ProviderFactory{
+						envprovider.NewFactory(),
+						credhubprovider.NewFactory(),
+					},
+				},
+			},
+		}
+		cmd := otelcol.NewCommand(set)
+		validatecf.Extend(cmd, set)
+		return cmd
+	}
+
+	It("adds the validate-cf command", func() {
+		cmd, _, err := newCommand().Find([]string{"validate-cf"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cmd.Name()).To(Equal("validate-cf"))
+	})
+
+	It("prints the initial config with CredHub references redacted", func() {
+		GinkgoT().Setenv("OTELCOL_TEST_CONFIG", `
+exporters:
+  otlphttp:
+    headers:
+      authorization: ${credhub:/otel/token}
+`)
+		DeferCleanup(featuregate.GlobalRegistry().Set, "otelcol.printInitialConfig", false)
+
+		cmd := newCommand()
+		cmd.SetArgs([]string{"print-initial-config", "--feature-gates", "otelcol.printInitialConfig", "--config", "env:OTELCOL_TEST_CONFIG"})
+		out := captureStdout(func() {
+			Expect(cmd.ExecuteContext(context.Background())).To(Succeed())
+		})
+
+		Expect(out).To(ContainSubstring("authorization: '[REDACTED]'"))
+	})
+})
+
+// captureStdout returns what f writes to stdout, where print-initial-config
+// prints the config.
+func captureStdout(f func()) string {
+	r, w, err := os.Pipe()
+	Expect(err).NotTo(HaveOccurred())
+	stdout := os.Stdout
+	os.Stdout = w
+	func() {
+		defer func() { os.Stdout = stdout }()
+		f()
+	}()
+	Expect(w.Close()).To(Succeed())
+
+	out, err := io.ReadAll(r)
+	Expect(err).NotTo(HaveOccurred())
+	return string(out)
+}
@@ -0,0 +1,178 @@
+package validatecf
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"code.cloudfoundry.org/otel-collector-release/src/components/converter/cfconverter"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/confmap"
+	"go.opentelemetry.io/collector/confmap/xconfmap"
+	"go.opentelemetry.io/collector/otelcol"
+)
+
+// reservedBBSAPIPort is the port of the BBS API on Diego instances, which
+// prometheus exporters may not listen on.
+const reservedBBSAPIPort = ":8889"
+
+// componentKinds are the kinds of components the config property may
+// configure. Receivers are not among them as the BOSH jobs replace them.
+var componentKinds = []string{"processors", "exporters", "extensions", "connectors"}
+
+// AllowList is the allow_list property of the BOSH jobs, the types of
+// components of each kind the config property may use. A kind that is not
+// set allows every type.
+type AllowList struct {
+	Processors []string `yaml:"processors"`
+	Exporters  []string `yaml:"exporters"`
+	Extensions []string `yaml:"extensions"`
+	Connectors []string `yaml:"connectors"`
+}
+
+func (a AllowList) of(kind string) []string {
+	switch kind {
+	case "processors":
+		return a.Processors
+	case "exporters":
+		return a.Exporters
+	case "extensions":
+		return a.Extensions
+	case "connectors":
+		return a.Connectors
+	}
+	return nil
+}
+
+// Violation is a part of the config property, or the allow_list property,
+// that violates the policy of the BOSH jobs.
+type Violation struct {
+	// Path is the path to the violating part, with keys separated by "::"
+	// like the collector does.
+	Path    string
+	Message string
+}
+
+func (v Violation) String() string {
+	return v.Path + ": " + v.Message
+}
+
+// Validate returns every violation of the policy of the BOSH jobs by cfg, the
+// config property, and allowList, the allow_list property. The configuration
+// of every component of a type in factories is validated as the collector
+// would. References to confmap providers and secrets are not resolved, so
+// they are validated as the strings they are.
+func Validate(cfg map[string]any, allowList AllowList, factories otelcol.Factories) []Violation {
+	v := &validator{factories: factories}
+
+	if cfg["exporters"] == nil {
+		v.add("exporters", "Exporter configuration must be provided")
+	}
+	if cfg["service"] == nil {
+		v.add("service", "Service configuration must be provided")
+	}
+	v.checkReservedBBSAPIPort(cfg)
+	for _, kind := range componentKinds {
+		components := sectionOf(cfg, kind)
+		for _, id := range slices.Sorted(maps.Keys(components)) {
+			v.checkComponent(kind, id, components[id], allowList.of(kind))
+		}
+	}
+	for _, kind := range componentKinds {
+		for i, typ := range allowList.of(kind) {
+			if !v.included(kind, typ) {
+				v.add("allow_list::"+kind+"::"+strconv.Itoa(i), fmt.Sprintf("%q is not included in this OpenTelemetry Collector distribution", typ))
+			}
+		}
+	}
+	return v.violations
+}
+
+type validator struct {
+	factories  otelcol.Factories
+	violations []Violation
+}
+
+func (v *validator) add(path, message string) {
+	v.violations = append(v.violations, Violation{Path: path, Message: message})
+}
+
+func (v *validator) checkReservedBBSAPIPort(cfg map[string]any) {
+	exporters := sectionOf(cfg, "exporters")
+	for _, id := range slices.Sorted(maps.Keys(exporters)) {
+		exporter, _ := exporters[id].(map[string]any)
+		endpoint, _ := exporter["endpoint"].(string)
+		if strings.HasPrefix(id, "prometheus/") && strings.HasSuffix(endpoint, reservedBBSAPIPort) {
+			v.add("exporters::"+id+"::endpoint", "Cannot define prometheus exporter listening on port 8889 (reserved for BBS API port)")
+		}
+	}
+}
+
+// checkComponent checks that a component is of an included and allowed
+// type, is not in the reserved namespace, and that its factory accepts its
+// configuration.
+func (v *validator) checkComponent(kind, id string, raw any, allowed []string) {
+	path := kind + "::" + id
+	if cfconverter.IsReserved(kind, id) {
+		v.add(path, "cannot be defined under cf-internal namespace")
+	}
+
+	typ, _, _ := strings.Cut(id, "/")
+	if !v.included(kind, typ) {
+		v.add(path, fmt.Sprintf("%q is not included in this OpenTelemetry Collector distribution", typ))
+		return
+	}
+	if allowed != nil && !slices.Contains(allowed, typ) {
+		v.add(path, fmt.Sprintf("%q is not allowed, allowed are %v", typ, allowed))
+	}
+
+	var componentID component.ID
+	if err := componentID.UnmarshalText([]byte(id)); err != nil {
+		v.add(path, err.Error())
+		return
+	}
+	cfg := v.factory(kind, componentID.Type()).CreateDefaultConfig()
+	section, ok := raw.(map[string]any)
+	if raw != nil && !ok {
+		v.add(path, fmt.Sprintf("expected a map, got %T", raw))
+		return
+	}
+	if err := confmap.NewFromStringMap(section).Unmarshal(cfg); err != nil {
+		v.add(path, err.Error())
+		return
+	}
+	if err := xconfmap.Validate(cfg); err != nil {
+		v.add(path, err.Error())
+	}
+}
+
+func (v *validator) included(kind, typ string) bool {
+	t, err := component.NewType(typ)
+	return err == nil && v.factory(kind, t) != nil
+}
+
+func (v *validator) factory(kind string, typ component.Type) component.Factory {
+	var f component.Factory
+	var ok bool
+	switch kind {
+	case "processors":
+		f, ok = v.factories.Processors[typ]
+	case "exporters":
+		f, ok = v.factories.Exporters[typ]
+	case "extensions":
+		f, ok = v.factories.Extensions[typ]
+	case "connectors":
+		f, ok = v.factories.Connectors[typ]
+	}
+	if !ok {
+		return nil
+	}
+	return f
+}
+
+func sectionOf(cfg map[string]any, kind string) map[string]any {
+	section, _ := cfg[kind].(map[string]any)
+	return section
+}
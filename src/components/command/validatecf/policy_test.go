@@ -0,0 +1,173 @@
+package validatecf_test
+
+import (
+	"errors"
+	"time"
+
+	"code.cloudfoundry.org/otel-collector-release/src/components/command/validatecf"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/connector"
+	"go.opentelemetry.io/collector/exporter"
+	"go.opentelemetry.io/collector/extension"
+	"go.opentelemetry.io/collector/otelcol"
+	"go.opentelemetry.io/collector/processor"
+)
+
+var _ = Describe("Validate", func() {
+	var (
+		cfg       map[string]any
+		allowList validatecf.AllowList
+	)
+
+	BeforeEach(func() {
+		cfg = map[string]any{
+			"processors": map[string]any{"batch": nil},
+			"exporters": map[string]any{
+				"otlp":            map[string]any{"endpoint": "otelcol.example.com:4317"},
+				"prometheus/main": map[string]any{"endpoint": "127.0.0.1:9090"},
+			},
+			"extensions": map[string]any{"pprof": nil},
+			"connectors": map[string]any{"redmetrics": nil},
+			"service": map[string]any{
+				"pipelines": map[string]any{
+					"metrics": map[string]any{"exporters": []any{"otlp", "prometheus/main"}},
+				},
+			},
+		}
+		allowList = validatecf.AllowList{}
+	})
+
+	validate := func() []string {
+		var out []string
+		for _, v := range validatecf.Validate(cfg, allowList, testFactories()) {
+			out = append(out, v.String())
+		}
+		return out
+	}
+
+	It("accepts a config that follows the policy", func() {
+		Expect(validate()).To(BeEmpty())
+	})
+
+	It("requires exporters and a service", func() {
+		delete(cfg, "exporters")
+		delete(cfg, "service")
+		Expect(validate()).To(ConsistOf(
+			"exporters: Exporter configuration must be provided",
+			"service: Service configuration must be provided",
+		))
+	})
+
+	It("rejects prometheus exporters listening on the BBS API port", func() {
+		cfg["exporters"].(map[string]any)["prometheus/main"] = map[string]any{"endpoint": "127.0.0.1:8889"}
+		Expect(validate()).To(ConsistOf(
+			"exporters::prometheus/main::endpoint: Cannot define prometheus exporter listening on port 8889 (reserved for BBS API port)",
+		))
+	})
+
+	It("rejects components that are not included in the distribution", func() {
+		cfg["processors"].(map[string]any)["unavailable/foo"] = nil
+		cfg["extensions"].(map[string]any)["unavailable"] = nil
+		Expect(validate()).To(ConsistOf(
+			`processors::unavailable/foo: "unavailable" is not included in this OpenTelemetry Collector distribution`,
+			`extensions::unavailable: "unavailable" is not included in this OpenTelemetry Collector distribution`,
+		))
+	})
+
+	It("rejects components that are not allowed", func() {
+		allowList.Exporters = []string{"otlp"}
+		allowList.Extensions = []string{}
+		Expect(validate()).To(ConsistOf(
+			`exporters::prometheus/main: "prometheus" is not allowed, allowed are [otlp]`,
+			`extensions::pprof: "pprof" is not allowed, allowed are []`,
+		))
+	})
+
+	It("rejects allow lists with components that are not included in the distribution", func() {
+		allowList.Processors = []string{"batch", "unavailable"}
+		Expect(validate()).To(ConsistOf(
+			`allow_list::processors::1: "unavailable" is not included in this OpenTelemetry Collector distribution`,
+		))
+	})
+
+	It("rejects components in the reserved namespace", func() {
+		cfg["processors"].(map[string]any)["batch/cf-internal-foo"] = nil
+		cfg["exporters"].(map[string]any)["otlp/cf-internal-foo"] = map[string]any{"endpoint": "otelcol.example.com:4317"}
+		Expect(validate()).To(ConsistOf(
+			"processors::batch/cf-internal-foo: cannot be defined under cf-internal namespace",
+			"exporters::otlp/cf-internal-foo: cannot be defined under cf-internal namespace",
+		))
+	})
+
+	It("validates the configuration of components with their factories", func() {
+		cfg["processors"].(map[string]any)["batch"] = map[string]any{"timeout": "-1s"}
+		cfg["exporters"].(map[string]any)["otlp"] = map[string]any{"endpoint": "otelcol.example.com:4317", "unknown": true}
+		cfg["connectors"].(map[string]any)["redmetrics"] = "invalid"
+		Expect(validate()).To(ConsistOf(
+			"processors::batch: timeout must not be negative",
+			ContainSubstring("exporters::otlp: decoding failed"),
+			"connectors::redmetrics: expected a map, got string",
+		))
+	})
+
+	It("reports every violation", func() {
+		delete(cfg, "service")
+		cfg["processors"].(map[string]any)["batch"] = map[string]any{"timeout": "-1s"}
+		cfg["exporters"].(map[string]any)["prometheus/main"] = map[string]any{"endpoint": "127.0.0.1:8889"}
+		cfg["extensions"].(map[string]any)["pprof/cf-internal-foo"] = nil
+		allowList.Connectors = []string{"logcount"}
+		Expect(validate()).To(HaveLen(5))
+	})
+})
+
+type batchConfig struct {
+	Timeout time.Duration `mapstructure:"timeout"`
+}
+
+func (c *batchConfig) Validate() error {
+	if c.Timeout < 0 {
+		return errors.New("timeout must not be negative")
+	}
+	return nil
+}
+
+type endpointConfig struct {
+	Endpoint string `mapstructure:"endpoint"`
+}
+
+type emptyConfig struct{}
+
+// testFactories are factories of components like the ones of the collector
+// distribution, with just enough configuration to be validated.
+func testFactories() otelcol.Factories {
+	newEndpointConfig := func() component.Config { return &endpointConfig{} }
+	newEmptyConfig := func() component.Config { return &emptyConfig{} }
+
+	processors, err := otelcol.MakeFactoryMap(
+		processor.NewFactory(component.MustNewType("batch"), func() component.Config { return &batchConfig{} }),
+	)
+	Expect(err).NotTo(HaveOccurred())
+	exporters, err := otelcol.MakeFactoryMap(
+		exporter.NewFactory(component.MustNewType("otlp"), newEndpointConfig),
+		exporter.NewFactory(component.MustNewType("prometheus"), newEndpointConfig),
+	)
+	Expect(err).NotTo(HaveOccurred())
+	extensions, err := otelcol.MakeFactoryMap(
+		extension.NewFactory(component.MustNewType("pprof"), newEmptyConfig, nil, component.StabilityLevelBeta),
+	)
+	Expect(err).NotTo(HaveOccurred())
+	connectors, err := otelcol.MakeFactoryMap(
+		connector.NewFactory(component.MustNewType("redmetrics"), newEmptyConfig),
+		connector.NewFactory(component.MustNewType("logcount"), newEmptyConfig),
+	)
+	Expect(err).NotTo(HaveOccurred())
+
+	return otelcol.Factories{
+		Processors: processors,
+		Exporters:  exporters,
+		Extensions: extensions,
+		Connectors: connectors,
+	}
+}
@@ -0,0 +1,13 @@
+package validatecf_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestValidateCF(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Validate CF Suite")
+}
@@ -0,0 +1,13 @@
+package attributeroutingconnector_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestAttributeRoutingConnector(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Attribute Routing Connector Suite")
+}
@@ -0,0 +1,63 @@
+package attributeroutingconnector
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/pipeline"
+)
+
+// Config defines the configuration for the attribute routing connector.
+type Config struct {
+	// Routes are tried in order, and every record goes to the pipeline of
+	// the first route it matches.
+	Routes []RouteConfig `mapstructure:"routes"`
+
+	// DefaultPipeline receives the records that match no route.
+	DefaultPipeline pipeline.ID `mapstructure:"default_pipeline"`
+
+	// ErrorMode decides what happens when a condition fails to evaluate.
+	ErrorMode ottl.ErrorMode `mapstructure:"error_mode"`
+}
+
+// RouteConfig defines a route and the records it matches.
+type RouteConfig struct {
+	// Attributes must all have the given values. They are read from the
+	// record attributes and then from the resource attributes.
+	Attributes map[string]string `mapstructure:"attributes"`
+
+	// Condition is an OTTL condition in the context of the record: a log
+	// record, a span or a datapoint.
+	Condition string `mapstructure:"condition"`
+
+	// Pipeline receives the records matching the route.
+	Pipeline pipeline.ID `mapstructure:"pipeline"`
+}
+
+var _ component.Config = (*Config)(nil)
+
+// Validate checks that every route matches on something and has a pipeline.
+func (cfg *Config) Validate() error {
+	if len(cfg.Routes) == 0 {
+		return errors.New(`"routes" must not be empty`)
+	}
+	for i, r := range cfg.Routes {
+		if len(r.Attributes) == 0 && r.Condition == "" {
+			return fmt.Errorf(`route %d requires "attributes" or a "condition"`, i)
+		}
+		if r.Pipeline == (pipeline.ID{}) {
+			return fmt.Errorf(`route %d requires a "pipeline"`, i)
+		}
+	}
+	if cfg.DefaultPipeline == (pipeline.ID{}) {
+		return errors.New(`"default_pipeline" is required`)
+	}
+	switch cfg.ErrorMode {
+	case ottl.IgnoreError, ottl.PropagateError, ottl.SilentError:
+	default:
+		return fmt.Errorf("unsupported error_mode %q, must be %s, %s or %s", cfg.ErrorMode, ottl.IgnoreError, ottl.PropagateError, ottl.SilentError)
+	}
+	return nil
+}
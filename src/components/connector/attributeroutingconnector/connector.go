@@ -0,0 +1,358 @@
+package attributeroutingconnector
+
+import (
+	"context"
+	"errors"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl/contexts/ottldatapoint"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl/contexts/ottllog"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl/contexts/ottlspan"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+type logsConnector struct {
+	component.StartFunc
+	component.ShutdownFunc
+	router *router[ottllog.TransformContext, consumer.Logs]
+}
+
+func (c *logsConnector) Capabilities() consumer.Capabilities {
+	return consumer.Capabilities{MutatesData: false}
+}
+
+func (c *logsConnector) ConsumeLogs(ctx context.Context, ld plog.Logs) error {
+	var routes []int
+	for i := 0; i < ld.ResourceLogs().Len(); i++ {
+		rl := ld.ResourceLogs().At(i)
+		for j := 0; j < rl.ScopeLogs().Len(); j++ {
+			sl := rl.ScopeLogs().At(j)
+			for k := 0; k < sl.LogRecords().Len(); k++ {
+				lr := sl.LogRecords().At(k)
+				route, err := c.router.match(ctx, lr.Attributes(), rl.Resource().Attributes(), func() ottllog.TransformContext {
+					return ottllog.NewTransformContext(lr, sl.Scope(), rl.Resource(), sl, rl)
+				})
+				if err != nil {
+					return err
+				}
+				routes = append(routes, route)
+			}
+		}
+	}
+	if len(routes) == 0 {
+		return nil
+	}
+	if sameRoute(routes) {
+		return c.router.consumers[routes[0]].ConsumeLogs(ctx, ld)
+	}
+
+	outs := map[int]plog.Logs{}
+	n := 0
+	for i := 0; i < ld.ResourceLogs().Len(); i++ {
+		rl := ld.ResourceLogs().At(i)
+		rlOuts := map[int]plog.ResourceLogs{}
+		for j := 0; j < rl.ScopeLogs().Len(); j++ {
+			sl := rl.ScopeLogs().At(j)
+			slOuts := map[int]plog.ScopeLogs{}
+			for k := 0; k < sl.LogRecords().Len(); k++ {
+				route := routes[n]
+				n++
+
+				slOut, ok := slOuts[route]
+				if !ok {
+					rlOut, ok := rlOuts[route]
+					if !ok {
+						out, ok := outs[route]
+						if !ok {
+							out = plog.NewLogs()
+							outs[route] = out
+						}
+						rlOut = out.ResourceLogs().AppendEmpty()
+						rl.Resource().CopyTo(rlOut.Resource())
+						rlOut.SetSchemaUrl(rl.SchemaUrl())
+						rlOuts[route] = rlOut
+					}
+					slOut = rlOut.ScopeLogs().AppendEmpty()
+					sl.Scope().CopyTo(slOut.Scope())
+					slOut.SetSchemaUrl(sl.SchemaUrl())
+					slOuts[route] = slOut
+				}
+				sl.LogRecords().At(k).CopyTo(slOut.LogRecords().AppendEmpty())
+			}
+		}
+	}
+
+	var errs error
+	for route, c := range c.router.consumers {
+		if out, ok := outs[route]; ok {
+			errs = errors.Join(errs, c.ConsumeLogs(ctx, out))
+		}
+	}
+	return errs
+}
+
+type tracesConnector struct {
+	component.StartFunc
+	component.ShutdownFunc
+	router *router[ottlspan.TransformContext, consumer.Traces]
+}
+
+func (c *tracesConnector) Capabilities() consumer.Capabilities {
+	return consumer.Capabilities{MutatesData: false}
+}
+
+func (c *tracesConnector) ConsumeTraces(ctx context.Context, td ptrace.Traces) error {
+	var routes []int
+	for i := 0; i < td.ResourceSpans().Len(); i++ {
+		rs := td.ResourceSpans().At(i)
+		for j := 0; j < rs.ScopeSpans().Len(); j++ {
+			ss := rs.ScopeSpans().At(j)
+			for k := 0; k < ss.Spans().Len(); k++ {
+				span := ss.Spans().At(k)
+				route, err := c.router.match(ctx, span.Attributes(), rs.Resource().Attributes(), func() ottlspan.TransformContext {
+					return ottlspan.NewTransformContext(span, ss.Scope(), rs.Resource(), ss, rs)
+				})
+				if err != nil {
+					return err
+				}
+				routes = append(routes, route)
+			}
+		}
+	}
+	if len(routes) == 0 {
+		return nil
+	}
+	if sameRoute(routes) {
+		return c.router.consumers[routes[0]].ConsumeTraces(ctx, td)
+	}
+
+	outs := map[int]ptrace.Traces{}
+	n := 0
+	for i := 0; i < td.ResourceSpans().Len(); i++ {
+		rs := td.ResourceSpans().At(i)
+		rsOuts := map[int]ptrace.ResourceSpans{}
+		for j := 0; j < rs.ScopeSpans().Len(); j++ {
+			ss := rs.ScopeSpans().At(j)
+			ssOuts := map[int]ptrace.ScopeSpans{}
+			for k := 0; k < ss.Spans().Len(); k++ {
+				route := routes[n]
+				n++
+
+				ssOut, ok := ssOuts[route]
+				if !ok {
+					rsOut, ok := rsOuts[route]
+					if !ok {
+						out, ok := outs[route]
+						if !ok {
+							out = ptrace.NewTraces()
+							outs[route] = out
+						}
+						rsOut = out.ResourceSpans().AppendEmpty()
+						rs.Resource().CopyTo(rsOut.Resource())
+						rsOut.SetSchemaUrl(rs.SchemaUrl())
+						rsOuts[route] = rsOut
+					}
+					ssOut = rsOut.ScopeSpans().AppendEmpty()
+					ss.Scope().CopyTo(ssOut.Scope())
+					ssOut.SetSchemaUrl(ss.SchemaUrl())
+					ssOuts[route] = ssOut
+				}
+				ss.Spans().At(k).CopyTo(ssOut.Spans().AppendEmpty())
+			}
+		}
+	}
+
+	var errs error
+	for route, c := range c.router.consumers {
+		if out, ok := outs[route]; ok {
+			errs = errors.Join(errs, c.ConsumeTraces(ctx, out))
+		}
+	}
+	return errs
+}
+
+type dataPoint[T any] interface {
+	Attributes() pcommon.Map
+	CopyTo(T)
+}
+
+type dataPointSlice[T any] interface {
+	Len() int
+	At(int) T
+	AppendEmpty() T
+}
+
+type metricsConnector struct {
+	component.StartFunc
+	component.ShutdownFunc
+	router *router[ottldatapoint.TransformContext, consumer.Metrics]
+}
+
+func (c *metricsConnector) Capabilities() consumer.Capabilities {
+	return consumer.Capabilities{MutatesData: false}
+}
+
+// ConsumeMetrics routes every datapoint on its own, so a metric can be split
+// between pipelines.
+func (c *metricsConnector) ConsumeMetrics(ctx context.Context, md pmetric.Metrics) error {
+	var routes []int
+	for i := 0; i < md.ResourceMetrics().Len(); i++ {
+		rm := md.ResourceMetrics().At(i)
+		for j := 0; j < rm.ScopeMetrics().Len(); j++ {
+			sm := rm.ScopeMetrics().At(j)
+			for k := 0; k < sm.Metrics().Len(); k++ {
+				m := sm.Metrics().At(k)
+				err := eachDataPoint(m, func(dp any, attrs pcommon.Map) error {
+					route, err := c.router.match(ctx, attrs, rm.Resource().Attributes(), func() ottldatapoint.TransformContext {
+						return ottldatapoint.NewTransformContext(dp, m, sm.Metrics(), sm.Scope(), rm.Resource(), sm, rm)
+					})
+					routes = append(routes, route)
+					return err
+				})
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+	if len(routes) == 0 {
+		return nil
+	}
+	if sameRoute(routes) {
+		return c.router.consumers[routes[0]].ConsumeMetrics(ctx, md)
+	}
+
+	outs := map[int]pmetric.Metrics{}
+	n := 0
+	for i := 0; i < md.ResourceMetrics().Len(); i++ {
+		rm := md.ResourceMetrics().At(i)
+		rmOuts := map[int]pmetric.ResourceMetrics{}
+		for j := 0; j < rm.ScopeMetrics().Len(); j++ {
+			sm := rm.ScopeMetrics().At(j)
+			smOuts := map[int]pmetric.ScopeMetrics{}
+			for k := 0; k < sm.Metrics().Len(); k++ {
+				m := sm.Metrics().At(k)
+				mOuts := map[int]pmetric.Metric{}
+				metricFor := func(route int) pmetric.Metric {
+					if mOut, ok := mOuts[route]; ok {
+						return mOut
+					}
+					smOut, ok := smOuts[route]
+					if !ok {
+						rmOut, ok := rmOuts[route]
+						if !ok {
+							out, ok := outs[route]
+							if !ok {
+								out = pmetric.NewMetrics()
+								outs[route] = out
+							}
+							rmOut = out.ResourceMetrics().AppendEmpty()
+							rm.Resource().CopyTo(rmOut.Resource())
+							rmOut.SetSchemaUrl(rm.SchemaUrl())
+							rmOuts[route] = rmOut
+						}
+						smOut = rmOut.ScopeMetrics().AppendEmpty()
+						sm.Scope().CopyTo(smOut.Scope())
+						smOut.SetSchemaUrl(sm.SchemaUrl())
+						smOuts[route] = smOut
+					}
+					mOut := smOut.Metrics().AppendEmpty()
+					copyMetricWithoutDataPoints(m, mOut)
+					mOuts[route] = mOut
+					return mOut
+				}
+
+				switch m.Type() {
+				case pmetric.MetricTypeGauge:
+					splitDataPoints(m.Gauge().DataPoints(), routes, &n, func(route int) pmetric.NumberDataPointSlice {
+						return metricFor(route).Gauge().DataPoints()
+					})
+				case pmetric.MetricTypeSum:
+					splitDataPoints(m.Sum().DataPoints(), routes, &n, func(route int) pmetric.NumberDataPointSlice {
+						return metricFor(route).Sum().DataPoints()
+					})
+				case pmetric.MetricTypeHistogram:
+					splitDataPoints(m.Histogram().DataPoints(), routes, &n, func(route int) pmetric.HistogramDataPointSlice {
+						return metricFor(route).Histogram().DataPoints()
+					})
+				case pmetric.MetricTypeExponentialHistogram:
+					splitDataPoints(m.ExponentialHistogram().DataPoints(), routes, &n, func(route int) pmetric.ExponentialHistogramDataPointSlice {
+						return metricFor(route).ExponentialHistogram().DataPoints()
+					})
+				case pmetric.MetricTypeSummary:
+					splitDataPoints(m.Summary().DataPoints(), routes, &n, func(route int) pmetric.SummaryDataPointSlice {
+						return metricFor(route).Summary().DataPoints()
+					})
+				}
+			}
+		}
+	}
+
+	var errs error
+	for route, c := range c.router.consumers {
+		if out, ok := outs[route]; ok {
+			errs = errors.Join(errs, c.ConsumeMetrics(ctx, out))
+		}
+	}
+	return errs
+}
+
+func eachDataPoint(m pmetric.Metric, f func(dp any, attrs pcommon.Map) error) error {
+	switch m.Type() {
+	case pmetric.MetricTypeGauge:
+		return eachDataPointOf(m.Gauge().DataPoints(), f)
+	case pmetric.MetricTypeSum:
+		return eachDataPointOf(m.Sum().DataPoints(), f)
+	case pmetric.MetricTypeHistogram:
+		return eachDataPointOf(m.Histogram().DataPoints(), f)
+	case pmetric.MetricTypeExponentialHistogram:
+		return eachDataPointOf(m.ExponentialHistogram().DataPoints(), f)
+	case pmetric.MetricTypeSummary:
+		return eachDataPointOf(m.Summary().DataPoints(), f)
+	}
+	return nil
+}
+
+func eachDataPointOf[T dataPoint[T]](dps dataPointSlice[T], f func(dp any, attrs pcommon.Map) error) error {
+	for i := 0; i < dps.Len(); i++ {
+		if err := f(dps.At(i), dps.At(i).Attributes()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// splitDataPoints copies every datapoint to the slice of its route. n is the
+// index of the first datapoint in routes, and is advanced past them.
+func splitDataPoints[T dataPoint[T], S dataPointSlice[T]](dps S, routes []int, n *int, slice func(route int) S) {
+	for i := 0; i < dps.Len(); i++ {
+		dps.At(i).CopyTo(slice(routes[*n]).AppendEmpty())
+		*n++
+	}
+}
+
+func copyMetricWithoutDataPoints(from, to pmetric.Metric) {
+	to.SetName(from.Name())
+	to.SetDescription(from.Description())
+	to.SetUnit(from.Unit())
+	from.Metadata().CopyTo(to.Metadata())
+
+	switch from.Type() {
+	case pmetric.MetricTypeGauge:
+		to.SetEmptyGauge()
+	case pmetric.MetricTypeSum:
+		sum := to.SetEmptySum()
+		sum.SetIsMonotonic(from.Sum().IsMonotonic())
+		sum.SetAggregationTemporality(from.Sum().AggregationTemporality())
+	case pmetric.MetricTypeHistogram:
+		to.SetEmptyHistogram().SetAggregationTemporality(from.Histogram().AggregationTemporality())
+	case pmetric.MetricTypeExponentialHistogram:
+		to.SetEmptyExponentialHistogram().SetAggregationTemporality(from.ExponentialHistogram().AggregationTemporality())
+	case pmetric.MetricTypeSummary:
+		to.SetEmptySummary()
+	}
+}
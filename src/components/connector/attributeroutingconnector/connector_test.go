@@ -0,0 +1,337 @@
+package attributeroutingconnector_test
+
+import (
+	"context"
+
+	"code.cloudfoundry.org/otel-collector-release/src/components/connector/attributeroutingconnector"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl"
+	"go.opentelemetry.io/collector/component/componenttest"
+	"go.opentelemetry.io/collector/connector"
+	"go.opentelemetry.io/collector/connector/connectortest"
+	"go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/consumer/consumertest"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.opentelemetry.io/collector/pipeline"
+)
+
+var (
+	isoSegLogs  = pipeline.NewIDWithName(pipeline.SignalLogs, "isoseg")
+	systemLogs  = pipeline.NewIDWithName(pipeline.SignalLogs, "system")
+	defaultLogs = pipeline.NewID(pipeline.SignalLogs)
+)
+
+var _ = Describe("Attribute routing connector", func() {
+	var cfg *attributeroutingconnector.Config
+
+	BeforeEach(func() {
+		cfg = attributeroutingconnector.NewFactory().CreateDefaultConfig().(*attributeroutingconnector.Config)
+		cfg.Routes = []attributeroutingconnector.RouteConfig{
+			{Attributes: map[string]string{"placement_tag": "isoseg"}, Pipeline: isoSegLogs},
+			{Condition: `attributes["organization_name"] == "system"`, Pipeline: systemLogs},
+		}
+		cfg.DefaultPipeline = defaultLogs
+	})
+
+	Describe("logs", func() {
+		var (
+			sinks map[pipeline.ID]*consumertest.LogsSink
+			c     connector.Logs
+		)
+
+		JustBeforeEach(func() {
+			Expect(cfg.Validate()).To(Succeed())
+			sinks = map[pipeline.ID]*consumertest.LogsSink{}
+			consumers := map[pipeline.ID]consumer.Logs{}
+			for _, id := range []pipeline.ID{isoSegLogs, systemLogs, defaultLogs} {
+				sinks[id] = new(consumertest.LogsSink)
+				consumers[id] = sinks[id]
+			}
+			factory := attributeroutingconnector.NewFactory()
+
+			var err error
+			c, err = factory.CreateLogsToLogs(context.Background(), connectortest.NewNopSettings(factory.Type()), cfg, connector.NewLogsRouter(consumers))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(c.Start(context.Background(), componenttest.NewNopHost())).To(Succeed())
+			DeferCleanup(c.Shutdown, context.Background())
+		})
+
+		It("splits records between the pipelines of the first route they match", func() {
+			ld := plog.NewLogs()
+			rl := ld.ResourceLogs().AppendEmpty()
+			rl.Resource().Attributes().PutStr("placement_tag", "isoseg")
+			sl := rl.ScopeLogs().AppendEmpty()
+			sl.Scope().SetName("loggregator")
+			addRecord(sl, "isolated app", "")
+			addRecord(sl, "isolated system app", "system")
+
+			rl = ld.ResourceLogs().AppendEmpty()
+			sl = rl.ScopeLogs().AppendEmpty()
+			addRecord(sl, "system app", "system")
+			addRecord(sl, "other app", "other")
+			Expect(c.ConsumeLogs(context.Background(), ld)).To(Succeed())
+
+			Expect(bodies(sinks[isoSegLogs])).To(Equal([]string{"isolated app", "isolated system app"}))
+			Expect(bodies(sinks[systemLogs])).To(Equal([]string{"system app"}))
+			Expect(bodies(sinks[defaultLogs])).To(Equal([]string{"other app"}))
+
+			out := sinks[isoSegLogs].AllLogs()[0].ResourceLogs().At(0)
+			Expect(out.Resource().Attributes().AsRaw()).To(Equal(map[string]any{"placement_tag": "isoseg"}))
+			Expect(out.ScopeLogs().At(0).Scope().Name()).To(Equal("loggregator"))
+		})
+
+		It("passes the data on as it is when every record has the same route", func() {
+			ld := plog.NewLogs()
+			sl := ld.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty()
+			addRecord(sl, "a", "other")
+			addRecord(sl, "b", "")
+			Expect(c.ConsumeLogs(context.Background(), ld)).To(Succeed())
+
+			Expect(sinks[defaultLogs].AllLogs()).To(HaveLen(1))
+			Expect(sinks[defaultLogs].AllLogs()[0]).To(Equal(ld))
+			Expect(sinks[isoSegLogs].AllLogs()).To(BeEmpty())
+			Expect(sinks[systemLogs].AllLogs()).To(BeEmpty())
+		})
+
+		It("reads route attributes from the records too", func() {
+			ld := plog.NewLogs()
+			sl := ld.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty()
+			addRecord(sl, "isolated", "")
+			sl.LogRecords().At(0).Attributes().PutStr("placement_tag", "isoseg")
+			addRecord(sl, "other", "")
+			Expect(c.ConsumeLogs(context.Background(), ld)).To(Succeed())
+
+			Expect(bodies(sinks[isoSegLogs])).To(Equal([]string{"isolated"}))
+			Expect(bodies(sinks[defaultLogs])).To(Equal([]string{"other"}))
+		})
+
+		Context("when a route needs both attributes and a condition", func() {
+			BeforeEach(func() {
+				cfg.Routes = []attributeroutingconnector.RouteConfig{{
+					Attributes: map[string]string{"placement_tag": "isoseg"},
+					Condition:  `severity_number >= SEVERITY_NUMBER_ERROR`,
+					Pipeline:   isoSegLogs,
+				}}
+			})
+
+			It("only matches records meeting both", func() {
+				ld := plog.NewLogs()
+				rl := ld.ResourceLogs().AppendEmpty()
+				rl.Resource().Attributes().PutStr("placement_tag", "isoseg")
+				sl := rl.ScopeLogs().AppendEmpty()
+				addRecord(sl, "error", "")
+				sl.LogRecords().At(0).SetSeverityNumber(plog.SeverityNumberError)
+				addRecord(sl, "info", "")
+				sl.LogRecords().At(1).SetSeverityNumber(plog.SeverityNumberInfo)
+				Expect(c.ConsumeLogs(context.Background(), ld)).To(Succeed())
+
+				Expect(bodies(sinks[isoSegLogs])).To(Equal([]string{"error"}))
+				Expect(bodies(sinks[defaultLogs])).To(Equal([]string{"info"}))
+			})
+		})
+
+		Context("when a condition fails to evaluate in propagate mode", func() {
+			BeforeEach(func() {
+				cfg.Routes = []attributeroutingconnector.RouteConfig{{Condition: `Int(body) > 0`, Pipeline: isoSegLogs}}
+				cfg.ErrorMode = ottl.PropagateError
+			})
+
+			It("returns the error", func() {
+				ld := plog.NewLogs()
+				sl := ld.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty()
+				addRecord(sl, "a", "")
+				sl.LogRecords().At(0).Body().SetEmptyMap()
+
+				Expect(c.ConsumeLogs(context.Background(), ld)).To(MatchError(ContainSubstring("failed to eval condition")))
+			})
+		})
+
+		Context("when a condition fails to evaluate in ignore mode", func() {
+			BeforeEach(func() {
+				cfg.Routes = []attributeroutingconnector.RouteConfig{{Condition: `Int(body) > 0`, Pipeline: isoSegLogs}}
+			})
+
+			It("treats the route as not matching", func() {
+				ld := plog.NewLogs()
+				sl := ld.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty()
+				addRecord(sl, "a", "")
+				sl.LogRecords().At(0).Body().SetEmptyMap()
+				Expect(c.ConsumeLogs(context.Background(), ld)).To(Succeed())
+
+				Expect(sinks[defaultLogs].LogRecordCount()).To(Equal(1))
+			})
+		})
+	})
+
+	It("fails to start routing to a pipeline it is not connected to", func() {
+		factory := attributeroutingconnector.NewFactory()
+		router := connector.NewLogsRouter(map[pipeline.ID]consumer.Logs{
+			isoSegLogs:  consumertest.NewNop(),
+			defaultLogs: consumertest.NewNop(),
+		})
+
+		_, err := factory.CreateLogsToLogs(context.Background(), connectortest.NewNopSettings(factory.Type()), cfg, router)
+		Expect(err).To(MatchError(ContainSubstring("route 1")))
+	})
+
+	It("fails to parse conditions that do not fit the signal", func() {
+		cfg.Routes = []attributeroutingconnector.RouteConfig{{Condition: `body == "a"`, Pipeline: pipeline.NewID(pipeline.SignalTraces)}}
+		cfg.DefaultPipeline = pipeline.NewID(pipeline.SignalTraces)
+		factory := attributeroutingconnector.NewFactory()
+		router := connector.NewTracesRouter(map[pipeline.ID]consumer.Traces{
+			pipeline.NewID(pipeline.SignalTraces): consumertest.NewNop(),
+		})
+
+		_, err := factory.CreateTracesToTraces(context.Background(), connectortest.NewNopSettings(factory.Type()), cfg, router)
+		Expect(err).To(MatchError(ContainSubstring("route 0")))
+	})
+
+	Describe("traces", func() {
+		It("splits spans by span and resource attributes", func() {
+			isoSeg := pipeline.NewIDWithName(pipeline.SignalTraces, "isoseg")
+			def := pipeline.NewID(pipeline.SignalTraces)
+			cfg.Routes = []attributeroutingconnector.RouteConfig{
+				{Attributes: map[string]string{"source_id": "gorouter", "placement_tag": "isoseg"}, Pipeline: isoSeg},
+			}
+			cfg.DefaultPipeline = def
+			isoSegSink, defSink := new(consumertest.TracesSink), new(consumertest.TracesSink)
+			factory := attributeroutingconnector.NewFactory()
+			c, err := factory.CreateTracesToTraces(context.Background(), connectortest.NewNopSettings(factory.Type()), cfg,
+				connector.NewTracesRouter(map[pipeline.ID]consumer.Traces{isoSeg: isoSegSink, def: defSink}))
+			Expect(err).NotTo(HaveOccurred())
+
+			td := ptrace.NewTraces()
+			rs := td.ResourceSpans().AppendEmpty()
+			rs.Resource().Attributes().PutStr("placement_tag", "isoseg")
+			spans := rs.ScopeSpans().AppendEmpty().Spans()
+			span := spans.AppendEmpty()
+			span.SetName("routed")
+			span.Attributes().PutStr("source_id", "gorouter")
+			span = spans.AppendEmpty()
+			span.SetName("app")
+			span.Attributes().PutStr("source_id", "my-app")
+			Expect(c.ConsumeTraces(context.Background(), td)).To(Succeed())
+
+			Expect(isoSegSink.SpanCount()).To(Equal(1))
+			Expect(isoSegSink.AllTraces()[0].ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0).Name()).To(Equal("routed"))
+			Expect(defSink.SpanCount()).To(Equal(1))
+			Expect(defSink.AllTraces()[0].ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0).Name()).To(Equal("app"))
+		})
+	})
+
+	Describe("metrics", func() {
+		It("splits datapoints, keeping the metric they belong to", func() {
+			system := pipeline.NewIDWithName(pipeline.SignalMetrics, "system")
+			def := pipeline.NewID(pipeline.SignalMetrics)
+			cfg.Routes = []attributeroutingconnector.RouteConfig{
+				{Condition: `attributes["organization_name"] == "system"`, Pipeline: system},
+			}
+			cfg.DefaultPipeline = def
+			systemSink, defSink := new(consumertest.MetricsSink), new(consumertest.MetricsSink)
+			factory := attributeroutingconnector.NewFactory()
+			c, err := factory.CreateMetricsToMetrics(context.Background(), connectortest.NewNopSettings(factory.Type()), cfg,
+				connector.NewMetricsRouter(map[pipeline.ID]consumer.Metrics{system: systemSink, def: defSink}))
+			Expect(err).NotTo(HaveOccurred())
+
+			md := pmetric.NewMetrics()
+			ms := md.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty().Metrics()
+			m := ms.AppendEmpty()
+			m.SetName("requests")
+			m.SetUnit("{requests}")
+			sum := m.SetEmptySum()
+			sum.SetIsMonotonic(true)
+			sum.SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
+			dp := sum.DataPoints().AppendEmpty()
+			dp.Attributes().PutStr("organization_name", "system")
+			dp.SetIntValue(1)
+			dp = sum.DataPoints().AppendEmpty()
+			dp.Attributes().PutStr("organization_name", "other")
+			dp.SetIntValue(2)
+			h := ms.AppendEmpty()
+			h.SetName("latency")
+			h.SetEmptyHistogram().DataPoints().AppendEmpty().Attributes().PutStr("organization_name", "other")
+			Expect(c.ConsumeMetrics(context.Background(), md)).To(Succeed())
+
+			Expect(systemSink.DataPointCount()).To(Equal(1))
+			got := systemSink.AllMetrics()[0].ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics()
+			Expect(got.Len()).To(Equal(1))
+			Expect(got.At(0).Name()).To(Equal("requests"))
+			Expect(got.At(0).Unit()).To(Equal("{requests}"))
+			Expect(got.At(0).Sum().IsMonotonic()).To(BeTrue())
+			Expect(got.At(0).Sum().AggregationTemporality()).To(Equal(pmetric.AggregationTemporalityCumulative))
+			Expect(got.At(0).Sum().DataPoints().At(0).IntValue()).To(Equal(int64(1)))
+
+			Expect(defSink.DataPointCount()).To(Equal(2))
+			got = defSink.AllMetrics()[0].ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics()
+			Expect(got.Len()).To(Equal(2))
+			Expect(got.At(0).Sum().DataPoints().At(0).IntValue()).To(Equal(int64(2)))
+			Expect(got.At(1).Name()).To(Equal("latency"))
+		})
+	})
+})
+
+var _ = Describe("Config", func() {
+	var cfg *attributeroutingconnector.Config
+
+	BeforeEach(func() {
+		cfg = attributeroutingconnector.NewFactory().CreateDefaultConfig().(*attributeroutingconnector.Config)
+		cfg.Routes = []attributeroutingconnector.RouteConfig{
+			{Attributes: map[string]string{"placement_tag": "isoseg"}, Pipeline: isoSegLogs},
+		}
+		cfg.DefaultPipeline = defaultLogs
+	})
+
+	It("is valid with a route and a default pipeline", func() {
+		Expect(cfg.Validate()).To(Succeed())
+	})
+
+	It("requires routes", func() {
+		cfg.Routes = nil
+		Expect(cfg.Validate()).To(MatchError(`"routes" must not be empty`))
+	})
+
+	It("requires every route to match on something and to have a pipeline", func() {
+		cfg.Routes[0].Attributes = nil
+		Expect(cfg.Validate()).To(MatchError(`route 0 requires "attributes" or a "condition"`))
+
+		cfg.Routes[0].Condition = "true"
+		cfg.Routes[0].Pipeline = pipeline.ID{}
+		Expect(cfg.Validate()).To(MatchError(`route 0 requires a "pipeline"`))
+	})
+
+	It("requires a default pipeline", func() {
+		cfg.DefaultPipeline = pipeline.ID{}
+		Expect(cfg.Validate()).To(MatchError(`"default_pipeline" is required`))
+	})
+
+	It("rejects unknown error modes", func() {
+		cfg.ErrorMode = "panic"
+		Expect(cfg.Validate()).To(MatchError(`unsupported error_mode "panic", must be ignore, propagate or silent`))
+	})
+})
+
+func addRecord(sl plog.ScopeLogs, body, org string) {
+	lr := sl.LogRecords().AppendEmpty()
+	lr.Body().SetStr(body)
+	if org != "" {
+		lr.Attributes().PutStr("organization_name", org)
+	}
+}
+
+func bodies(sink *consumertest.LogsSink) []string {
+	var bodies []string
+	for _, ld := range sink.AllLogs() {
+		for i := 0; i < ld.ResourceLogs().Len(); i++ {
+			for j := 0; j < ld.ResourceLogs().At(i).ScopeLogs().Len(); j++ {
+				records := ld.ResourceLogs().At(i).ScopeLogs().At(j).LogRecords()
+				for k := 0; k < records.Len(); k++ {
+					bodies = append(bodies, records.At(k).Body().AsString())
+				}
+			}
+		}
+	}
+	return bodies
+}
@@ -0,0 +1,73 @@
+// Package attributeroutingconnector implements a connector that splits
+// telemetry between pipelines by attributes or OTTL conditions.
+package attributeroutingconnector
+
+import (
+	"context"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl/contexts/ottldatapoint"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl/contexts/ottllog"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl/contexts/ottlspan"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl/ottlfuncs"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/connector"
+	"go.opentelemetry.io/collector/consumer"
+)
+
+const stability = component.StabilityLevelAlpha
+
+var componentType = component.MustNewType("attributerouting")
+
+// NewFactory creates a factory for the attribute routing connector.
+func NewFactory() connector.Factory {
+	return connector.NewFactory(
+		componentType,
+		createDefaultConfig,
+		connector.WithTracesToTraces(createTracesToTraces, stability),
+		connector.WithMetricsToMetrics(createMetricsToMetrics, stability),
+		connector.WithLogsToLogs(createLogsToLogs, stability),
+	)
+}
+
+func createDefaultConfig() component.Config {
+	return &Config{
+		ErrorMode: ottl.IgnoreError,
+	}
+}
+
+func createTracesToTraces(_ context.Context, set connector.Settings, cfg component.Config, next consumer.Traces) (connector.Traces, error) {
+	parser, err := ottlspan.NewParser(ottlfuncs.StandardConverters[ottlspan.TransformContext](), set.TelemetrySettings)
+	if err != nil {
+		return nil, err
+	}
+	r, err := newRouter(cfg.(*Config), set.TelemetrySettings, parser, next.(connector.TracesRouterAndConsumer).Consumer)
+	if err != nil {
+		return nil, err
+	}
+	return &tracesConnector{router: r}, nil
+}
+
+func createMetricsToMetrics(_ context.Context, set connector.Settings, cfg component.Config, next consumer.Metrics) (connector.Metrics, error) {
+	parser, err := ottldatapoint.NewParser(ottlfuncs.StandardConverters[ottldatapoint.TransformContext](), set.TelemetrySettings)
+	if err != nil {
+		return nil, err
+	}
+	r, err := newRouter(cfg.(*Config), set.TelemetrySettings, parser, next.(connector.MetricsRouterAndConsumer).Consumer)
+	if err != nil {
+		return nil, err
+	}
+	return &metricsConnector{router: r}, nil
+}
+
+func createLogsToLogs(_ context.Context, set connector.Settings, cfg component.Config, next consumer.Logs) (connector.Logs, error) {
+	parser, err := ottllog.NewParser(ottlfuncs.StandardConverters[ottllog.TransformContext](), set.TelemetrySettings)
+	if err != nil {
+		return nil, err
+	}
+	r, err := newRouter(cfg.(*Config), set.TelemetrySettings, parser, next.(connector.LogsRouterAndConsumer).Consumer)
+	if err != nil {
+		return nil, err
+	}
+	return &logsConnector{router: r}, nil
+}
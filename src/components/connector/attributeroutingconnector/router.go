@@ -0,0 +1,100 @@
+package attributeroutingconnector
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pipeline"
+)
+
+type route[K any] struct {
+	attributes map[string]string
+	condition  *ottl.ConditionSequence[K]
+}
+
+// router finds the route of a record. It is shared by the connectors of
+// every signal, K being the OTTL context of the signal's records and C the
+// signal's consumer.
+type router[K, C any] struct {
+	routes []route[K]
+
+	// consumers holds the consumer of every route, followed by the consumer
+	// of the default pipeline.
+	consumers []C
+}
+
+func newRouter[K, C any](cfg *Config, set component.TelemetrySettings, parser ottl.Parser[K], consumer func(...pipeline.ID) (C, error)) (*router[K, C], error) {
+	r := &router[K, C]{}
+	for i, rc := range cfg.Routes {
+		rt := route[K]{attributes: rc.Attributes}
+		if rc.Condition != "" {
+			condition, err := parser.ParseCondition(rc.Condition)
+			if err != nil {
+				return nil, fmt.Errorf("route %d: %w", i, err)
+			}
+			seq := ottl.NewConditionSequence([]*ottl.Condition[K]{condition}, set, ottl.WithConditionSequenceErrorMode[K](cfg.ErrorMode))
+			rt.condition = &seq
+		}
+		c, err := consumer(rc.Pipeline)
+		if err != nil {
+			return nil, fmt.Errorf("route %d: %w", i, err)
+		}
+		r.routes = append(r.routes, rt)
+		r.consumers = append(r.consumers, c)
+	}
+
+	c, err := consumer(cfg.DefaultPipeline)
+	if err != nil {
+		return nil, fmt.Errorf("default pipeline: %w", err)
+	}
+	r.consumers = append(r.consumers, c)
+	return r, nil
+}
+
+// match returns the index of the first route the record matches, or the
+// index of the default pipeline.
+func (r *router[K, C]) match(ctx context.Context, attrs, resource pcommon.Map, tCtx func() K) (int, error) {
+	for i, rt := range r.routes {
+		if !matchAttributes(rt.attributes, attrs, resource) {
+			continue
+		}
+		if rt.condition != nil {
+			ok, err := rt.condition.Eval(ctx, tCtx())
+			if err != nil {
+				return 0, err
+			}
+			if !ok {
+				continue
+			}
+		}
+		return i, nil
+	}
+	return len(r.routes), nil
+}
+
+func matchAttributes(want map[string]string, attrs, resource pcommon.Map) bool {
+	for k, v := range want {
+		got, ok := attrs.Get(k)
+		if !ok {
+			got, ok = resource.Get(k)
+		}
+		if !ok || got.AsString() != v {
+			return false
+		}
+	}
+	return true
+}
+
+// sameRoute reports whether every record goes to the same route, in which
+// case the data is passed on as it is.
+func sameRoute(routes []int) bool {
+	for _, r := range routes {
+		if r != routes[0] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,76 @@
+package logcountconnector
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl"
+	"go.opentelemetry.io/collector/component"
+	"go.uber.org/zap"
+)
+
+// attributeSeverity is the severity text of the log record, or the name of
+// its severity number when there is no text.
+const attributeSeverity = "severity"
+
+// Config defines the configuration for the log count connector.
+type Config struct {
+	// Attributes are the attributes the counts are grouped by. severity is
+	// derived from the record, any other attribute is read from the record
+	// attributes and then from the resource attributes.
+	Attributes []string `mapstructure:"attributes"`
+
+	// Conditions are OTTL log conditions. Only records matching at least
+	// one of them are counted. All records are counted when empty.
+	Conditions []string `mapstructure:"conditions"`
+
+	// ErrorMode decides what happens when a condition fails to evaluate.
+	ErrorMode ottl.ErrorMode `mapstructure:"error_mode"`
+
+	// FlushInterval is how often the counts are passed on.
+	FlushInterval time.Duration `mapstructure:"flush_interval"`
+
+	// MaxSeries is the most attribute combinations kept. Records for new
+	// combinations beyond it are counted in a single series with the
+	// otel.overflow attribute.
+	MaxSeries int `mapstructure:"max_series"`
+
+	// MaxStale is how long a series is kept after its last record. A series
+	// that is counted again after it was removed starts again from zero.
+	MaxStale time.Duration `mapstructure:"max_stale"`
+}
+
+var _ component.Config = (*Config)(nil)
+
+// Validate checks the attributes, the conditions and the intervals.
+func (cfg *Config) Validate() error {
+	seen := map[string]bool{}
+	for _, a := range cfg.Attributes {
+		if a == "" {
+			return errors.New("attributes must not be empty")
+		}
+		if seen[a] {
+			return fmt.Errorf("duplicate attribute %q", a)
+		}
+		seen[a] = true
+	}
+	if _, err := parseConditions(cfg.Conditions, component.TelemetrySettings{Logger: zap.NewNop()}); err != nil {
+		return err
+	}
+	switch cfg.ErrorMode {
+	case ottl.IgnoreError, ottl.PropagateError, ottl.SilentError:
+	default:
+		return fmt.Errorf("unsupported error_mode %q, must be %s, %s or %s", cfg.ErrorMode, ottl.IgnoreError, ottl.PropagateError, ottl.SilentError)
+	}
+	if cfg.FlushInterval <= 0 {
+		return errors.New(`"flush_interval" must be positive`)
+	}
+	if cfg.MaxSeries <= 0 {
+		return errors.New(`"max_series" must be positive`)
+	}
+	if cfg.MaxStale <= 0 {
+		return errors.New(`"max_stale" must be positive`)
+	}
+	return nil
+}
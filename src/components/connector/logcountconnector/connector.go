@@ -0,0 +1,175 @@
+package logcountconnector
+
+import (
+	"context"
+	"time"
+
+	"code.cloudfoundry.org/otel-collector-release/src/components/internal/series"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl/contexts/ottllog"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl/ottlfuncs"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+)
+
+const (
+	metricRecords = "log.records"
+	metricBytes   = "log.body.bytes"
+
+	scopeName = "code.cloudfoundry.org/otel-collector-release/src/components/connector/logcountconnector"
+)
+
+// counts are the running totals for one combination of attribute values.
+type counts struct {
+	records uint64
+	bytes   uint64
+}
+
+type logCountConnector struct {
+	cfg        *Config
+	conditions *ottl.ConditionSequence[ottllog.TransformContext]
+
+	series  *series.Store[counts]
+	flusher *series.Flusher
+}
+
+func newLogCountConnector(cfg *Config, set component.TelemetrySettings, next consumer.Metrics) (*logCountConnector, error) {
+	c := &logCountConnector{
+		cfg:    cfg,
+		series: series.NewStore[counts](cfg.MaxSeries, cfg.MaxStale),
+	}
+	c.flusher = series.NewFlusher(cfg.FlushInterval, set.Logger, next, c.metrics)
+	if len(cfg.Conditions) > 0 {
+		conditions, err := parseConditions(cfg.Conditions, set)
+		if err != nil {
+			return nil, err
+		}
+		seq := ottllog.NewConditionSequence(conditions, set, ottllog.WithConditionSequenceErrorMode(cfg.ErrorMode))
+		c.conditions = &seq
+	}
+	return c, nil
+}
+
+func parseConditions(conditions []string, set component.TelemetrySettings) ([]*ottl.Condition[ottllog.TransformContext], error) {
+	parser, err := ottllog.NewParser(ottlfuncs.StandardConverters[ottllog.TransformContext](), set)
+	if err != nil {
+		return nil, err
+	}
+	return parser.ParseConditions(conditions)
+}
+
+func (c *logCountConnector) Capabilities() consumer.Capabilities {
+	return consumer.Capabilities{MutatesData: false}
+}
+
+func (c *logCountConnector) Start(context.Context, component.Host) error {
+	c.series.Start()
+	c.flusher.Start()
+	return nil
+}
+
+// Shutdown passes on the counts a last time.
+func (c *logCountConnector) Shutdown(ctx context.Context) error {
+	err := c.flusher.Shutdown(ctx)
+	c.series.Shutdown()
+	return err
+}
+
+func (c *logCountConnector) ConsumeLogs(ctx context.Context, ld plog.Logs) error {
+	for i := 0; i < ld.ResourceLogs().Len(); i++ {
+		rl := ld.ResourceLogs().At(i)
+		for j := 0; j < rl.ScopeLogs().Len(); j++ {
+			sl := rl.ScopeLogs().At(j)
+			for k := 0; k < sl.LogRecords().Len(); k++ {
+				lr := sl.LogRecords().At(k)
+				if c.conditions != nil {
+					tCtx := ottllog.NewTransformContext(lr, sl.Scope(), rl.Resource(), sl, rl)
+					match, err := c.conditions.Eval(ctx, tCtx)
+					if err != nil {
+						return err
+					}
+					if !match {
+						continue
+					}
+				}
+				c.record(lr, rl.Resource().Attributes())
+			}
+		}
+	}
+	return nil
+}
+
+func (c *logCountConnector) record(lr plog.LogRecord, resource pcommon.Map) {
+	attrs := pcommon.NewMap()
+	for _, a := range c.cfg.Attributes {
+		if v, ok := attribute(a, lr, resource); ok {
+			attrs.PutStr(a, v)
+		}
+	}
+	size := uint64(len(lr.Body().AsString()))
+
+	c.series.Update(attrs, pcommon.NewTimestampFromTime(time.Now()), func(n *counts) {
+		n.records++
+		n.bytes += size
+	})
+}
+
+// metrics returns the cumulative totals of every series.
+func (c *logCountConnector) metrics(now pcommon.Timestamp) pmetric.Metrics {
+	md := pmetric.NewMetrics()
+	sm := md.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty()
+	sm.Scope().SetName(scopeName)
+
+	records := sm.Metrics().AppendEmpty()
+	records.SetName(metricRecords)
+	records.SetDescription("Number of log records.")
+	records.SetUnit("{records}")
+	recordsSum := records.SetEmptySum()
+	recordsSum.SetIsMonotonic(true)
+	recordsSum.SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
+
+	bytes := sm.Metrics().AppendEmpty()
+	bytes.SetName(metricBytes)
+	bytes.SetDescription("Size of the log record bodies.")
+	bytes.SetUnit("By")
+	bytesSum := bytes.SetEmptySum()
+	bytesSum.SetIsMonotonic(true)
+	bytesSum.SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
+
+	c.series.Range(func(attrs pcommon.Map, start pcommon.Timestamp, n counts) {
+		dp := recordsSum.DataPoints().AppendEmpty()
+		attrs.CopyTo(dp.Attributes())
+		dp.SetStartTimestamp(start)
+		dp.SetTimestamp(now)
+		dp.SetIntValue(int64(n.records))
+
+		dp = bytesSum.DataPoints().AppendEmpty()
+		attrs.CopyTo(dp.Attributes())
+		dp.SetStartTimestamp(start)
+		dp.SetTimestamp(now)
+		dp.SetIntValue(int64(n.bytes))
+	})
+	return md
+}
+
+func attribute(name string, lr plog.LogRecord, resource pcommon.Map) (string, bool) {
+	if name == attributeSeverity {
+		if lr.SeverityText() != "" {
+			return lr.SeverityText(), true
+		}
+		if lr.SeverityNumber() != plog.SeverityNumberUnspecified {
+			return lr.SeverityNumber().String(), true
+		}
+		return "", false
+	}
+	if v, ok := lr.Attributes().Get(name); ok {
+		return v.AsString(), true
+	}
+	if v, ok := resource.Get(name); ok {
+		return v.AsString(), true
+	}
+	return "", false
+}
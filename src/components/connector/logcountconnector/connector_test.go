@@ -0,0 +1,310 @@
+package logcountconnector_test
+
+import (
+	"context"
+	"time"
+
+	"code.cloudfoundry.org/otel-collector-release/src/components/connector/logcountconnector"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl"
+	"go.opentelemetry.io/collector/component/componenttest"
+	"go.opentelemetry.io/collector/connector"
+	"go.opentelemetry.io/collector/connector/connectortest"
+	"go.opentelemetry.io/collector/consumer/consumertest"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+)
+
+var _ = Describe("Log count connector", func() {
+	var (
+		cfg  *logcountconnector.Config
+		sink *consumertest.MetricsSink
+		c    connector.Logs
+	)
+
+	BeforeEach(func() {
+		cfg = logcountconnector.NewFactory().CreateDefaultConfig().(*logcountconnector.Config)
+		cfg.FlushInterval = time.Hour
+		sink = new(consumertest.MetricsSink)
+	})
+
+	JustBeforeEach(func() {
+		Expect(cfg.Validate()).To(Succeed())
+		factory := logcountconnector.NewFactory()
+
+		var err error
+		c, err = factory.CreateLogsToMetrics(context.Background(), connectortest.NewNopSettings(factory.Type()), cfg, sink)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(c.Start(context.Background(), componenttest.NewNopHost())).To(Succeed())
+	})
+
+	// flush shuts the connector down, which passes on the counts, and
+	// returns them by name.
+	flush := func() map[string]pmetric.Metric {
+		Expect(c.Shutdown(context.Background())).To(Succeed())
+		Expect(sink.AllMetrics()).To(HaveLen(1))
+
+		metrics := map[string]pmetric.Metric{}
+		ms := sink.AllMetrics()[0].ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics()
+		for i := 0; i < ms.Len(); i++ {
+			metrics[ms.At(i).Name()] = ms.At(i)
+		}
+		return metrics
+	}
+
+	It("counts records and body bytes per org, space, app and severity", func() {
+		ld := plog.NewLogs()
+		addRecord(ld, "dora", "INFO", "hello")
+		addRecord(ld, "dora", "INFO", "world!")
+		addRecord(ld, "dora", "ERROR", "boom")
+		addRecord(ld, "spring-music", "INFO", "started")
+		Expect(c.ConsumeLogs(context.Background(), ld)).To(Succeed())
+
+		metrics := flush()
+		Expect(metrics).To(HaveLen(2))
+
+		records := metrics["log.records"].Sum()
+		Expect(records.AggregationTemporality()).To(Equal(pmetric.AggregationTemporalityCumulative))
+		Expect(records.IsMonotonic()).To(BeTrue())
+		Expect(values(records.DataPoints())).To(Equal(map[string]int64{
+			"dora INFO":         2,
+			"dora ERROR":        1,
+			"spring-music INFO": 1,
+		}))
+		Expect(records.DataPoints().At(0).Attributes().AsRaw()).To(HaveKeyWithValue("organization_name", "system"))
+		Expect(records.DataPoints().At(0).Attributes().AsRaw()).To(HaveKeyWithValue("space_name", "apps"))
+
+		Expect(metrics["log.body.bytes"].Unit()).To(Equal("By"))
+		Expect(values(metrics["log.body.bytes"].Sum().DataPoints())).To(Equal(map[string]int64{
+			"dora INFO":         11,
+			"dora ERROR":        4,
+			"spring-music INFO": 7,
+		}))
+	})
+
+	It("keeps counting across batches", func() {
+		for range 3 {
+			ld := plog.NewLogs()
+			addRecord(ld, "dora", "INFO", "hello")
+			Expect(c.ConsumeLogs(context.Background(), ld)).To(Succeed())
+		}
+
+		Expect(values(flush()["log.records"].Sum().DataPoints())).To(Equal(map[string]int64{"dora INFO": 3}))
+	})
+
+	It("uses the severity number when there is no severity text", func() {
+		ld := plog.NewLogs()
+		addRecord(ld, "dora", "", "hello")
+		ld.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0).SetSeverityNumber(plog.SeverityNumberWarn)
+		Expect(c.ConsumeLogs(context.Background(), ld)).To(Succeed())
+
+		Expect(values(flush()["log.records"].Sum().DataPoints())).To(Equal(map[string]int64{"dora Warn": 1}))
+	})
+
+	It("passes on nothing when there were no records", func() {
+		Expect(c.Shutdown(context.Background())).To(Succeed())
+		Expect(sink.AllMetrics()).To(BeEmpty())
+	})
+
+	Context("with conditions", func() {
+		BeforeEach(func() {
+			cfg.Conditions = []string{
+				`severity_number >= SEVERITY_NUMBER_ERROR`,
+				`IsMatch(body, "^WARN")`,
+			}
+		})
+
+		It("only counts records matching one of them", func() {
+			ld := plog.NewLogs()
+			addRecord(ld, "dora", "INFO", "hello")
+			addRecord(ld, "dora", "INFO", "WARN disk almost full")
+			addRecord(ld, "dora", "ERROR", "boom")
+			records := ld.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords()
+			records.At(0).SetSeverityNumber(plog.SeverityNumberInfo)
+			records.At(1).SetSeverityNumber(plog.SeverityNumberInfo)
+			records.At(2).SetSeverityNumber(plog.SeverityNumberError)
+			Expect(c.ConsumeLogs(context.Background(), ld)).To(Succeed())
+
+			Expect(values(flush()["log.records"].Sum().DataPoints())).To(Equal(map[string]int64{
+				"dora INFO":  1,
+				"dora ERROR": 1,
+			}))
+		})
+	})
+
+	Context("with conditions that fail to evaluate", func() {
+		BeforeEach(func() {
+			cfg.Conditions = []string{`Int(body) > 0`}
+			cfg.ErrorMode = ottl.PropagateError
+		})
+
+		It("returns the error in propagate mode", func() {
+			ld := plog.NewLogs()
+			addRecord(ld, "dora", "INFO", "hello")
+			ld.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0).Body().SetEmptyMap()
+			Expect(c.ConsumeLogs(context.Background(), ld)).To(MatchError(ContainSubstring("failed to eval condition")))
+			Expect(c.Shutdown(context.Background())).To(Succeed())
+		})
+	})
+
+	Context("with custom attributes", func() {
+		BeforeEach(func() {
+			cfg.Attributes = []string{"source_type"}
+		})
+
+		It("groups by them", func() {
+			ld := plog.NewLogs()
+			addRecord(ld, "dora", "INFO", "hello")
+			addRecord(ld, "dora", "ERROR", "boom")
+			Expect(c.ConsumeLogs(context.Background(), ld)).To(Succeed())
+
+			dps := flush()["log.records"].Sum().DataPoints()
+			Expect(dps.Len()).To(Equal(1))
+			Expect(dps.At(0).IntValue()).To(Equal(int64(2)))
+			Expect(dps.At(0).Attributes().AsRaw()).To(Equal(map[string]any{"source_type": "APP/PROC/WEB"}))
+		})
+	})
+
+	Context("with a series limit", func() {
+		BeforeEach(func() {
+			cfg.MaxSeries = 1
+		})
+
+		It("counts records for new series in the overflow series", func() {
+			ld := plog.NewLogs()
+			addRecord(ld, "dora", "INFO", "a")
+			addRecord(ld, "spring-music", "INFO", "b")
+			addRecord(ld, "dora", "INFO", "c")
+			addRecord(ld, "nginx", "INFO", "d")
+			Expect(c.ConsumeLogs(context.Background(), ld)).To(Succeed())
+
+			Expect(values(flush()["log.records"].Sum().DataPoints())).To(Equal(map[string]int64{
+				"dora INFO": 2,
+				"overflow":  2,
+			}))
+		})
+	})
+
+	Context("with a short staleness", func() {
+		BeforeEach(func() {
+			cfg.FlushInterval = 20 * time.Millisecond
+			cfg.MaxStale = 100 * time.Millisecond
+		})
+
+		It("forgets series that were not counted for max_stale", func() {
+			defer c.Shutdown(context.Background())
+
+			ld := plog.NewLogs()
+			addRecord(ld, "dora", "INFO", "hello")
+			addRecord(ld, "dora", "INFO", "world")
+			Expect(c.ConsumeLogs(context.Background(), ld)).To(Succeed())
+
+			// Nothing is passed on once the series is removed.
+			Eventually(func() bool {
+				n := len(sink.AllMetrics())
+				time.Sleep(100 * time.Millisecond)
+				return n > 0 && len(sink.AllMetrics()) == n
+			}, 3*time.Second).Should(BeTrue())
+
+			ld = plog.NewLogs()
+			addRecord(ld, "dora", "INFO", "again")
+			Expect(c.ConsumeLogs(context.Background(), ld)).To(Succeed())
+
+			Eventually(func() map[string]int64 {
+				all := sink.AllMetrics()
+				last := all[len(all)-1].ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0)
+				return values(last.Sum().DataPoints())
+			}).Should(Equal(map[string]int64{"dora INFO": 1}))
+		})
+	})
+
+	Context("with a short flush interval", func() {
+		BeforeEach(func() {
+			cfg.FlushInterval = 20 * time.Millisecond
+		})
+
+		It("passes on the counts every interval", func() {
+			defer c.Shutdown(context.Background())
+
+			ld := plog.NewLogs()
+			addRecord(ld, "dora", "INFO", "hello")
+			Expect(c.ConsumeLogs(context.Background(), ld)).To(Succeed())
+
+			Eventually(sink.AllMetrics).Should(HaveLen(2))
+		})
+	})
+})
+
+var _ = Describe("Config", func() {
+	var cfg *logcountconnector.Config
+
+	BeforeEach(func() {
+		cfg = logcountconnector.NewFactory().CreateDefaultConfig().(*logcountconnector.Config)
+	})
+
+	It("is valid by default", func() {
+		Expect(cfg.Validate()).To(Succeed())
+	})
+
+	It("requires conditions that parse", func() {
+		cfg.Conditions = []string{`body ==`}
+		Expect(cfg.Validate()).To(HaveOccurred())
+	})
+
+	It("rejects duplicate attributes", func() {
+		cfg.Attributes = []string{"app_name", "app_name"}
+		Expect(cfg.Validate()).To(MatchError(`duplicate attribute "app_name"`))
+	})
+
+	It("rejects unknown error modes", func() {
+		cfg.ErrorMode = "panic"
+		Expect(cfg.Validate()).To(MatchError(`unsupported error_mode "panic", must be ignore, propagate or silent`))
+	})
+
+	It("requires a positive flush interval, series limit and staleness", func() {
+		cfg.FlushInterval = 0
+		Expect(cfg.Validate()).To(MatchError(`"flush_interval" must be positive`))
+
+		cfg.FlushInterval = time.Second
+		cfg.MaxSeries = 0
+		Expect(cfg.Validate()).To(MatchError(`"max_series" must be positive`))
+
+		cfg.MaxSeries = 1
+		cfg.MaxStale = 0
+		Expect(cfg.Validate()).To(MatchError(`"max_stale" must be positive`))
+	})
+})
+
+// addRecord adds an app log record enriched like the capimetadata processor
+// does.
+func addRecord(ld plog.Logs, appName, severity, body string) {
+	if ld.ResourceLogs().Len() == 0 {
+		rl := ld.ResourceLogs().AppendEmpty()
+		rl.Resource().Attributes().PutStr("organization_name", "system")
+		rl.Resource().Attributes().PutStr("space_name", "apps")
+		rl.Resource().Attributes().PutStr("source_type", "APP/PROC/WEB")
+		rl.ScopeLogs().AppendEmpty()
+	}
+	lr := ld.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().AppendEmpty()
+	lr.Attributes().PutStr("app_name", appName)
+	lr.SetSeverityText(severity)
+	lr.Body().SetStr(body)
+}
+
+// values returns the datapoint values by app name and severity, and the value
+// of the overflow series as overflow.
+func values(dps pmetric.NumberDataPointSlice) map[string]int64 {
+	values := map[string]int64{}
+	for i := 0; i < dps.Len(); i++ {
+		app, ok := dps.At(i).Attributes().Get("app_name")
+		if !ok {
+			Expect(dps.At(i).Attributes().AsRaw()).To(Equal(map[string]any{"otel.overflow": true}))
+			values["overflow"] = dps.At(i).IntValue()
+			continue
+		}
+		severity, _ := dps.At(i).Attributes().Get("severity")
+		values[app.Str()+" "+severity.Str()] = dps.At(i).IntValue()
+	}
+	return values
+}
@@ -0,0 +1,40 @@
+// Package logcountconnector implements a connector that counts log records
+// and their body bytes, grouped by attributes, as metrics.
+package logcountconnector
+
+import (
+	"context"
+	"time"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/connector"
+	"go.opentelemetry.io/collector/consumer"
+)
+
+const stability = component.StabilityLevelAlpha
+
+var componentType = component.MustNewType("logcount")
+
+// NewFactory creates a factory for the log count connector.
+func NewFactory() connector.Factory {
+	return connector.NewFactory(
+		componentType,
+		createDefaultConfig,
+		connector.WithLogsToMetrics(createLogsToMetrics, stability),
+	)
+}
+
+func createDefaultConfig() component.Config {
+	return &Config{
+		Attributes:    []string{"organization_name", "space_name", "app_name", attributeSeverity},
+		ErrorMode:     ottl.IgnoreError,
+		FlushInterval: 15 * time.Second,
+		MaxSeries:     10000,
+		MaxStale:      5 * time.Minute,
+	}
+}
+
+func createLogsToMetrics(_ context.Context, set connector.Settings, cfg component.Config, next consumer.Metrics) (connector.Logs, error) {
+	return newLogCountConnector(cfg.(*Config), set.TelemetrySettings, next)
+}
@@ -0,0 +1,13 @@
+package logcountconnector_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestLogCountConnector(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Log Count Connector Suite")
+}
@@ -0,0 +1,80 @@
+package redmetricsconnector
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/collector/component"
+)
+
+const (
+	// dimensionRouteHost is the host of the requested URI.
+	dimensionRouteHost = "route_host"
+
+	// dimensionStatusClass is the class of the response status code, such as
+	// 2xx or 5xx.
+	dimensionStatusClass = "status_class"
+)
+
+// Config defines the configuration for the RED metrics connector.
+type Config struct {
+	// SourceIDs limits the spans turned into metrics to those with one of
+	// these source IDs. All spans are used when empty.
+	SourceIDs []string `mapstructure:"source_ids"`
+
+	// Dimensions are the attributes the metrics are grouped by. route_host
+	// and status_class are derived from the uri and status_code attributes,
+	// any other dimension is read from the span attributes and then from
+	// the resource attributes.
+	Dimensions []string `mapstructure:"dimensions"`
+
+	// Buckets are the upper bounds of the request duration histogram.
+	Buckets []time.Duration `mapstructure:"buckets"`
+
+	// FlushInterval is how often the metrics are passed on.
+	FlushInterval time.Duration `mapstructure:"flush_interval"`
+
+	// MaxSeries is the most dimension combinations kept. Requests for new
+	// combinations beyond it are counted in a single series with the
+	// otel.overflow attribute.
+	MaxSeries int `mapstructure:"max_series"`
+
+	// MaxStale is how long a series is kept after its last request. A series
+	// that is counted again after it was removed starts again from zero.
+	MaxStale time.Duration `mapstructure:"max_stale"`
+}
+
+var _ component.Config = (*Config)(nil)
+
+// Validate checks the dimensions, the buckets and the intervals.
+func (cfg *Config) Validate() error {
+	seen := map[string]bool{}
+	for _, d := range cfg.Dimensions {
+		if d == "" {
+			return errors.New("dimensions must not be empty")
+		}
+		if seen[d] {
+			return fmt.Errorf("duplicate dimension %q", d)
+		}
+		seen[d] = true
+	}
+	for i, b := range cfg.Buckets {
+		if b <= 0 {
+			return fmt.Errorf("bucket %s must be positive", b)
+		}
+		if i > 0 && b <= cfg.Buckets[i-1] {
+			return errors.New("buckets must be in increasing order")
+		}
+	}
+	if cfg.FlushInterval <= 0 {
+		return errors.New(`"flush_interval" must be positive`)
+	}
+	if cfg.MaxSeries <= 0 {
+		return errors.New(`"max_series" must be positive`)
+	}
+	if cfg.MaxStale <= 0 {
+		return errors.New(`"max_stale" must be positive`)
+	}
+	return nil
+}
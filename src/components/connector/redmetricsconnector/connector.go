@@ -0,0 +1,231 @@
+package redmetricsconnector
+
+import (
+	"context"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/otel-collector-release/src/components/internal/series"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.uber.org/zap"
+)
+
+const (
+	metricRequests = "gorouter.requests"
+	metricErrors   = "gorouter.errors"
+	metricDuration = "gorouter.request.duration"
+
+	attributeSourceID   = "source_id"
+	attributeURI        = "uri"
+	attributeStatusCode = "status_code"
+
+	scopeName = "code.cloudfoundry.org/otel-collector-release/src/components/connector/redmetricsconnector"
+)
+
+// totals are the running totals for one combination of dimension values.
+type totals struct {
+	count  uint64
+	errors uint64
+	sum    float64
+	counts []uint64
+}
+
+type redMetricsConnector struct {
+	cfg     *Config
+	bounds  []float64
+	sources map[string]bool
+
+	series  *series.Store[totals]
+	flusher *series.Flusher
+}
+
+func newREDMetricsConnector(cfg *Config, logger *zap.Logger, next consumer.Metrics) *redMetricsConnector {
+	c := &redMetricsConnector{
+		cfg:     cfg,
+		sources: map[string]bool{},
+		series:  series.NewStore[totals](cfg.MaxSeries, cfg.MaxStale),
+	}
+	c.flusher = series.NewFlusher(cfg.FlushInterval, logger, next, c.metrics)
+	for _, b := range cfg.Buckets {
+		c.bounds = append(c.bounds, b.Seconds())
+	}
+	for _, id := range cfg.SourceIDs {
+		c.sources[id] = true
+	}
+	return c
+}
+
+func (c *redMetricsConnector) Capabilities() consumer.Capabilities {
+	return consumer.Capabilities{MutatesData: false}
+}
+
+func (c *redMetricsConnector) Start(context.Context, component.Host) error {
+	c.series.Start()
+	c.flusher.Start()
+	return nil
+}
+
+// Shutdown passes on the metrics a last time.
+func (c *redMetricsConnector) Shutdown(ctx context.Context) error {
+	err := c.flusher.Shutdown(ctx)
+	c.series.Shutdown()
+	return err
+}
+
+func (c *redMetricsConnector) ConsumeTraces(_ context.Context, td ptrace.Traces) error {
+	for i := 0; i < td.ResourceSpans().Len(); i++ {
+		rs := td.ResourceSpans().At(i)
+		resource := rs.Resource().Attributes()
+		for j := 0; j < rs.ScopeSpans().Len(); j++ {
+			spans := rs.ScopeSpans().At(j).Spans()
+			for k := 0; k < spans.Len(); k++ {
+				c.record(spans.At(k), resource)
+			}
+		}
+	}
+	return nil
+}
+
+func (c *redMetricsConnector) record(span ptrace.Span, resource pcommon.Map) {
+	if len(c.sources) > 0 {
+		sourceID, _ := lookup(attributeSourceID, span.Attributes(), resource)
+		if !c.sources[sourceID] {
+			return
+		}
+	}
+
+	attrs := pcommon.NewMap()
+	for _, d := range c.cfg.Dimensions {
+		if v, ok := c.dimension(d, span, resource); ok {
+			attrs.PutStr(d, v)
+		}
+	}
+	duration := time.Duration(span.EndTimestamp() - span.StartTimestamp()).Seconds()
+	failed := isError(span, resource)
+
+	c.series.Update(attrs, span.StartTimestamp(), func(t *totals) {
+		if t.counts == nil {
+			t.counts = make([]uint64, len(c.bounds)+1)
+		}
+		t.count++
+		t.sum += duration
+		t.counts[sort.SearchFloat64s(c.bounds, duration)]++
+		if failed {
+			t.errors++
+		}
+	})
+}
+
+func (c *redMetricsConnector) dimension(name string, span ptrace.Span, resource pcommon.Map) (string, bool) {
+	switch name {
+	case dimensionRouteHost:
+		uri, ok := lookup(attributeURI, span.Attributes(), resource)
+		if !ok {
+			return "", false
+		}
+		u, err := url.Parse(uri)
+		if err != nil || u.Hostname() == "" {
+			return "", false
+		}
+		return strings.ToLower(u.Hostname()), true
+	case dimensionStatusClass:
+		code, ok := statusCode(span, resource)
+		if !ok {
+			return "", false
+		}
+		return strconv.Itoa(code/100) + "xx", true
+	}
+	return lookup(name, span.Attributes(), resource)
+}
+
+// metrics returns the cumulative totals of every series.
+func (c *redMetricsConnector) metrics(now pcommon.Timestamp) pmetric.Metrics {
+	md := pmetric.NewMetrics()
+	sm := md.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty()
+	sm.Scope().SetName(scopeName)
+
+	requests := sm.Metrics().AppendEmpty()
+	requests.SetName(metricRequests)
+	requests.SetDescription("Number of requests routed by the gorouter.")
+	requests.SetUnit("{requests}")
+	requestsSum := requests.SetEmptySum()
+	requestsSum.SetIsMonotonic(true)
+	requestsSum.SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
+
+	errs := sm.Metrics().AppendEmpty()
+	errs.SetName(metricErrors)
+	errs.SetDescription("Number of requests routed by the gorouter that failed with a 5xx status.")
+	errs.SetUnit("{requests}")
+	errsSum := errs.SetEmptySum()
+	errsSum.SetIsMonotonic(true)
+	errsSum.SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
+
+	duration := sm.Metrics().AppendEmpty()
+	duration.SetName(metricDuration)
+	duration.SetDescription("Duration of requests routed by the gorouter.")
+	duration.SetUnit("s")
+	durationHistogram := duration.SetEmptyHistogram()
+	durationHistogram.SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
+
+	c.series.Range(func(attrs pcommon.Map, start pcommon.Timestamp, t totals) {
+		dp := requestsSum.DataPoints().AppendEmpty()
+		attrs.CopyTo(dp.Attributes())
+		dp.SetStartTimestamp(start)
+		dp.SetTimestamp(now)
+		dp.SetIntValue(int64(t.count))
+
+		dp = errsSum.DataPoints().AppendEmpty()
+		attrs.CopyTo(dp.Attributes())
+		dp.SetStartTimestamp(start)
+		dp.SetTimestamp(now)
+		dp.SetIntValue(int64(t.errors))
+
+		hdp := durationHistogram.DataPoints().AppendEmpty()
+		attrs.CopyTo(hdp.Attributes())
+		hdp.SetStartTimestamp(start)
+		hdp.SetTimestamp(now)
+		hdp.SetCount(t.count)
+		hdp.SetSum(t.sum)
+		hdp.ExplicitBounds().FromRaw(c.bounds)
+		hdp.BucketCounts().FromRaw(t.counts)
+	})
+	return md
+}
+
+// isError reports whether the request failed with a 5xx status, or, for
+// spans without a status code, whether the span status is an error.
+func isError(span ptrace.Span, resource pcommon.Map) bool {
+	if code, ok := statusCode(span, resource); ok {
+		return code >= 500
+	}
+	return span.Status().Code() == ptrace.StatusCodeError
+}
+
+func statusCode(span ptrace.Span, resource pcommon.Map) (int, bool) {
+	v, ok := lookup(attributeStatusCode, span.Attributes(), resource)
+	if !ok {
+		return 0, false
+	}
+	code, err := strconv.Atoi(v)
+	if err != nil || code < 100 || code > 599 {
+		return 0, false
+	}
+	return code, true
+}
+
+func lookup(name string, attrs, resource pcommon.Map) (string, bool) {
+	if v, ok := attrs.Get(name); ok {
+		return v.AsString(), true
+	}
+	if v, ok := resource.Get(name); ok {
+		return v.AsString(), true
+	}
+	return "", false
+}
@@ -0,0 +1,307 @@
+package redmetricsconnector_test
+
+import (
+	"context"
+	"time"
+
+	"code.cloudfoundry.org/otel-collector-release/src/components/connector/redmetricsconnector"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.opentelemetry.io/collector/component/componenttest"
+	"go.opentelemetry.io/collector/connector"
+	"go.opentelemetry.io/collector/connector/connectortest"
+	"go.opentelemetry.io/collector/consumer/consumertest"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+const appID = "6f4b2b3a-8a8f-4b8e-9d6e-2f2c1f1a0b1c"
+
+var _ = Describe("RED metrics connector", func() {
+	var (
+		cfg  *redmetricsconnector.Config
+		sink *consumertest.MetricsSink
+		c    connector.Traces
+	)
+
+	BeforeEach(func() {
+		cfg = redmetricsconnector.NewFactory().CreateDefaultConfig().(*redmetricsconnector.Config)
+		cfg.FlushInterval = time.Hour
+		cfg.Buckets = []time.Duration{10 * time.Millisecond, 100 * time.Millisecond}
+		sink = new(consumertest.MetricsSink)
+	})
+
+	JustBeforeEach(func() {
+		Expect(cfg.Validate()).To(Succeed())
+		factory := redmetricsconnector.NewFactory()
+
+		var err error
+		c, err = factory.CreateTracesToMetrics(context.Background(), connectortest.NewNopSettings(factory.Type()), cfg, sink)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(c.Start(context.Background(), componenttest.NewNopHost())).To(Succeed())
+	})
+
+	// flush shuts the connector down, which passes on the metrics, and
+	// returns them by name.
+	flush := func() map[string]pmetric.Metric {
+		Expect(c.Shutdown(context.Background())).To(Succeed())
+		Expect(sink.AllMetrics()).To(HaveLen(1))
+
+		metrics := map[string]pmetric.Metric{}
+		ms := sink.AllMetrics()[0].ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics()
+		for i := 0; i < ms.Len(); i++ {
+			metrics[ms.At(i).Name()] = ms.At(i)
+		}
+		return metrics
+	}
+
+	It("counts requests, errors and durations per route host, app, method and status class", func() {
+		td := ptrace.NewTraces()
+		addSpan(td, "https://Dora.apps.example.com/stats", "GET", "200", 5*time.Millisecond)
+		addSpan(td, "https://dora.apps.example.com/", "GET", "204", 50*time.Millisecond)
+		addSpan(td, "https://dora.apps.example.com/", "GET", "502", 200*time.Millisecond)
+		addSpan(td, "https://dora.apps.example.com/", "POST", "201", 10*time.Millisecond)
+		Expect(c.ConsumeTraces(context.Background(), td)).To(Succeed())
+
+		metrics := flush()
+		Expect(metrics).To(HaveLen(3))
+
+		requests := metrics["gorouter.requests"].Sum()
+		Expect(requests.AggregationTemporality()).To(Equal(pmetric.AggregationTemporalityCumulative))
+		Expect(requests.IsMonotonic()).To(BeTrue())
+		Expect(numberValues(requests.DataPoints())).To(Equal(map[string]int64{
+			"GET 2xx":  2,
+			"GET 5xx":  1,
+			"POST 2xx": 1,
+		}))
+		Expect(requests.DataPoints().At(0).Attributes().AsRaw()).To(HaveKeyWithValue("route_host", "dora.apps.example.com"))
+		Expect(requests.DataPoints().At(0).Attributes().AsRaw()).To(HaveKeyWithValue("app_id", appID))
+
+		Expect(numberValues(metrics["gorouter.errors"].Sum().DataPoints())).To(Equal(map[string]int64{
+			"GET 2xx":  0,
+			"GET 5xx":  1,
+			"POST 2xx": 0,
+		}))
+
+		duration := metrics["gorouter.request.duration"]
+		Expect(duration.Unit()).To(Equal("s"))
+		dp := histogramFor(duration.Histogram().DataPoints(), "GET", "2xx")
+		Expect(dp.ExplicitBounds().AsRaw()).To(Equal([]float64{0.01, 0.1}))
+		Expect(dp.BucketCounts().AsRaw()).To(Equal([]uint64{1, 1, 0}))
+		Expect(dp.Count()).To(Equal(uint64(2)))
+		Expect(dp.Sum()).To(BeNumerically("~", 0.055, 1e-9))
+	})
+
+	It("keeps counting across batches", func() {
+		for range 3 {
+			td := ptrace.NewTraces()
+			addSpan(td, "https://dora.apps.example.com/", "GET", "200", time.Millisecond)
+			Expect(c.ConsumeTraces(context.Background(), td)).To(Succeed())
+		}
+
+		Expect(numberValues(flush()["gorouter.requests"].Sum().DataPoints())).To(Equal(map[string]int64{"GET 2xx": 3}))
+	})
+
+	It("ignores spans from other sources", func() {
+		td := ptrace.NewTraces()
+		addSpan(td, "https://dora.apps.example.com/", "GET", "200", time.Millisecond)
+		td.ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0).Attributes().PutStr("source_id", "my-app")
+		Expect(c.ConsumeTraces(context.Background(), td)).To(Succeed())
+
+		Expect(c.Shutdown(context.Background())).To(Succeed())
+		Expect(sink.AllMetrics()).To(BeEmpty())
+	})
+
+	It("counts spans with an error status and no status code as errors", func() {
+		td := ptrace.NewTraces()
+		addSpan(td, "https://dora.apps.example.com/", "GET", "", time.Millisecond)
+		td.ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0).Status().SetCode(ptrace.StatusCodeError)
+		Expect(c.ConsumeTraces(context.Background(), td)).To(Succeed())
+
+		dps := flush()["gorouter.errors"].Sum().DataPoints()
+		Expect(dps.At(0).IntValue()).To(Equal(int64(1)))
+		Expect(dps.At(0).Attributes().AsRaw()).NotTo(HaveKey("status_class"))
+	})
+
+	It("passes on metrics every flush interval", func() {
+		Expect(c.Shutdown(context.Background())).To(Succeed())
+
+		cfg.FlushInterval = 20 * time.Millisecond
+		factory := redmetricsconnector.NewFactory()
+		var err error
+		c, err = factory.CreateTracesToMetrics(context.Background(), connectortest.NewNopSettings(factory.Type()), cfg, sink)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(c.Start(context.Background(), componenttest.NewNopHost())).To(Succeed())
+		DeferCleanup(c.Shutdown, context.Background())
+
+		td := ptrace.NewTraces()
+		addSpan(td, "https://dora.apps.example.com/", "GET", "200", time.Millisecond)
+		Expect(c.ConsumeTraces(context.Background(), td)).To(Succeed())
+
+		Eventually(sink.AllMetrics).Should(HaveLen(2))
+	})
+
+	It("forgets series that were not counted for max_stale", func() {
+		Expect(c.Shutdown(context.Background())).To(Succeed())
+
+		cfg.FlushInterval = 20 * time.Millisecond
+		cfg.MaxStale = 100 * time.Millisecond
+		factory := redmetricsconnector.NewFactory()
+		var err error
+		c, err = factory.CreateTracesToMetrics(context.Background(), connectortest.NewNopSettings(factory.Type()), cfg, sink)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(c.Start(context.Background(), componenttest.NewNopHost())).To(Succeed())
+		DeferCleanup(c.Shutdown, context.Background())
+
+		td := ptrace.NewTraces()
+		addSpan(td, "https://dora.apps.example.com/", "GET", "200", time.Millisecond)
+		addSpan(td, "https://dora.apps.example.com/", "GET", "200", time.Millisecond)
+		Expect(c.ConsumeTraces(context.Background(), td)).To(Succeed())
+
+		// Nothing is passed on once the series is removed.
+		Eventually(func() bool {
+			n := len(sink.AllMetrics())
+			time.Sleep(100 * time.Millisecond)
+			return n > 0 && len(sink.AllMetrics()) == n
+		}, 3*time.Second).Should(BeTrue())
+
+		td = ptrace.NewTraces()
+		addSpan(td, "https://dora.apps.example.com/", "GET", "200", time.Millisecond)
+		Expect(c.ConsumeTraces(context.Background(), td)).To(Succeed())
+
+		Eventually(func() map[string]int64 {
+			all := sink.AllMetrics()
+			last := all[len(all)-1].ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0)
+			return numberValues(last.Sum().DataPoints())
+		}).Should(Equal(map[string]int64{"GET 2xx": 1}))
+	})
+
+	Context("with custom dimensions", func() {
+		BeforeEach(func() {
+			cfg.Dimensions = []string{"route_host", "organization_name"}
+		})
+
+		It("groups by them, reading resource attributes too", func() {
+			td := ptrace.NewTraces()
+			addSpan(td, "https://dora.apps.example.com/", "GET", "200", time.Millisecond)
+			addSpan(td, "https://dora.apps.example.com/", "POST", "500", time.Millisecond)
+			td.ResourceSpans().At(0).Resource().Attributes().PutStr("organization_name", "system")
+			Expect(c.ConsumeTraces(context.Background(), td)).To(Succeed())
+
+			dps := flush()["gorouter.requests"].Sum().DataPoints()
+			Expect(dps.Len()).To(Equal(1))
+			Expect(dps.At(0).IntValue()).To(Equal(int64(2)))
+			Expect(dps.At(0).Attributes().AsRaw()).To(Equal(map[string]any{
+				"route_host":        "dora.apps.example.com",
+				"organization_name": "system",
+			}))
+		})
+	})
+
+	Context("with a series limit", func() {
+		BeforeEach(func() {
+			cfg.MaxSeries = 2
+		})
+
+		It("counts requests for new series in the overflow series", func() {
+			td := ptrace.NewTraces()
+			for _, host := range []string{"a", "b", "c", "d", "a"} {
+				addSpan(td, "https://"+host+".apps.example.com/", "GET", "200", time.Millisecond)
+			}
+			Expect(c.ConsumeTraces(context.Background(), td)).To(Succeed())
+
+			dps := flush()["gorouter.requests"].Sum().DataPoints()
+			counts := map[string]int64{}
+			for i := 0; i < dps.Len(); i++ {
+				host, ok := dps.At(i).Attributes().Get("route_host")
+				if !ok {
+					Expect(dps.At(i).Attributes().AsRaw()).To(Equal(map[string]any{"otel.overflow": true}))
+					counts["overflow"] = dps.At(i).IntValue()
+					continue
+				}
+				counts[host.Str()] = dps.At(i).IntValue()
+			}
+			Expect(counts).To(Equal(map[string]int64{"a.apps.example.com": 2, "b.apps.example.com": 1, "overflow": 2}))
+		})
+	})
+})
+
+var _ = Describe("Config", func() {
+	var cfg *redmetricsconnector.Config
+
+	BeforeEach(func() {
+		cfg = redmetricsconnector.NewFactory().CreateDefaultConfig().(*redmetricsconnector.Config)
+	})
+
+	It("is valid by default", func() {
+		Expect(cfg.Validate()).To(Succeed())
+	})
+
+	It("requires increasing buckets", func() {
+		cfg.Buckets = []time.Duration{time.Second, time.Millisecond}
+		Expect(cfg.Validate()).To(MatchError("buckets must be in increasing order"))
+	})
+
+	It("rejects duplicate dimensions", func() {
+		cfg.Dimensions = []string{"app_id", "app_id"}
+		Expect(cfg.Validate()).To(MatchError(`duplicate dimension "app_id"`))
+	})
+
+	It("requires a positive flush interval, series limit and staleness", func() {
+		cfg.FlushInterval = 0
+		Expect(cfg.Validate()).To(MatchError(`"flush_interval" must be positive`))
+
+		cfg.FlushInterval = time.Second
+		cfg.MaxSeries = 0
+		Expect(cfg.Validate()).To(MatchError(`"max_series" must be positive`))
+
+		cfg.MaxSeries = 1
+		cfg.MaxStale = 0
+		Expect(cfg.Validate()).To(MatchError(`"max_stale" must be positive`))
+	})
+})
+
+// addSpan adds a gorouter span like those in the integration tests.
+func addSpan(td ptrace.Traces, uri, method, statusCode string, duration time.Duration) {
+	if td.ResourceSpans().Len() == 0 {
+		td.ResourceSpans().AppendEmpty().ScopeSpans().AppendEmpty()
+	}
+	span := td.ResourceSpans().At(0).ScopeSpans().At(0).Spans().AppendEmpty()
+	span.SetName("/")
+	span.SetKind(ptrace.SpanKindServer)
+	start := time.Unix(1741216325, 0)
+	span.SetStartTimestamp(pcommon.NewTimestampFromTime(start))
+	span.SetEndTimestamp(pcommon.NewTimestampFromTime(start.Add(duration)))
+	span.Attributes().PutStr("source_id", "gorouter")
+	span.Attributes().PutStr("app_id", appID)
+	span.Attributes().PutStr("uri", uri)
+	span.Attributes().PutStr("method", method)
+	if statusCode != "" {
+		span.Attributes().PutStr("status_code", statusCode)
+	}
+}
+
+// numberValues returns the datapoint values by method and status class.
+func numberValues(dps pmetric.NumberDataPointSlice) map[string]int64 {
+	values := map[string]int64{}
+	for i := 0; i < dps.Len(); i++ {
+		method, _ := dps.At(i).Attributes().Get("method")
+		class, _ := dps.At(i).Attributes().Get("status_class")
+		values[method.Str()+" "+class.Str()] = dps.At(i).IntValue()
+	}
+	return values
+}
+
+// histogramFor returns the datapoint of the method and status class.
+func histogramFor(dps pmetric.HistogramDataPointSlice, method, class string) pmetric.HistogramDataPoint {
+	for i := 0; i < dps.Len(); i++ {
+		m, _ := dps.At(i).Attributes().Get("method")
+		c, _ := dps.At(i).Attributes().Get("status_class")
+		if m.Str() == method && c.Str() == class {
+			return dps.At(i)
+		}
+	}
+	Fail("no datapoint for " + method + " " + class)
+	return pmetric.HistogramDataPoint{}
+}
@@ -0,0 +1,53 @@
+// Package redmetricsconnector implements a connector that turns the spans of
+// requests routed by the gorouter into request rate, error rate and duration
+// metrics.
+package redmetricsconnector
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/connector"
+	"go.opentelemetry.io/collector/consumer"
+)
+
+const stability = component.StabilityLevelAlpha
+
+var componentType = component.MustNewType("redmetrics")
+
+// NewFactory creates a factory for the RED metrics connector.
+func NewFactory() connector.Factory {
+	return connector.NewFactory(
+		componentType,
+		createDefaultConfig,
+		connector.WithTracesToMetrics(createTracesToMetrics, stability),
+	)
+}
+
+func createDefaultConfig() component.Config {
+	return &Config{
+		SourceIDs:  []string{"gorouter"},
+		Dimensions: []string{dimensionRouteHost, "app_id", "method", dimensionStatusClass},
+		Buckets: []time.Duration{
+			5 * time.Millisecond,
+			10 * time.Millisecond,
+			25 * time.Millisecond,
+			50 * time.Millisecond,
+			100 * time.Millisecond,
+			250 * time.Millisecond,
+			500 * time.Millisecond,
+			time.Second,
+			2500 * time.Millisecond,
+			5 * time.Second,
+			10 * time.Second,
+		},
+		FlushInterval: 15 * time.Second,
+		MaxSeries:     10000,
+		MaxStale:      5 * time.Minute,
+	}
+}
+
+func createTracesToMetrics(_ context.Context, set connector.Settings, cfg component.Config, next consumer.Metrics) (connector.Traces, error) {
+	return newREDMetricsConnector(cfg.(*Config), set.Logger, next), nil
+}
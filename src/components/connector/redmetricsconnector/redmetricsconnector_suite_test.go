@@ -0,0 +1,13 @@
+package redmetricsconnector_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestREDMetricsConnector(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "RED Metrics Connector Suite")
+}
@@ -0,0 +1,44 @@
+package cfconverter
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// allowList maps a kind of component, like processors, to the types of
+// components of that kind that are allowed. Kinds without types allow all
+// types, like an empty allow_list property of the BOSH jobs.
+type allowList map[string][]string
+
+func readAllowList(path string) (allowList, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the allow list file: %w", err)
+	}
+	var list allowList
+	if err := yaml.Unmarshal(content, &list); err != nil {
+		return nil, fmt.Errorf("unable to parse the allow list file %s: %w", path, err)
+	}
+	return list, nil
+}
+
+// checkFragments rejects components of fragments with types the allow list
+// doesn't allow. The BOSH jobs check the components of the config property
+// themselves when they render it.
+func (l allowList) checkFragments(definitions []fragmentDefinition) error {
+	for _, d := range definitions {
+		allowed := l[d.path]
+		if len(allowed) == 0 {
+			continue
+		}
+		typ, _, _ := strings.Cut(d.id, "/")
+		if !slices.Contains(allowed, typ) {
+			return fmt.Errorf("%s %q of fragment %v is not allowed, allowed are %v", d.path, d.id, d.fragment, allowed)
+		}
+	}
+	return nil
+}
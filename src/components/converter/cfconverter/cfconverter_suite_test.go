@@ -0,0 +1,13 @@
+package cfconverter_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestCFConverter(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "CF Converter Suite")
+}
@@ -0,0 +1,80 @@
+// Package cfconverter implements a converter that applies the conventions of
+// the Cloud Foundry BOSH jobs to the configuration when the collector loads
+// it: it interpolates the secrets of the jobs' secrets property, rejects
+// secrets that aren't used, reserves the cf-internal namespace for the
+// components the jobs add themselves, rejects configurations that override
+// the configuration fragments of co-located jobs, applies the allow list of
+// the jobs to the components of the fragments and checks the senders of
+// their pipelines like the jobs do for their own.
+package cfconverter
+
+import (
+	"context"
+	"os"
+
+	"go.opentelemetry.io/collector/confmap"
+)
+
+// EnvSecretsFile is the environment variable with the path of the YAML file
+// holding the secrets to interpolate, a list of entries with a name and a
+// cert, key, ca or secret, like the secrets property of the BOSH jobs.
+const EnvSecretsFile = "OTELCOL_CF_SECRETS_FILE"
+
+// EnvAllowListFile is the environment variable with the path of the YAML
+// file holding the types of components the configuration fragments may use,
+// a map of kinds of components to lists of types like the allow_list
+// property of the BOSH jobs.
+const EnvAllowListFile = "OTELCOL_CF_ALLOW_LIST_FILE"
+
+type converter struct{}
+
+// NewFactory creates a factory for the Cloud Foundry converter.
+func NewFactory() confmap.ConverterFactory {
+	return confmap.NewConverterFactory(func(confmap.ConverterSettings) confmap.Converter {
+		return converter{}
+	})
+}
+
+func (converter) Convert(_ context.Context, conf *confmap.Conf) error {
+	var secrets *secrets
+	if path := os.Getenv(EnvSecretsFile); path != "" {
+		var err error
+		secrets, err = readSecrets(path)
+		if err != nil {
+			return err
+		}
+	}
+
+	var allowed allowList
+	if path := os.Getenv(EnvAllowListFile); path != "" {
+		var err error
+		allowed, err = readAllowList(path)
+		if err != nil {
+			return err
+		}
+	}
+
+	cfg := conf.ToStringMap()
+	fragments := fragmentDefinitions(cfg)
+	if err := checkFragments(cfg, fragments); err != nil {
+		return err
+	}
+	if err := allowed.checkFragments(fragments); err != nil {
+		return err
+	}
+	if err := identifyFragmentSenders(cfg, fragments); err != nil {
+		return err
+	}
+	delete(cfg, fragmentsKey)
+	conf.Delete(fragmentsKey)
+	if secrets != nil {
+		cfg = secrets.interpolate(cfg).(map[string]any)
+		if err := secrets.checkUnused(); err != nil {
+			return err
+		}
+	}
+	if err := CheckReservedPrefix(cfg); err != nil {
+		return err
+	}
+	return conf.Merge(confmap.NewFromStringMap(cfg))
+}
@@ -0,0 +1,317 @@
+package cfconverter_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	"code.cloudfoundry.org/otel-collector-release/src/components/converter/cfconverter"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.opentelemetry.io/collector/confmap"
+)
+
+var _ = Describe("CF converter", func() {
+	var (
+		secretsFile string
+		cfg         map[string]any
+	)
+
+	BeforeEach(func() {
+		secretsFile = filepath.Join(GinkgoT().TempDir(), "secrets.yml")
+		writeFile(secretsFile, `
+- name: test-secret
+  cert: |-
+    -----BEGIN CERTIFICATE-----
+    MIIE4jCCAsqgAwIBAgIUO/DRqVeXUmewgpy33MkQpe0ME7YwDQYJKoZIhvcNAQEL
+    -----END CERTIFICATE-----
+  key: bar
+  ca: baz
+- name: anothersecret
+  secret: foobarbaz
+`)
+		GinkgoT().Setenv(cfconverter.EnvSecretsFile, secretsFile)
+
+		cfg = map[string]any{
+			"exporters": map[string]any{
+				"otlp": map[string]any{
+					"endpoint": "otelcol:4317",
+					"tls": map[string]any{
+						"cert_pem": "{{ .test-secret.cert }}",
+						"key_pem":  "{{ .test-secret.key }}",
+						"ca_pem":   "{{ .test-secret.ca }}",
+					},
+					"headers": map[string]any{"auth": "{{ .anothersecret.secret }}"},
+				},
+				"prometheus/test": map[string]any{
+					"tags": []any{"{{ .anothersecret.secret }}"},
+				},
+			},
+		}
+	})
+
+	convert := func() (map[string]any, error) {
+		conf := confmap.NewFromStringMap(cfg)
+		err := cfconverter.NewFactory().Create(confmap.ConverterSettings{}).Convert(context.Background(), conf)
+		return conf.ToStringMap(), err
+	}
+
+	otlpTLS := func(out map[string]any) map[string]any {
+		return out["exporters"].(map[string]any)["otlp"].(map[string]any)["tls"].(map[string]any)
+	}
+
+	It("interpolates the secrets", func() {
+		out, err := convert()
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(otlpTLS(out)).To(Equal(map[string]any{
+			"cert_pem": "-----BEGIN CERTIFICATE-----\nMIIE4jCCAsqgAwIBAgIUO/DRqVeXUmewgpy33MkQpe0ME7YwDQYJKoZIhvcNAQEL\n-----END CERTIFICATE-----",
+			"key_pem":  "bar",
+			"ca_pem":   "baz",
+		}))
+		exporters := out["exporters"].(map[string]any)
+		Expect(exporters["otlp"].(map[string]any)["headers"]).To(Equal(map[string]any{"auth": "foobarbaz"}))
+		Expect(exporters["prometheus/test"].(map[string]any)["tags"]).To(Equal([]any{"foobarbaz"}))
+		Expect(exporters["otlp"].(map[string]any)["endpoint"]).To(Equal("otelcol:4317"))
+	})
+
+	It("does not interpolate template variables without secrets", func() {
+		writeFile(secretsFile, `
+- name: test-secret
+  cert: foo
+  key: ""
+  ca: null
+`)
+		delete(cfg["exporters"].(map[string]any), "prometheus/test")
+
+		out, err := convert()
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(otlpTLS(out)).To(Equal(map[string]any{
+			"cert_pem": "foo",
+			"key_pem":  "{{ .test-secret.key }}",
+			"ca_pem":   "{{ .test-secret.ca }}",
+		}))
+		Expect(out["exporters"].(map[string]any)["otlp"].(map[string]any)["headers"]).To(Equal(map[string]any{"auth": "{{ .anothersecret.secret }}"}))
+	})
+
+	It("rejects secrets no template variable uses", func() {
+		delete(cfg["exporters"].(map[string]any)["otlp"].(map[string]any), "headers")
+		delete(cfg["exporters"].(map[string]any), "prometheus/test")
+
+		_, err := convert()
+		Expect(err).To(MatchError("The following secrets are unused: ['anothersecret.secret']"))
+	})
+
+	It("allows differing amounts of space separation", func() {
+		tls := cfg["exporters"].(map[string]any)["otlp"].(map[string]any)["tls"].(map[string]any)
+		tls["cert_pem"] = "{{.test-secret.cert}}"
+		tls["key_pem"] = "{{        .test-secret.key}}"
+		tls["ca_pem"] = "{{   .test-secret.ca     }}"
+
+		out, err := convert()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(otlpTLS(out)).To(HaveKeyWithValue("key_pem", "bar"))
+		Expect(otlpTLS(out)).To(HaveKeyWithValue("ca_pem", "baz"))
+	})
+
+	It("does not match template variables that are not formatted correctly", func() {
+		tls := cfg["exporters"].(map[string]any)["otlp"].(map[string]any)["tls"].(map[string]any)
+		tls["cert_pem"] = "{{test-secret.cert}}"
+		tls["key_pem"] = "{{ .test-secret }}"
+		tls["ca_pem"] = "{{\n .test-secret.ca \n}}"
+
+		_, err := convert()
+		Expect(err).To(MatchError("The following secrets are unused: ['test-secret.cert', 'test-secret.key', 'test-secret.ca']"))
+	})
+
+	It("fails when the secrets file can't be read", func() {
+		Expect(os.Remove(secretsFile)).To(Succeed())
+
+		_, err := convert()
+		Expect(err).To(MatchError(ContainSubstring("unable to read the secrets file")))
+	})
+
+	It("only checks the reserved namespace without a secrets file", func() {
+		GinkgoT().Setenv(cfconverter.EnvSecretsFile, "")
+
+		out, err := convert()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(otlpTLS(out)).To(HaveKeyWithValue("key_pem", "{{ .test-secret.key }}"))
+	})
+
+	DescribeTable("rejects components in the reserved namespace",
+		func(kind, id, message string) {
+			GinkgoT().Setenv(cfconverter.EnvSecretsFile, "")
+			cfg[kind] = map[string]any{id: nil}
+			_, err := convert()
+			Expect(err).To(MatchError(message))
+		},
+		Entry("exporters", "exporters", "otlp/cf-internal-foo", "Exporters cannot be defined under cf-internal namespace"),
+		Entry("processors", "processors", "batch/cf-internal-foo", "Processors cannot be defined under cf-internal namespace"),
+		Entry("extensions", "extensions", "pprof/cf-internal-foo", "Extensions cannot be defined under cf-internal namespace"),
+	)
+
+	It("allows the components the BOSH jobs add in the reserved namespace", func() {
+		cfg["processors"] = map[string]any{"senderidentity/cf-internal-local": nil, "senderidentity/cf-internal-apps": nil}
+		cfg["extensions"] = map[string]any{"clientcertauth/cf-internal-local": nil, "instanceidentityauth/cf-internal-apps": nil}
+		cfg["receivers"] = map[string]any{"otlp/cf-internal-local": nil}
+
+		_, err := convert()
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	Describe("configuration fragments", func() {
+		BeforeEach(func() {
+			GinkgoT().Setenv(cfconverter.EnvSecretsFile, "")
+			cfg["processors"] = map[string]any{"batch": map[string]any{"timeout": "1s", "send_batch_size": 100}}
+			cfg["cf_fragments"] = []any{
+				map[string]any{
+					"path":     "processors",
+					"id":       "batch",
+					"fragment": "/var/vcap/jobs/gorouter/config/otel-collector.d/logs.yml",
+					"config":   map[string]any{"timeout": "1s"},
+				},
+			}
+		})
+
+		It("removes the list of their components", func() {
+			out, err := convert()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(out).NotTo(HaveKey("cf_fragments"))
+			Expect(out).To(HaveKey("processors"))
+		})
+
+		It("allows the configuration to add fields to their components", func() {
+			_, err := convert()
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("rejects configurations that set their fields to different values", func() {
+			cfg["processors"] = map[string]any{"batch": map[string]any{"timeout": "5s"}}
+
+			_, err := convert()
+			Expect(err).To(MatchError(`processors "batch" of fragment /var/vcap/jobs/gorouter/config/otel-collector.d/logs.yml conflicts with the configuration`))
+		})
+
+		It("rejects configurations that replace their pipelines", func() {
+			cfg["service"] = map[string]any{"pipelines": map[string]any{
+				"logs/gorouter": map[string]any{"receivers": []any{"otlp"}, "exporters": []any{"debug"}},
+			}}
+			cfg["cf_fragments"] = []any{
+				map[string]any{
+					"path":     "service::pipelines",
+					"id":       "logs/gorouter",
+					"fragment": "/var/vcap/jobs/gorouter/config/otel-collector.d/logs.yml",
+					"config":   map[string]any{"receivers": []any{"otlp/cf-internal-local"}, "exporters": []any{"debug"}},
+				},
+			}
+
+			_, err := convert()
+			Expect(err).To(MatchError(ContainSubstring(`service::pipelines "logs/gorouter" of fragment`)))
+		})
+
+		Context("with pipelines receiving from the internal receiver", func() {
+			BeforeEach(func() {
+				pipeline := map[string]any{"receivers": []any{"otlp/cf-internal-local"}, "processors": []any{"batch"}, "exporters": []any{"debug"}}
+				cfg["service"] = map[string]any{"pipelines": map[string]any{"logs/gorouter": pipeline}}
+				cfg["cf_fragments"] = append(cfg["cf_fragments"].([]any), map[string]any{
+					"path":     "service::pipelines",
+					"id":       "logs/gorouter",
+					"fragment": "/var/vcap/jobs/gorouter/config/otel-collector.d/logs.yml",
+					"config":   pipeline,
+				})
+			})
+
+			gorouterProcessors := func(out map[string]any) any {
+				pipelines := out["service"].(map[string]any)["pipelines"].(map[string]any)
+				return pipelines["logs/gorouter"].(map[string]any)["processors"]
+			}
+
+			It("checks the senders when the jobs authenticate them", func() {
+				cfg["processors"].(map[string]any)["senderidentity/cf-internal-local"] = map[string]any{"allowed_identities": []any{"gorouter"}}
+
+				out, err := convert()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(gorouterProcessors(out)).To(Equal([]any{"senderidentity/cf-internal-local", "batch"}))
+			})
+
+			It("leaves the pipelines as they are when the jobs don't authenticate the senders", func() {
+				out, err := convert()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(gorouterProcessors(out)).To(Equal([]any{"batch"}))
+			})
+
+			It("rejects pipelines also receiving from connectors", func() {
+				cfg["processors"].(map[string]any)["senderidentity/cf-internal-local"] = nil
+				pipelines := cfg["service"].(map[string]any)["pipelines"].(map[string]any)
+				pipelines["logs/gorouter"].(map[string]any)["receivers"] = []any{"otlp/cf-internal-local", "forward"}
+
+				_, err := convert()
+				Expect(err).To(MatchError(ContainSubstring("which is only supported in a pipeline of its own")))
+			})
+		})
+
+		Context("with an allow list", func() {
+			BeforeEach(func() {
+				allowListFile := filepath.Join(GinkgoT().TempDir(), "allow_list.yml")
+				GinkgoT().Setenv(cfconverter.EnvAllowListFile, allowListFile)
+				writeFile(allowListFile, `
+processors: [batch, transform]
+exporters: []
+`)
+			})
+
+			It("allows the components it lists", func() {
+				_, err := convert()
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("rejects the components it doesn't list", func() {
+				cfg["processors"] = map[string]any{"filter/gorouter": nil}
+				cfg["cf_fragments"] = []any{
+					map[string]any{
+						"path":     "processors",
+						"id":       "filter/gorouter",
+						"fragment": "/var/vcap/jobs/gorouter/config/otel-collector.d/logs.yml",
+					},
+				}
+
+				_, err := convert()
+				Expect(err).To(MatchError(`processors "filter/gorouter" of fragment /var/vcap/jobs/gorouter/config/otel-collector.d/logs.yml is not allowed, allowed are [batch transform]`))
+			})
+
+			It("allows all components of kinds it lists nothing for", func() {
+				cfg["exporters"].(map[string]any)["debug"] = nil
+				cfg["cf_fragments"] = []any{
+					map[string]any{
+						"path":     "exporters",
+						"id":       "debug",
+						"fragment": "/var/vcap/jobs/gorouter/config/otel-collector.d/logs.yml",
+					},
+				}
+
+				_, err := convert()
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("fails when the allow list file can't be read", func() {
+				GinkgoT().Setenv(cfconverter.EnvAllowListFile, filepath.Join(GinkgoT().TempDir(), "missing.yml"))
+
+				_, err := convert()
+				Expect(err).To(MatchError(ContainSubstring("unable to read the allow list file")))
+			})
+		})
+	})
+
+	It("tells which components are reserved", func() {
+		Expect(cfconverter.IsReserved("exporters", "otlp/cf-internal-foo")).To(BeTrue())
+		Expect(cfconverter.IsReserved("extensions", "clientcertauth/cf-internal-local")).To(BeFalse())
+		Expect(cfconverter.IsReserved("receivers", "otlp/cf-internal-local")).To(BeFalse())
+		Expect(cfconverter.IsReserved("processors", "batch")).To(BeFalse())
+	})
+})
+
+func writeFile(path, content string) {
+	Expect(os.WriteFile(path, []byte(content), 0o600)).To(Succeed())
+}
@@ -0,0 +1,126 @@
+package cfconverter
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// fragmentsKey is the key the fragments provider lists the components and
+// pipelines of the configuration fragments under, its DefinitionsKey.
+const fragmentsKey = "cf_fragments"
+
+const pipelinesPath = "service::pipelines"
+
+// senderIdentityProcessors are the receivers the BOSH jobs add, with the
+// processor the jobs put first in the pipelines receiving from them when
+// they authenticate the senders.
+var senderIdentityProcessors = map[string]string{
+	"otlp/cf-internal-local": "senderidentity/cf-internal-local",
+	"otlp/cf-internal-apps":  "senderidentity/cf-internal-apps",
+}
+
+// fragmentDefinition is a component or pipeline of the configuration
+// fragments, as listed by the fragments provider.
+type fragmentDefinition struct {
+	// path is the section of the definition, like exporters or
+	// service::pipelines.
+	path     string
+	id       string
+	fragment any
+	config   any
+}
+
+func fragmentDefinitions(cfg map[string]any) []fragmentDefinition {
+	listed, _ := cfg[fragmentsKey].([]any)
+	definitions := make([]fragmentDefinition, 0, len(listed))
+	for _, d := range listed {
+		definition, _ := d.(map[string]any)
+		path, _ := definition["path"].(string)
+		id, _ := definition["id"].(string)
+		definitions = append(definitions, fragmentDefinition{
+			path:     path,
+			id:       id,
+			fragment: definition["fragment"],
+			config:   definition["config"],
+		})
+	}
+	return definitions
+}
+
+// section returns the map at the path of the definition in cfg, or nil.
+func (d fragmentDefinition) section(cfg map[string]any) map[string]any {
+	var section any = cfg
+	for _, key := range strings.Split(d.path, "::") {
+		parent, _ := section.(map[string]any)
+		section = parent[key]
+	}
+	m, _ := section.(map[string]any)
+	return m
+}
+
+// checkFragments rejects configurations that set fields of the components
+// and pipelines of fragments to different values, which would otherwise
+// silently override them as they are merged after the fragments. Adding
+// fields is allowed, like it is between fragments.
+func checkFragments(cfg map[string]any, definitions []fragmentDefinition) error {
+	for _, d := range definitions {
+		if !includes(d.section(cfg)[d.id], d.config) {
+			return fmt.Errorf("%s %q of fragment %v conflicts with the configuration", d.path, d.id, d.fragment)
+		}
+	}
+	return nil
+}
+
+// identifyFragmentSenders puts the sender identity processor first in the
+// pipelines of fragments that receive from a receiver of the BOSH jobs
+// authenticating its senders, like the jobs do for their own pipelines, so
+// that fragments can't skip checking the senders. The jobs authenticate the
+// senders of a receiver when they define its processor.
+func identifyFragmentSenders(cfg map[string]any, definitions []fragmentDefinition) error {
+	processors, _ := cfg["processors"].(map[string]any)
+	for _, d := range definitions {
+		if d.path != pipelinesPath {
+			continue
+		}
+		pipeline, _ := d.section(cfg)[d.id].(map[string]any)
+		receivers, _ := pipeline["receivers"].([]any)
+		for _, receiver := range receivers {
+			processor, ok := senderIdentityProcessors[fmt.Sprint(receiver)]
+			if !ok {
+				continue
+			}
+			if _, ok := processors[processor]; !ok {
+				continue
+			}
+			// Data from other receivers and connectors has no sender
+			// identity, which the processor can't tell apart.
+			if len(receivers) > 1 {
+				return fmt.Errorf("%s %q of fragment %v receives from %v next to %s, which is only supported in a pipeline of its own", d.path, d.id, d.fragment, receivers, receiver)
+			}
+			rest, _ := pipeline["processors"].([]any)
+			rest = slices.DeleteFunc(slices.Clone(rest), func(p any) bool { return p == processor })
+			pipeline["processors"] = append([]any{processor}, rest...)
+		}
+	}
+	return nil
+}
+
+// includes reports whether every field set in b has the same value in a.
+func includes(a, b any) bool {
+	bm, bIsMap := b.(map[string]any)
+	if !bIsMap {
+		return b == nil || reflect.DeepEqual(a, b)
+	}
+	am, aIsMap := a.(map[string]any)
+	if !aIsMap {
+		return len(bm) == 0
+	}
+	for k, bv := range bm {
+		if !includes(am[k], bv) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,55 @@
+package cfconverter
+
+import (
+	_ "embed"
+	"fmt"
+	"slices"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+)
+
+const reservedNamespace = "/cf-internal"
+
+// reservedKinds are the kinds of components that may not be named in the
+// reserved namespace, in the order they are checked.
+var reservedKinds = []string{"exporters", "processors", "extensions"}
+
+// internalComponentsYAML lists the components in the reserved namespace that
+// the BOSH jobs add to the configuration themselves. The job specs read it
+// too.
+//
+//go:embed internal_components.yaml
+var internalComponentsYAML []byte
+
+// internalComponents are the components of internalComponentsYAML by kind.
+var internalComponents = func() map[string][]string {
+	var components map[string][]string
+	if err := yaml.Unmarshal(internalComponentsYAML, &components); err != nil {
+		panic(fmt.Sprintf("invalid internal_components.yaml: %v", err))
+	}
+	return components
+}()
+
+// CheckReservedPrefix rejects exporters, processors and extensions named in
+// the cf-internal namespace other than the ones the BOSH jobs add.
+func CheckReservedPrefix(cfg map[string]any) error {
+	for _, kind := range reservedKinds {
+		components, _ := cfg[kind].(map[string]any)
+		for id := range components {
+			if IsReserved(kind, id) {
+				return fmt.Errorf("%s cannot be defined under cf-internal namespace", strings.ToUpper(kind[:1])+kind[1:])
+			}
+		}
+	}
+	return nil
+}
+
+// IsReserved reports whether a component of kind, like exporters, may not be
+// named id because it is in the cf-internal namespace and not one the BOSH
+// jobs add.
+func IsReserved(kind, id string) bool {
+	return slices.Contains(reservedKinds, kind) &&
+		strings.Contains(id, reservedNamespace) &&
+		!slices.Contains(internalComponents[kind], id)
+}
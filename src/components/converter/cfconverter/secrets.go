@@ -0,0 +1,104 @@
+package cfconverter
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// secretPattern matches template variables like {{ .name.cert }}, allowing
+// spaces but no line breaks inside the braces.
+var secretPattern = regexp.MustCompile(`\{\{[^\S\r\n]*\.([\w\-]+)\.([\w\-]+)[^\S\r\n]*\}\}`)
+
+// secretTypes are the values a secret can have, in the order unused secrets
+// are reported.
+var secretTypes = []string{"cert", "key", "ca", "secret"}
+
+type secrets struct {
+	// names are the names of the secrets in the order they are listed.
+	names  []string
+	values map[string]map[string]string
+	used   map[string]bool
+}
+
+func readSecrets(path string) (*secrets, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the secrets file: %w", err)
+	}
+	var entries []map[string]any
+	if err := yaml.Unmarshal(content, &entries); err != nil {
+		return nil, fmt.Errorf("unable to parse the secrets file %s: %w", path, err)
+	}
+
+	s := &secrets{values: map[string]map[string]string{}, used: map[string]bool{}}
+	for _, entry := range entries {
+		name := fmt.Sprint(entry["name"])
+		if _, ok := s.values[name]; !ok {
+			s.names = append(s.names, name)
+		}
+		values := map[string]string{}
+		for _, t := range secretTypes {
+			if v, ok := entry[t].(string); ok {
+				values[t] = v
+			}
+		}
+		s.values[name] = values
+	}
+	return s, nil
+}
+
+// interpolate replaces every string with a template variable of a secret
+// with the value of that secret. Only the first template variable of a
+// string is considered, and the whole string is replaced. Variables of
+// secrets without a value are left as they are.
+func (s *secrets) interpolate(v any) any {
+	switch v := v.(type) {
+	case string:
+		match := secretPattern.FindStringSubmatch(v)
+		if match == nil {
+			return v
+		}
+		name, typ := match[1], match[2]
+		value := s.values[name][typ]
+		if value == "" {
+			return v
+		}
+		s.used[name+"."+typ] = true
+		return value
+	case []any:
+		for i := range v {
+			v[i] = s.interpolate(v[i])
+		}
+		return v
+	case map[string]any:
+		for k := range v {
+			v[k] = s.interpolate(v[k])
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+// checkUnused rejects secrets with values that no template variable used.
+func (s *secrets) checkUnused() error {
+	var unused []string
+	for _, name := range s.names {
+		for _, typ := range secretTypes {
+			key := name + "." + typ
+			if s.values[name][typ] != "" && !s.used[key] {
+				unused = append(unused, key)
+			}
+		}
+	}
+	if len(unused) > 0 {
+		// The message is the one the BOSH jobs gave when they interpolated
+		// the secrets themselves.
+		return fmt.Errorf("The following secrets are unused: ['%s']", strings.Join(unused, "', '"))
+	}
+	return nil
+}
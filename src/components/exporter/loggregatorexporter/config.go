@@ -0,0 +1,40 @@
+package loggregatorexporter
+
+import (
+	"errors"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/config/configgrpc"
+	"go.opentelemetry.io/collector/config/configretry"
+	"go.opentelemetry.io/collector/exporter/exporterhelper"
+)
+
+// Config defines the configuration for the Loggregator v2 exporter.
+type Config struct {
+	TimeoutConfig exporterhelper.TimeoutConfig    `mapstructure:",squash"`
+	QueueConfig   exporterhelper.QueueBatchConfig `mapstructure:"sending_queue"`
+	RetryConfig   configretry.BackOffConfig       `mapstructure:"retry_on_failure"`
+	ClientConfig  configgrpc.ClientConfig         `mapstructure:",squash"`
+}
+
+var _ component.Config = (*Config)(nil)
+
+// Validate checks that the exporter presents a client certificate, which the
+// Loggregator agents require on their Ingress API.
+func (cfg *Config) Validate() error {
+	if cfg.ClientConfig.Endpoint == "" {
+		return errors.New(`requires a non-empty "endpoint"`)
+	}
+
+	tls := cfg.ClientConfig.TLS
+	if tls.Insecure {
+		return errors.New("tls cannot be disabled, the Loggregator agent requires mutual TLS")
+	}
+	if tls.CertFile == "" && tls.CertPem == "" {
+		return errors.New("a client certificate must be provided with tls.cert_file or tls.cert_pem")
+	}
+	if tls.KeyFile == "" && tls.KeyPem == "" {
+		return errors.New("a client key must be provided with tls.key_file or tls.key_pem")
+	}
+	return nil
+}
@@ -0,0 +1,235 @@
+package loggregatorexporter
+
+import (
+	"math"
+	"time"
+
+	"code.cloudfoundry.org/go-loggregator/v10/rpc/loggregator_v2"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+const (
+	attributeSourceID   = "source_id"
+	attributeInstanceID = "instance_id"
+
+	attributeServiceName       = "service.name"
+	attributeServiceInstanceID = "service.instance.id"
+
+	tagTraceID      = "trace_id"
+	tagSpanID       = "span_id"
+	tagParentSpanID = "parent_span_id"
+	tagPeerType     = "peer_type"
+)
+
+// logsToEnvelopes converts every log record into a log envelope. Records
+// whose body is a map with a title are converted into event envelopes, which
+// is how the Loggregator receiver represents them.
+func logsToEnvelopes(ld plog.Logs) []*loggregator_v2.Envelope {
+	envelopes := make([]*loggregator_v2.Envelope, 0, ld.LogRecordCount())
+
+	for i := 0; i < ld.ResourceLogs().Len(); i++ {
+		rl := ld.ResourceLogs().At(i)
+		for j := 0; j < rl.ScopeLogs().Len(); j++ {
+			records := rl.ScopeLogs().At(j).LogRecords()
+			for k := 0; k < records.Len(); k++ {
+				lr := records.At(k)
+
+				ts := lr.Timestamp()
+				if ts == 0 {
+					ts = lr.ObservedTimestamp()
+				}
+				e := newEnvelope(ts, rl.Resource().Attributes(), lr.Attributes())
+
+				if title, ok := eventTitle(lr.Body()); ok {
+					var body string
+					if v, ok := lr.Body().Map().Get("body"); ok {
+						body = v.AsString()
+					}
+					e.Message = &loggregator_v2.Envelope_Event{
+						Event: &loggregator_v2.Event{Title: title, Body: body},
+					}
+				} else {
+					logType := loggregator_v2.Log_OUT
+					if lr.SeverityNumber() >= plog.SeverityNumberError {
+						logType = loggregator_v2.Log_ERR
+					}
+					e.Message = &loggregator_v2.Envelope_Log{
+						Log: &loggregator_v2.Log{Payload: []byte(lr.Body().AsString()), Type: logType},
+					}
+				}
+
+				envelopes = append(envelopes, e)
+			}
+		}
+	}
+
+	return envelopes
+}
+
+// metricsToEnvelopes converts gauges and sums into envelopes. Monotonic sums
+// become counters, carrying either a delta or a total depending on their
+// temporality, and non-monotonic sums become gauges. Other metric types have
+// no Loggregator equivalent and are skipped.
+func metricsToEnvelopes(md pmetric.Metrics) []*loggregator_v2.Envelope {
+	var envelopes []*loggregator_v2.Envelope
+
+	for i := 0; i < md.ResourceMetrics().Len(); i++ {
+		rm := md.ResourceMetrics().At(i)
+		for j := 0; j < rm.ScopeMetrics().Len(); j++ {
+			metrics := rm.ScopeMetrics().At(j).Metrics()
+			for k := 0; k < metrics.Len(); k++ {
+				m := metrics.At(k)
+
+				switch m.Type() {
+				case pmetric.MetricTypeGauge:
+					dps := m.Gauge().DataPoints()
+					for l := 0; l < dps.Len(); l++ {
+						envelopes = append(envelopes, gaugeEnvelope(m, dps.At(l), rm.Resource().Attributes()))
+					}
+				case pmetric.MetricTypeSum:
+					dps := m.Sum().DataPoints()
+					for l := 0; l < dps.Len(); l++ {
+						if m.Sum().IsMonotonic() {
+							envelopes = append(envelopes, counterEnvelope(m, dps.At(l), rm.Resource().Attributes()))
+						} else {
+							envelopes = append(envelopes, gaugeEnvelope(m, dps.At(l), rm.Resource().Attributes()))
+						}
+					}
+				}
+			}
+		}
+	}
+
+	return envelopes
+}
+
+// tracesToEnvelopes converts every span into a timer envelope carrying the
+// trace context as tags.
+func tracesToEnvelopes(td ptrace.Traces) []*loggregator_v2.Envelope {
+	envelopes := make([]*loggregator_v2.Envelope, 0, td.SpanCount())
+
+	for i := 0; i < td.ResourceSpans().Len(); i++ {
+		rs := td.ResourceSpans().At(i)
+		for j := 0; j < rs.ScopeSpans().Len(); j++ {
+			spans := rs.ScopeSpans().At(j).Spans()
+			for k := 0; k < spans.Len(); k++ {
+				span := spans.At(k)
+
+				e := newEnvelope(span.EndTimestamp(), rs.Resource().Attributes(), span.Attributes())
+				e.Tags[tagTraceID] = span.TraceID().String()
+				e.Tags[tagSpanID] = span.SpanID().String()
+				if !span.ParentSpanID().IsEmpty() {
+					e.Tags[tagParentSpanID] = span.ParentSpanID().String()
+				}
+				if _, ok := e.Tags[tagPeerType]; !ok {
+					switch span.Kind() {
+					case ptrace.SpanKindServer:
+						e.Tags[tagPeerType] = "Server"
+					case ptrace.SpanKindClient:
+						e.Tags[tagPeerType] = "Client"
+					}
+				}
+				e.Message = &loggregator_v2.Envelope_Timer{
+					Timer: &loggregator_v2.Timer{
+						Name:  span.Name(),
+						Start: int64(span.StartTimestamp()),
+						Stop:  int64(span.EndTimestamp()),
+					},
+				}
+
+				envelopes = append(envelopes, e)
+			}
+		}
+	}
+
+	return envelopes
+}
+
+func gaugeEnvelope(m pmetric.Metric, dp pmetric.NumberDataPoint, resource pcommon.Map) *loggregator_v2.Envelope {
+	e := newEnvelope(dp.Timestamp(), resource, dp.Attributes())
+	e.Message = &loggregator_v2.Envelope_Gauge{
+		Gauge: &loggregator_v2.Gauge{
+			Metrics: map[string]*loggregator_v2.GaugeValue{
+				m.Name(): {Unit: m.Unit(), Value: doubleValue(dp)},
+			},
+		},
+	}
+	return e
+}
+
+func counterEnvelope(m pmetric.Metric, dp pmetric.NumberDataPoint, resource pcommon.Map) *loggregator_v2.Envelope {
+	e := newEnvelope(dp.Timestamp(), resource, dp.Attributes())
+
+	counter := &loggregator_v2.Counter{Name: m.Name()}
+	value := uint64(math.Max(0, math.Round(doubleValue(dp))))
+	if m.Sum().AggregationTemporality() == pmetric.AggregationTemporalityDelta {
+		counter.Delta = value
+	} else {
+		counter.Total = value
+	}
+	e.Message = &loggregator_v2.Envelope_Counter{Counter: counter}
+	return e
+}
+
+// newEnvelope creates an envelope whose tags are the resource attributes
+// overlaid with the record attributes. The source and instance IDs are taken
+// from the Loggregator attributes, falling back to the service semantic
+// conventions on the resource.
+func newEnvelope(ts pcommon.Timestamp, resource, attrs pcommon.Map) *loggregator_v2.Envelope {
+	if ts == 0 {
+		ts = pcommon.NewTimestampFromTime(time.Now())
+	}
+
+	tags := make(map[string]string, resource.Len()+attrs.Len())
+	resource.Range(func(k string, v pcommon.Value) bool {
+		tags[k] = v.AsString()
+		return true
+	})
+	attrs.Range(func(k string, v pcommon.Value) bool {
+		tags[k] = v.AsString()
+		return true
+	})
+
+	sourceID := popTag(tags, attributeSourceID)
+	if sourceID == "" {
+		sourceID = tags[attributeServiceName]
+	}
+	instanceID := popTag(tags, attributeInstanceID)
+	if instanceID == "" {
+		instanceID = tags[attributeServiceInstanceID]
+	}
+
+	return &loggregator_v2.Envelope{
+		Timestamp:  int64(ts),
+		SourceId:   sourceID,
+		InstanceId: instanceID,
+		Tags:       tags,
+	}
+}
+
+func popTag(tags map[string]string, key string) string {
+	v := tags[key]
+	delete(tags, key)
+	return v
+}
+
+func eventTitle(body pcommon.Value) (string, bool) {
+	if body.Type() != pcommon.ValueTypeMap {
+		return "", false
+	}
+	title, ok := body.Map().Get("title")
+	if !ok {
+		return "", false
+	}
+	return title.AsString(), true
+}
+
+func doubleValue(dp pmetric.NumberDataPoint) float64 {
+	if dp.ValueType() == pmetric.NumberDataPointValueTypeInt {
+		return float64(dp.IntValue())
+	}
+	return dp.DoubleValue()
+}
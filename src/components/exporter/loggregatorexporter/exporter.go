@@ -0,0 +1,110 @@
+package loggregatorexporter
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+
+	"code.cloudfoundry.org/go-loggregator/v10/rpc/loggregator_v2"
+	"code.cloudfoundry.org/otel-collector-release/src/components/internal/exporterstatus"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/config/configgrpc"
+	"go.opentelemetry.io/collector/consumer/consumererror"
+	"go.opentelemetry.io/collector/exporter"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type loggregatorExporter struct {
+	cfg      *Config
+	settings component.TelemetrySettings
+
+	clientConn *grpc.ClientConn
+	client     loggregator_v2.IngressClient
+
+	userAgent string
+
+	// status reports failing to reach the agent, so the health extension
+	// can see a failing exporter. Batches the agent rejects say nothing
+	// about its health.
+	status exporterstatus.Reporter
+}
+
+func newLoggregatorExporter(cfg *Config, set exporter.Settings) *loggregatorExporter {
+	userAgent := fmt.Sprintf("%s/%s (%s/%s)",
+		set.BuildInfo.Description, set.BuildInfo.Version, runtime.GOOS, runtime.GOARCH)
+
+	return &loggregatorExporter{
+		cfg:       cfg,
+		settings:  set.TelemetrySettings,
+		userAgent: userAgent,
+	}
+}
+
+func (e *loggregatorExporter) start(ctx context.Context, host component.Host) error {
+	agentOpt := configgrpc.WithGrpcDialOption(grpc.WithUserAgent(e.userAgent))
+	conn, err := e.cfg.ClientConfig.ToClientConn(ctx, host, e.settings, agentOpt)
+	if err != nil {
+		return err
+	}
+	e.status.Start(host)
+	e.clientConn = conn
+	e.client = loggregator_v2.NewIngressClient(conn)
+	return nil
+}
+
+func (e *loggregatorExporter) shutdown(context.Context) error {
+	if e.clientConn != nil {
+		return e.clientConn.Close()
+	}
+	return nil
+}
+
+func (e *loggregatorExporter) pushLogs(ctx context.Context, ld plog.Logs) error {
+	return e.send(ctx, logsToEnvelopes(ld))
+}
+
+func (e *loggregatorExporter) pushMetrics(ctx context.Context, md pmetric.Metrics) error {
+	return e.send(ctx, metricsToEnvelopes(md))
+}
+
+func (e *loggregatorExporter) pushTraces(ctx context.Context, td ptrace.Traces) error {
+	return e.send(ctx, tracesToEnvelopes(td))
+}
+
+func (e *loggregatorExporter) send(ctx context.Context, envelopes []*loggregator_v2.Envelope) error {
+	if len(envelopes) == 0 {
+		return nil
+	}
+
+	_, err := e.client.Send(ctx, &loggregator_v2.EnvelopeBatch{Batch: envelopes},
+		grpc.WaitForReady(e.cfg.ClientConfig.WaitForReady),
+	)
+	err = processError(err)
+	e.status.Report(err)
+	return err
+}
+
+// processError marks errors that will not succeed on a retry as permanent so
+// that the exporterhelper drops the batch instead of retrying it.
+func processError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	st, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+
+	switch st.Code() {
+	case codes.InvalidArgument, codes.Unimplemented, codes.Unauthenticated, codes.PermissionDenied:
+		return consumererror.NewPermanent(err)
+	default:
+		return err
+	}
+}
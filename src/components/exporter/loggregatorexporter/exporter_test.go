@@ -0,0 +1,407 @@
+package loggregatorexporter_test
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/go-loggregator/v10/rpc/loggregator_v2"
+	"code.cloudfoundry.org/otel-collector-release/src/components/exporter/loggregatorexporter"
+	"code.cloudfoundry.org/tlsconfig/certtest"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/component/componentstatus"
+	"go.opentelemetry.io/collector/component/componenttest"
+	"go.opentelemetry.io/collector/config/configtls"
+	"go.opentelemetry.io/collector/exporter/exportertest"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+)
+
+var _ = Describe("Loggregator exporter", func() {
+	var (
+		cfg     *loggregatorexporter.Config
+		ingress *fakeIngressServer
+	)
+
+	BeforeEach(func() {
+		ca, err := certtest.BuildCA("loggregator")
+		Expect(err).NotTo(HaveOccurred())
+		dir := GinkgoT().TempDir()
+
+		ingress = startFakeIngressServer(ca)
+		DeferCleanup(ingress.stop)
+
+		caFile, certFile, keyFile := writeCerts(ca, dir, "otel-collector")
+		cfg = loggregatorexporter.NewFactory().CreateDefaultConfig().(*loggregatorexporter.Config)
+		cfg.ClientConfig.Endpoint = ingress.addr
+		cfg.ClientConfig.TLS = configtls.ClientConfig{
+			Config: configtls.Config{
+				CAFile:   caFile,
+				CertFile: certFile,
+				KeyFile:  keyFile,
+			},
+			ServerName: "localhost",
+		}
+		cfg.QueueConfig.Enabled = false
+		cfg.RetryConfig.InitialInterval = 10 * time.Millisecond
+		cfg.RetryConfig.MaxInterval = 10 * time.Millisecond
+		Expect(cfg.Validate()).To(Succeed())
+	})
+
+	start := func(c component.Component) {
+		Expect(c.Start(context.Background(), componenttest.NewNopHost())).To(Succeed())
+		DeferCleanup(c.Shutdown, context.Background())
+	}
+
+	Describe("logs", func() {
+		It("sends log records as log envelopes", func() {
+			exp, err := loggregatorexporter.NewFactory().CreateLogs(context.Background(), exportertest.NewNopSettings(loggregatorexporter.NewFactory().Type()), cfg)
+			Expect(err).NotTo(HaveOccurred())
+			start(exp)
+
+			ld := plog.NewLogs()
+			rl := ld.ResourceLogs().AppendEmpty()
+			rl.Resource().Attributes().PutStr("deployment", "cf")
+			records := rl.ScopeLogs().AppendEmpty().LogRecords()
+
+			out := records.AppendEmpty()
+			out.SetTimestamp(pcommon.Timestamp(1741217715635087516))
+			out.SetSeverityNumber(plog.SeverityNumberInfo)
+			out.Body().SetStr("hello")
+			out.Attributes().PutStr("source_id", "64f96e84-e84d-4543-bf85-9cbfc567aa44")
+			out.Attributes().PutStr("instance_id", "0")
+			out.Attributes().PutStr("source_type", "APP/PROC/WEB")
+
+			errLog := records.AppendEmpty()
+			errLog.SetSeverityNumber(plog.SeverityNumberError)
+			errLog.Body().SetStr("oops")
+
+			event := records.AppendEmpty()
+			event.Body().SetEmptyMap().PutStr("title", "vm alert")
+			event.Body().Map().PutStr("body", "disk full")
+
+			Expect(exp.ConsumeLogs(context.Background(), ld)).To(Succeed())
+
+			envelopes := ingress.envelopes()
+			Expect(envelopes).To(HaveLen(3))
+
+			Expect(envelopes[0].GetTimestamp()).To(Equal(int64(1741217715635087516)))
+			Expect(envelopes[0].GetSourceId()).To(Equal("64f96e84-e84d-4543-bf85-9cbfc567aa44"))
+			Expect(envelopes[0].GetInstanceId()).To(Equal("0"))
+			Expect(envelopes[0].GetTags()).To(Equal(map[string]string{"deployment": "cf", "source_type": "APP/PROC/WEB"}))
+			Expect(envelopes[0].GetLog().GetPayload()).To(Equal([]byte("hello")))
+			Expect(envelopes[0].GetLog().GetType()).To(Equal(loggregator_v2.Log_OUT))
+
+			Expect(envelopes[1].GetLog().GetType()).To(Equal(loggregator_v2.Log_ERR))
+			Expect(envelopes[1].GetTimestamp()).NotTo(BeZero())
+
+			Expect(envelopes[2].GetEvent().GetTitle()).To(Equal("vm alert"))
+			Expect(envelopes[2].GetEvent().GetBody()).To(Equal("disk full"))
+		})
+
+		It("sends records with a map body with only a title as events without a body", func() {
+			exp, err := loggregatorexporter.NewFactory().CreateLogs(context.Background(), exportertest.NewNopSettings(loggregatorexporter.NewFactory().Type()), cfg)
+			Expect(err).NotTo(HaveOccurred())
+			start(exp)
+
+			ld := plog.NewLogs()
+			ld.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords().AppendEmpty().Body().SetEmptyMap().PutStr("title", "vm alert")
+
+			Expect(exp.ConsumeLogs(context.Background(), ld)).To(Succeed())
+			Expect(ingress.envelopes()[0].GetEvent().GetTitle()).To(Equal("vm alert"))
+			Expect(ingress.envelopes()[0].GetEvent().GetBody()).To(BeEmpty())
+		})
+
+		It("falls back to the service resource attributes for the source and instance IDs", func() {
+			exp, err := loggregatorexporter.NewFactory().CreateLogs(context.Background(), exportertest.NewNopSettings(loggregatorexporter.NewFactory().Type()), cfg)
+			Expect(err).NotTo(HaveOccurred())
+			start(exp)
+
+			ld := plog.NewLogs()
+			rl := ld.ResourceLogs().AppendEmpty()
+			rl.Resource().Attributes().PutStr("service.name", "uaa")
+			rl.Resource().Attributes().PutStr("service.instance.id", "2")
+			rl.ScopeLogs().AppendEmpty().LogRecords().AppendEmpty().Body().SetStr("hello")
+
+			Expect(exp.ConsumeLogs(context.Background(), ld)).To(Succeed())
+			Expect(ingress.envelopes()[0].GetSourceId()).To(Equal("uaa"))
+			Expect(ingress.envelopes()[0].GetInstanceId()).To(Equal("2"))
+		})
+	})
+
+	Describe("metrics", func() {
+		It("sends gauges and sums as gauge and counter envelopes", func() {
+			exp, err := loggregatorexporter.NewFactory().CreateMetrics(context.Background(), exportertest.NewNopSettings(loggregatorexporter.NewFactory().Type()), cfg)
+			Expect(err).NotTo(HaveOccurred())
+			start(exp)
+
+			md := pmetric.NewMetrics()
+			ms := md.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty().Metrics()
+
+			gauge := ms.AppendEmpty()
+			gauge.SetName("system_disk_persistent_read_bytes")
+			gauge.SetUnit("Bytes")
+			dp := gauge.SetEmptyGauge().DataPoints().AppendEmpty()
+			dp.SetDoubleValue(5436416)
+			dp.Attributes().PutStr("source_id", "system_metrics_agent")
+
+			cumulative := ms.AppendEmpty()
+			cumulative.SetName("total_requests")
+			cumulative.SetEmptySum().SetIsMonotonic(true)
+			cumulative.Sum().SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
+			cumulative.Sum().DataPoints().AppendEmpty().SetIntValue(42)
+
+			delta := ms.AppendEmpty()
+			delta.SetName("bad_gateways")
+			delta.SetEmptySum().SetIsMonotonic(true)
+			delta.Sum().SetAggregationTemporality(pmetric.AggregationTemporalityDelta)
+			delta.Sum().DataPoints().AppendEmpty().SetIntValue(3)
+
+			upDown := ms.AppendEmpty()
+			upDown.SetName("active_connections")
+			upDown.SetEmptySum().SetIsMonotonic(false)
+			upDown.Sum().DataPoints().AppendEmpty().SetIntValue(7)
+
+			histogram := ms.AppendEmpty()
+			histogram.SetName("latency")
+			histogram.SetEmptyHistogram().DataPoints().AppendEmpty()
+
+			Expect(exp.ConsumeMetrics(context.Background(), md)).To(Succeed())
+
+			envelopes := ingress.envelopes()
+			Expect(envelopes).To(HaveLen(4))
+
+			Expect(envelopes[0].GetSourceId()).To(Equal("system_metrics_agent"))
+			Expect(envelopes[0].GetGauge().GetMetrics()).To(HaveKey("system_disk_persistent_read_bytes"))
+			Expect(envelopes[0].GetGauge().GetMetrics()["system_disk_persistent_read_bytes"].GetUnit()).To(Equal("Bytes"))
+			Expect(envelopes[0].GetGauge().GetMetrics()["system_disk_persistent_read_bytes"].GetValue()).To(Equal(float64(5436416)))
+
+			Expect(envelopes[1].GetCounter().GetName()).To(Equal("total_requests"))
+			Expect(envelopes[1].GetCounter().GetTotal()).To(Equal(uint64(42)))
+			Expect(envelopes[1].GetCounter().GetDelta()).To(BeZero())
+
+			Expect(envelopes[2].GetCounter().GetName()).To(Equal("bad_gateways"))
+			Expect(envelopes[2].GetCounter().GetDelta()).To(Equal(uint64(3)))
+
+			Expect(envelopes[3].GetGauge().GetMetrics()["active_connections"].GetValue()).To(Equal(float64(7)))
+		})
+	})
+
+	Describe("traces", func() {
+		It("sends spans as timer envelopes", func() {
+			exp, err := loggregatorexporter.NewFactory().CreateTraces(context.Background(), exportertest.NewNopSettings(loggregatorexporter.NewFactory().Type()), cfg)
+			Expect(err).NotTo(HaveOccurred())
+			start(exp)
+
+			td := ptrace.NewTraces()
+			span := td.ResourceSpans().AppendEmpty().ScopeSpans().AppendEmpty().Spans().AppendEmpty()
+			span.SetName("/")
+			span.SetKind(ptrace.SpanKindServer)
+			span.SetTraceID(pcommon.TraceID{0xf0, 0xdb, 0x48, 0x01, 0xb9, 0x63, 0x4b, 0x85, 0x63, 0x6b, 0x69, 0xc9, 0x4e, 0x62, 0x2b, 0x26})
+			span.SetSpanID(pcommon.SpanID{0x63, 0x6b, 0x69, 0xc9, 0x4e, 0x62, 0x2b, 0x26})
+			span.SetStartTimestamp(pcommon.Timestamp(1741216325739358994))
+			span.SetEndTimestamp(pcommon.Timestamp(1741216325759969237))
+			span.Attributes().PutStr("source_id", "gorouter")
+			span.Attributes().PutStr("method", "GET")
+
+			Expect(exp.ConsumeTraces(context.Background(), td)).To(Succeed())
+
+			envelopes := ingress.envelopes()
+			Expect(envelopes).To(HaveLen(1))
+			Expect(envelopes[0].GetSourceId()).To(Equal("gorouter"))
+			Expect(envelopes[0].GetTimer().GetName()).To(Equal("/"))
+			Expect(envelopes[0].GetTimer().GetStart()).To(Equal(int64(1741216325739358994)))
+			Expect(envelopes[0].GetTimer().GetStop()).To(Equal(int64(1741216325759969237)))
+			Expect(envelopes[0].GetTags()).To(Equal(map[string]string{
+				"trace_id":  "f0db4801b9634b85636b69c94e622b26",
+				"span_id":   "636b69c94e622b26",
+				"peer_type": "Server",
+				"method":    "GET",
+			}))
+		})
+	})
+
+	Describe("failures", func() {
+		It("retries when the agent is unavailable", func() {
+			ingress.failWith(codes.Unavailable, 2)
+
+			exp, err := loggregatorexporter.NewFactory().CreateLogs(context.Background(), exportertest.NewNopSettings(loggregatorexporter.NewFactory().Type()), cfg)
+			Expect(err).NotTo(HaveOccurred())
+			start(exp)
+
+			ld := plog.NewLogs()
+			ld.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords().AppendEmpty().Body().SetStr("hello")
+
+			Expect(exp.ConsumeLogs(context.Background(), ld)).To(Succeed())
+			Expect(ingress.envelopes()).To(HaveLen(1))
+		})
+
+		It("does not retry when the agent rejects the batch", func() {
+			ingress.failWith(codes.InvalidArgument, 1)
+
+			exp, err := loggregatorexporter.NewFactory().CreateLogs(context.Background(), exportertest.NewNopSettings(loggregatorexporter.NewFactory().Type()), cfg)
+			Expect(err).NotTo(HaveOccurred())
+			start(exp)
+
+			ld := plog.NewLogs()
+			ld.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords().AppendEmpty().Body().SetStr("hello")
+
+			Expect(exp.ConsumeLogs(context.Background(), ld)).To(MatchError(ContainSubstring("InvalidArgument")))
+			Expect(ingress.envelopes()).To(BeEmpty())
+		})
+
+		It("reports failing to reach the agent and recovering as status events", func() {
+			ingress.failWith(codes.Unavailable, 2)
+
+			exp, err := loggregatorexporter.NewFactory().CreateLogs(context.Background(), exportertest.NewNopSettings(loggregatorexporter.NewFactory().Type()), cfg)
+			Expect(err).NotTo(HaveOccurred())
+			host := &statusHost{Host: componenttest.NewNopHost()}
+			Expect(exp.Start(context.Background(), host)).To(Succeed())
+			DeferCleanup(exp.Shutdown, context.Background())
+
+			ld := plog.NewLogs()
+			ld.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords().AppendEmpty().Body().SetStr("hello")
+
+			Expect(exp.ConsumeLogs(context.Background(), ld)).To(Succeed())
+			Expect(exp.ConsumeLogs(context.Background(), ld)).To(Succeed())
+			Expect(host.statuses()).To(Equal([]componentstatus.Status{
+				componentstatus.StatusRecoverableError,
+				componentstatus.StatusOK,
+			}))
+		})
+	})
+})
+
+var _ = Describe("Config", func() {
+	It("requires a client certificate", func() {
+		cfg := loggregatorexporter.NewFactory().CreateDefaultConfig().(*loggregatorexporter.Config)
+		Expect(cfg.Validate()).To(MatchError(ContainSubstring("client certificate")))
+
+		cfg.ClientConfig.TLS.CertFile = "cert.pem"
+		Expect(cfg.Validate()).To(MatchError(ContainSubstring("client key")))
+
+		cfg.ClientConfig.TLS.KeyFile = "key.pem"
+		Expect(cfg.Validate()).To(Succeed())
+
+		cfg.ClientConfig.TLS.Insecure = true
+		Expect(cfg.Validate()).To(MatchError(ContainSubstring("mutual TLS")))
+	})
+})
+
+type fakeIngressServer struct {
+	loggregator_v2.UnimplementedIngressServer
+
+	addr   string
+	server *grpc.Server
+
+	mu        sync.Mutex
+	received  []*loggregator_v2.Envelope
+	failCode  codes.Code
+	failCount int
+}
+
+func startFakeIngressServer(ca *certtest.Authority) *fakeIngressServer {
+	cert, err := ca.BuildSignedCertificate("metron")
+	Expect(err).NotTo(HaveOccurred())
+	tlsCert, err := cert.TLSCertificate()
+	Expect(err).NotTo(HaveOccurred())
+	pool, err := ca.CertPool()
+	Expect(err).NotTo(HaveOccurred())
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	Expect(err).NotTo(HaveOccurred())
+
+	f := &fakeIngressServer{addr: lis.Addr().String()}
+	f.server = grpc.NewServer(grpc.Creds(credentials.NewTLS(&tls.Config{
+		Certificates: []tls.Certificate{tlsCert},
+		ClientCAs:    pool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+	})))
+	loggregator_v2.RegisterIngressServer(f.server, f)
+	go f.server.Serve(lis)
+	return f
+}
+
+func (f *fakeIngressServer) Send(_ context.Context, b *loggregator_v2.EnvelopeBatch) (*loggregator_v2.SendResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.failCount > 0 {
+		f.failCount--
+		return nil, status.Error(f.failCode, "failing on purpose")
+	}
+	f.received = append(f.received, b.GetBatch()...)
+	return &loggregator_v2.SendResponse{}, nil
+}
+
+func (f *fakeIngressServer) failWith(code codes.Code, times int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.failCode = code
+	f.failCount = times
+}
+
+func (f *fakeIngressServer) envelopes() []*loggregator_v2.Envelope {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.received
+}
+
+func (f *fakeIngressServer) stop() {
+	f.server.Stop()
+}
+
+// statusHost records the status events reported by the exporter.
+type statusHost struct {
+	component.Host
+
+	mu     sync.Mutex
+	events []*componentstatus.Event
+}
+
+func (h *statusHost) Report(ev *componentstatus.Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.events = append(h.events, ev)
+}
+
+func (h *statusHost) statuses() []componentstatus.Status {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var statuses []componentstatus.Status
+	for _, ev := range h.events {
+		statuses = append(statuses, ev.Status())
+	}
+	return statuses
+}
+
+func writeCerts(ca *certtest.Authority, dir, name string) (string, string, string) {
+	caPEM, err := ca.CertificatePEM()
+	Expect(err).NotTo(HaveOccurred())
+	Expect(x509.NewCertPool().AppendCertsFromPEM(caPEM)).To(BeTrue())
+	cert, err := ca.BuildSignedCertificate(name)
+	Expect(err).NotTo(HaveOccurred())
+	certPEM, keyPEM, err := cert.CertificatePEMAndPrivateKey()
+	Expect(err).NotTo(HaveOccurred())
+
+	caFile := filepath.Join(dir, "ca.crt")
+	certFile := filepath.Join(dir, name+".crt")
+	keyFile := filepath.Join(dir, name+".key")
+	Expect(os.WriteFile(caFile, caPEM, 0600)).To(Succeed())
+	Expect(os.WriteFile(certFile, certPEM, 0600)).To(Succeed())
+	Expect(os.WriteFile(keyFile, keyPEM, 0600)).To(Succeed())
+	return caFile, certFile, keyFile
+}
@@ -0,0 +1,78 @@
+// Package loggregatorexporter implements an exporter that converts pdata into
+// Loggregator v2 envelopes and sends them to a Loggregator agent.
+package loggregatorexporter
+
+import (
+	"context"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/config/configgrpc"
+	"go.opentelemetry.io/collector/config/configretry"
+	"go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/exporter"
+	"go.opentelemetry.io/collector/exporter/exporterhelper"
+)
+
+const stability = component.StabilityLevelAlpha
+
+var componentType = component.MustNewType("loggregator")
+
+// NewFactory creates a factory for the Loggregator v2 exporter.
+func NewFactory() exporter.Factory {
+	return exporter.NewFactory(
+		componentType,
+		createDefaultConfig,
+		exporter.WithLogs(createLogs, stability),
+		exporter.WithMetrics(createMetrics, stability),
+		exporter.WithTraces(createTraces, stability),
+	)
+}
+
+func createDefaultConfig() component.Config {
+	clientCfg := configgrpc.NewDefaultClientConfig()
+	clientCfg.Endpoint = "localhost:3458"
+	clientCfg.Keepalive = nil
+	clientCfg.BalancerName = ""
+
+	return &Config{
+		TimeoutConfig: exporterhelper.NewDefaultTimeoutConfig(),
+		QueueConfig:   exporterhelper.NewDefaultQueueConfig(),
+		RetryConfig:   configretry.NewDefaultBackOffConfig(),
+		ClientConfig:  clientCfg,
+	}
+}
+
+func createLogs(ctx context.Context, set exporter.Settings, cfg component.Config) (exporter.Logs, error) {
+	le := newLoggregatorExporter(cfg.(*Config), set)
+	return exporterhelper.NewLogs(ctx, set, cfg,
+		le.pushLogs,
+		options(le)...,
+	)
+}
+
+func createMetrics(ctx context.Context, set exporter.Settings, cfg component.Config) (exporter.Metrics, error) {
+	le := newLoggregatorExporter(cfg.(*Config), set)
+	return exporterhelper.NewMetrics(ctx, set, cfg,
+		le.pushMetrics,
+		options(le)...,
+	)
+}
+
+func createTraces(ctx context.Context, set exporter.Settings, cfg component.Config) (exporter.Traces, error) {
+	le := newLoggregatorExporter(cfg.(*Config), set)
+	return exporterhelper.NewTraces(ctx, set, cfg,
+		le.pushTraces,
+		options(le)...,
+	)
+}
+
+func options(le *loggregatorExporter) []exporterhelper.Option {
+	return []exporterhelper.Option{
+		exporterhelper.WithCapabilities(consumer.Capabilities{MutatesData: false}),
+		exporterhelper.WithTimeout(le.cfg.TimeoutConfig),
+		exporterhelper.WithRetry(le.cfg.RetryConfig),
+		exporterhelper.WithQueue(le.cfg.QueueConfig),
+		exporterhelper.WithStart(le.start),
+		exporterhelper.WithShutdown(le.shutdown),
+	}
+}
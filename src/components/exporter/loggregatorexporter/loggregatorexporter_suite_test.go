@@ -0,0 +1,13 @@
+package loggregatorexporter_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestLoggregatorExporter(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Loggregator Exporter Suite")
+}
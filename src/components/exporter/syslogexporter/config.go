@@ -0,0 +1,68 @@
+package syslogexporter
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/config/configretry"
+	"go.opentelemetry.io/collector/config/configtls"
+	"go.opentelemetry.io/collector/exporter/exporterhelper"
+)
+
+const (
+	schemeSyslog    = "syslog"
+	schemeSyslogTLS = "syslog-tls"
+	schemeHTTPS     = "https"
+)
+
+// Config defines the configuration for the syslog exporter.
+type Config struct {
+	TimeoutConfig exporterhelper.TimeoutConfig    `mapstructure:",squash"`
+	QueueConfig   exporterhelper.QueueBatchConfig `mapstructure:"sending_queue"`
+	RetryConfig   configretry.BackOffConfig       `mapstructure:"retry_on_failure"`
+
+	// Endpoint is the drain URL, using the same syslog://, syslog-tls:// and
+	// https:// schemes as CF syslog drains.
+	Endpoint string `mapstructure:"endpoint"`
+
+	// TLS configures the connection for syslog-tls:// and https:// drains.
+	TLS configtls.ClientConfig `mapstructure:"tls"`
+
+	// Hostname is used for records that do not carry the organization, space
+	// and app names.
+	Hostname string `mapstructure:"hostname"`
+}
+
+var _ component.Config = (*Config)(nil)
+
+// Validate checks that the endpoint is a drain URL with a supported scheme.
+func (cfg *Config) Validate() error {
+	if cfg.Endpoint == "" {
+		return errors.New(`requires a non-empty "endpoint"`)
+	}
+
+	u, err := url.Parse(cfg.Endpoint)
+	if err != nil {
+		return fmt.Errorf("invalid endpoint: %w", err)
+	}
+
+	switch u.Scheme {
+	case schemeSyslog, schemeSyslogTLS:
+		if u.Hostname() == "" || u.Port() == "" {
+			return fmt.Errorf("endpoint %q must include a host and port", cfg.Endpoint)
+		}
+	case schemeHTTPS:
+		if u.Hostname() == "" {
+			return fmt.Errorf("endpoint %q must include a host", cfg.Endpoint)
+		}
+	default:
+		return fmt.Errorf("unsupported endpoint scheme %q, must be one of syslog, syslog-tls or https", u.Scheme)
+	}
+
+	if len(cfg.Hostname) > maxHostnameLength {
+		return fmt.Errorf("hostname must be at most %d characters", maxHostnameLength)
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package syslogexporter
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net/url"
+
+	"code.cloudfoundry.org/otel-collector-release/src/components/internal/exporterstatus"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/consumer/consumererror"
+	"go.opentelemetry.io/collector/pdata/plog"
+)
+
+type syslogExporter struct {
+	cfg    *Config
+	writer drainWriter
+
+	// status reports failing to reach the drain, so the health extension
+	// can see a failing exporter. Messages the drain rejects say nothing
+	// about its health.
+	status exporterstatus.Reporter
+}
+
+func newSyslogExporter(cfg *Config) *syslogExporter {
+	return &syslogExporter{cfg: cfg}
+}
+
+func (e *syslogExporter) start(ctx context.Context, host component.Host) error {
+	e.status.Start(host)
+
+	u, err := url.Parse(e.cfg.Endpoint)
+	if err != nil {
+		return err
+	}
+
+	var tlsConfig *tls.Config
+	if u.Scheme != schemeSyslog {
+		tlsConfig, err = e.cfg.TLS.LoadTLSConfig(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	switch u.Scheme {
+	case schemeHTTPS:
+		e.writer = newHTTPSWriter(u.String(), tlsConfig)
+	default:
+		e.writer = newTCPWriter(u.Host, tlsConfig)
+	}
+	return nil
+}
+
+func (e *syslogExporter) shutdown(context.Context) error {
+	if e.writer != nil {
+		return e.writer.close()
+	}
+	return nil
+}
+
+func (e *syslogExporter) pushLogs(ctx context.Context, ld plog.Logs) error {
+	messages := logsToMessages(ld, e.cfg.Hostname)
+	if len(messages) == 0 {
+		return nil
+	}
+	err := e.writer.write(ctx, messages)
+	e.status.Report(err)
+
+	var undelivered *undeliveredError
+	if errors.As(err, &undelivered) {
+		// Only the undelivered records are retried, so that the drain
+		// doesn't receive the delivered ones twice.
+		return consumererror.NewLogs(undelivered.err, logsFrom(ld, undelivered.index))
+	}
+	return err
+}
+
+// logsFrom returns the log records of ld from the given index on, in the
+// order logsToMessages formats them.
+func logsFrom(ld plog.Logs, index int) plog.Logs {
+	remaining := plog.NewLogs()
+	ld.CopyTo(remaining)
+
+	i := 0
+	remaining.ResourceLogs().RemoveIf(func(rl plog.ResourceLogs) bool {
+		rl.ScopeLogs().RemoveIf(func(sl plog.ScopeLogs) bool {
+			sl.LogRecords().RemoveIf(func(plog.LogRecord) bool {
+				i++
+				return i <= index
+			})
+			return sl.LogRecords().Len() == 0
+		})
+		return rl.ScopeLogs().Len() == 0
+	})
+	return remaining
+}
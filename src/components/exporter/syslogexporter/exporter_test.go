@@ -0,0 +1,410 @@
+package syslogexporter_test
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"code.cloudfoundry.org/otel-collector-release/src/components/exporter/syslogexporter"
+	"code.cloudfoundry.org/tlsconfig/certtest"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/component/componentstatus"
+	"go.opentelemetry.io/collector/component/componenttest"
+	"go.opentelemetry.io/collector/consumer/consumererror"
+	"go.opentelemetry.io/collector/exporter"
+	"go.opentelemetry.io/collector/exporter/exportertest"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+)
+
+var _ = Describe("Syslog exporter", func() {
+	var (
+		ca     *certtest.Authority
+		caFile string
+		cfg    *syslogexporter.Config
+	)
+
+	BeforeEach(func() {
+		var err error
+		ca, err = certtest.BuildCA("syslog")
+		Expect(err).NotTo(HaveOccurred())
+		caPEM, err := ca.CertificatePEM()
+		Expect(err).NotTo(HaveOccurred())
+		caFile = filepath.Join(GinkgoT().TempDir(), "ca.crt")
+		Expect(os.WriteFile(caFile, caPEM, 0600)).To(Succeed())
+
+		cfg = syslogexporter.NewFactory().CreateDefaultConfig().(*syslogexporter.Config)
+		cfg.QueueConfig.Enabled = false
+		cfg.RetryConfig.Enabled = false
+		cfg.TLS.CAFile = caFile
+		cfg.Hostname = "otel-collector"
+	})
+
+	createLogs := func() exporter.Logs {
+		Expect(cfg.Validate()).To(Succeed())
+		exp, err := syslogexporter.NewFactory().CreateLogs(context.Background(), exportertest.NewNopSettings(syslogexporter.NewFactory().Type()), cfg)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(exp.Start(context.Background(), componenttest.NewNopHost())).To(Succeed())
+		DeferCleanup(exp.Shutdown, context.Background())
+		return exp
+	}
+
+	Describe("syslog-tls drains", func() {
+		var (
+			messages        chan string
+			dropConnections func()
+		)
+
+		BeforeEach(func() {
+			tlsConfig := serverTLSConfig(ca)
+			lis, err := tls.Listen("tcp", "127.0.0.1:0", tlsConfig)
+			Expect(err).NotTo(HaveOccurred())
+			DeferCleanup(lis.Close)
+			messages, dropConnections = acceptOctetCounted(lis)
+
+			cfg.Endpoint = "syslog-tls://" + lis.Addr().String()
+		})
+
+		It("maps the CF attributes onto the RFC 5424 header", func() {
+			exp := createLogs()
+
+			Expect(exp.ConsumeLogs(context.Background(), appLogs())).To(Succeed())
+
+			Eventually(messages).Should(Receive(Equal(
+				`<14>1 2025-03-05T23:35:15.635087516Z system.my-space.my-app 64f96e84-e84d-4543-bf85-9cbfc567aa44 [APP/PROC/WEB/0] - ` +
+					`[tags@47450 app_id="64f96e84-e84d-4543-bf85-9cbfc567aa44" app_name="my app" deployment="cf" organization_name="system" source_type="APP/PROC/WEB" space_name="my space"] ` +
+					"Added process: \"web\"\n",
+			)))
+		})
+
+		It("uses the error priority for error logs", func() {
+			exp := createLogs()
+
+			ld := plog.NewLogs()
+			lr := ld.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords().AppendEmpty()
+			lr.SetTimestamp(pcommon.Timestamp(1741217715635087516))
+			lr.SetSeverityNumber(plog.SeverityNumberError)
+			lr.Body().SetStr("oops\n")
+			lr.Attributes().PutStr("source_id", "gorouter")
+
+			Expect(exp.ConsumeLogs(context.Background(), ld)).To(Succeed())
+
+			Eventually(messages).Should(Receive(Equal(
+				"<11>1 2025-03-05T23:35:15.635087516Z otel-collector gorouter - - - oops\n",
+			)))
+		})
+
+		It("escapes structured data values", func() {
+			exp := createLogs()
+
+			ld := plog.NewLogs()
+			lr := ld.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords().AppendEmpty()
+			lr.Body().SetStr("hello")
+			lr.Attributes().PutStr("quoted", `say "hi" [now] \o/`)
+
+			Expect(exp.ConsumeLogs(context.Background(), ld)).To(Succeed())
+
+			var msg string
+			Eventually(messages).Should(Receive(&msg))
+			Expect(msg).To(ContainSubstring(`[tags@47450 quoted="say \"hi\" [now\] \\o/"] hello`))
+		})
+
+		It("reconnects after the drain drops the connection", func() {
+			exp := createLogs()
+
+			Expect(exp.ConsumeLogs(context.Background(), appLogs())).To(Succeed())
+			Eventually(messages).Should(Receive())
+
+			dropConnections()
+
+			Eventually(func() <-chan string {
+				_ = exp.ConsumeLogs(context.Background(), appLogs())
+				return messages
+			}).Should(Receive())
+		})
+	})
+
+	Describe("syslog drains", func() {
+		It("writes octet counted messages over plain TCP", func() {
+			lis, err := net.Listen("tcp", "127.0.0.1:0")
+			Expect(err).NotTo(HaveOccurred())
+			DeferCleanup(lis.Close)
+			messages, _ := acceptOctetCounted(lis)
+
+			cfg.Endpoint = "syslog://" + lis.Addr().String()
+			exp := createLogs()
+
+			Expect(exp.ConsumeLogs(context.Background(), appLogs())).To(Succeed())
+			Eventually(messages).Should(Receive(HaveSuffix("Added process: \"web\"\n")))
+		})
+
+		It("returns an error when the drain is unreachable", func() {
+			lis, err := net.Listen("tcp", "127.0.0.1:0")
+			Expect(err).NotTo(HaveOccurred())
+			addr := lis.Addr().String()
+			Expect(lis.Close()).To(Succeed())
+
+			cfg.Endpoint = "syslog://" + addr
+			exp := createLogs()
+
+			Expect(exp.ConsumeLogs(context.Background(), appLogs())).To(MatchError(ContainSubstring("connection refused")))
+		})
+
+		It("reports failing to reach the drain and recovering as status events", func() {
+			lis, err := net.Listen("tcp", "127.0.0.1:0")
+			Expect(err).NotTo(HaveOccurred())
+			addr := lis.Addr().String()
+			Expect(lis.Close()).To(Succeed())
+
+			cfg.Endpoint = "syslog://" + addr
+			exp, err := syslogexporter.NewFactory().CreateLogs(context.Background(), exportertest.NewNopSettings(syslogexporter.NewFactory().Type()), cfg)
+			Expect(err).NotTo(HaveOccurred())
+			host := &statusHost{Host: componenttest.NewNopHost()}
+			Expect(exp.Start(context.Background(), host)).To(Succeed())
+			DeferCleanup(exp.Shutdown, context.Background())
+
+			Expect(exp.ConsumeLogs(context.Background(), appLogs())).NotTo(Succeed())
+			Expect(exp.ConsumeLogs(context.Background(), appLogs())).NotTo(Succeed())
+
+			lis, err = net.Listen("tcp", addr)
+			Expect(err).NotTo(HaveOccurred())
+			DeferCleanup(lis.Close)
+			messages, _ := acceptOctetCounted(lis)
+
+			Expect(exp.ConsumeLogs(context.Background(), appLogs())).To(Succeed())
+			Eventually(messages).Should(Receive())
+			Expect(host.statuses()).To(Equal([]componentstatus.Status{
+				componentstatus.StatusRecoverableError,
+				componentstatus.StatusOK,
+			}))
+		})
+	})
+
+	Describe("https drains", func() {
+		var (
+			server  *httptest.Server
+			bodies  chan string
+			respond func(body string) int
+		)
+
+		BeforeEach(func() {
+			bodies = make(chan string, 10)
+			respond = func(string) int { return http.StatusOK }
+			server = httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				defer GinkgoRecover()
+				Expect(r.Method).To(Equal(http.MethodPost))
+				Expect(r.Header.Get("Content-Type")).To(Equal("text/plain"))
+				body, err := io.ReadAll(r.Body)
+				Expect(err).NotTo(HaveOccurred())
+				bodies <- string(body)
+				w.WriteHeader(respond(string(body)))
+			}))
+			server.TLS = serverTLSConfig(ca)
+			server.StartTLS()
+			DeferCleanup(server.Close)
+
+			cfg.Endpoint = server.URL + "/drain"
+		})
+
+		It("posts every message in its own request", func() {
+			exp := createLogs()
+
+			ld := appLogs()
+			ld.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().AppendEmpty().Body().SetStr("second")
+			Expect(exp.ConsumeLogs(context.Background(), ld)).To(Succeed())
+
+			Eventually(bodies).Should(Receive(HavePrefix("<14>1 2025-03-05T23:35:15.635087516Z system.my-space.my-app")))
+			Eventually(bodies).Should(Receive(HaveSuffix("second\n")))
+		})
+
+		It("does not retry rejected messages", func() {
+			respond = func(string) int { return http.StatusBadRequest }
+			cfg.RetryConfig.Enabled = true
+			exp := createLogs()
+
+			err := exp.ConsumeLogs(context.Background(), appLogs())
+			Expect(err).To(MatchError(ContainSubstring("400 Bad Request")))
+			Expect(bodies).To(HaveLen(1))
+		})
+
+		It("posts the messages after a rejected one", func() {
+			respond = func(body string) int {
+				if strings.HasSuffix(body, "first\n") {
+					return http.StatusBadRequest
+				}
+				return http.StatusOK
+			}
+			exp := createLogs()
+
+			err := exp.ConsumeLogs(context.Background(), numberedLogs("first", "second"))
+			Expect(err).To(MatchError(ContainSubstring("400 Bad Request")))
+			Expect(consumererror.IsPermanent(err)).To(BeTrue())
+			Expect(bodies).To(Receive(HaveSuffix("first\n")))
+			Expect(bodies).To(Receive(HaveSuffix("second\n")))
+		})
+
+		It("only retries the messages that were not delivered", func() {
+			var failed atomic.Bool
+			respond = func(body string) int {
+				if strings.HasSuffix(body, "second\n") && failed.CompareAndSwap(false, true) {
+					return http.StatusServiceUnavailable
+				}
+				return http.StatusOK
+			}
+			cfg.RetryConfig.Enabled = true
+			cfg.RetryConfig.InitialInterval = 10 * time.Millisecond
+			exp := createLogs()
+
+			Expect(exp.ConsumeLogs(context.Background(), numberedLogs("first", "second", "third"))).To(Succeed())
+
+			var posted []string
+			for len(bodies) > 0 {
+				body := <-bodies
+				posted = append(posted, body[strings.LastIndex(body, " ")+1:])
+			}
+			Expect(posted).To(Equal([]string{"first\n", "second\n", "second\n", "third\n"}))
+		})
+	})
+})
+
+var _ = Describe("Config", func() {
+	DescribeTable("validates the endpoint",
+		func(endpoint string, matcher OmegaMatcher) {
+			cfg := syslogexporter.NewFactory().CreateDefaultConfig().(*syslogexporter.Config)
+			cfg.Endpoint = endpoint
+			Expect(cfg.Validate()).To(matcher)
+		},
+		Entry("syslog", "syslog://drain.example.com:514", Succeed()),
+		Entry("syslog-tls", "syslog-tls://drain.example.com:6514", Succeed()),
+		Entry("https", "https://drain.example.com/logs", Succeed()),
+		Entry("empty", "", MatchError(ContainSubstring("non-empty"))),
+		Entry("missing port", "syslog-tls://drain.example.com", MatchError(ContainSubstring("host and port"))),
+		Entry("unsupported scheme", "http://drain.example.com", MatchError(ContainSubstring("unsupported endpoint scheme"))),
+	)
+})
+
+// statusHost records the status events reported by the exporter.
+type statusHost struct {
+	component.Host
+
+	mu     sync.Mutex
+	events []*componentstatus.Event
+}
+
+func (h *statusHost) Report(ev *componentstatus.Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.events = append(h.events, ev)
+}
+
+func (h *statusHost) statuses() []componentstatus.Status {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var statuses []componentstatus.Status
+	for _, ev := range h.events {
+		statuses = append(statuses, ev.Status())
+	}
+	return statuses
+}
+
+func appLogs() plog.Logs {
+	ld := plog.NewLogs()
+	rl := ld.ResourceLogs().AppendEmpty()
+	rl.Resource().Attributes().PutStr("deployment", "cf")
+	lr := rl.ScopeLogs().AppendEmpty().LogRecords().AppendEmpty()
+	lr.SetTimestamp(pcommon.Timestamp(1741217715635087516))
+	lr.Body().SetStr("Added process: \"web\"")
+	lr.Attributes().PutStr("source_id", "64f96e84-e84d-4543-bf85-9cbfc567aa44")
+	lr.Attributes().PutStr("app_id", "64f96e84-e84d-4543-bf85-9cbfc567aa44")
+	lr.Attributes().PutStr("instance_id", "0")
+	lr.Attributes().PutStr("source_type", "APP/PROC/WEB")
+	lr.Attributes().PutStr("organization_name", "system")
+	lr.Attributes().PutStr("space_name", "my space")
+	lr.Attributes().PutStr("app_name", "my app")
+	return ld
+}
+
+// numberedLogs returns app logs with a record for every body, each in its
+// own resource.
+func numberedLogs(bodies ...string) plog.Logs {
+	ld := plog.NewLogs()
+	for _, body := range bodies {
+		rl := ld.ResourceLogs().AppendEmpty()
+		appLogs().ResourceLogs().At(0).CopyTo(rl)
+		rl.ScopeLogs().At(0).LogRecords().At(0).Body().SetStr(body)
+	}
+	return ld
+}
+
+func serverTLSConfig(ca *certtest.Authority) *tls.Config {
+	cert, err := ca.BuildSignedCertificate("drain", certtest.WithIPs(net.ParseIP("127.0.0.1")))
+	Expect(err).NotTo(HaveOccurred())
+	tlsCert, err := cert.TLSCertificate()
+	Expect(err).NotTo(HaveOccurred())
+	return &tls.Config{Certificates: []tls.Certificate{tlsCert}}
+}
+
+// acceptOctetCounted reads octet counted messages from every connection
+// accepted on the listener. The returned function closes all connections
+// accepted so far, simulating a drain restart.
+func acceptOctetCounted(lis net.Listener) (chan string, func()) {
+	messages := make(chan string, 100)
+	var (
+		mu    sync.Mutex
+		conns []net.Conn
+	)
+	go func() {
+		for {
+			conn, err := lis.Accept()
+			if err != nil {
+				return
+			}
+			mu.Lock()
+			conns = append(conns, conn)
+			mu.Unlock()
+			go readOctetCounted(conn, messages)
+		}
+	}()
+	return messages, func() {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, conn := range conns {
+			conn.Close()
+		}
+		conns = nil
+	}
+}
+
+func readOctetCounted(conn net.Conn, messages chan string) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	for {
+		length, err := r.ReadString(' ')
+		if err != nil {
+			return
+		}
+		n, err := strconv.Atoi(strings.TrimSuffix(length, " "))
+		if err != nil {
+			panic(fmt.Sprintf("invalid octet count %q", length))
+		}
+		msg := make([]byte, n)
+		if _, err := io.ReadFull(r, msg); err != nil {
+			return
+		}
+		messages <- string(msg)
+	}
+}
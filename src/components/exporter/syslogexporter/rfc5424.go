@@ -0,0 +1,218 @@
+package syslogexporter
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+)
+
+const (
+	attributeAppID            = "app_id"
+	attributeSourceID         = "source_id"
+	attributeInstanceID       = "instance_id"
+	attributeSourceType       = "source_type"
+	attributeOrganizationName = "organization_name"
+	attributeSpaceName        = "space_name"
+	attributeAppName          = "app_name"
+
+	// priorityInfo and priorityErr are the user-level facility with the
+	// informational and error severities, matching the CF syslog agent.
+	priorityInfo = "<14>"
+	priorityErr  = "<11>"
+
+	nilValue = "-"
+
+	maxHostnameLength = 255
+	maxAppNameLength  = 48
+	maxProcIDLength   = 128
+	maxSDNameLength   = 32
+
+	tagsSDID = "tags@47450"
+)
+
+// message is a single RFC 5424 formatted syslog message.
+type message []byte
+
+// logsToMessages formats every log record as an RFC 5424 message. The CF
+// attributes are mapped onto the header the same way the CF syslog agent
+// does for app log drains: the hostname is org.space.app, the app-name is
+// the app GUID and the procid is [source_type/instance_id].
+func logsToMessages(ld plog.Logs, defaultHostname string) []message {
+	messages := make([]message, 0, ld.LogRecordCount())
+
+	for i := 0; i < ld.ResourceLogs().Len(); i++ {
+		rl := ld.ResourceLogs().At(i)
+		for j := 0; j < rl.ScopeLogs().Len(); j++ {
+			records := rl.ScopeLogs().At(j).LogRecords()
+			for k := 0; k < records.Len(); k++ {
+				messages = append(messages, toRFC5424(records.At(k), rl.Resource().Attributes(), defaultHostname))
+			}
+		}
+	}
+
+	return messages
+}
+
+func toRFC5424(lr plog.LogRecord, resource pcommon.Map, defaultHostname string) message {
+	attrs := mergeAttributes(resource, lr.Attributes())
+
+	priority := priorityInfo
+	if lr.SeverityNumber() >= plog.SeverityNumberError {
+		priority = priorityErr
+	}
+
+	ts := lr.Timestamp()
+	if ts == 0 {
+		ts = lr.ObservedTimestamp()
+	}
+	if ts == 0 {
+		ts = pcommon.NewTimestampFromTime(time.Now())
+	}
+
+	hostname := defaultHostname
+	if org, space, app := attrs[attributeOrganizationName], attrs[attributeSpaceName], attrs[attributeAppName]; org != "" && space != "" && app != "" {
+		hostname = sanitizeHostname(org + "." + space + "." + app)
+	}
+
+	appID := attrs[attributeAppID]
+	if appID == "" {
+		appID = attrs[attributeSourceID]
+	}
+
+	procID := processID(attrs[attributeSourceType], attrs[attributeInstanceID])
+
+	delete(attrs, attributeSourceID)
+	delete(attrs, attributeInstanceID)
+
+	var buf bytes.Buffer
+	buf.WriteString(priority)
+	buf.WriteString("1 ")
+	buf.WriteString(ts.AsTime().UTC().Format(time.RFC3339Nano))
+	buf.WriteByte(' ')
+	buf.WriteString(header(hostname, maxHostnameLength))
+	buf.WriteByte(' ')
+	buf.WriteString(header(appID, maxAppNameLength))
+	buf.WriteByte(' ')
+	buf.WriteString(header(procID, maxProcIDLength))
+	buf.WriteString(" - ")
+	writeStructuredData(&buf, attrs)
+	buf.WriteByte(' ')
+	buf.Write(bytes.ReplaceAll([]byte(lr.Body().AsString()), []byte{0}, nil))
+	if buf.Bytes()[buf.Len()-1] != '\n' {
+		buf.WriteByte('\n')
+	}
+
+	return buf.Bytes()
+}
+
+// processID formats the procid as [SOURCE_TYPE/instance], which is how CF
+// shows the origin of a log line, e.g. [APP/PROC/WEB/0].
+func processID(sourceType, instanceID string) string {
+	sourceType = strings.ReplaceAll(strings.ToUpper(sourceType), " ", "-")
+	if instanceID == "" {
+		return sourceType
+	}
+	return "[" + sourceType + "/" + instanceID + "]"
+}
+
+// sanitizeHostname replaces whitespace with dashes and drops any character
+// that is not allowed in a hostname, as Cloud Controller does for the
+// hostname of syslog bindings.
+func sanitizeHostname(hostname string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case unicode.IsSpace(r):
+			return '-'
+		case r == '-' || r == '.' || r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			return r
+		default:
+			return -1
+		}
+	}, hostname)
+}
+
+// header returns the value as a header field, printable ASCII truncated to
+// the field's maximum length, or the NILVALUE when empty.
+func header(value string, maxLength int) string {
+	value = printableASCII(value)
+	if len(value) > maxLength {
+		value = value[:maxLength]
+	}
+	if value == "" {
+		return nilValue
+	}
+	return value
+}
+
+// writeStructuredData writes the attributes as a tags@47450 SD-ELEMENT,
+// the private enterprise number CF syslog agents use, or the NILVALUE when
+// there are no attributes.
+func writeStructuredData(buf *bytes.Buffer, attrs map[string]string) {
+	keys := make([]string, 0, len(attrs))
+	for k := range attrs {
+		if sdName(k) != "" {
+			keys = append(keys, k)
+		}
+	}
+	if len(keys) == 0 {
+		buf.WriteString(nilValue)
+		return
+	}
+	sort.Strings(keys)
+
+	buf.WriteString("[" + tagsSDID)
+	for _, k := range keys {
+		buf.WriteByte(' ')
+		buf.WriteString(sdName(k))
+		buf.WriteString(`="`)
+		buf.WriteString(sdParamValueEscaper.Replace(attrs[k]))
+		buf.WriteByte('"')
+	}
+	buf.WriteByte(']')
+}
+
+var sdParamValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`)
+
+// sdName returns the key as an SD-NAME, which is printable ASCII excluding
+// '=', ']' and '"', and at most 32 characters.
+func sdName(key string) string {
+	name := strings.Map(func(r rune) rune {
+		if r == '=' || r == ']' || r == '"' {
+			return -1
+		}
+		return r
+	}, printableASCII(key))
+	if len(name) > maxSDNameLength {
+		name = name[:maxSDNameLength]
+	}
+	return name
+}
+
+func printableASCII(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r < 33 || r > 126 {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// mergeAttributes flattens the resource attributes overlaid with the record
+// attributes into strings.
+func mergeAttributes(resource, attrs pcommon.Map) map[string]string {
+	merged := make(map[string]string, resource.Len()+attrs.Len())
+	resource.Range(func(k string, v pcommon.Value) bool {
+		merged[k] = v.AsString()
+		return true
+	})
+	attrs.Range(func(k string, v pcommon.Value) bool {
+		merged[k] = v.AsString()
+		return true
+	})
+	return merged
+}
@@ -0,0 +1,13 @@
+package syslogexporter_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestSyslogExporter(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Syslog Exporter Suite")
+}
@@ -0,0 +1,187 @@
+package syslogexporter
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/collector/consumer/consumererror"
+)
+
+const dialTimeout = 5 * time.Second
+
+// drainWriter delivers syslog messages to a drain.
+type drainWriter interface {
+	write(ctx context.Context, messages []message) error
+	close() error
+}
+
+// undeliveredError is returned by writers that delivered or rejected the
+// messages before index, but not the ones from index on.
+type undeliveredError struct {
+	index int
+	err   error
+}
+
+func (e *undeliveredError) Error() string {
+	return e.err.Error()
+}
+
+// tcpWriter writes octet counted messages (RFC 6587) over a long lived TCP
+// connection, optionally wrapped in TLS. The connection is dropped on any
+// write error and redialed on the next write.
+type tcpWriter struct {
+	address   string
+	tlsConfig *tls.Config
+
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+func newTCPWriter(address string, tlsConfig *tls.Config) *tcpWriter {
+	return &tcpWriter{address: address, tlsConfig: tlsConfig}
+}
+
+func (w *tcpWriter) write(ctx context.Context, messages []message) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.conn == nil {
+		conn, err := w.dial(ctx)
+		if err != nil {
+			return err
+		}
+		w.conn = conn
+	}
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Time{}
+	}
+	if err := w.conn.SetWriteDeadline(deadline); err != nil {
+		w.reset()
+		return err
+	}
+
+	var buf bytes.Buffer
+	for _, m := range messages {
+		buf.WriteString(strconv.Itoa(len(m)))
+		buf.WriteByte(' ')
+		buf.Write(m)
+	}
+	if _, err := w.conn.Write(buf.Bytes()); err != nil {
+		w.reset()
+		return err
+	}
+	return nil
+}
+
+func (w *tcpWriter) dial(ctx context.Context) (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: dialTimeout, KeepAlive: 30 * time.Second}
+	if w.tlsConfig == nil {
+		return dialer.DialContext(ctx, "tcp", w.address)
+	}
+	tlsDialer := &tls.Dialer{NetDialer: dialer, Config: w.tlsConfig}
+	return tlsDialer.DialContext(ctx, "tcp", w.address)
+}
+
+func (w *tcpWriter) reset() {
+	_ = w.conn.Close()
+	w.conn = nil
+}
+
+func (w *tcpWriter) close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.conn == nil {
+		return nil
+	}
+	err := w.conn.Close()
+	w.conn = nil
+	return err
+}
+
+// httpsWriter posts every message in its own request, as CF does for
+// https:// drains.
+type httpsWriter struct {
+	url    string
+	client *http.Client
+}
+
+func newHTTPSWriter(url string, tlsConfig *tls.Config) *httpsWriter {
+	return &httpsWriter{
+		url: url,
+		client: &http.Client{
+			Transport: &http.Transport{
+				Proxy:               http.ProxyFromEnvironment,
+				TLSClientConfig:     tlsConfig,
+				TLSHandshakeTimeout: dialTimeout,
+				MaxIdleConnsPerHost: 10,
+			},
+		},
+	}
+}
+
+// write posts the messages in order. Messages the drain rejects are skipped,
+// so that one bad message doesn't drop the ones after it. The first message
+// that fails to post otherwise stops the write, and it and the messages after
+// it are returned as undelivered.
+func (w *httpsWriter) write(ctx context.Context, messages []message) error {
+	var rejected []error
+	for i, m := range messages {
+		err := w.post(ctx, m)
+		if err == nil {
+			continue
+		}
+		if consumererror.IsPermanent(err) {
+			rejected = append(rejected, err)
+			continue
+		}
+		if len(rejected) > 0 {
+			// The rejections are only kept as text, as the undelivered
+			// messages would not be retried otherwise.
+			err = fmt.Errorf("%w (%d earlier messages rejected: %v)", err, len(rejected), errors.Join(rejected...))
+		}
+		return &undeliveredError{index: i, err: err}
+	}
+	return errors.Join(rejected...)
+}
+
+func (w *httpsWriter) post(ctx context.Context, m message) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(m))
+	if err != nil {
+		return consumererror.NewPermanent(err)
+	}
+	req.Header.Set("Content-Type", "text/plain")
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+
+	err = fmt.Errorf("drain responded with %s", resp.Status)
+	if resp.StatusCode >= 400 && resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests {
+		return consumererror.NewPermanent(err)
+	}
+	return err
+}
+
+func (w *httpsWriter) close() error {
+	w.client.CloseIdleConnections()
+	return nil
+}
@@ -0,0 +1,13 @@
+package clientcertauthextension_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestClientCertAuthExtension(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Client Cert Auth Extension Suite")
+}
@@ -0,0 +1,30 @@
+package clientcertauthextension
+
+import (
+	"errors"
+
+	"go.opentelemetry.io/collector/component"
+)
+
+// Config defines the configuration for the client cert auth extension.
+type Config struct {
+	// AllowedIdentities are the client certificate common names and subject
+	// alternative names that may send data. The first name of a certificate
+	// that is allowed, starting with its common name, is its identity.
+	AllowedIdentities []string `mapstructure:"allowed_identities"`
+}
+
+var _ component.Config = (*Config)(nil)
+
+// Validate checks that identities are allowed.
+func (cfg *Config) Validate() error {
+	if len(cfg.AllowedIdentities) == 0 {
+		return errors.New(`requires a non-empty "allowed_identities"`)
+	}
+	for _, identity := range cfg.AllowedIdentities {
+		if identity == "" {
+			return errors.New(`"allowed_identities" must not contain empty identities`)
+		}
+	}
+	return nil
+}
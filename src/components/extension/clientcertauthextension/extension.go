@@ -0,0 +1,122 @@
+package clientcertauthextension
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"slices"
+
+	"go.opentelemetry.io/collector/client"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/extension"
+	"go.opentelemetry.io/collector/extension/extensionauth"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
+
+// The attributes of the auth data the extension adds to the client info of
+// authenticated requests.
+const (
+	AttributeIdentity        = "identity"
+	AttributeCommonName      = "common_name"
+	AttributeSubjectAltNames = "subject_alt_names"
+)
+
+var (
+	_ extension.Extension  = (*clientCertAuth)(nil)
+	_ extensionauth.Server = (*clientCertAuth)(nil)
+	_ client.AuthData      = (*authData)(nil)
+)
+
+var errNoClientCertificate = errors.New("no verified client certificate")
+
+type clientCertAuth struct {
+	component.StartFunc
+	component.ShutdownFunc
+
+	allowed map[string]struct{}
+}
+
+func newClientCertAuth(cfg *Config) *clientCertAuth {
+	allowed := make(map[string]struct{}, len(cfg.AllowedIdentities))
+	for _, identity := range cfg.AllowedIdentities {
+		allowed[identity] = struct{}{}
+	}
+	return &clientCertAuth{allowed: allowed}
+}
+
+// Authenticate checks the client certificate the TLS handshake verified,
+// which only gRPC receivers pass to authenticators.
+func (a *clientCertAuth) Authenticate(ctx context.Context, _ map[string][]string) (context.Context, error) {
+	cert, err := verifiedClientCertificate(ctx)
+	if err != nil {
+		return ctx, err
+	}
+
+	names := certificateNames(cert)
+	i := slices.IndexFunc(names, func(name string) bool {
+		_, ok := a.allowed[name]
+		return ok
+	})
+	if i < 0 {
+		return ctx, fmt.Errorf("client certificate %q is not allowed", cert.Subject.CommonName)
+	}
+
+	info := client.FromContext(ctx)
+	info.Auth = &authData{
+		identity:        names[i],
+		commonName:      cert.Subject.CommonName,
+		subjectAltNames: names[1:],
+	}
+	return client.NewContext(ctx, info), nil
+}
+
+func verifiedClientCertificate(ctx context.Context) (*x509.Certificate, error) {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return nil, errNoClientCertificate
+	}
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(tlsInfo.State.VerifiedChains) == 0 || len(tlsInfo.State.VerifiedChains[0]) == 0 {
+		return nil, errNoClientCertificate
+	}
+	return tlsInfo.State.VerifiedChains[0][0], nil
+}
+
+// certificateNames are the common name followed by the DNS, IP and URI
+// subject alternative names of cert.
+func certificateNames(cert *x509.Certificate) []string {
+	names := []string{cert.Subject.CommonName}
+	names = append(names, cert.DNSNames...)
+	for _, ip := range cert.IPAddresses {
+		names = append(names, ip.String())
+	}
+	for _, uri := range cert.URIs {
+		names = append(names, uri.String())
+	}
+	return names
+}
+
+type authData struct {
+	identity        string
+	commonName      string
+	subjectAltNames []string
+}
+
+func (d *authData) GetAttribute(name string) any {
+	switch name {
+	case AttributeIdentity:
+		return d.identity
+	case AttributeCommonName:
+		return d.commonName
+	case AttributeSubjectAltNames:
+		return slices.Clone(d.subjectAltNames)
+	default:
+		return nil
+	}
+}
+
+func (*authData) GetAttributeNames() []string {
+	return []string{AttributeIdentity, AttributeCommonName, AttributeSubjectAltNames}
+}
@@ -0,0 +1,122 @@
+package clientcertauthextension_test
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+
+	"code.cloudfoundry.org/otel-collector-release/src/components/extension/clientcertauthextension"
+	"code.cloudfoundry.org/tlsconfig/certtest"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.opentelemetry.io/collector/client"
+	"go.opentelemetry.io/collector/component/componenttest"
+	"go.opentelemetry.io/collector/extension/extensionauth"
+	"go.opentelemetry.io/collector/extension/extensiontest"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
+
+var _ = Describe("Client cert auth extension", func() {
+	var (
+		ca   *certtest.Authority
+		cfg  *clientcertauthextension.Config
+		auth extensionauth.Server
+	)
+
+	BeforeEach(func() {
+		var err error
+		ca, err = certtest.BuildCA("otel-collector")
+		Expect(err).NotTo(HaveOccurred())
+
+		cfg = clientcertauthextension.NewFactory().CreateDefaultConfig().(*clientcertauthextension.Config)
+		cfg.AllowedIdentities = []string{"loggregator-agent", "forwarder-agent.service.cf.internal"}
+	})
+
+	JustBeforeEach(func() {
+		f := clientcertauthextension.NewFactory()
+		ext, err := f.Create(context.Background(), extensiontest.NewNopSettings(f.Type()), cfg)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ext.Start(context.Background(), componenttest.NewNopHost())).To(Succeed())
+		DeferCleanup(ext.Shutdown, context.Background())
+		auth = ext.(extensionauth.Server)
+	})
+
+	// peerWith is the context of a gRPC request from a client whose
+	// certificate for name was verified.
+	peerWith := func(name string, opts ...certtest.SignOption) context.Context {
+		cert, err := ca.BuildSignedCertificate(name, opts...)
+		Expect(err).NotTo(HaveOccurred())
+		tlsCert, err := cert.TLSCertificate()
+		Expect(err).NotTo(HaveOccurred())
+		leaf, err := x509.ParseCertificate(tlsCert.Certificate[0])
+		Expect(err).NotTo(HaveOccurred())
+
+		return peer.NewContext(context.Background(), &peer.Peer{
+			Addr: &net.TCPAddr{IP: net.IPv4(10, 0, 1, 12), Port: 40000},
+			AuthInfo: credentials.TLSInfo{State: tls.ConnectionState{
+				PeerCertificates: []*x509.Certificate{leaf},
+				VerifiedChains:   [][]*x509.Certificate{{leaf}},
+			}},
+		})
+	}
+
+	It("authenticates a client whose common name is allowed", func() {
+		ctx, err := auth.Authenticate(peerWith("loggregator-agent"), nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		data := client.FromContext(ctx).Auth
+		Expect(data).NotTo(BeNil())
+		Expect(data.GetAttribute("identity")).To(Equal("loggregator-agent"))
+		Expect(data.GetAttribute("common_name")).To(Equal("loggregator-agent"))
+		Expect(data.GetAttributeNames()).To(ConsistOf("identity", "common_name", "subject_alt_names"))
+	})
+
+	It("authenticates a client whose subject alternative name is allowed", func() {
+		ctx, err := auth.Authenticate(peerWith("forwarder-agent", certtest.WithDomains("forwarder-agent.service.cf.internal")), nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		data := client.FromContext(ctx).Auth
+		Expect(data.GetAttribute("identity")).To(Equal("forwarder-agent.service.cf.internal"))
+		Expect(data.GetAttribute("common_name")).To(Equal("forwarder-agent"))
+		Expect(data.GetAttribute("subject_alt_names")).To(ContainElement("forwarder-agent.service.cf.internal"))
+	})
+
+	It("keeps the client address", func() {
+		ctx, err := auth.Authenticate(client.NewContext(peerWith("loggregator-agent"), client.Info{
+			Addr: &net.TCPAddr{IP: net.IPv4(10, 0, 1, 12), Port: 40000},
+		}), nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(client.FromContext(ctx).Addr.String()).To(Equal("10.0.1.12:40000"))
+	})
+
+	It("rejects a client whose names are not allowed", func() {
+		_, err := auth.Authenticate(peerWith("some-app", certtest.WithDomains("some-app.apps.internal")), nil)
+		Expect(err).To(MatchError(`client certificate "some-app" is not allowed`))
+	})
+
+	It("rejects a client without a verified certificate", func() {
+		_, err := auth.Authenticate(context.Background(), nil)
+		Expect(err).To(MatchError("no verified client certificate"))
+
+		_, err = auth.Authenticate(peer.NewContext(context.Background(), &peer.Peer{
+			AuthInfo: credentials.TLSInfo{State: tls.ConnectionState{}},
+		}), nil)
+		Expect(err).To(MatchError("no verified client certificate"))
+	})
+
+	Describe("Config", func() {
+		It("requires allowed identities", func() {
+			cfg := clientcertauthextension.NewFactory().CreateDefaultConfig().(*clientcertauthextension.Config)
+			Expect(cfg.Validate()).To(MatchError(ContainSubstring("allowed_identities")))
+
+			cfg.AllowedIdentities = []string{""}
+			Expect(cfg.Validate()).To(MatchError(ContainSubstring("empty identities")))
+
+			cfg.AllowedIdentities = []string{"loggregator-agent"}
+			Expect(cfg.Validate()).To(Succeed())
+		})
+	})
+})
@@ -0,0 +1,33 @@
+// Package clientcertauthextension implements a server auth extension that
+// authenticates gRPC clients by the identity in their verified client
+// certificate, and exposes that identity to the processors of the pipeline.
+package clientcertauthextension
+
+import (
+	"context"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/extension"
+)
+
+const stability = component.StabilityLevelAlpha
+
+var componentType = component.MustNewType("clientcertauth")
+
+// NewFactory creates a factory for the client cert auth extension.
+func NewFactory() extension.Factory {
+	return extension.NewFactory(
+		componentType,
+		createDefaultConfig,
+		createExtension,
+		stability,
+	)
+}
+
+func createDefaultConfig() component.Config {
+	return &Config{}
+}
+
+func createExtension(_ context.Context, _ extension.Settings, cfg component.Config) (extension.Extension, error) {
+	return newClientCertAuth(cfg.(*Config)), nil
+}
@@ -0,0 +1,499 @@
+package diskstorageextension
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"go.opentelemetry.io/collector/extension/xextension/storage"
+	"go.uber.org/zap"
+)
+
+// A file is a sequence of records, each holding the operations of one write:
+//
+//	crc32 of the payload (4 bytes) | payload length (4 bytes) | payload
+//
+// and the payload a sequence of operations:
+//
+//	opSet    | key length (uvarint) | key | value length (uvarint) | value
+//	opDelete | key length (uvarint) | key
+//
+// A write is appended as a single record, so a crash leaves either all of
+// its operations or none of them: a torn record at the end of the file fails
+// its checksum and is truncated on the next open.
+const (
+	opSet    byte = 1
+	opDelete byte = 2
+
+	recordHeaderSize = 8
+
+	mib = 1 << 20
+)
+
+var (
+	errClosed = errors.New("storage client is closed")
+	errNoFile = errors.New("storage could not be reopened after compaction")
+)
+
+// entry locates the value of a key in the file.
+type entry struct {
+	offset int64
+	size   int
+
+	// recordSize is the size of the record the value would take on its
+	// own, which is what it adds to a compacted file.
+	recordSize int64
+}
+
+type client struct {
+	cfg     *Config
+	logger  *zap.Logger
+	path    string
+	onClose func()
+
+	mu sync.Mutex
+	// file is nil if it couldn't be reopened after a compaction.
+	file  *os.File
+	index map[string]entry
+	size  int64
+	live  int64
+	dirty bool
+
+	closed bool
+}
+
+var _ storage.Client = (*client)(nil)
+
+func openClient(path string, cfg *Config, logger *zap.Logger, onClose func()) (*client, error) {
+	// A compaction that did not finish leaves its file behind, the original
+	// is still complete.
+	if err := os.Remove(path + ".compact"); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open storage: %w", err)
+	}
+	c := &client{
+		cfg:     cfg,
+		logger:  logger,
+		path:    path,
+		onClose: onClose,
+		file:    f,
+		index:   map[string]entry{},
+	}
+	if err := c.recover(); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if cfg.Compaction.OnStart && c.size > c.live {
+		if err := c.compact(); err != nil {
+			if c.file != nil {
+				c.file.Close()
+			}
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// recover rebuilds the index from the file, truncating it after the last
+// complete record.
+func (c *client) recover() error {
+	info, err := c.file.Stat()
+	if err != nil {
+		return err
+	}
+	fileSize := info.Size()
+
+	r := bufio.NewReader(io.NewSectionReader(c.file, 0, fileSize))
+	var offset int64
+	header := make([]byte, recordHeaderSize)
+	for {
+		if _, err := io.ReadFull(r, header); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if errors.Is(err, io.ErrUnexpectedEOF) {
+				c.logger.Warn("Truncating incomplete record at the end of the storage", zap.Int64("offset", offset))
+				break
+			}
+			return err
+		}
+		length := int64(binary.LittleEndian.Uint32(header[4:]))
+		if offset+recordHeaderSize+length > fileSize {
+			c.logger.Warn("Truncating incomplete record at the end of the storage", zap.Int64("offset", offset))
+			break
+		}
+		payload := make([]byte, length)
+		if _, err := io.ReadFull(r, payload); err != nil {
+			return err
+		}
+		if crc32.ChecksumIEEE(payload) != binary.LittleEndian.Uint32(header) {
+			c.logger.Warn("Truncating corrupt record at the end of the storage", zap.Int64("offset", offset))
+			break
+		}
+		if err := c.apply(offset+recordHeaderSize, payload); err != nil {
+			c.logger.Warn("Truncating corrupt record at the end of the storage", zap.Int64("offset", offset), zap.Error(err))
+			break
+		}
+		offset += recordHeaderSize + length
+		c.size = offset
+	}
+
+	if c.size < fileSize {
+		if err := c.file.Truncate(c.size); err != nil {
+			return err
+		}
+		return c.file.Sync()
+	}
+	return nil
+}
+
+// apply updates the index with the operations of a record whose payload
+// starts at offset.
+func (c *client) apply(offset int64, payload []byte) error {
+	type update struct {
+		key     string
+		entry   entry
+		deleted bool
+	}
+	var updates []update
+
+	for pos := 0; pos < len(payload); {
+		op := payload[pos]
+		pos++
+		key, n, err := readBytes(payload[pos:])
+		if err != nil {
+			return err
+		}
+		pos += n
+
+		switch op {
+		case opSet:
+			value, n, err := readBytes(payload[pos:])
+			if err != nil {
+				return err
+			}
+			valueOffset := pos + n - len(value)
+			pos += n
+			updates = append(updates, update{key: string(key), entry: entry{
+				offset:     offset + int64(valueOffset),
+				size:       len(value),
+				recordSize: recordHeaderSize + int64(setSize(string(key), value)),
+			}})
+		case opDelete:
+			updates = append(updates, update{key: string(key), deleted: true})
+		default:
+			return fmt.Errorf("unknown operation %d", op)
+		}
+	}
+
+	for _, u := range updates {
+		c.remove(u.key)
+		if !u.deleted {
+			c.index[u.key] = u.entry
+			c.live += u.entry.recordSize
+		}
+	}
+	return nil
+}
+
+func (c *client) remove(key string) {
+	if e, ok := c.index[key]; ok {
+		c.live -= e.recordSize
+		delete(c.index, key)
+	}
+}
+
+func (c *client) Get(ctx context.Context, key string) ([]byte, error) {
+	op := storage.GetOperation(key)
+	if err := c.Batch(ctx, op); err != nil {
+		return nil, err
+	}
+	return op.Value, nil
+}
+
+func (c *client) Set(ctx context.Context, key string, value []byte) error {
+	return c.Batch(ctx, storage.SetOperation(key, value))
+}
+
+func (c *client) Delete(ctx context.Context, key string) error {
+	return c.Batch(ctx, storage.DeleteOperation(key))
+}
+
+// Batch runs the operations in order, writing the sets and deletes as a
+// single record.
+func (c *client) Batch(_ context.Context, ops ...*storage.Operation) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return errClosed
+	}
+	if c.file == nil {
+		return errNoFile
+	}
+
+	// pending holds the values set or deleted (nil) earlier in the batch,
+	// for the gets that follow them.
+	pending := map[string][]byte{}
+	var payload []byte
+	var sets bool
+	for _, op := range ops {
+		switch op.Type {
+		case storage.Get:
+			if value, ok := pending[op.Key]; ok {
+				op.Value = value
+				continue
+			}
+			value, err := c.read(op.Key)
+			if err != nil {
+				return err
+			}
+			op.Value = value
+		case storage.Set:
+			payload = appendSet(payload, op.Key, op.Value)
+			pending[op.Key] = op.Value
+			sets = true
+		case storage.Delete:
+			if _, ok := c.index[op.Key]; !ok {
+				if _, ok := pending[op.Key]; !ok {
+					continue
+				}
+			}
+			payload = appendDelete(payload, op.Key)
+			pending[op.Key] = nil
+		default:
+			return fmt.Errorf("unknown operation type %d", op.Type)
+		}
+	}
+	if len(payload) == 0 {
+		return nil
+	}
+	return c.write(payload, sets)
+}
+
+func (c *client) read(key string) ([]byte, error) {
+	e, ok := c.index[key]
+	if !ok {
+		return nil, nil
+	}
+	value := make([]byte, e.size)
+	if _, err := c.file.ReadAt(value, e.offset); err != nil {
+		return nil, fmt.Errorf("failed to read %q: %w", key, err)
+	}
+	return value, nil
+}
+
+// write appends a record. Writes that only delete are allowed beyond the size
+// cap, since they are what frees up space. A failed compaction only fails the
+// write if it leaves no space for it, as the record is written either way.
+func (c *client) write(payload []byte, sets bool) error {
+	maxSize := c.cfg.MaxSizeMiB * mib
+	recordSize := int64(recordHeaderSize + len(payload))
+	if sets && c.size+recordSize > maxSize {
+		if c.size > c.live {
+			c.tryCompact()
+			if c.file == nil {
+				return errNoFile
+			}
+		}
+		if c.size+recordSize > maxSize {
+			return storage.ErrStorageFull
+		}
+	}
+
+	record := make([]byte, recordHeaderSize, recordSize)
+	binary.LittleEndian.PutUint32(record, crc32.ChecksumIEEE(payload))
+	binary.LittleEndian.PutUint32(record[4:], uint32(len(payload)))
+	record = append(record, payload...)
+
+	if _, err := c.file.WriteAt(record, c.size); err != nil {
+		// Leave no partial record behind for the next write to follow.
+		_ = c.file.Truncate(c.size)
+		return fmt.Errorf("failed to write storage: %w", err)
+	}
+	if err := c.apply(c.size+recordHeaderSize, payload); err != nil {
+		return err
+	}
+	c.size += recordSize
+
+	switch c.cfg.Fsync {
+	case fsyncAlways:
+		if err := c.file.Sync(); err != nil {
+			return fmt.Errorf("failed to sync storage: %w", err)
+		}
+	case fsyncInterval:
+		c.dirty = true
+	}
+
+	if c.size >= c.cfg.Compaction.MinSizeMiB*mib && float64(c.size-c.live) > c.cfg.Compaction.GarbageRatio*float64(c.size) {
+		c.tryCompact()
+	}
+	return nil
+}
+
+// tryCompact compacts the file, logging a failure rather than failing the
+// write that triggered it.
+func (c *client) tryCompact() {
+	if err := c.compact(); err != nil {
+		c.logger.Warn("Failed to compact storage", zap.Error(err))
+	}
+}
+
+// compact rewrites the file with a record for every live key, replacing the
+// file once the new one is synced. Windows can't rename over a file that is
+// open, so both files are closed for the rename and the result reopened.
+func (c *client) compact() error {
+	before := c.size
+	tmpPath := c.path + ".compact"
+	tmp, err := os.OpenFile(tmpPath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to compact storage: %w", err)
+	}
+	fail := func(err error) error {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to compact storage: %w", err)
+	}
+
+	w := bufio.NewWriter(tmp)
+	index := make(map[string]entry, len(c.index))
+	var offset int64
+	for key, e := range c.index {
+		value, err := c.read(key)
+		if err != nil {
+			return fail(err)
+		}
+		payload := appendSet(nil, key, value)
+		header := make([]byte, recordHeaderSize)
+		binary.LittleEndian.PutUint32(header, crc32.ChecksumIEEE(payload))
+		binary.LittleEndian.PutUint32(header[4:], uint32(len(payload)))
+		if _, err := w.Write(header); err != nil {
+			return fail(err)
+		}
+		if _, err := w.Write(payload); err != nil {
+			return fail(err)
+		}
+		e.offset = offset + int64(recordHeaderSize+len(payload)-len(value))
+		index[key] = e
+		offset += int64(recordHeaderSize + len(payload))
+	}
+	if err := w.Flush(); err != nil {
+		return fail(err)
+	}
+	if err := tmp.Sync(); err != nil {
+		return fail(err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to compact storage: %w", err)
+	}
+
+	c.file.Close()
+	renameErr := os.Rename(tmpPath, c.path)
+	if renameErr != nil {
+		os.Remove(tmpPath)
+	} else {
+		syncDir(filepath.Dir(c.path))
+	}
+	// The original file is reopened if the rename failed, it is still
+	// complete.
+	f, err := os.OpenFile(c.path, os.O_RDWR, 0o600)
+	if err != nil {
+		c.file = nil
+		return fmt.Errorf("failed to reopen storage: %w", err)
+	}
+	c.file = f
+	if renameErr != nil {
+		return fmt.Errorf("failed to compact storage: %w", renameErr)
+	}
+
+	c.index = index
+	c.size = offset
+	c.live = offset
+	c.dirty = false
+
+	c.logger.Debug("Compacted storage", zap.Int64("before", before), zap.Int64("after", c.size))
+	return nil
+}
+
+func (c *client) sync() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed || c.file == nil || !c.dirty {
+		return nil
+	}
+	c.dirty = false
+	return c.file.Sync()
+}
+
+func (c *client) Close(context.Context) error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil
+	}
+	c.closed = true
+	var err error
+	if c.file != nil {
+		err = errors.Join(c.file.Sync(), c.file.Close())
+	}
+	c.mu.Unlock()
+
+	c.onClose()
+	return err
+}
+
+func setSize(key string, value []byte) int {
+	return 1 + uvarintSize(len(key)) + len(key) + uvarintSize(len(value)) + len(value)
+}
+
+func appendSet(b []byte, key string, value []byte) []byte {
+	b = append(b, opSet)
+	b = binary.AppendUvarint(b, uint64(len(key)))
+	b = append(b, key...)
+	b = binary.AppendUvarint(b, uint64(len(value)))
+	return append(b, value...)
+}
+
+func appendDelete(b []byte, key string) []byte {
+	b = append(b, opDelete)
+	b = binary.AppendUvarint(b, uint64(len(key)))
+	return append(b, key...)
+}
+
+// readBytes reads a length-prefixed byte string, returning it and the number
+// of bytes read.
+func readBytes(b []byte) ([]byte, int, error) {
+	length, n := binary.Uvarint(b)
+	if n <= 0 || uint64(len(b)-n) < length {
+		return nil, 0, errors.New("malformed record")
+	}
+	return b[n : n+int(length)], n + int(length), nil
+}
+
+func uvarintSize(n int) int {
+	return len(binary.AppendUvarint(nil, uint64(n)))
+}
+
+// syncDir makes a rename in dir durable. It is best effort, since not every
+// platform supports syncing directories.
+func syncDir(dir string) {
+	d, err := os.Open(dir)
+	if err != nil {
+		return
+	}
+	defer d.Close()
+	_ = d.Sync()
+}
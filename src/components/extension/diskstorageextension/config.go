@@ -0,0 +1,85 @@
+package diskstorageextension
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/collector/component"
+)
+
+const (
+	// fsyncAlways syncs the file after every write.
+	fsyncAlways = "always"
+
+	// fsyncInterval syncs the files with unsynced writes every
+	// fsync_interval.
+	fsyncInterval = "interval"
+
+	// fsyncNever leaves syncing to the operating system.
+	fsyncNever = "never"
+)
+
+// Config defines the configuration for the disk storage extension.
+type Config struct {
+	// Directory holds a file for every component using the extension.
+	Directory string `mapstructure:"directory"`
+
+	// MaxSizeMiB is the largest a single file may grow. Writes that would
+	// grow it further fail with storage.ErrStorageFull.
+	MaxSizeMiB int64 `mapstructure:"max_size_mib"`
+
+	// Fsync is when writes are synced to disk: always, interval or never.
+	Fsync string `mapstructure:"fsync"`
+
+	// FsyncInterval is how often writes are synced with the interval
+	// policy.
+	FsyncInterval time.Duration `mapstructure:"fsync_interval"`
+
+	// Compaction configures when files are rewritten without the values
+	// that were overwritten or deleted.
+	Compaction CompactionConfig `mapstructure:"compaction"`
+}
+
+// CompactionConfig defines when files are compacted.
+type CompactionConfig struct {
+	// OnStart compacts a file when it is opened.
+	OnStart bool `mapstructure:"on_start"`
+
+	// GarbageRatio is the share of a file taken by overwritten or deleted
+	// values above which it is compacted.
+	GarbageRatio float64 `mapstructure:"garbage_ratio"`
+
+	// MinSizeMiB is the size below which a file is never compacted, other
+	// than on start.
+	MinSizeMiB int64 `mapstructure:"min_size_mib"`
+}
+
+var _ component.Config = (*Config)(nil)
+
+// Validate checks the directory, the size cap and the fsync and compaction
+// settings.
+func (cfg *Config) Validate() error {
+	if cfg.Directory == "" {
+		return errors.New(`"directory" is required`)
+	}
+	if cfg.MaxSizeMiB <= 0 {
+		return errors.New(`"max_size_mib" must be positive`)
+	}
+	switch cfg.Fsync {
+	case fsyncAlways, fsyncNever:
+	case fsyncInterval:
+		if cfg.FsyncInterval <= 0 {
+			return errors.New(`"fsync_interval" must be positive`)
+		}
+	default:
+		return fmt.Errorf("unsupported fsync %q, must be %s, %s or %s", cfg.Fsync, fsyncAlways, fsyncInterval, fsyncNever)
+	}
+	if cfg.Compaction.GarbageRatio <= 0 || cfg.Compaction.GarbageRatio > 1 {
+		return errors.New(`"compaction.garbage_ratio" must be greater than 0 and at most 1`)
+	}
+	if cfg.Compaction.MinSizeMiB < 0 {
+		return errors.New(`"compaction.min_size_mib" must not be negative`)
+	}
+	return nil
+}
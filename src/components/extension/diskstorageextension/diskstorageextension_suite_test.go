@@ -0,0 +1,13 @@
+package diskstorageextension_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestDiskStorageExtension(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Disk Storage Extension Suite")
+}
@@ -0,0 +1,129 @@
+package diskstorageextension
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/extension/xextension/storage"
+	"go.uber.org/zap"
+)
+
+// unsafeFileNameChars are replaced in the names of the files.
+var unsafeFileNameChars = regexp.MustCompile(`[^A-Za-z0-9._-]`)
+
+type diskStorage struct {
+	cfg    *Config
+	logger *zap.Logger
+
+	mu      sync.Mutex
+	clients map[string]*client
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+var _ storage.Extension = (*diskStorage)(nil)
+
+func newDiskStorage(cfg *Config, logger *zap.Logger) *diskStorage {
+	return &diskStorage{
+		cfg:     cfg,
+		logger:  logger,
+		clients: map[string]*client{},
+		done:    make(chan struct{}),
+	}
+}
+
+func (d *diskStorage) Start(context.Context, component.Host) error {
+	if err := os.MkdirAll(d.cfg.Directory, 0o700); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	if d.cfg.Fsync != fsyncInterval {
+		return nil
+	}
+
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+
+		ticker := time.NewTicker(d.cfg.FsyncInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-d.done:
+				return
+			case <-ticker.C:
+				d.sync()
+			}
+		}
+	}()
+	return nil
+}
+
+// Shutdown closes the files the components did not close themselves.
+func (d *diskStorage) Shutdown(ctx context.Context) error {
+	close(d.done)
+	d.wg.Wait()
+
+	d.mu.Lock()
+	clients := make([]*client, 0, len(d.clients))
+	for _, c := range d.clients {
+		clients = append(clients, c)
+	}
+	d.mu.Unlock()
+
+	var errs error
+	for _, c := range clients {
+		errs = errors.Join(errs, c.Close(ctx))
+	}
+	return errs
+}
+
+// GetClient opens the file of the component's storage, recovering the data
+// written before the collector last stopped.
+func (d *diskStorage) GetClient(_ context.Context, kind component.Kind, id component.ID, storageName string) (storage.Client, error) {
+	name := strings.ToLower(kind.String()) + "_" + id.Type().String() + "_" + id.Name()
+	if storageName != "" {
+		name += "_" + storageName
+	}
+	name = unsafeFileNameChars.ReplaceAllString(name, "~")
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, ok := d.clients[name]; ok {
+		return nil, fmt.Errorf("storage %q is already in use", name)
+	}
+	c, err := openClient(filepath.Join(d.cfg.Directory, name), d.cfg, d.logger.With(zap.String("file", name)), func() {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		delete(d.clients, name)
+	})
+	if err != nil {
+		return nil, err
+	}
+	d.clients[name] = c
+	return c, nil
+}
+
+func (d *diskStorage) sync() {
+	d.mu.Lock()
+	clients := make([]*client, 0, len(d.clients))
+	for _, c := range d.clients {
+		clients = append(clients, c)
+	}
+	d.mu.Unlock()
+
+	for _, c := range clients {
+		if err := c.sync(); err != nil {
+			d.logger.Error("Failed to sync storage", zap.String("file", c.path), zap.Error(err))
+		}
+	}
+}
@@ -0,0 +1,369 @@
+package diskstorageextension_test
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+
+	"code.cloudfoundry.org/otel-collector-release/src/components/extension/diskstorageextension"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/component/componenttest"
+	"go.opentelemetry.io/collector/extension"
+	"go.opentelemetry.io/collector/extension/extensiontest"
+	"go.opentelemetry.io/collector/extension/xextension/storage"
+)
+
+var _ = Describe("Disk storage extension", func() {
+	var (
+		cfg    *diskstorageextension.Config
+		ext    extension.Extension
+		client storage.Client
+		file   string
+	)
+
+	exporterID := component.MustNewID("otlp")
+
+	BeforeEach(func() {
+		cfg = diskstorageextension.NewFactory().CreateDefaultConfig().(*diskstorageextension.Config)
+		cfg.Directory = filepath.Join(GinkgoT().TempDir(), "otel-collector")
+		cfg.FsyncInterval = 10 * time.Millisecond
+		file = filepath.Join(cfg.Directory, "exporter_otlp_")
+	})
+
+	// start creates and starts the extension and opens the storage of the
+	// otlp exporter.
+	start := func() {
+		Expect(cfg.Validate()).To(Succeed())
+		factory := diskstorageextension.NewFactory()
+
+		var err error
+		ext, err = factory.Create(context.Background(), extensiontest.NewNopSettings(factory.Type()), cfg)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ext.Start(context.Background(), componenttest.NewNopHost())).To(Succeed())
+
+		client, err = ext.(storage.Extension).GetClient(context.Background(), component.KindExporter, exporterID, "")
+		Expect(err).NotTo(HaveOccurred())
+	}
+
+	// restart shuts the extension down and starts it again on the same
+	// directory.
+	restart := func() {
+		Expect(ext.Shutdown(context.Background())).To(Succeed())
+		start()
+	}
+
+	JustBeforeEach(func() {
+		start()
+	})
+
+	AfterEach(func() {
+		Expect(ext.Shutdown(context.Background())).To(Succeed())
+	})
+
+	get := func(key string) []byte {
+		value, err := client.Get(context.Background(), key)
+		Expect(err).NotTo(HaveOccurred())
+		return value
+	}
+
+	It("creates the directory", func() {
+		info, err := os.Stat(cfg.Directory)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info.IsDir()).To(BeTrue())
+		Expect(info.Mode().Perm()).To(Equal(os.FileMode(0o700)))
+	})
+
+	It("keeps values across restarts", func() {
+		Expect(client.Set(context.Background(), "a", []byte("1"))).To(Succeed())
+		Expect(client.Set(context.Background(), "b", []byte("2"))).To(Succeed())
+		Expect(client.Set(context.Background(), "a", []byte("3"))).To(Succeed())
+		Expect(client.Delete(context.Background(), "b")).To(Succeed())
+		Expect(get("a")).To(Equal([]byte("3")))
+		Expect(get("b")).To(BeNil())
+
+		restart()
+		Expect(get("a")).To(Equal([]byte("3")))
+		Expect(get("b")).To(BeNil())
+		Expect(get("c")).To(BeNil())
+	})
+
+	It("sees earlier writes of a batch in its gets", func() {
+		Expect(client.Set(context.Background(), "a", []byte("1"))).To(Succeed())
+
+		getBefore := storage.GetOperation("a")
+		getAfterSet := storage.GetOperation("a")
+		getAfterDelete := storage.GetOperation("a")
+		Expect(client.Batch(context.Background(),
+			getBefore,
+			storage.SetOperation("a", []byte("2")),
+			getAfterSet,
+			storage.DeleteOperation("a"),
+			getAfterDelete,
+			storage.SetOperation("b", []byte("3")),
+		)).To(Succeed())
+
+		Expect(getBefore.Value).To(Equal([]byte("1")))
+		Expect(getAfterSet.Value).To(Equal([]byte("2")))
+		Expect(getAfterDelete.Value).To(BeNil())
+
+		restart()
+		Expect(get("a")).To(BeNil())
+		Expect(get("b")).To(Equal([]byte("3")))
+	})
+
+	It("keeps the storage of every component apart", func() {
+		other, err := ext.(storage.Extension).GetClient(context.Background(), component.KindExporter, component.MustNewIDWithName("otlp", "other"), "")
+		Expect(err).NotTo(HaveOccurred())
+		defer other.Close(context.Background())
+
+		Expect(client.Set(context.Background(), "a", []byte("1"))).To(Succeed())
+		Expect(other.Get(context.Background(), "a")).To(BeNil())
+		Expect(filepath.Join(cfg.Directory, "exporter_otlp_other")).To(BeAnExistingFile())
+	})
+
+	It("does not open a storage twice", func() {
+		_, err := ext.(storage.Extension).GetClient(context.Background(), component.KindExporter, exporterID, "")
+		Expect(err).To(MatchError(`storage "exporter_otlp_" is already in use`))
+
+		Expect(client.Close(context.Background())).To(Succeed())
+		client, err = ext.(storage.Extension).GetClient(context.Background(), component.KindExporter, exporterID, "")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("fails operations on a closed storage", func() {
+		Expect(client.Close(context.Background())).To(Succeed())
+		Expect(client.Close(context.Background())).To(Succeed())
+		Expect(client.Set(context.Background(), "a", []byte("1"))).To(MatchError("storage client is closed"))
+	})
+
+	Context("when the size cap is reached", func() {
+		BeforeEach(func() {
+			cfg.MaxSizeMiB = 1
+			cfg.Compaction.GarbageRatio = 1
+		})
+
+		It("fails writes until values are deleted", func() {
+			value := bytes.Repeat([]byte("x"), 300<<10)
+			Expect(client.Set(context.Background(), "a", value)).To(Succeed())
+			Expect(client.Set(context.Background(), "b", value)).To(Succeed())
+			Expect(client.Set(context.Background(), "c", value)).To(Succeed())
+			Expect(client.Set(context.Background(), "d", value)).To(MatchError(storage.ErrStorageFull))
+
+			Expect(client.Delete(context.Background(), "a")).To(Succeed())
+			Expect(client.Set(context.Background(), "d", value)).To(Succeed())
+			Expect(get("b")).To(Equal(value))
+			Expect(get("d")).To(Equal(value))
+
+			info, err := os.Stat(file)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info.Size()).To(BeNumerically("<=", 1<<20))
+		})
+	})
+
+	Context("when a file has grown with overwritten values", func() {
+		BeforeEach(func() {
+			cfg.Compaction.MinSizeMiB = 0
+		})
+
+		It("compacts it", func() {
+			value := bytes.Repeat([]byte("x"), 1<<10)
+			for i := 0; i < 100; i++ {
+				Expect(client.Set(context.Background(), "a", value)).To(Succeed())
+			}
+			Expect(client.Set(context.Background(), "b", []byte("2"))).To(Succeed())
+
+			info, err := os.Stat(file)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info.Size()).To(BeNumerically("<", 4<<10))
+			Expect(get("a")).To(Equal(value))
+
+			restart()
+			Expect(get("a")).To(Equal(value))
+			Expect(get("b")).To(Equal([]byte("2")))
+		})
+
+		It("keeps writing when it fails to compact", func() {
+			// A directory in the way of the compacted file fails the
+			// compaction.
+			Expect(os.MkdirAll(filepath.Join(file+".compact", "blocked"), 0o700)).To(Succeed())
+
+			value := bytes.Repeat([]byte("x"), 1<<10)
+			for i := 0; i < 100; i++ {
+				Expect(client.Set(context.Background(), "a", value)).To(Succeed())
+			}
+			Expect(get("a")).To(Equal(value))
+
+			info, err := os.Stat(file)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info.Size()).To(BeNumerically(">", 100<<10))
+
+			Expect(os.RemoveAll(file + ".compact")).To(Succeed())
+			Expect(client.Set(context.Background(), "b", []byte("2"))).To(Succeed())
+			info, err = os.Stat(file)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info.Size()).To(BeNumerically("<", 4<<10))
+
+			restart()
+			Expect(get("a")).To(Equal(value))
+			Expect(get("b")).To(Equal([]byte("2")))
+		})
+	})
+
+	Context("when compaction is left to the start", func() {
+		BeforeEach(func() {
+			cfg.Compaction.GarbageRatio = 1
+		})
+
+		It("compacts the file when it is opened", func() {
+			for i := 0; i < 100; i++ {
+				Expect(client.Set(context.Background(), "a", []byte("value"))).To(Succeed())
+			}
+			before, err := os.Stat(file)
+			Expect(err).NotTo(HaveOccurred())
+
+			restart()
+			after, err := os.Stat(file)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(after.Size()).To(BeNumerically("<", before.Size()/50))
+			Expect(get("a")).To(Equal([]byte("value")))
+			Expect(file + ".compact").NotTo(BeAnExistingFile())
+		})
+	})
+
+	Describe("crash recovery", func() {
+		BeforeEach(func() {
+			cfg.Fsync = "always"
+		})
+
+		// crash writes a and b, then leaves the file as a crash during
+		// further writes would have, by handing it to damage.
+		crash := func(damage func(f *os.File, size int64)) {
+			Expect(client.Set(context.Background(), "a", []byte("1"))).To(Succeed())
+			Expect(client.Set(context.Background(), "b", []byte("2"))).To(Succeed())
+			Expect(ext.Shutdown(context.Background())).To(Succeed())
+
+			info, err := os.Stat(file)
+			Expect(err).NotTo(HaveOccurred())
+			f, err := os.OpenFile(file, os.O_RDWR, 0)
+			Expect(err).NotTo(HaveOccurred())
+			damage(f, info.Size())
+			Expect(f.Close()).To(Succeed())
+
+			start()
+		}
+
+		It("drops a record that was only partly written", func() {
+			crash(func(f *os.File, size int64) {
+				Expect(f.Truncate(size - 1)).To(Succeed())
+			})
+
+			Expect(get("a")).To(Equal([]byte("1")))
+			Expect(get("b")).To(BeNil())
+
+			Expect(client.Set(context.Background(), "c", []byte("3"))).To(Succeed())
+			restart()
+			Expect(get("a")).To(Equal([]byte("1")))
+			Expect(get("c")).To(Equal([]byte("3")))
+		})
+
+		It("drops a record whose payload does not match its checksum", func() {
+			crash(func(f *os.File, size int64) {
+				_, err := f.WriteAt([]byte("9"), size-1)
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			Expect(get("a")).To(Equal([]byte("1")))
+			Expect(get("b")).To(BeNil())
+		})
+
+		It("drops garbage after the last record", func() {
+			var size int64
+			crash(func(f *os.File, s int64) {
+				size = s
+				_, err := f.WriteAt([]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff}, s)
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			Expect(get("a")).To(Equal([]byte("1")))
+			Expect(get("b")).To(Equal([]byte("2")))
+			info, err := os.Stat(file)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info.Size()).To(Equal(size))
+		})
+
+		It("ignores an unfinished compaction", func() {
+			crash(func(*os.File, int64) {
+				Expect(os.WriteFile(file+".compact", []byte("partial"), 0o600)).To(Succeed())
+			})
+
+			Expect(get("a")).To(Equal([]byte("1")))
+			Expect(get("b")).To(Equal([]byte("2")))
+			Expect(file + ".compact").NotTo(BeAnExistingFile())
+		})
+	})
+
+	Context("with the interval fsync policy", func() {
+		It("keeps values written between syncs across restarts", func() {
+			Expect(client.Set(context.Background(), "a", []byte("1"))).To(Succeed())
+			time.Sleep(5 * cfg.FsyncInterval)
+			Expect(client.Set(context.Background(), "b", []byte("2"))).To(Succeed())
+
+			restart()
+			Expect(get("a")).To(Equal([]byte("1")))
+			Expect(get("b")).To(Equal([]byte("2")))
+		})
+	})
+
+	Describe("Config", func() {
+		// cfg is separate from the one of the running extension.
+		var cfg *diskstorageextension.Config
+
+		BeforeEach(func() {
+			cfg = diskstorageextension.NewFactory().CreateDefaultConfig().(*diskstorageextension.Config)
+		})
+
+		It("defaults to the persistent disk of the VM", func() {
+			Expect(cfg.Directory).To(Equal("/var/vcap/data/otel-collector"))
+			Expect(cfg.Validate()).To(Succeed())
+		})
+
+		It("requires a directory", func() {
+			cfg.Directory = ""
+			Expect(cfg.Validate()).To(MatchError(`"directory" is required`))
+		})
+
+		It("rejects a size cap that is not positive", func() {
+			cfg.MaxSizeMiB = 0
+			Expect(cfg.Validate()).To(MatchError(`"max_size_mib" must be positive`))
+		})
+
+		It("rejects an unsupported fsync policy", func() {
+			cfg.Fsync = "sometimes"
+			Expect(cfg.Validate()).To(MatchError(`unsupported fsync "sometimes", must be always, interval or never`))
+		})
+
+		It("rejects an fsync interval that is not positive", func() {
+			cfg.FsyncInterval = 0
+			Expect(cfg.Validate()).To(MatchError(`"fsync_interval" must be positive`))
+
+			cfg.Fsync = "never"
+			Expect(cfg.Validate()).To(Succeed())
+		})
+
+		It("rejects a garbage ratio out of range", func() {
+			cfg.Compaction.GarbageRatio = 0
+			Expect(cfg.Validate()).To(MatchError(`"compaction.garbage_ratio" must be greater than 0 and at most 1`))
+			cfg.Compaction.GarbageRatio = 1.5
+			Expect(cfg.Validate()).To(MatchError(`"compaction.garbage_ratio" must be greater than 0 and at most 1`))
+		})
+
+		It("rejects a negative minimum compaction size", func() {
+			cfg.Compaction.MinSizeMiB = -1
+			Expect(cfg.Validate()).To(MatchError(`"compaction.min_size_mib" must not be negative`))
+		})
+	})
+})
@@ -0,0 +1,44 @@
+// Package diskstorageextension implements a storage extension that keeps
+// data in files on disk, such as the queues of exporters using
+// sending_queue.storage, so it survives restarts of the collector.
+package diskstorageextension
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/extension"
+)
+
+const stability = component.StabilityLevelAlpha
+
+var componentType = component.MustNewType("diskstorage")
+
+// NewFactory creates a factory for the disk storage extension.
+func NewFactory() extension.Factory {
+	return extension.NewFactory(
+		componentType,
+		createDefaultConfig,
+		createExtension,
+		stability,
+	)
+}
+
+func createDefaultConfig() component.Config {
+	return &Config{
+		Directory:     "/var/vcap/data/otel-collector",
+		MaxSizeMiB:    1024,
+		Fsync:         fsyncInterval,
+		FsyncInterval: time.Second,
+		Compaction: CompactionConfig{
+			OnStart:      true,
+			GarbageRatio: 0.5,
+			MinSizeMiB:   1,
+		},
+	}
+}
+
+func createExtension(_ context.Context, set extension.Settings, cfg component.Config) (extension.Extension, error) {
+	return newDiskStorage(cfg.(*Config), set.Logger), nil
+}
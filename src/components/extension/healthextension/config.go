@@ -0,0 +1,73 @@
+package healthextension
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/collector/component"
+)
+
+// Config defines the configuration for the health extension.
+type Config struct {
+	// Endpoint is the loopback address the health endpoint listens on.
+	Endpoint string `mapstructure:"endpoint"`
+
+	// Path is the path the health is served on.
+	Path string `mapstructure:"path"`
+
+	// ReadinessPath is the path the readiness is served on, which only
+	// follows whether the pipelines are running, so that monit can restart
+	// a collector that is stuck without restarting it over exporters that
+	// can't reach their destination.
+	ReadinessPath string `mapstructure:"readiness_path"`
+
+	// Exporters configures when failing exporters make the collector
+	// unhealthy.
+	Exporters ExportersConfig `mapstructure:"exporters"`
+}
+
+// ExportersConfig defines how the health of exporters follows their
+// failures.
+type ExportersConfig struct {
+	// FailureThreshold is how long an exporter may keep failing before it
+	// is unhealthy rather than degraded.
+	FailureThreshold time.Duration `mapstructure:"failure_threshold"`
+
+	// RecoveryWindow is how long an exporter stays degraded after it last
+	// failed. A failure within the window continues the earlier one, so an
+	// exporter that keeps failing between successes still becomes unhealthy.
+	RecoveryWindow time.Duration `mapstructure:"recovery_window"`
+}
+
+var _ component.Config = (*Config)(nil)
+
+// Validate checks that the endpoint is on loopback and that the path and
+// durations are usable.
+func (cfg *Config) Validate() error {
+	host, _, err := net.SplitHostPort(cfg.Endpoint)
+	if err != nil {
+		return fmt.Errorf("invalid endpoint %q: %w", cfg.Endpoint, err)
+	}
+	if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+		return fmt.Errorf("endpoint %q must be on loopback", cfg.Endpoint)
+	}
+	if !strings.HasPrefix(cfg.Path, "/") {
+		return fmt.Errorf("path %q must start with /", cfg.Path)
+	}
+	if !strings.HasPrefix(cfg.ReadinessPath, "/") {
+		return fmt.Errorf("readiness path %q must start with /", cfg.ReadinessPath)
+	}
+	if cfg.ReadinessPath == cfg.Path {
+		return fmt.Errorf("readiness path %q must differ from the path", cfg.ReadinessPath)
+	}
+	if cfg.Exporters.FailureThreshold < 0 {
+		return errors.New(`"exporters.failure_threshold" must not be negative`)
+	}
+	if cfg.Exporters.RecoveryWindow < 0 {
+		return errors.New(`"exporters.recovery_window" must not be negative`)
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package healthextension
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/component/componentstatus"
+	"go.opentelemetry.io/collector/extension"
+	"go.opentelemetry.io/collector/extension/extensioncapabilities"
+	"go.uber.org/zap"
+)
+
+type healthExtension struct {
+	cfg     *Config
+	logger  *zap.Logger
+	tracker *tracker
+
+	server *http.Server
+}
+
+var (
+	_ extension.Extension                   = (*healthExtension)(nil)
+	_ componentstatus.Watcher               = (*healthExtension)(nil)
+	_ extensioncapabilities.PipelineWatcher = (*healthExtension)(nil)
+)
+
+func newHealthExtension(cfg *Config, logger *zap.Logger) *healthExtension {
+	return &healthExtension{
+		cfg:     cfg,
+		logger:  logger,
+		tracker: newTracker(cfg.Exporters),
+	}
+}
+
+func (e *healthExtension) Start(_ context.Context, host component.Host) error {
+	lis, err := net.Listen("tcp", e.cfg.Endpoint)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", e.cfg.Endpoint, err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(e.cfg.Path, e)
+	mux.HandleFunc(e.cfg.ReadinessPath, e.serveReadiness)
+	e.server = &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	go func() {
+		if err := e.server.Serve(lis); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			componentstatus.ReportStatus(host, componentstatus.NewFatalErrorEvent(err))
+		}
+	}()
+	return nil
+}
+
+func (e *healthExtension) Shutdown(ctx context.Context) error {
+	if e.server == nil {
+		return nil
+	}
+	return e.server.Shutdown(ctx)
+}
+
+// ComponentStatusChanged records the status events of every component,
+// including this extension.
+func (e *healthExtension) ComponentStatusChanged(source *componentstatus.InstanceID, event *componentstatus.Event) {
+	e.tracker.update(source, event)
+}
+
+// Ready is called once the pipelines have started.
+func (e *healthExtension) Ready() error {
+	e.tracker.setReady(true)
+	return nil
+}
+
+// NotReady is called before the pipelines are shut down.
+func (e *healthExtension) NotReady() error {
+	e.tracker.setReady(false)
+	return nil
+}
+
+// ServeHTTP serves the health report, with status 503 when the collector is
+// unhealthy so that a check of the status code alone is enough.
+func (e *healthExtension) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	r := e.tracker.report(time.Now())
+
+	w.Header().Set("Content-Type", "application/json")
+	if r.Status == unhealthy {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	if err := json.NewEncoder(w).Encode(r); err != nil {
+		e.logger.Debug("Failed to write health report", zap.Error(err))
+	}
+}
+
+// serveReadiness serves whether the pipelines are running, with status 503
+// when they are not.
+func (e *healthExtension) serveReadiness(w http.ResponseWriter, _ *http.Request) {
+	ready := e.tracker.isReady()
+
+	w.Header().Set("Content-Type", "application/json")
+	if !ready {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	if err := json.NewEncoder(w).Encode(map[string]bool{"ready": ready}); err != nil {
+		e.logger.Debug("Failed to write readiness", zap.Error(err))
+	}
+}
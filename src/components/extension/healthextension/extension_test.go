@@ -0,0 +1,311 @@
+package healthextension_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"code.cloudfoundry.org/otel-collector-release/src/components/extension/healthextension"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/component/componentstatus"
+	"go.opentelemetry.io/collector/component/componenttest"
+	"go.opentelemetry.io/collector/extension"
+	"go.opentelemetry.io/collector/extension/extensioncapabilities"
+	"go.opentelemetry.io/collector/extension/extensiontest"
+	"go.opentelemetry.io/collector/pipeline"
+)
+
+// healthReport is the body served by the endpoint.
+type healthReport struct {
+	Status    string                     `json:"status"`
+	Ready     bool                       `json:"ready"`
+	Pipelines map[string]pipelineReport  `json:"pipelines"`
+	Exporters map[string]componentReport `json:"exporters"`
+}
+
+type pipelineReport struct {
+	Status     string                     `json:"status"`
+	Components map[string]componentReport `json:"components"`
+}
+
+type componentReport struct {
+	Status          string     `json:"status"`
+	ComponentStatus string     `json:"component_status"`
+	Error           string     `json:"error"`
+	FailingSince    *time.Time `json:"failing_since"`
+}
+
+var _ = Describe("Health extension", func() {
+	var (
+		cfg *healthextension.Config
+		ext extension.Extension
+	)
+
+	logs := pipeline.NewID(pipeline.SignalLogs)
+	metrics := pipeline.NewID(pipeline.SignalMetrics)
+	receiver := componentstatus.NewInstanceID(component.MustNewID("otlp"), component.KindReceiver, logs, metrics)
+	processor := componentstatus.NewInstanceID(component.MustNewID("batch"), component.KindProcessor, logs)
+	logsExporter := componentstatus.NewInstanceID(component.MustNewID("otlp"), component.KindExporter, logs)
+	metricsExporter := componentstatus.NewInstanceID(component.MustNewID("otlp"), component.KindExporter, metrics)
+
+	BeforeEach(func() {
+		cfg = healthextension.NewFactory().CreateDefaultConfig().(*healthextension.Config)
+		cfg.Endpoint = fmt.Sprintf("127.0.0.1:%d", freePort())
+		cfg.Exporters.FailureThreshold = 200 * time.Millisecond
+		cfg.Exporters.RecoveryWindow = 200 * time.Millisecond
+	})
+
+	JustBeforeEach(func() {
+		Expect(cfg.Validate()).To(Succeed())
+		factory := healthextension.NewFactory()
+
+		var err error
+		ext, err = factory.Create(context.Background(), extensiontest.NewNopSettings(factory.Type()), cfg)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ext.Start(context.Background(), componenttest.NewNopHost())).To(Succeed())
+		DeferCleanup(ext.Shutdown, context.Background())
+	})
+
+	report := func(instance *componentstatus.InstanceID, ev *componentstatus.Event) {
+		ext.(componentstatus.Watcher).ComponentStatusChanged(instance, ev)
+	}
+
+	// start reports the components as running and the pipelines as ready.
+	start := func() {
+		for _, instance := range []*componentstatus.InstanceID{receiver, processor, logsExporter, metricsExporter} {
+			report(instance, componentstatus.NewEvent(componentstatus.StatusStarting))
+			report(instance, componentstatus.NewEvent(componentstatus.StatusOK))
+		}
+		Expect(ext.(extensioncapabilities.PipelineWatcher).Ready()).To(Succeed())
+	}
+
+	get := func(g Gomega) (int, healthReport) {
+		resp, err := http.Get(fmt.Sprintf("http://%s/health", cfg.Endpoint))
+		g.Expect(err).NotTo(HaveOccurred())
+		defer resp.Body.Close()
+		g.Expect(resp.Header.Get("Content-Type")).To(Equal("application/json"))
+
+		var r healthReport
+		g.Expect(json.NewDecoder(resp.Body).Decode(&r)).To(Succeed())
+		return resp.StatusCode, r
+	}
+
+	status := func(g Gomega) string {
+		_, r := get(g)
+		return r.Status
+	}
+
+	It("is unhealthy until the pipelines are ready", func() {
+		code, r := get(Default)
+		Expect(code).To(Equal(http.StatusServiceUnavailable))
+		Expect(r.Status).To(Equal("unhealthy"))
+		Expect(r.Ready).To(BeFalse())
+
+		start()
+		code, r = get(Default)
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(r.Status).To(Equal("healthy"))
+		Expect(r.Ready).To(BeTrue())
+
+		Expect(ext.(extensioncapabilities.PipelineWatcher).NotReady()).To(Succeed())
+		code, _ = get(Default)
+		Expect(code).To(Equal(http.StatusServiceUnavailable))
+	})
+
+	Describe("readiness", func() {
+		getReady := func() (int, bool) {
+			resp, err := http.Get(fmt.Sprintf("http://%s/ready", cfg.Endpoint))
+			Expect(err).NotTo(HaveOccurred())
+			defer resp.Body.Close()
+
+			var r struct {
+				Ready bool `json:"ready"`
+			}
+			Expect(json.NewDecoder(resp.Body).Decode(&r)).To(Succeed())
+			return resp.StatusCode, r.Ready
+		}
+
+		It("is served while the pipelines are running", func() {
+			code, ready := getReady()
+			Expect(code).To(Equal(http.StatusServiceUnavailable))
+			Expect(ready).To(BeFalse())
+
+			start()
+			code, ready = getReady()
+			Expect(code).To(Equal(http.StatusOK))
+			Expect(ready).To(BeTrue())
+
+			Expect(ext.(extensioncapabilities.PipelineWatcher).NotReady()).To(Succeed())
+			code, _ = getReady()
+			Expect(code).To(Equal(http.StatusServiceUnavailable))
+		})
+
+		It("does not follow failing exporters", func() {
+			start()
+			report(logsExporter, componentstatus.NewRecoverableErrorEvent(errors.New("connection refused")))
+
+			Eventually(status).WithTimeout(2 * cfg.Exporters.FailureThreshold).Should(Equal("unhealthy"))
+			code, ready := getReady()
+			Expect(code).To(Equal(http.StatusOK))
+			Expect(ready).To(BeTrue())
+		})
+	})
+
+	It("reports every pipeline and exporter", func() {
+		start()
+
+		_, r := get(Default)
+		Expect(r.Pipelines).To(HaveLen(2))
+		Expect(r.Pipelines["logs"].Status).To(Equal("healthy"))
+		Expect(r.Pipelines["logs"].Components).To(HaveKey("receiver:otlp"))
+		Expect(r.Pipelines["logs"].Components).To(HaveKey("processor:batch"))
+		Expect(r.Pipelines["logs"].Components).To(HaveKey("exporter:otlp"))
+		Expect(r.Pipelines["metrics"].Components).NotTo(HaveKey("processor:batch"))
+		Expect(r.Exporters).To(HaveKeyWithValue("otlp", HaveField("ComponentStatus", "StatusOK")))
+	})
+
+	It("is degraded while an exporter fails, then unhealthy past the failure threshold", func() {
+		start()
+		report(logsExporter, componentstatus.NewRecoverableErrorEvent(errors.New("connection refused")))
+
+		code, r := get(Default)
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(r.Status).To(Equal("degraded"))
+		Expect(r.Pipelines["logs"].Status).To(Equal("degraded"))
+		Expect(r.Pipelines["metrics"].Status).To(Equal("healthy"))
+		Expect(r.Exporters["otlp"].Status).To(Equal("degraded"))
+		Expect(r.Exporters["otlp"].Error).To(Equal("connection refused"))
+		Expect(r.Exporters["otlp"].FailingSince).NotTo(BeNil())
+
+		Eventually(status).Should(Equal("unhealthy"))
+		code, r = get(Default)
+		Expect(code).To(Equal(http.StatusServiceUnavailable))
+		Expect(r.Pipelines["logs"].Status).To(Equal("unhealthy"))
+		Expect(r.Pipelines["metrics"].Status).To(Equal("healthy"))
+	})
+
+	It("stays degraded for the recovery window after an exporter recovers", func() {
+		start()
+		report(logsExporter, componentstatus.NewRecoverableErrorEvent(errors.New("connection refused")))
+		report(logsExporter, componentstatus.NewEvent(componentstatus.StatusOK))
+
+		Expect(status(Default)).To(Equal("degraded"))
+		Eventually(status).Should(Equal("healthy"))
+	})
+
+	It("counts failures within the recovery window as one", func() {
+		start()
+		report(logsExporter, componentstatus.NewRecoverableErrorEvent(errors.New("connection refused")))
+		_, first := get(Default)
+
+		time.Sleep(cfg.Exporters.FailureThreshold / 2)
+		report(logsExporter, componentstatus.NewEvent(componentstatus.StatusOK))
+		report(logsExporter, componentstatus.NewRecoverableErrorEvent(errors.New("connection refused")))
+
+		_, r := get(Default)
+		Expect(*r.Exporters["otlp"].FailingSince).To(Equal(*first.Exporters["otlp"].FailingSince))
+		Eventually(status, cfg.Exporters.FailureThreshold).Should(Equal("unhealthy"))
+	})
+
+	It("is unhealthy when a component fails permanently", func() {
+		start()
+		report(processor, componentstatus.NewPermanentErrorEvent(errors.New("invalid configuration")))
+
+		code, r := get(Default)
+		Expect(code).To(Equal(http.StatusServiceUnavailable))
+		Expect(r.Pipelines["logs"].Components["processor:batch"].Error).To(Equal("invalid configuration"))
+		Expect(r.Pipelines["metrics"].Status).To(Equal("healthy"))
+	})
+
+	It("is only degraded by recoverable errors of components other than exporters", func() {
+		start()
+		report(receiver, componentstatus.NewRecoverableErrorEvent(errors.New("too many requests")))
+
+		Consistently(status, 2*cfg.Exporters.FailureThreshold).Should(Equal("degraded"))
+	})
+
+	Context("when the path is configured", func() {
+		BeforeEach(func() {
+			cfg.Path = "/healthz"
+		})
+
+		It("serves the health on that path", func() {
+			start()
+
+			resp, err := http.Get(fmt.Sprintf("http://%s/healthz", cfg.Endpoint))
+			Expect(err).NotTo(HaveOccurred())
+			resp.Body.Close()
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+			resp, err = http.Get(fmt.Sprintf("http://%s/health", cfg.Endpoint))
+			Expect(err).NotTo(HaveOccurred())
+			resp.Body.Close()
+			Expect(resp.StatusCode).To(Equal(http.StatusNotFound))
+		})
+	})
+
+	Describe("Config", func() {
+		It("listens on loopback by default", func() {
+			cfg := healthextension.NewFactory().CreateDefaultConfig().(*healthextension.Config)
+			Expect(cfg.Endpoint).To(Equal("127.0.0.1:13133"))
+			Expect(cfg.Validate()).To(Succeed())
+		})
+
+		It("accepts the other loopback addresses", func() {
+			for _, endpoint := range []string{"localhost:13133", "[::1]:13133", "127.0.0.2:13133"} {
+				cfg.Endpoint = endpoint
+				Expect(cfg.Validate()).To(Succeed())
+			}
+		})
+
+		It("rejects an endpoint that is not on loopback", func() {
+			cfg.Endpoint = "0.0.0.0:13133"
+			Expect(cfg.Validate()).To(MatchError(`endpoint "0.0.0.0:13133" must be on loopback`))
+
+			cfg.Endpoint = ":13133"
+			Expect(cfg.Validate()).To(MatchError(`endpoint ":13133" must be on loopback`))
+		})
+
+		It("rejects an invalid endpoint", func() {
+			cfg.Endpoint = "127.0.0.1"
+			Expect(cfg.Validate()).To(MatchError(ContainSubstring(`invalid endpoint "127.0.0.1"`)))
+		})
+
+		It("rejects a relative path", func() {
+			cfg.Path = "health"
+			Expect(cfg.Validate()).To(MatchError(`path "health" must start with /`))
+		})
+
+		It("rejects a relative readiness path", func() {
+			cfg.ReadinessPath = "ready"
+			Expect(cfg.Validate()).To(MatchError(`readiness path "ready" must start with /`))
+		})
+
+		It("rejects a readiness path that is the path", func() {
+			cfg.ReadinessPath = "/health"
+			Expect(cfg.Validate()).To(MatchError(`readiness path "/health" must differ from the path`))
+		})
+
+		It("rejects negative durations", func() {
+			cfg.Exporters.FailureThreshold = -time.Second
+			Expect(cfg.Validate()).To(MatchError(`"exporters.failure_threshold" must not be negative`))
+
+			cfg.Exporters.FailureThreshold = 0
+			cfg.Exporters.RecoveryWindow = -time.Second
+			Expect(cfg.Validate()).To(MatchError(`"exporters.recovery_window" must not be negative`))
+		})
+	})
+})
+
+func freePort() int {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	Expect(err).NotTo(HaveOccurred())
+	defer lis.Close()
+	return lis.Addr().(*net.TCPAddr).Port
+}
@@ -0,0 +1,42 @@
+// Package healthextension implements an extension that serves the health of
+// the collector, its pipelines and its exporters on loopback, following the
+// status events of the components.
+package healthextension
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/extension"
+)
+
+const stability = component.StabilityLevelAlpha
+
+var componentType = component.MustNewType("health")
+
+// NewFactory creates a factory for the health extension.
+func NewFactory() extension.Factory {
+	return extension.NewFactory(
+		componentType,
+		createDefaultConfig,
+		createExtension,
+		stability,
+	)
+}
+
+func createDefaultConfig() component.Config {
+	return &Config{
+		Endpoint:      "127.0.0.1:13133",
+		Path:          "/health",
+		ReadinessPath: "/ready",
+		Exporters: ExportersConfig{
+			FailureThreshold: 5 * time.Minute,
+			RecoveryWindow:   time.Minute,
+		},
+	}
+}
+
+func createExtension(_ context.Context, set extension.Settings, cfg component.Config) (extension.Extension, error) {
+	return newHealthExtension(cfg.(*Config), set.Logger), nil
+}
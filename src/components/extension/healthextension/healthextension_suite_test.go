@@ -0,0 +1,13 @@
+package healthextension_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestHealthExtension(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Health Extension Suite")
+}
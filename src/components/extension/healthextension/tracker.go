@@ -0,0 +1,199 @@
+package healthextension
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/component/componentstatus"
+	"go.opentelemetry.io/collector/pipeline"
+)
+
+// health is what the endpoint reports for a component, a pipeline or the
+// collector, ordered from best to worst.
+type health int
+
+const (
+	healthy health = iota
+	degraded
+	unhealthy
+)
+
+func (h health) String() string {
+	switch h {
+	case healthy:
+		return "healthy"
+	case degraded:
+		return "degraded"
+	default:
+		return "unhealthy"
+	}
+}
+
+func (h health) MarshalText() ([]byte, error) {
+	return []byte(h.String()), nil
+}
+
+// report is the body served by the health endpoint.
+type report struct {
+	Status    health                     `json:"status"`
+	Ready     bool                       `json:"ready"`
+	Pipelines map[string]pipelineReport  `json:"pipelines"`
+	Exporters map[string]componentReport `json:"exporters"`
+}
+
+type pipelineReport struct {
+	Status     health                     `json:"status"`
+	Components map[string]componentReport `json:"components"`
+}
+
+type componentReport struct {
+	Status          health     `json:"status"`
+	ComponentStatus string     `json:"component_status"`
+	Error           string     `json:"error,omitempty"`
+	Timestamp       time.Time  `json:"timestamp"`
+	FailingSince    *time.Time `json:"failing_since,omitempty"`
+}
+
+// componentState is what is known about a component instance from its
+// status events.
+type componentState struct {
+	event *componentstatus.Event
+
+	// failingSince is when an exporter started failing, counting failures
+	// within the recovery window as one.
+	failingSince time.Time
+
+	// lastFailure is when an exporter last failed, or when it recovered
+	// from the failure.
+	lastFailure time.Time
+}
+
+// tracker keeps the latest status of every component instance.
+type tracker struct {
+	cfg ExportersConfig
+
+	mu         sync.Mutex
+	ready      bool
+	components map[*componentstatus.InstanceID]*componentState
+}
+
+func newTracker(cfg ExportersConfig) *tracker {
+	return &tracker{
+		cfg:        cfg,
+		components: map[*componentstatus.InstanceID]*componentState{},
+	}
+}
+
+func (t *tracker) setReady(ready bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.ready = ready
+}
+
+func (t *tracker) isReady() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.ready
+}
+
+func (t *tracker) update(id *componentstatus.InstanceID, ev *componentstatus.Event) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	s, ok := t.components[id]
+	if !ok {
+		s = &componentState{}
+		t.components[id] = s
+	}
+
+	if id.Kind() == component.KindExporter {
+		wasFailing := s.event != nil && s.event.Status() == componentstatus.StatusRecoverableError
+		switch ev.Status() {
+		case componentstatus.StatusRecoverableError:
+			if !wasFailing && (s.failingSince.IsZero() || ev.Timestamp().Sub(s.lastFailure) >= t.cfg.RecoveryWindow) {
+				s.failingSince = ev.Timestamp()
+			}
+			s.lastFailure = ev.Timestamp()
+		case componentstatus.StatusOK:
+			if wasFailing {
+				s.lastFailure = ev.Timestamp()
+			}
+		}
+	}
+	s.event = ev
+}
+
+func (t *tracker) report(now time.Time) report {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	r := report{
+		Status:    healthy,
+		Ready:     t.ready,
+		Pipelines: map[string]pipelineReport{},
+		Exporters: map[string]componentReport{},
+	}
+	if !t.ready {
+		r.Status = unhealthy
+	}
+
+	for id, s := range t.components {
+		c := t.componentReport(id, s, now)
+		r.Status = max(r.Status, c.Status)
+
+		name := strings.ToLower(id.Kind().String()) + ":" + id.ComponentID().String()
+		id.AllPipelineIDs(func(pipelineID pipeline.ID) bool {
+			p, ok := r.Pipelines[pipelineID.String()]
+			if !ok {
+				p = pipelineReport{Components: map[string]componentReport{}}
+			}
+			p.Status = max(p.Status, c.Status)
+			p.Components[name] = c
+			r.Pipelines[pipelineID.String()] = p
+			return true
+		})
+
+		// An exporter has an instance for every signal it exports, report
+		// the worst of them.
+		if id.Kind() == component.KindExporter {
+			key := id.ComponentID().String()
+			if e, ok := r.Exporters[key]; !ok || c.Status > e.Status {
+				r.Exporters[key] = c
+			}
+		}
+	}
+	return r
+}
+
+func (t *tracker) componentReport(id *componentstatus.InstanceID, s *componentState, now time.Time) componentReport {
+	c := componentReport{
+		Status:          healthy,
+		ComponentStatus: s.event.Status().String(),
+		Timestamp:       s.event.Timestamp(),
+	}
+	if err := s.event.Err(); err != nil {
+		c.Error = err.Error()
+	}
+
+	switch s.event.Status() {
+	case componentstatus.StatusRecoverableError:
+		c.Status = degraded
+		if id.Kind() == component.KindExporter {
+			failingSince := s.failingSince
+			c.FailingSince = &failingSince
+			if now.Sub(s.failingSince) >= t.cfg.FailureThreshold {
+				c.Status = unhealthy
+			}
+		}
+	case componentstatus.StatusOK:
+		if id.Kind() == component.KindExporter && !s.lastFailure.IsZero() && now.Sub(s.lastFailure) < t.cfg.RecoveryWindow {
+			c.Status = degraded
+		}
+	case componentstatus.StatusPermanentError, componentstatus.StatusFatalError,
+		componentstatus.StatusStopping, componentstatus.StatusStopped:
+		c.Status = unhealthy
+	}
+	return c
+}
@@ -0,0 +1,24 @@
+package instanceidentityauthextension
+
+import (
+	"errors"
+
+	"go.opentelemetry.io/collector/component"
+)
+
+// Config defines the configuration for the instance identity auth extension.
+type Config struct {
+	// CAFile is the Diego instance identity CA that signs the certificates
+	// of app instances.
+	CAFile string `mapstructure:"ca_file"`
+}
+
+var _ component.Config = (*Config)(nil)
+
+// Validate checks that the instance identity CA is set.
+func (cfg *Config) Validate() error {
+	if cfg.CAFile == "" {
+		return errors.New(`requires a non-empty "ca_file"`)
+	}
+	return nil
+}
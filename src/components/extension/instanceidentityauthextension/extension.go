@@ -0,0 +1,167 @@
+package instanceidentityauthextension
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/collector/client"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/extension"
+	"go.opentelemetry.io/collector/extension/extensionauth"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
+
+// The attributes of the auth data the extension adds to the client info of
+// authenticated requests. The identity is the instance GUID, the common name
+// of instance identity certificates.
+const (
+	AttributeIdentity         = "identity"
+	AttributeAppGUID          = "app_guid"
+	AttributeSpaceGUID        = "space_guid"
+	AttributeOrganizationGUID = "organization_guid"
+)
+
+// The prefixes of the organizational units Diego sets on instance identity
+// certificates.
+const (
+	unitApp          = "app:"
+	unitSpace        = "space:"
+	unitOrganization = "organization:"
+)
+
+var (
+	_ extension.Extension  = (*instanceIdentityAuth)(nil)
+	_ extensionauth.Server = (*instanceIdentityAuth)(nil)
+	_ client.AuthData      = (*authData)(nil)
+)
+
+var errNoClientCertificate = errors.New("no client certificate")
+
+type instanceIdentityAuth struct {
+	component.ShutdownFunc
+
+	caFile string
+	roots  *x509.CertPool
+}
+
+func newInstanceIdentityAuth(cfg *Config) *instanceIdentityAuth {
+	return &instanceIdentityAuth{caFile: cfg.CAFile}
+}
+
+func (a *instanceIdentityAuth) Start(context.Context, component.Host) error {
+	caPEM, err := os.ReadFile(a.caFile)
+	if err != nil {
+		return fmt.Errorf("failed to read the instance identity CA: %w", err)
+	}
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(caPEM) {
+		return fmt.Errorf("no certificates in the instance identity CA %q", a.caFile)
+	}
+	a.roots = roots
+	return nil
+}
+
+// Authenticate verifies the client certificate against the instance identity
+// CA itself, since the receiver usually also trusts the CAs of platform
+// components. Only gRPC receivers pass the TLS state to authenticators.
+func (a *instanceIdentityAuth) Authenticate(ctx context.Context, _ map[string][]string) (context.Context, error) {
+	chain, err := clientCertificates(ctx)
+	if err != nil {
+		return ctx, err
+	}
+	cert := chain[0]
+
+	intermediates := x509.NewCertPool()
+	for _, c := range chain[1:] {
+		intermediates.AddCert(c)
+	}
+	if _, err := cert.Verify(x509.VerifyOptions{
+		Roots:         a.roots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}); err != nil {
+		return ctx, fmt.Errorf("client certificate %q is not an instance identity certificate: %w", cert.Subject.CommonName, err)
+	}
+
+	data, err := parseInstanceIdentity(cert)
+	if err != nil {
+		return ctx, err
+	}
+
+	info := client.FromContext(ctx)
+	info.Auth = data
+	return client.NewContext(ctx, info), nil
+}
+
+func clientCertificates(ctx context.Context) ([]*x509.Certificate, error) {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return nil, errNoClientCertificate
+	}
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(tlsInfo.State.PeerCertificates) == 0 {
+		return nil, errNoClientCertificate
+	}
+	return tlsInfo.State.PeerCertificates, nil
+}
+
+// parseInstanceIdentity reads the app, space and organization GUIDs from the
+// organizational units of cert, which must each appear exactly once.
+func parseInstanceIdentity(cert *x509.Certificate) (*authData, error) {
+	guids := map[string]string{}
+	for _, unit := range cert.Subject.OrganizationalUnit {
+		for _, prefix := range []string{unitApp, unitSpace, unitOrganization} {
+			guid, ok := strings.CutPrefix(unit, prefix)
+			if !ok {
+				continue
+			}
+			if _, seen := guids[prefix]; seen {
+				return nil, fmt.Errorf("client certificate %q has more than one %q organizational unit", cert.Subject.CommonName, prefix)
+			}
+			guids[prefix] = guid
+		}
+	}
+	for _, prefix := range []string{unitApp, unitSpace, unitOrganization} {
+		if guids[prefix] == "" {
+			return nil, fmt.Errorf("client certificate %q has no %q organizational unit", cert.Subject.CommonName, prefix)
+		}
+	}
+
+	return &authData{
+		identity:         cert.Subject.CommonName,
+		appGUID:          guids[unitApp],
+		spaceGUID:        guids[unitSpace],
+		organizationGUID: guids[unitOrganization],
+	}, nil
+}
+
+type authData struct {
+	identity         string
+	appGUID          string
+	spaceGUID        string
+	organizationGUID string
+}
+
+func (d *authData) GetAttribute(name string) any {
+	switch name {
+	case AttributeIdentity:
+		return d.identity
+	case AttributeAppGUID:
+		return d.appGUID
+	case AttributeSpaceGUID:
+		return d.spaceGUID
+	case AttributeOrganizationGUID:
+		return d.organizationGUID
+	default:
+		return nil
+	}
+}
+
+func (*authData) GetAttributeNames() []string {
+	return []string{AttributeIdentity, AttributeAppGUID, AttributeSpaceGUID, AttributeOrganizationGUID}
+}
@@ -0,0 +1,217 @@
+package instanceidentityauthextension_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"code.cloudfoundry.org/otel-collector-release/src/components/extension/instanceidentityauthextension"
+	"code.cloudfoundry.org/tlsconfig/certtest"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.opentelemetry.io/collector/client"
+	"go.opentelemetry.io/collector/component/componenttest"
+	"go.opentelemetry.io/collector/extension"
+	"go.opentelemetry.io/collector/extension/extensionauth"
+	"go.opentelemetry.io/collector/extension/extensiontest"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
+
+const (
+	appGUID          = "6d3a3b9f-8a2c-4c6e-9c43-2f1e0a5b7d11"
+	spaceGUID        = "0b1c9e42-51d7-4d0e-8f6b-1a2b3c4d5e6f"
+	organizationGUID = "f4e3d2c1-b0a9-4876-9543-210fedcba987"
+	instanceGUID     = "a1b2c3d4-e5f6-4a7b-8c9d-0e1f2a3b4c5d"
+)
+
+var _ = Describe("Instance identity auth extension", func() {
+	var (
+		ca   *testCA
+		cfg  *instanceidentityauthextension.Config
+		ext  extension.Extension
+		auth extensionauth.Server
+	)
+
+	BeforeEach(func() {
+		ca = newTestCA("instanceIdentityCA")
+		cfg = instanceidentityauthextension.NewFactory().CreateDefaultConfig().(*instanceidentityauthextension.Config)
+		cfg.CAFile = ca.write()
+	})
+
+	JustBeforeEach(func() {
+		f := instanceidentityauthextension.NewFactory()
+		var err error
+		ext, err = f.Create(context.Background(), extensiontest.NewNopSettings(f.Type()), cfg)
+		Expect(err).NotTo(HaveOccurred())
+		auth = ext.(extensionauth.Server)
+	})
+
+	Context("when started", func() {
+		JustBeforeEach(func() {
+			Expect(ext.Start(context.Background(), componenttest.NewNopHost())).To(Succeed())
+			DeferCleanup(ext.Shutdown, context.Background())
+		})
+
+		It("exposes the app, space and organization of an instance", func() {
+			cert := ca.sign(instanceGUID, "organization:"+organizationGUID, "space:"+spaceGUID, "app:"+appGUID)
+
+			ctx, err := auth.Authenticate(peerWith(cert), nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			data := client.FromContext(ctx).Auth
+			Expect(data).NotTo(BeNil())
+			Expect(data.GetAttribute("identity")).To(Equal(instanceGUID))
+			Expect(data.GetAttribute("app_guid")).To(Equal(appGUID))
+			Expect(data.GetAttribute("space_guid")).To(Equal(spaceGUID))
+			Expect(data.GetAttribute("organization_guid")).To(Equal(organizationGUID))
+			Expect(data.GetAttributeNames()).To(ConsistOf("identity", "app_guid", "space_guid", "organization_guid"))
+		})
+
+		It("verifies the chain the instance sends", func() {
+			intermediate := ca.intermediate("instanceIdentityIntermediateCA")
+			cert := intermediate.sign(instanceGUID, "organization:"+organizationGUID, "space:"+spaceGUID, "app:"+appGUID)
+
+			_, err := auth.Authenticate(peerWith(cert), nil)
+			Expect(err).To(MatchError(ContainSubstring("is not an instance identity certificate")))
+
+			_, err = auth.Authenticate(peerWith(cert, intermediate.cert), nil)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("rejects certificates of other CAs", func() {
+			other, err := certtest.BuildCA("instanceIdentityCA")
+			Expect(err).NotTo(HaveOccurred())
+			cert, err := other.BuildSignedCertificate(instanceGUID)
+			Expect(err).NotTo(HaveOccurred())
+			tlsCert, err := cert.TLSCertificate()
+			Expect(err).NotTo(HaveOccurred())
+			leaf, err := x509.ParseCertificate(tlsCert.Certificate[0])
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = auth.Authenticate(peerWith(leaf), nil)
+			Expect(err).To(MatchError(ContainSubstring(`client certificate "` + instanceGUID + `" is not an instance identity certificate`)))
+		})
+
+		It("rejects certificates without an app, space or organization", func() {
+			cert := ca.sign(instanceGUID, "organization:"+organizationGUID, "app:"+appGUID)
+
+			_, err := auth.Authenticate(peerWith(cert), nil)
+			Expect(err).To(MatchError(`client certificate "` + instanceGUID + `" has no "space:" organizational unit`))
+		})
+
+		It("rejects certificates with more than one app", func() {
+			cert := ca.sign(instanceGUID, "organization:"+organizationGUID, "space:"+spaceGUID, "app:"+appGUID, "app:other")
+
+			_, err := auth.Authenticate(peerWith(cert), nil)
+			Expect(err).To(MatchError(ContainSubstring(`more than one "app:" organizational unit`)))
+		})
+
+		It("rejects requests without a client certificate", func() {
+			_, err := auth.Authenticate(context.Background(), nil)
+			Expect(err).To(MatchError("no client certificate"))
+		})
+	})
+
+	It("fails to start without the CA", func() {
+		Expect(os.Remove(cfg.CAFile)).To(Succeed())
+		Expect(ext.Start(context.Background(), componenttest.NewNopHost())).To(MatchError(ContainSubstring("failed to read the instance identity CA")))
+	})
+
+	It("fails to start when the CA has no certificates", func() {
+		Expect(os.WriteFile(cfg.CAFile, []byte("not a certificate"), 0o600)).To(Succeed())
+		Expect(ext.Start(context.Background(), componenttest.NewNopHost())).To(MatchError(ContainSubstring("no certificates in the instance identity CA")))
+	})
+
+	Describe("Config", func() {
+		It("requires the CA", func() {
+			cfg := instanceidentityauthextension.NewFactory().CreateDefaultConfig().(*instanceidentityauthextension.Config)
+			Expect(cfg.Validate()).To(MatchError(`requires a non-empty "ca_file"`))
+
+			cfg.CAFile = "/var/vcap/jobs/otel-collector/config/certs/instance-identity-ca.crt"
+			Expect(cfg.Validate()).To(Succeed())
+		})
+	})
+})
+
+// peerWith is the context of a gRPC request from a client that presented
+// chain. Diego instance identity certificates have organizational units,
+// which certtest can't set, so the certificates are built by a testCA.
+func peerWith(chain ...*x509.Certificate) context.Context {
+	return peer.NewContext(context.Background(), &peer.Peer{
+		Addr:     &net.TCPAddr{IP: net.IPv4(10, 255, 0, 7), Port: 40000},
+		AuthInfo: credentials.TLSInfo{State: tls.ConnectionState{PeerCertificates: chain}},
+	})
+}
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newTestCA(name string) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).NotTo(HaveOccurred())
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	Expect(err).NotTo(HaveOccurred())
+	cert, err := x509.ParseCertificate(der)
+	Expect(err).NotTo(HaveOccurred())
+	return &testCA{cert: cert, key: key}
+}
+
+// intermediate creates a CA signed by ca.
+func (ca *testCA) intermediate(name string) *testCA {
+	i := newTestCA(name)
+	der, err := x509.CreateCertificate(rand.Reader, i.cert, ca.cert, &i.key.PublicKey, ca.key)
+	Expect(err).NotTo(HaveOccurred())
+	i.cert, err = x509.ParseCertificate(der)
+	Expect(err).NotTo(HaveOccurred())
+	return i
+}
+
+// sign creates a client certificate like the instance identity certificate
+// Diego issues for an app instance.
+func (ca *testCA) sign(commonName string, units ...string) *x509.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).NotTo(HaveOccurred())
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: commonName, OrganizationalUnit: units},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageServerAuth},
+		IPAddresses:  []net.IP{net.IPv4(10, 255, 0, 7)},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	Expect(err).NotTo(HaveOccurred())
+	cert, err := x509.ParseCertificate(der)
+	Expect(err).NotTo(HaveOccurred())
+	return cert
+}
+
+// write stores the CA certificate in a file and returns its path.
+func (ca *testCA) write() string {
+	path := filepath.Join(GinkgoT().TempDir(), "instance-identity-ca.crt")
+	Expect(os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.cert.Raw}), 0o600)).To(Succeed())
+	return path
+}
@@ -0,0 +1,34 @@
+// Package instanceidentityauthextension implements a server auth extension
+// that authenticates app instances in Diego containers by their instance
+// identity certificate, and exposes the app, space and organization of the
+// instance to the processors of the pipeline.
+package instanceidentityauthextension
+
+import (
+	"context"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/extension"
+)
+
+const stability = component.StabilityLevelAlpha
+
+var componentType = component.MustNewType("instanceidentityauth")
+
+// NewFactory creates a factory for the instance identity auth extension.
+func NewFactory() extension.Factory {
+	return extension.NewFactory(
+		componentType,
+		createDefaultConfig,
+		createExtension,
+		stability,
+	)
+}
+
+func createDefaultConfig() component.Config {
+	return &Config{}
+}
+
+func createExtension(_ context.Context, _ extension.Settings, cfg component.Config) (extension.Extension, error) {
+	return newInstanceIdentityAuth(cfg.(*Config)), nil
+}
@@ -0,0 +1,13 @@
+package instanceidentityauthextension_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestInstanceIdentityAuthExtension(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Instance Identity Auth Extension Suite")
+}
@@ -0,0 +1,74 @@
+package uaaauthextension
+
+import (
+	"errors"
+	"time"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/config/configopaque"
+	"go.opentelemetry.io/collector/config/configtls"
+)
+
+// Config defines the configuration for the UAA auth extension.
+type Config struct {
+	// Endpoint is the UAA, e.g. https://uaa.sys.example.com.
+	Endpoint string `mapstructure:"endpoint"`
+
+	// ClientID and ClientSecret are the UAA client whose client credentials
+	// grant is used to get tokens.
+	ClientID     string              `mapstructure:"client_id"`
+	ClientSecret configopaque.String `mapstructure:"client_secret"`
+
+	// Scopes are requested for the token, all the scopes of the client when
+	// empty.
+	Scopes []string `mapstructure:"scopes"`
+
+	// TLS configures the connections to UAA.
+	TLS configtls.ClientConfig `mapstructure:"tls"`
+
+	// Timeout bounds every request to UAA.
+	Timeout time.Duration `mapstructure:"timeout"`
+
+	// RefreshBefore is how long before its expiry a token is replaced. A
+	// token is used for at least half of its lifetime.
+	RefreshBefore time.Duration `mapstructure:"refresh_before"`
+
+	// Backoff configures how failed token requests are retried.
+	Backoff BackoffConfig `mapstructure:"backoff"`
+}
+
+// BackoffConfig defines the exponential backoff between failed token
+// requests.
+type BackoffConfig struct {
+	// InitialInterval is the wait after the first failure, doubled after
+	// every further failure.
+	InitialInterval time.Duration `mapstructure:"initial_interval"`
+
+	// MaxInterval caps the wait between failures.
+	MaxInterval time.Duration `mapstructure:"max_interval"`
+}
+
+var _ component.Config = (*Config)(nil)
+
+// Validate checks that UAA and the client are configured.
+func (cfg *Config) Validate() error {
+	if cfg.Endpoint == "" {
+		return errors.New(`requires a non-empty "endpoint"`)
+	}
+	if cfg.ClientID == "" || cfg.ClientSecret == "" {
+		return errors.New(`requires "client_id" and "client_secret"`)
+	}
+	if cfg.Timeout <= 0 {
+		return errors.New(`"timeout" must be positive`)
+	}
+	if cfg.RefreshBefore < 0 {
+		return errors.New(`"refresh_before" must not be negative`)
+	}
+	if cfg.Backoff.InitialInterval <= 0 {
+		return errors.New(`"backoff.initial_interval" must be positive`)
+	}
+	if cfg.Backoff.MaxInterval < cfg.Backoff.InitialInterval {
+		return errors.New(`"backoff.max_interval" must not be less than "backoff.initial_interval"`)
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package uaaauthextension
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/extension"
+	"go.opentelemetry.io/collector/extension/extensionauth"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/credentials"
+)
+
+var (
+	_ extension.Extension           = (*uaaAuth)(nil)
+	_ extensionauth.HTTPClient      = (*uaaAuth)(nil)
+	_ extensionauth.GRPCClient      = (*uaaAuth)(nil)
+	_ credentials.PerRPCCredentials = (*perRPCCredentials)(nil)
+)
+
+var errNotStarted = errors.New("the UAA auth extension is not started")
+
+type uaaAuth struct {
+	cfg    *Config
+	logger *zap.Logger
+
+	tokens *tokenSource
+}
+
+func newUAAAuth(cfg *Config, logger *zap.Logger) *uaaAuth {
+	return &uaaAuth{cfg: cfg, logger: logger}
+}
+
+func (u *uaaAuth) Start(ctx context.Context, _ component.Host) error {
+	tlsConfig, err := u.cfg.TLS.LoadTLSConfig(ctx)
+	if err != nil {
+		return err
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	u.tokens = newTokenSource(u.cfg, u.logger, &http.Client{Transport: transport, Timeout: u.cfg.Timeout})
+	u.tokens.start()
+	return nil
+}
+
+func (u *uaaAuth) Shutdown(context.Context) error {
+	if u.tokens != nil {
+		u.tokens.stop()
+	}
+	return nil
+}
+
+// RoundTripper adds the token to the requests of HTTP exporters, dropping it
+// when the server rejects it.
+func (u *uaaAuth) RoundTripper(base http.RoundTripper) (http.RoundTripper, error) {
+	if u.tokens == nil {
+		return nil, errNotStarted
+	}
+	return &roundTripper{base: base, tokens: u.tokens}, nil
+}
+
+// PerRPCCredentials adds the token to the requests of gRPC exporters.
+func (u *uaaAuth) PerRPCCredentials() (credentials.PerRPCCredentials, error) {
+	if u.tokens == nil {
+		return nil, errNotStarted
+	}
+	return &perRPCCredentials{tokens: u.tokens}, nil
+}
+
+type roundTripper struct {
+	base   http.RoundTripper
+	tokens *tokenSource
+}
+
+func (rt *roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := rt.tokens.get(req.Context())
+	if err != nil {
+		if req.Body != nil {
+			_ = req.Body.Close()
+		}
+		return nil, err
+	}
+
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := rt.base.RoundTrip(req)
+	if err == nil && resp.StatusCode == http.StatusUnauthorized {
+		rt.tokens.reject(token)
+	}
+	return resp, err
+}
+
+type perRPCCredentials struct {
+	tokens *tokenSource
+}
+
+func (c *perRPCCredentials) GetRequestMetadata(ctx context.Context, _ ...string) (map[string]string, error) {
+	token, err := c.tokens.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{"authorization": "Bearer " + token}, nil
+}
+
+// RequireTransportSecurity keeps tokens from being sent in plain text.
+func (*perRPCCredentials) RequireTransportSecurity() bool {
+	return true
+}
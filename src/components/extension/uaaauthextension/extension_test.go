@@ -0,0 +1,336 @@
+package uaaauthextension_test
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/otel-collector-release/src/components/extension/uaaauthextension"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/component/componenttest"
+	"go.opentelemetry.io/collector/extension"
+	"go.opentelemetry.io/collector/extension/extensionauth"
+	"go.opentelemetry.io/collector/extension/extensiontest"
+	"google.golang.org/grpc/credentials"
+)
+
+var _ = Describe("UAA auth extension", func() {
+	var (
+		uaa *fakeUAA
+		cfg *uaaauthextension.Config
+		ext extension.Extension
+	)
+
+	BeforeEach(func() {
+		uaa = newFakeUAA()
+		DeferCleanup(uaa.server.Close)
+
+		cfg = uaaauthextension.NewFactory().CreateDefaultConfig().(*uaaauthextension.Config)
+		cfg.Endpoint = uaa.server.URL + "/"
+		cfg.ClientID = "otel-collector"
+		cfg.ClientSecret = "secret"
+		cfg.TLS.CAFile = uaa.caFile
+		cfg.Backoff.InitialInterval = 20 * time.Millisecond
+		cfg.Backoff.MaxInterval = 80 * time.Millisecond
+	})
+
+	JustBeforeEach(func() {
+		f := uaaauthextension.NewFactory()
+		var err error
+		ext, err = f.Create(context.Background(), extensiontest.NewNopSettings(f.Type()), cfg)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ext.Start(context.Background(), componenttest.NewNopHost())).To(Succeed())
+		DeferCleanup(ext.Shutdown, context.Background())
+	})
+
+	perRPCCredentials := func() credentials.PerRPCCredentials {
+		creds, err := ext.(extensionauth.GRPCClient).PerRPCCredentials()
+		Expect(err).NotTo(HaveOccurred())
+		return creds
+	}
+
+	authorization := func(creds credentials.PerRPCCredentials) (string, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		md, err := creds.GetRequestMetadata(ctx)
+		return md["authorization"], err
+	}
+
+	It("adds a bearer token to gRPC requests", func() {
+		creds := perRPCCredentials()
+
+		Expect(authorization(creds)).To(Equal("Bearer token-1"))
+		Expect(creds.RequireTransportSecurity()).To(BeTrue())
+	})
+
+	It("adds a bearer token to HTTP requests", func() {
+		var auth []string
+		backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			auth = append(auth, r.Header.Get("Authorization"))
+		}))
+		DeferCleanup(backend.Close)
+
+		rt, err := ext.(extensionauth.HTTPClient).RoundTripper(http.DefaultTransport)
+		Expect(err).NotTo(HaveOccurred())
+		resp, err := (&http.Client{Transport: rt}).Get(backend.URL)
+		Expect(err).NotTo(HaveOccurred())
+		resp.Body.Close()
+
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+		Expect(auth).To(Equal([]string{"Bearer token-1"}))
+	})
+
+	It("caches the token", func() {
+		creds := perRPCCredentials()
+		for range 10 {
+			Expect(authorization(creds)).To(Equal("Bearer token-1"))
+		}
+
+		Expect(uaa.tokenRequests()).To(Equal(1))
+	})
+
+	Context("when scopes are configured", func() {
+		BeforeEach(func() {
+			cfg.Scopes = []string{"logs.write", "metrics.write"}
+		})
+
+		It("requests them", func() {
+			Expect(authorization(perRPCCredentials())).To(Equal("Bearer token-1"))
+
+			uaa.mu.Lock()
+			defer uaa.mu.Unlock()
+			Expect(uaa.scope).To(Equal("logs.write metrics.write"))
+		})
+	})
+
+	Context("when tokens are short lived", func() {
+		BeforeEach(func() {
+			uaa.expiresIn = 2
+			cfg.RefreshBefore = 1500 * time.Millisecond
+		})
+
+		It("refreshes the token before it expires", func() {
+			creds := perRPCCredentials()
+			Expect(authorization(creds)).To(Equal("Bearer token-1"))
+
+			Eventually(func() (string, error) { return authorization(creds) }, 2*time.Second).Should(Equal("Bearer token-2"))
+		})
+
+		It("uses the token until it expires while UAA fails", func() {
+			creds := perRPCCredentials()
+			Expect(authorization(creds)).To(Equal("Bearer token-1"))
+			uaa.fail(1000)
+
+			Eventually(uaa.tokenRequests, 2*time.Second).Should(BeNumerically(">", 1))
+			Expect(authorization(creds)).To(Equal("Bearer token-1"))
+
+			Eventually(func() error {
+				_, err := authorization(creds)
+				return err
+			}, 2*time.Second).Should(MatchError(ContainSubstring("failed to get UAA token: unexpected status 500")))
+		})
+	})
+
+	Context("when UAA fails", func() {
+		BeforeEach(func() {
+			uaa.fail(3)
+		})
+
+		It("retries with backoff", func() {
+			creds := perRPCCredentials()
+			_, err := authorization(creds)
+			Expect(err).To(MatchError(ContainSubstring("failed to get UAA token: unexpected status 500")))
+
+			Eventually(func() (string, error) { return authorization(creds) }).Should(Equal("Bearer token-1"))
+
+			uaa.mu.Lock()
+			defer uaa.mu.Unlock()
+			Expect(uaa.requestTimes).To(HaveLen(4))
+			for i, wait := range []time.Duration{20 * time.Millisecond, 40 * time.Millisecond, 80 * time.Millisecond} {
+				Expect(uaa.requestTimes[i+1].Sub(uaa.requestTimes[i])).To(BeNumerically(">=", wait))
+			}
+		})
+	})
+
+	It("requests a new token when the server rejects the token", func() {
+		backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != "Bearer token-2" {
+				w.WriteHeader(http.StatusUnauthorized)
+			}
+		}))
+		DeferCleanup(backend.Close)
+
+		rt, err := ext.(extensionauth.HTTPClient).RoundTripper(http.DefaultTransport)
+		Expect(err).NotTo(HaveOccurred())
+		client := &http.Client{Transport: rt}
+		status := func() (int, error) {
+			resp, err := client.Get(backend.URL)
+			if err != nil {
+				return 0, err
+			}
+			resp.Body.Close()
+			return resp.StatusCode, nil
+		}
+
+		Expect(status()).To(Equal(http.StatusUnauthorized))
+		Eventually(status).Should(Equal(http.StatusOK))
+		Expect(uaa.tokenRequests()).To(Equal(2))
+	})
+
+	It("requests new tokens with backoff while the server keeps rejecting them", func() {
+		backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusUnauthorized)
+		}))
+		DeferCleanup(backend.Close)
+
+		rt, err := ext.(extensionauth.HTTPClient).RoundTripper(http.DefaultTransport)
+		Expect(err).NotTo(HaveOccurred())
+		client := &http.Client{Transport: rt}
+
+		var errs []error
+		Eventually(func() int {
+			resp, err := client.Get(backend.URL)
+			if err != nil {
+				errs = append(errs, err)
+			} else {
+				resp.Body.Close()
+			}
+			return uaa.tokenRequests()
+		}, 2*time.Second, time.Millisecond).Should(BeNumerically(">=", 5))
+		Expect(errs).To(ContainElement(MatchError(ContainSubstring("the last tokens were rejected"))))
+
+		uaa.mu.Lock()
+		defer uaa.mu.Unlock()
+		for i, wait := range []time.Duration{20 * time.Millisecond, 40 * time.Millisecond, 80 * time.Millisecond} {
+			Expect(uaa.requestTimes[i+2].Sub(uaa.requestTimes[i+1])).To(BeNumerically(">=", wait))
+		}
+	})
+
+	It("stops requesting tokens once shut down", func() {
+		uaa.fail(1000)
+		Eventually(uaa.tokenRequests).Should(BeNumerically(">", 2))
+
+		Expect(ext.Shutdown(context.Background())).To(Succeed())
+		requests := uaa.tokenRequests()
+		Consistently(uaa.tokenRequests, 200*time.Millisecond).Should(Equal(requests))
+	})
+
+	Describe("Config", func() {
+		var cfg *uaaauthextension.Config
+
+		BeforeEach(func() {
+			cfg = uaaauthextension.NewFactory().CreateDefaultConfig().(*uaaauthextension.Config)
+			cfg.Endpoint = "https://uaa.sys.example.com"
+			cfg.ClientID = "otel-collector"
+			cfg.ClientSecret = "secret"
+		})
+
+		It("is valid with defaults", func() {
+			Expect(cfg.Validate()).To(Succeed())
+			Expect(cfg.Timeout).To(Equal(10 * time.Second))
+			Expect(cfg.RefreshBefore).To(Equal(time.Minute))
+		})
+
+		It("requires UAA and the client", func() {
+			cfg.Endpoint = ""
+			Expect(cfg.Validate()).To(MatchError(ContainSubstring(`"endpoint"`)))
+
+			cfg.Endpoint = "https://uaa.sys.example.com"
+			cfg.ClientSecret = ""
+			Expect(cfg.Validate()).To(MatchError(ContainSubstring(`"client_id" and "client_secret"`)))
+		})
+
+		It("requires a usable backoff", func() {
+			cfg.Backoff.InitialInterval = 0
+			Expect(cfg.Validate()).To(MatchError(ContainSubstring("backoff.initial_interval")))
+
+			cfg.Backoff.InitialInterval = time.Minute
+			cfg.Backoff.MaxInterval = time.Second
+			Expect(cfg.Validate()).To(MatchError(ContainSubstring("backoff.max_interval")))
+		})
+
+		It("rejects a negative refresh_before", func() {
+			cfg.RefreshBefore = -time.Second
+			Expect(cfg.Validate()).To(MatchError(ContainSubstring("refresh_before")))
+		})
+
+		It("is registered as uaaauth", func() {
+			Expect(uaaauthextension.NewFactory().Type()).To(Equal(component.MustNewType("uaaauth")))
+		})
+	})
+})
+
+// fakeUAA serves the UAA token endpoint, issuing token-1, token-2 and so on
+// to the otel-collector client.
+type fakeUAA struct {
+	server *httptest.Server
+	caFile string
+
+	mu           sync.Mutex
+	tokens       int
+	expiresIn    int
+	failures     int
+	scope        string
+	requestTimes []time.Time
+}
+
+func newFakeUAA() *fakeUAA {
+	f := &fakeUAA{expiresIn: 3600}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /oauth/token", f.token)
+	f.server = httptest.NewTLSServer(mux)
+
+	f.caFile = filepath.Join(GinkgoT().TempDir(), "ca.crt")
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: f.server.Certificate().Raw})
+	Expect(os.WriteFile(f.caFile, caPEM, 0600)).To(Succeed())
+	return f
+}
+
+func (f *fakeUAA) token(w http.ResponseWriter, r *http.Request) {
+	user, pass, ok := r.BasicAuth()
+	if !ok || user != "otel-collector" || pass != "secret" || r.FormValue("grant_type") != "client_credentials" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.requestTimes = append(f.requestTimes, time.Now())
+	f.scope = r.FormValue("scope")
+	if f.failures > 0 {
+		f.failures--
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	f.tokens++
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"access_token": fmt.Sprintf("token-%d", f.tokens),
+		"token_type":   "bearer",
+		"expires_in":   f.expiresIn,
+	})
+}
+
+// fail fails the next n token requests.
+func (f *fakeUAA) fail(n int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.failures = n
+}
+
+func (f *fakeUAA) tokenRequests() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.requestTimes)
+}
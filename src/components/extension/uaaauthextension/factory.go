@@ -0,0 +1,41 @@
+// Package uaaauthextension implements a client auth extension that gets
+// tokens from UAA with the client credentials grant and adds them as bearer
+// tokens to the requests of gRPC and HTTP exporters.
+package uaaauthextension
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/extension"
+)
+
+const stability = component.StabilityLevelAlpha
+
+var componentType = component.MustNewType("uaaauth")
+
+// NewFactory creates a factory for the UAA auth extension.
+func NewFactory() extension.Factory {
+	return extension.NewFactory(
+		componentType,
+		createDefaultConfig,
+		createExtension,
+		stability,
+	)
+}
+
+func createDefaultConfig() component.Config {
+	return &Config{
+		Timeout:       10 * time.Second,
+		RefreshBefore: time.Minute,
+		Backoff: BackoffConfig{
+			InitialInterval: time.Second,
+			MaxInterval:     time.Minute,
+		},
+	}
+}
+
+func createExtension(_ context.Context, set extension.Settings, cfg component.Config) (extension.Extension, error) {
+	return newUAAAuth(cfg.(*Config), set.Logger), nil
+}
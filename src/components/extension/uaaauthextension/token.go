@@ -0,0 +1,230 @@
+package uaaauthextension
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// errTokenRejected fails requests while a token is held back after rejected
+// tokens.
+var errTokenRejected = errors.New("failed to get UAA token: the last tokens were rejected, retrying")
+
+// tokenSource keeps a UAA token, refreshing it in the background before it
+// expires and retrying with backoff while UAA fails.
+type tokenSource struct {
+	cfg        *Config
+	logger     *zap.Logger
+	tokenURL   string
+	httpClient *http.Client
+
+	mu     sync.Mutex
+	token  string
+	expiry time.Time
+	// lastErr is why the last token request failed, or why no token is
+	// requested after rejected ones, nil once one succeeds.
+	lastErr error
+	// updated is closed and replaced whenever a token request completes.
+	updated chan struct{}
+
+	// refresh asks for a token right away, after a token was rejected.
+	refresh  chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+func newTokenSource(cfg *Config, logger *zap.Logger, httpClient *http.Client) *tokenSource {
+	return &tokenSource{
+		cfg:        cfg,
+		logger:     logger,
+		tokenURL:   strings.TrimSuffix(cfg.Endpoint, "/") + "/oauth/token",
+		httpClient: httpClient,
+		updated:    make(chan struct{}),
+		refresh:    make(chan struct{}, 1),
+		done:       make(chan struct{}),
+	}
+}
+
+func (s *tokenSource) start() {
+	s.wg.Add(1)
+	go s.run()
+}
+
+func (s *tokenSource) stop() {
+	s.stopOnce.Do(func() {
+		close(s.done)
+		s.wg.Wait()
+	})
+}
+
+// get returns the current token. Before the first token request completes
+// it waits for it, afterwards it fails right away while UAA fails and there
+// is no unexpired token.
+func (s *tokenSource) get(ctx context.Context) (string, error) {
+	for {
+		s.mu.Lock()
+		token, expiry, lastErr, updated := s.token, s.expiry, s.lastErr, s.updated
+		s.mu.Unlock()
+
+		if token != "" && time.Now().Before(expiry) {
+			return token, nil
+		}
+		if lastErr != nil {
+			return "", lastErr
+		}
+		select {
+		case <-updated:
+		case <-ctx.Done():
+			return "", fmt.Errorf("failed to get UAA token: %w", ctx.Err())
+		case <-s.done:
+			return "", errors.New("failed to get UAA token: extension is shut down")
+		}
+	}
+}
+
+// reject drops a token the server did not accept, for instance because it
+// was revoked, and has a new one requested.
+func (s *tokenSource) reject(token string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if token != s.token {
+		return
+	}
+	s.token = ""
+	select {
+	case s.refresh <- struct{}{}:
+	default:
+	}
+}
+
+func (s *tokenSource) run() {
+	defer s.wg.Done()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-s.done
+		cancel()
+	}()
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	backoff := s.cfg.Backoff.InitialInterval
+
+	// A rejected token is replaced right away, but the next one only
+	// rejectBackoff later, doubled for every further replacement until a
+	// token lasts until it is refreshed, so that a backend rejecting every
+	// token, for instance as the client lacks a scope, does not have a token
+	// requested for every export.
+	var (
+		rejectBackoff time.Duration
+		// replaced is when the last rejected token was replaced.
+		replaced time.Time
+		// replacing is whether the next request replaces a rejected token.
+		replacing bool
+	)
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-timer.C:
+		case <-s.refresh:
+			timer.Stop()
+			replacing = true
+			if wait := time.Until(replaced.Add(rejectBackoff)); wait > 0 {
+				s.logger.Warn("UAA token was rejected again, retrying", zap.Duration("retry_in", wait))
+				s.complete(func() { s.lastErr = errTokenRejected })
+				timer.Reset(wait)
+				continue
+			}
+		}
+		token, lifetime, err := s.request(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			s.logger.Warn("Failed to get UAA token, retrying", zap.Duration("retry_in", backoff), zap.Error(err))
+			s.complete(func() { s.lastErr = err })
+			timer.Reset(backoff)
+			backoff = min(2*backoff, s.cfg.Backoff.MaxInterval)
+			continue
+		}
+
+		s.complete(func() {
+			s.token = token
+			s.expiry = time.Now().Add(lifetime)
+			s.lastErr = nil
+		})
+		timer.Reset(max(lifetime-s.cfg.RefreshBefore, lifetime/2))
+		backoff = s.cfg.Backoff.InitialInterval
+		if replacing {
+			replaced = time.Now()
+			rejectBackoff = min(max(2*rejectBackoff, s.cfg.Backoff.InitialInterval), s.cfg.Backoff.MaxInterval)
+			replacing = false
+		} else {
+			rejectBackoff = 0
+		}
+	}
+}
+
+// complete updates the token under the lock and wakes up the waiting
+// requests.
+func (s *tokenSource) complete(update func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	update()
+	close(s.updated)
+	s.updated = make(chan struct{})
+}
+
+type uaaTokenResponse struct {
+	AccessToken string `json:"access_token"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+// request gets a token with the client credentials grant.
+func (s *tokenSource) request(ctx context.Context) (string, time.Duration, error) {
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(s.cfg.Scopes) > 0 {
+		form.Set("scope", strings.Join(s.cfg.Scopes, " "))
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", 0, err
+	}
+	req.SetBasicAuth(url.QueryEscape(s.cfg.ClientID), url.QueryEscape(string(s.cfg.ClientSecret)))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to get UAA token: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return "", 0, fmt.Errorf("failed to get UAA token: unexpected status %s", resp.Status)
+	}
+
+	var body uaaTokenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", 0, fmt.Errorf("failed to get UAA token: %w", err)
+	}
+	if body.AccessToken == "" {
+		return "", 0, errors.New("failed to get UAA token: response has no access_token")
+	}
+	if body.ExpiresIn <= 0 {
+		return "", 0, errors.New("failed to get UAA token: response has no expires_in")
+	}
+	return body.AccessToken, time.Duration(body.ExpiresIn) * time.Second, nil
+}
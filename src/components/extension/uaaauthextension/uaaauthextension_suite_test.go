@@ -0,0 +1,13 @@
+package uaaauthextension_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestUAAAuthExtension(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "UAA Auth Extension Suite")
+}
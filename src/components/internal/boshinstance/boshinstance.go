@@ -0,0 +1,114 @@
+// Package boshinstance reads the files the BOSH agent writes on every VM to
+// describe the instance.
+package boshinstance
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"slices"
+	"sort"
+)
+
+const (
+	// EnvSpecFile is the environment variable with the path of a copy of the
+	// instance spec to use by default, for when the collector can't read the
+	// one the BOSH agent writes, like under BPM.
+	EnvSpecFile = "OTELCOL_BOSH_SPEC_FILE"
+
+	DefaultSettingsPath        = "/var/vcap/bosh/settings.json"
+	DefaultStemcellVersionPath = "/var/vcap/bosh/etc/stemcell_version"
+
+	defaultSpecPath = "/var/vcap/bosh/spec.json"
+)
+
+// DefaultSpecPath is the instance spec to read by default, the one of
+// EnvSpecFile when set and the one the BOSH agent writes otherwise.
+func DefaultSpecPath() string {
+	if path := os.Getenv(EnvSpecFile); path != "" {
+		return path
+	}
+	return defaultSpecPath
+}
+
+// Spec is the subset of the instance spec the BOSH agent writes to
+// /var/vcap/bosh/spec.json that identifies the instance.
+type Spec struct {
+	Deployment string             `json:"deployment"`
+	Name       string             `json:"name"`
+	ID         string             `json:"id"`
+	AZ         string             `json:"az"`
+	IP         string             `json:"ip"`
+	Networks   map[string]network `json:"networks"`
+}
+
+// agentSettings is the subset of /var/vcap/bosh/settings.json the instance
+// IP is read from when the spec has none.
+type agentSettings struct {
+	Networks map[string]network `json:"networks"`
+}
+
+type network struct {
+	IP      string   `json:"ip"`
+	Default []string `json:"default"`
+}
+
+// ReadSpec reads the instance spec at path.
+func ReadSpec(path string) (Spec, error) {
+	var spec Spec
+	if err := readJSON(path, &spec); err != nil {
+		return Spec{}, fmt.Errorf("failed to read BOSH instance spec: %w", err)
+	}
+	return spec, nil
+}
+
+// InstanceIP returns the IP of the instance: the one of the spec, falling
+// back to the network with the default gateway in the spec and then in the
+// agent settings at settingsPath. The settings are skipped when settingsPath
+// is empty or does not exist.
+func (s Spec) InstanceIP(settingsPath string) (string, error) {
+	if s.IP != "" {
+		return s.IP, nil
+	}
+	if ip := defaultIP(s.Networks); ip != "" {
+		return ip, nil
+	}
+	if settingsPath == "" {
+		return "", nil
+	}
+	var settings agentSettings
+	if err := readJSON(settingsPath, &settings); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return "", fmt.Errorf("failed to read BOSH agent settings: %w", err)
+	}
+	return defaultIP(settings.Networks), nil
+}
+
+// defaultIP returns the IP of the network that provides the default gateway,
+// falling back to the first network by name.
+func defaultIP(networks map[string]network) string {
+	names := make([]string, 0, len(networks))
+	for name := range networks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if slices.Contains(networks[name].Default, "gateway") {
+			return networks[name].IP
+		}
+	}
+	if len(names) > 0 {
+		return networks[names[0]].IP
+	}
+	return ""
+}
+
+func readJSON(path string, v any) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
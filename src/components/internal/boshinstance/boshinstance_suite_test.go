@@ -0,0 +1,13 @@
+package boshinstance_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestBOSHInstance(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "BOSH Instance Suite")
+}
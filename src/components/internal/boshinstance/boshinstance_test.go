@@ -0,0 +1,51 @@
+package boshinstance_test
+
+import (
+	"path/filepath"
+
+	"code.cloudfoundry.org/otel-collector-release/src/components/internal/boshinstance"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("BOSH instance", func() {
+	settingsPath := filepath.Join("testdata", "settings.json")
+
+	It("reads the spec", func() {
+		spec, err := boshinstance.ReadSpec(filepath.Join("testdata", "spec.json"))
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(spec.Deployment).To(Equal("cf"))
+		Expect(spec.Name).To(Equal("router"))
+		Expect(spec.ID).To(Equal("0c5b9b3a-4a4e-4a3c-8d7a-7c0a9e1f6b2d"))
+		Expect(spec.AZ).To(Equal("z1"))
+		Expect(spec.InstanceIP(settingsPath)).To(Equal("10.0.1.12"))
+	})
+
+	It("fails when the spec cannot be read", func() {
+		_, err := boshinstance.ReadSpec(filepath.Join("testdata", "missing.json"))
+		Expect(err).To(MatchError(ContainSubstring("failed to read BOSH instance spec")))
+	})
+
+	It("uses the IP of the network with the default gateway when the spec has no IP", func() {
+		spec, err := boshinstance.ReadSpec(filepath.Join("testdata", "spec_without_ip.json"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(spec.InstanceIP(settingsPath)).To(Equal("10.0.16.7"))
+	})
+
+	It("uses the IP of the agent settings when the spec has no networks", func() {
+		spec, err := boshinstance.ReadSpec(filepath.Join("testdata", "spec_without_networks.json"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(spec.InstanceIP(settingsPath)).To(Equal("10.0.1.12"))
+		Expect(spec.InstanceIP(filepath.Join("testdata", "missing.json"))).To(BeEmpty())
+		Expect(spec.InstanceIP("")).To(BeEmpty())
+	})
+
+	It("reads the spec of OTELCOL_BOSH_SPEC_FILE by default", func() {
+		GinkgoT().Setenv(boshinstance.EnvSpecFile, "")
+		Expect(boshinstance.DefaultSpecPath()).To(Equal("/var/vcap/bosh/spec.json"))
+
+		GinkgoT().Setenv(boshinstance.EnvSpecFile, "/var/vcap/jobs/otel-collector/config/bosh/spec.json")
+		Expect(boshinstance.DefaultSpecPath()).To(Equal("/var/vcap/jobs/otel-collector/config/bosh/spec.json"))
+	})
+})
@@ -0,0 +1,51 @@
+// Package exporterstatus reports the status of exporters that send to a
+// single destination as component status events, so that the health
+// extension can see a failing exporter.
+package exporterstatus
+
+import (
+	"sync"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/component/componentstatus"
+	"go.opentelemetry.io/collector/consumer/consumererror"
+)
+
+// Reporter reports failing to reach the destination as a recoverable error,
+// and the next successful send as a recovery. The zero value is ready to be
+// started.
+type Reporter struct {
+	host component.Host
+
+	// failing is whether the destination could not be reached on the last
+	// send, so that only changes are reported as status events.
+	mu      sync.Mutex
+	failing bool
+}
+
+// Start sets the host status events are reported to.
+func (r *Reporter) Start(host component.Host) {
+	r.host = host
+}
+
+// Report reports the result of a send. Data the destination rejects, a
+// permanent error, says nothing about its health.
+func (r *Reporter) Report(err error) {
+	if consumererror.IsPermanent(err) {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	failing := err != nil
+	if failing == r.failing {
+		return
+	}
+	r.failing = failing
+	if failing {
+		componentstatus.ReportStatus(r.host, componentstatus.NewRecoverableErrorEvent(err))
+	} else {
+		componentstatus.ReportStatus(r.host, componentstatus.NewEvent(componentstatus.StatusOK))
+	}
+}
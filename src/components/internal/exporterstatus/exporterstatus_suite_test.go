@@ -0,0 +1,13 @@
+package exporterstatus_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestExporterStatus(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Exporter Status Suite")
+}
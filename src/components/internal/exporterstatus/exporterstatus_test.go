@@ -0,0 +1,55 @@
+package exporterstatus_test
+
+import (
+	"errors"
+
+	"code.cloudfoundry.org/otel-collector-release/src/components/internal/exporterstatus"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/component/componentstatus"
+	"go.opentelemetry.io/collector/component/componenttest"
+	"go.opentelemetry.io/collector/consumer/consumererror"
+)
+
+var _ = Describe("Reporter", func() {
+	var (
+		host     *statusHost
+		reporter *exporterstatus.Reporter
+	)
+
+	BeforeEach(func() {
+		host = &statusHost{Host: componenttest.NewNopHost()}
+		reporter = &exporterstatus.Reporter{}
+		reporter.Start(host)
+	})
+
+	It("reports failing and recovering once each", func() {
+		reporter.Report(nil)
+		reporter.Report(errors.New("connection refused"))
+		reporter.Report(errors.New("connection refused"))
+		reporter.Report(nil)
+		reporter.Report(nil)
+
+		Expect(host.events).To(HaveLen(2))
+		Expect(host.events[0].Status()).To(Equal(componentstatus.StatusRecoverableError))
+		Expect(host.events[0].Err()).To(MatchError("connection refused"))
+		Expect(host.events[1].Status()).To(Equal(componentstatus.StatusOK))
+	})
+
+	It("ignores data the destination rejects", func() {
+		reporter.Report(consumererror.NewPermanent(errors.New("invalid data")))
+
+		Expect(host.events).To(BeEmpty())
+	})
+})
+
+// statusHost records the status events reported to it.
+type statusHost struct {
+	component.Host
+	events []*componentstatus.Event
+}
+
+func (h *statusHost) Report(ev *componentstatus.Event) {
+	h.events = append(h.events, ev)
+}
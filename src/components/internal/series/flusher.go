@@ -0,0 +1,56 @@
+package series
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.uber.org/zap"
+)
+
+// Flusher passes on the metrics of a connector at an interval, and a last
+// time when it is shut down, since connectors are shut down before the
+// exporters of the pipelines they feed.
+type Flusher struct {
+	logger  *zap.Logger
+	next    consumer.Metrics
+	metrics func(now pcommon.Timestamp) pmetric.Metrics
+	ticker  *ticker
+}
+
+// NewFlusher creates a flusher passing on what metrics returns to next.
+func NewFlusher(interval time.Duration, logger *zap.Logger, next consumer.Metrics, metrics func(now pcommon.Timestamp) pmetric.Metrics) *Flusher {
+	f := &Flusher{
+		logger:  logger,
+		next:    next,
+		metrics: metrics,
+	}
+	f.ticker = newTicker(interval, func(time.Time) {
+		f.flush(context.Background())
+	})
+	return f
+}
+
+func (f *Flusher) Start() {
+	f.ticker.start()
+}
+
+// Shutdown stops the flushes and passes on the metrics a last time.
+func (f *Flusher) Shutdown(ctx context.Context) error {
+	f.ticker.stop()
+	return f.flush(ctx)
+}
+
+func (f *Flusher) flush(ctx context.Context) error {
+	md := f.metrics(pcommon.NewTimestampFromTime(time.Now()))
+	if md.DataPointCount() == 0 {
+		return nil
+	}
+	err := f.next.ConsumeMetrics(ctx, md)
+	if err != nil {
+		f.logger.Error("Failed to pass on metrics", zap.Error(err))
+	}
+	return err
+}
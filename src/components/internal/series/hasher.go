@@ -0,0 +1,83 @@
+// Package series identifies the series of metrics by a hash of their
+// attributes and removes the series that expire, for the components that
+// keep state per series. Store and Flusher hold and pass on that state for
+// connectors that aggregate what they receive into cumulative metrics.
+package series
+
+import (
+	"encoding/binary"
+	"hash"
+	"hash/fnv"
+	"math"
+	"sort"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+)
+
+// ID identifies a series by a hash of what tells it apart, like its
+// resource and datapoint attributes.
+type ID [16]byte
+
+// Hasher hashes attribute maps in key order, so that the same attributes
+// produce the same ID regardless of their insertion order.
+type Hasher struct {
+	h    hash.Hash
+	keys []string
+	buf  [8]byte
+}
+
+// NewHasher creates a hasher using FNV-128a.
+func NewHasher() *Hasher {
+	return &Hasher{h: fnv.New128a()}
+}
+
+func (h *Hasher) Reset() {
+	h.h.Reset()
+}
+
+// WriteID writes an ID, like the one of a resource the series shares with
+// others.
+func (h *Hasher) WriteID(id ID) {
+	h.h.Write(id[:])
+}
+
+// WriteString writes a string, terminated so that it can't run into what
+// follows.
+func (h *Hasher) WriteString(s string) {
+	h.h.Write([]byte(s))
+	h.h.Write([]byte{0})
+}
+
+// WriteMap writes the keys and typed values of an attribute map.
+func (h *Hasher) WriteMap(m pcommon.Map) {
+	h.keys = h.keys[:0]
+	for k := range m.All() {
+		h.keys = append(h.keys, k)
+	}
+	sort.Strings(h.keys)
+
+	for _, k := range h.keys {
+		v, _ := m.Get(k)
+		h.h.Write([]byte(k))
+		h.h.Write([]byte{0, byte(v.Type())})
+		switch v.Type() {
+		case pcommon.ValueTypeInt:
+			binary.LittleEndian.PutUint64(h.buf[:], uint64(v.Int()))
+			h.h.Write(h.buf[:])
+		case pcommon.ValueTypeDouble:
+			binary.LittleEndian.PutUint64(h.buf[:], math.Float64bits(v.Double()))
+			h.h.Write(h.buf[:])
+		default:
+			h.h.Write([]byte(v.AsString()))
+		}
+		h.h.Write([]byte{0})
+	}
+	h.h.Write([]byte{0xff})
+}
+
+// Sum is the ID of what was written since the last reset.
+func (h *Hasher) Sum() ID {
+	var id ID
+	h.h.Sum(id[:0])
+	return id
+}
@@ -0,0 +1,217 @@
+package series_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"code.cloudfoundry.org/otel-collector-release/src/components/internal/series"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.opentelemetry.io/collector/consumer/consumertest"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.uber.org/zap"
+)
+
+var _ = Describe("Hasher", func() {
+	hash := func(m pcommon.Map) series.ID {
+		h := series.NewHasher()
+		h.WriteMap(m)
+		return h.Sum()
+	}
+
+	It("hashes the same attributes the same regardless of their order", func() {
+		a := pcommon.NewMap()
+		a.PutStr("method", "GET")
+		a.PutInt("status", 200)
+		b := pcommon.NewMap()
+		b.PutInt("status", 200)
+		b.PutStr("method", "GET")
+
+		Expect(hash(a)).To(Equal(hash(b)))
+	})
+
+	It("tells values of different types apart", func() {
+		a := pcommon.NewMap()
+		a.PutInt("status", 200)
+		b := pcommon.NewMap()
+		b.PutStr("status", "200")
+
+		Expect(hash(a)).NotTo(Equal(hash(b)))
+	})
+
+	It("tells keys and values apart", func() {
+		a := pcommon.NewMap()
+		a.PutStr("ab", "c")
+		b := pcommon.NewMap()
+		b.PutStr("a", "bc")
+
+		Expect(hash(a)).NotTo(Equal(hash(b)))
+	})
+
+	It("starts over after a reset", func() {
+		m := pcommon.NewMap()
+		m.PutStr("method", "GET")
+
+		h := series.NewHasher()
+		h.WriteString("other")
+		h.Reset()
+		h.WriteMap(m)
+
+		Expect(h.Sum()).To(Equal(hash(m)))
+	})
+})
+
+var _ = Describe("Sweeper", func() {
+	It("sweeps until it is shut down", func() {
+		var sweeps atomic.Int64
+		s := series.NewSweeper(0, func(time.Time) { sweeps.Add(1) })
+		s.Start()
+
+		Eventually(sweeps.Load, 3*time.Second).Should(BeNumerically(">=", 1))
+		s.Shutdown()
+		n := sweeps.Load()
+		Consistently(sweeps.Load, 1500*time.Millisecond).Should(Equal(n))
+	})
+
+	It("can be shut down without being started", func() {
+		s := series.NewSweeper(time.Minute, func(time.Time) {})
+		s.Shutdown()
+	})
+})
+
+var _ = Describe("Store", func() {
+	attrs := func(app string) pcommon.Map {
+		m := pcommon.NewMap()
+		m.PutStr("app_name", app)
+		return m
+	}
+
+	count := func(v *int) { *v++ }
+
+	values := func(s *series.Store[int]) map[string]int {
+		values := map[string]int{}
+		s.Range(func(attrs pcommon.Map, _ pcommon.Timestamp, v int) {
+			if _, ok := attrs.Get("otel.overflow"); ok {
+				values["overflow"] = v
+				return
+			}
+			app, _ := attrs.Get("app_name")
+			values[app.Str()] = v
+		})
+		return values
+	}
+
+	It("keeps a value and the first start time per series", func() {
+		s := series.NewStore[int](10, time.Minute)
+		s.Update(attrs("dora"), 10, count)
+		s.Update(attrs("dora"), 20, count)
+		s.Update(attrs("spring-music"), 30, count)
+
+		Expect(values(s)).To(Equal(map[string]int{"dora": 2, "spring-music": 1}))
+
+		var starts []pcommon.Timestamp
+		s.Range(func(_ pcommon.Map, start pcommon.Timestamp, _ int) {
+			starts = append(starts, start)
+		})
+		Expect(starts).To(ConsistOf(pcommon.Timestamp(10), pcommon.Timestamp(30)))
+	})
+
+	It("updates the overflow series once the most series are kept", func() {
+		s := series.NewStore[int](2, time.Minute)
+		s.Update(attrs("dora"), 10, count)
+		s.Update(attrs("spring-music"), 10, count)
+		s.Update(attrs("diego"), 10, count)
+		s.Update(attrs("uaa"), 10, count)
+		s.Update(attrs("dora"), 10, count)
+
+		Expect(values(s)).To(Equal(map[string]int{"dora": 2, "spring-music": 1, "overflow": 2}))
+	})
+
+	It("ranges over the series in the same order every time", func() {
+		s := series.NewStore[int](10, time.Minute)
+		for _, app := range []string{"dora", "spring-music", "diego", "uaa"} {
+			s.Update(attrs(app), 10, count)
+		}
+
+		order := func() []string {
+			var apps []string
+			s.Range(func(attrs pcommon.Map, _ pcommon.Timestamp, _ int) {
+				app, _ := attrs.Get("app_name")
+				apps = append(apps, app.Str())
+			})
+			return apps
+		}
+		Expect(order()).To(Equal(order()))
+	})
+
+	It("removes series that were not updated for max stale", func() {
+		s := series.NewStore[int](1, 100*time.Millisecond)
+		s.Start()
+		defer s.Shutdown()
+
+		s.Update(attrs("dora"), 10, count)
+		s.Update(attrs("spring-music"), 10, count)
+
+		Eventually(func() map[string]int { return values(s) }, 3*time.Second).Should(BeEmpty())
+
+		s.Update(attrs("spring-music"), 20, count)
+		Expect(values(s)).To(Equal(map[string]int{"spring-music": 1}))
+	})
+})
+
+var _ = Describe("Flusher", func() {
+	var (
+		sink    *consumertest.MetricsSink
+		flushes atomic.Int64
+	)
+
+	BeforeEach(func() {
+		sink = new(consumertest.MetricsSink)
+		flushes.Store(0)
+	})
+
+	metrics := func(now pcommon.Timestamp) pmetric.Metrics {
+		flushes.Add(1)
+		md := pmetric.NewMetrics()
+		dp := md.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty().Metrics().AppendEmpty().SetEmptyGauge().DataPoints().AppendEmpty()
+		dp.SetTimestamp(now)
+		return md
+	}
+
+	It("passes on the metrics at the interval", func() {
+		f := series.NewFlusher(50*time.Millisecond, zap.NewNop(), sink, metrics)
+		f.Start()
+		defer f.Shutdown(context.Background())
+
+		Eventually(sink.AllMetrics).Should(HaveLen(2))
+	})
+
+	It("passes on the metrics a last time when shut down", func() {
+		f := series.NewFlusher(time.Hour, zap.NewNop(), sink, metrics)
+		f.Start()
+
+		Expect(f.Shutdown(context.Background())).To(Succeed())
+		Expect(sink.AllMetrics()).To(HaveLen(1))
+		Expect(sink.AllMetrics()[0].ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0).Gauge().DataPoints().At(0).Timestamp()).NotTo(BeZero())
+	})
+
+	It("passes on nothing when there are no datapoints", func() {
+		f := series.NewFlusher(time.Hour, zap.NewNop(), sink, func(pcommon.Timestamp) pmetric.Metrics {
+			flushes.Add(1)
+			return pmetric.NewMetrics()
+		})
+
+		Expect(f.Shutdown(context.Background())).To(Succeed())
+		Expect(flushes.Load()).To(Equal(int64(1)))
+		Expect(sink.AllMetrics()).To(BeEmpty())
+	})
+
+	It("returns the error of the next consumer", func() {
+		f := series.NewFlusher(time.Hour, zap.NewNop(), consumertest.NewErr(errors.New("boom")), metrics)
+
+		Expect(f.Shutdown(context.Background())).To(MatchError("boom"))
+	})
+})
@@ -0,0 +1,123 @@
+package series
+
+import (
+	"bytes"
+	"slices"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+)
+
+// attributeOverflow marks the series that updates beyond the most series
+// kept go to.
+const attributeOverflow = "otel.overflow"
+
+// Store keeps a value per series for the connectors that aggregate what they
+// receive into cumulative metrics. Once maxSeries series are kept, updates
+// for new series go to a single series with the otel.overflow attribute.
+// Series that are not updated for maxStale are removed, and start again from
+// the zero value on their next update.
+type Store[T any] struct {
+	maxSeries int
+	maxStale  time.Duration
+
+	mu      sync.Mutex
+	hasher  *Hasher
+	series  map[ID]*entry[T]
+	sweeper *Sweeper
+}
+
+type entry[T any] struct {
+	attrs    pcommon.Map
+	start    pcommon.Timestamp
+	lastSeen time.Time
+	value    T
+}
+
+// NewStore creates a store, which removes stale series once started.
+func NewStore[T any](maxSeries int, maxStale time.Duration) *Store[T] {
+	s := &Store[T]{
+		maxSeries: maxSeries,
+		maxStale:  maxStale,
+		hasher:    NewHasher(),
+		series:    map[ID]*entry[T]{},
+	}
+	s.sweeper = NewSweeper(maxStale, s.sweep)
+	return s
+}
+
+func (s *Store[T]) Start() {
+	s.sweeper.Start()
+}
+
+// Shutdown stops removing stale series, it may be called without Start.
+func (s *Store[T]) Shutdown() {
+	s.sweeper.Shutdown()
+}
+
+// Update calls update with the value of the series of attrs, creating the
+// series with start as its start time when it is not kept.
+func (s *Store[T]) Update(attrs pcommon.Map, start pcommon.Timestamp, update func(v *T)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e := s.entry(attrs, start)
+	e.lastSeen = time.Now()
+	update(&e.value)
+}
+
+func (s *Store[T]) entry(attrs pcommon.Map, start pcommon.Timestamp) *entry[T] {
+	id := s.id(attrs)
+	if e, ok := s.series[id]; ok {
+		return e
+	}
+	if len(s.series) >= s.maxSeries {
+		attrs = pcommon.NewMap()
+		attrs.PutBool(attributeOverflow, true)
+		id = s.id(attrs)
+		if e, ok := s.series[id]; ok {
+			return e
+		}
+	}
+
+	e := &entry[T]{attrs: attrs, start: start}
+	s.series[id] = e
+	return e
+}
+
+func (s *Store[T]) id(attrs pcommon.Map) ID {
+	s.hasher.Reset()
+	s.hasher.WriteMap(attrs)
+	return s.hasher.Sum()
+}
+
+// Range calls f with every series, in the same order every time.
+func (s *Store[T]) Range(f func(attrs pcommon.Map, start pcommon.Timestamp, v T)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ids := make([]ID, 0, len(s.series))
+	for id := range s.series {
+		ids = append(ids, id)
+	}
+	slices.SortFunc(ids, func(a, b ID) int {
+		return bytes.Compare(a[:], b[:])
+	})
+
+	for _, id := range ids {
+		e := s.series[id]
+		f(e.attrs, e.start, e.value)
+	}
+}
+
+func (s *Store[T]) sweep(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, e := range s.series {
+		if now.Sub(e.lastSeen) > s.maxStale {
+			delete(s.series, id)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package series
+
+import (
+	"time"
+)
+
+const (
+	// minSweepInterval is the shortest interval at which expired series are
+	// removed.
+	minSweepInterval = time.Second
+
+	// sweepsPerWindow is the number of times expired series are removed per
+	// window, which bounds how long an expired series is kept.
+	sweepsPerWindow = 10
+)
+
+// Sweeper removes expired series in the background, sweeping a tenth of the
+// window they expire after, but no more than once a second.
+type Sweeper struct {
+	ticker *ticker
+}
+
+// NewSweeper creates a sweeper calling sweep with the current time.
+func NewSweeper(window time.Duration, sweep func(now time.Time)) *Sweeper {
+	return &Sweeper{
+		ticker: newTicker(max(window/sweepsPerWindow, minSweepInterval), sweep),
+	}
+}
+
+func (s *Sweeper) Start() {
+	s.ticker.start()
+}
+
+// Shutdown stops the sweeps, it may be called without Start.
+func (s *Sweeper) Shutdown() {
+	s.ticker.stop()
+}
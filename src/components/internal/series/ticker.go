@@ -0,0 +1,47 @@
+package series
+
+import (
+	"sync"
+	"time"
+)
+
+// ticker calls tick at an interval in the background until it is stopped.
+type ticker struct {
+	interval time.Duration
+	tick     func(now time.Time)
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+func newTicker(interval time.Duration, tick func(now time.Time)) *ticker {
+	return &ticker{
+		interval: interval,
+		tick:     tick,
+		done:     make(chan struct{}),
+	}
+}
+
+func (t *ticker) start() {
+	t.wg.Add(1)
+	go func() {
+		defer t.wg.Done()
+
+		ticker := time.NewTicker(t.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-t.done:
+				return
+			case now := <-ticker.C:
+				t.tick(now)
+			}
+		}
+	}()
+}
+
+// stop waits for a running tick to return, it may be called without start.
+func (t *ticker) stop() {
+	close(t.done)
+	t.wg.Wait()
+}
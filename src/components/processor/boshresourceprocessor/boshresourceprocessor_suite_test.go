@@ -0,0 +1,13 @@
+package boshresourceprocessor_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestBOSHResourceProcessor(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "BOSH Resource Processor Suite")
+}
@@ -0,0 +1,34 @@
+package boshresourceprocessor
+
+import (
+	"errors"
+
+	"go.opentelemetry.io/collector/component"
+)
+
+// Config defines the configuration for the BOSH resource processor.
+type Config struct {
+	// SpecPath is the instance spec written by the BOSH agent.
+	SpecPath string `mapstructure:"spec_path"`
+
+	// SettingsPath is the agent settings, used for the instance IP when the
+	// spec does not contain it.
+	SettingsPath string `mapstructure:"settings_path"`
+
+	// StemcellVersionPath is the file containing the stemcell version.
+	StemcellVersionPath string `mapstructure:"stemcell_version_path"`
+
+	// Override replaces attributes already present on the resource. When
+	// false, only missing attributes are added.
+	Override bool `mapstructure:"override"`
+}
+
+var _ component.Config = (*Config)(nil)
+
+// Validate checks that the instance spec path is set.
+func (cfg *Config) Validate() error {
+	if cfg.SpecPath == "" {
+		return errors.New(`requires a non-empty "spec_path"`)
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package boshresourceprocessor
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+
+	"code.cloudfoundry.org/otel-collector-release/src/components/internal/boshinstance"
+)
+
+const (
+	attributeBOSHDeployment      = "bosh.deployment"
+	attributeBOSHInstanceGroup   = "bosh.instance_group"
+	attributeBOSHID              = "bosh.id"
+	attributeBOSHAZ              = "bosh.az"
+	attributeBOSHStemcellVersion = "bosh.stemcell.version"
+	attributeHostIP              = "host.ip"
+)
+
+// detect reads the BOSH agent files and returns the resource attributes that
+// describe this instance. Only the spec is required; the settings and
+// stemcell version are skipped when they do not exist.
+func detect(cfg *Config) (map[string]string, error) {
+	spec, err := boshinstance.ReadSpec(cfg.SpecPath)
+	if err != nil {
+		return nil, err
+	}
+	ip, err := spec.InstanceIP(cfg.SettingsPath)
+	if err != nil {
+		return nil, err
+	}
+
+	attrs := map[string]string{
+		attributeBOSHDeployment:    spec.Deployment,
+		attributeBOSHInstanceGroup: spec.Name,
+		attributeBOSHID:            spec.ID,
+		attributeBOSHAZ:            spec.AZ,
+		attributeHostIP:            ip,
+	}
+
+	if cfg.StemcellVersionPath != "" {
+		version, err := os.ReadFile(cfg.StemcellVersionPath)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("failed to read stemcell version: %w", err)
+		}
+		attrs[attributeBOSHStemcellVersion] = strings.TrimSpace(string(version))
+	}
+
+	for k, v := range attrs {
+		if v == "" {
+			delete(attrs, k)
+		}
+	}
+	return attrs, nil
+}
@@ -0,0 +1,65 @@
+// Package boshresourceprocessor implements a processor that adds the identity
+// of the BOSH instance the collector runs on as resource attributes.
+package boshresourceprocessor
+
+import (
+	"context"
+
+	"code.cloudfoundry.org/otel-collector-release/src/components/internal/boshinstance"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/processor"
+	"go.opentelemetry.io/collector/processor/processorhelper"
+)
+
+const stability = component.StabilityLevelAlpha
+
+var componentType = component.MustNewType("boshresource")
+
+var processorCapabilities = consumer.Capabilities{MutatesData: true}
+
+// NewFactory creates a factory for the BOSH resource processor.
+func NewFactory() processor.Factory {
+	return processor.NewFactory(
+		componentType,
+		createDefaultConfig,
+		processor.WithLogs(createLogs, stability),
+		processor.WithMetrics(createMetrics, stability),
+		processor.WithTraces(createTraces, stability),
+	)
+}
+
+func createDefaultConfig() component.Config {
+	return &Config{
+		SpecPath:            boshinstance.DefaultSpecPath(),
+		SettingsPath:        boshinstance.DefaultSettingsPath,
+		StemcellVersionPath: boshinstance.DefaultStemcellVersionPath,
+	}
+}
+
+func createLogs(ctx context.Context, set processor.Settings, cfg component.Config, next consumer.Logs) (processor.Logs, error) {
+	p := newBOSHResourceProcessor(cfg.(*Config), set.Logger)
+	return processorhelper.NewLogs(ctx, set, cfg, next,
+		p.processLogs,
+		processorhelper.WithCapabilities(processorCapabilities),
+		processorhelper.WithStart(p.start),
+	)
+}
+
+func createMetrics(ctx context.Context, set processor.Settings, cfg component.Config, next consumer.Metrics) (processor.Metrics, error) {
+	p := newBOSHResourceProcessor(cfg.(*Config), set.Logger)
+	return processorhelper.NewMetrics(ctx, set, cfg, next,
+		p.processMetrics,
+		processorhelper.WithCapabilities(processorCapabilities),
+		processorhelper.WithStart(p.start),
+	)
+}
+
+func createTraces(ctx context.Context, set processor.Settings, cfg component.Config, next consumer.Traces) (processor.Traces, error) {
+	p := newBOSHResourceProcessor(cfg.(*Config), set.Logger)
+	return processorhelper.NewTraces(ctx, set, cfg, next,
+		p.processTraces,
+		processorhelper.WithCapabilities(processorCapabilities),
+		processorhelper.WithStart(p.start),
+	)
+}
@@ -0,0 +1,65 @@
+package boshresourceprocessor
+
+import (
+	"context"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.uber.org/zap"
+)
+
+type boshResourceProcessor struct {
+	cfg    *Config
+	logger *zap.Logger
+
+	attributes map[string]string
+}
+
+func newBOSHResourceProcessor(cfg *Config, logger *zap.Logger) *boshResourceProcessor {
+	return &boshResourceProcessor{cfg: cfg, logger: logger}
+}
+
+// start reads the BOSH agent files once. They only change when the instance
+// is recreated, which also restarts the collector.
+func (p *boshResourceProcessor) start(context.Context, component.Host) error {
+	attrs, err := detect(p.cfg)
+	if err != nil {
+		return err
+	}
+	p.attributes = attrs
+	p.logger.Info("Detected BOSH instance", zap.Any("attributes", attrs))
+	return nil
+}
+
+func (p *boshResourceProcessor) processLogs(_ context.Context, ld plog.Logs) (plog.Logs, error) {
+	for i := 0; i < ld.ResourceLogs().Len(); i++ {
+		p.apply(ld.ResourceLogs().At(i).Resource().Attributes())
+	}
+	return ld, nil
+}
+
+func (p *boshResourceProcessor) processMetrics(_ context.Context, md pmetric.Metrics) (pmetric.Metrics, error) {
+	for i := 0; i < md.ResourceMetrics().Len(); i++ {
+		p.apply(md.ResourceMetrics().At(i).Resource().Attributes())
+	}
+	return md, nil
+}
+
+func (p *boshResourceProcessor) processTraces(_ context.Context, td ptrace.Traces) (ptrace.Traces, error) {
+	for i := 0; i < td.ResourceSpans().Len(); i++ {
+		p.apply(td.ResourceSpans().At(i).Resource().Attributes())
+	}
+	return td, nil
+}
+
+func (p *boshResourceProcessor) apply(resource pcommon.Map) {
+	for k, v := range p.attributes {
+		if _, ok := resource.Get(k); ok && !p.cfg.Override {
+			continue
+		}
+		resource.PutStr(k, v)
+	}
+}
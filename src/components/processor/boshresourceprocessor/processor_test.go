@@ -0,0 +1,155 @@
+package boshresourceprocessor_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	"code.cloudfoundry.org/otel-collector-release/src/components/internal/boshinstance"
+	"code.cloudfoundry.org/otel-collector-release/src/components/processor/boshresourceprocessor"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.opentelemetry.io/collector/component/componenttest"
+	"go.opentelemetry.io/collector/consumer/consumertest"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.opentelemetry.io/collector/processor/processortest"
+)
+
+// The BOSH instance files are kept next to the internal boshinstance package,
+// which reads them.
+var boshTestdata = filepath.Join("..", "..", "internal", "boshinstance", "testdata")
+
+var _ = Describe("BOSH resource processor", func() {
+	var cfg *boshresourceprocessor.Config
+
+	BeforeEach(func() {
+		cfg = boshresourceprocessor.NewFactory().CreateDefaultConfig().(*boshresourceprocessor.Config)
+		cfg.SpecPath = filepath.Join(boshTestdata, "spec.json")
+		cfg.SettingsPath = filepath.Join(boshTestdata, "settings.json")
+		cfg.StemcellVersionPath = filepath.Join("testdata", "stemcell_version")
+	})
+
+	processMetrics := func(md pmetric.Metrics) pmetric.Metrics {
+		sink := new(consumertest.MetricsSink)
+		factory := boshresourceprocessor.NewFactory()
+		p, err := factory.CreateMetrics(context.Background(), processortest.NewNopSettings(factory.Type()), cfg, sink)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(p.Start(context.Background(), componenttest.NewNopHost())).To(Succeed())
+		DeferCleanup(p.Shutdown, context.Background())
+
+		Expect(p.ConsumeMetrics(context.Background(), md)).To(Succeed())
+		Expect(sink.AllMetrics()).To(HaveLen(1))
+		return sink.AllMetrics()[0]
+	}
+
+	It("adds the instance identity as resource attributes", func() {
+		md := pmetric.NewMetrics()
+		md.ResourceMetrics().AppendEmpty()
+
+		out := processMetrics(md)
+
+		Expect(out.ResourceMetrics().At(0).Resource().Attributes().AsRaw()).To(Equal(map[string]any{
+			"bosh.deployment":       "cf",
+			"bosh.instance_group":   "router",
+			"bosh.id":               "0c5b9b3a-4a4e-4a3c-8d7a-7c0a9e1f6b2d",
+			"bosh.az":               "z1",
+			"bosh.stemcell.version": "1.785",
+			"host.ip":               "10.0.1.12",
+		}))
+	})
+
+	It("keeps existing attributes by default", func() {
+		md := pmetric.NewMetrics()
+		md.ResourceMetrics().AppendEmpty().Resource().Attributes().PutStr("bosh.deployment", "cf-isolated")
+
+		out := processMetrics(md)
+
+		attrs := out.ResourceMetrics().At(0).Resource().Attributes().AsRaw()
+		Expect(attrs).To(HaveKeyWithValue("bosh.deployment", "cf-isolated"))
+		Expect(attrs).To(HaveKeyWithValue("bosh.instance_group", "router"))
+	})
+
+	It("replaces existing attributes when override is enabled", func() {
+		cfg.Override = true
+		md := pmetric.NewMetrics()
+		md.ResourceMetrics().AppendEmpty().Resource().Attributes().PutStr("bosh.deployment", "cf-isolated")
+
+		out := processMetrics(md)
+
+		Expect(out.ResourceMetrics().At(0).Resource().Attributes().AsRaw()).To(HaveKeyWithValue("bosh.deployment", "cf"))
+	})
+
+	It("uses the default gateway network when the spec has no top level IP", func() {
+		cfg.SpecPath = filepath.Join(boshTestdata, "spec_without_ip.json")
+		md := pmetric.NewMetrics()
+		md.ResourceMetrics().AppendEmpty()
+
+		out := processMetrics(md)
+
+		attrs := out.ResourceMetrics().At(0).Resource().Attributes().AsRaw()
+		Expect(attrs).To(HaveKeyWithValue("host.ip", "10.0.16.7"))
+		Expect(attrs).To(HaveKeyWithValue("bosh.instance_group", "diego-cell"))
+	})
+
+	It("falls back to the agent settings for the IP", func() {
+		cfg.SpecPath = filepath.Join(GinkgoT().TempDir(), "spec.json")
+		Expect(os.WriteFile(cfg.SpecPath, []byte(`{"deployment":"cf","name":"router"}`), 0600)).To(Succeed())
+		md := pmetric.NewMetrics()
+		md.ResourceMetrics().AppendEmpty()
+
+		out := processMetrics(md)
+
+		Expect(out.ResourceMetrics().At(0).Resource().Attributes().AsRaw()).To(HaveKeyWithValue("host.ip", "10.0.1.12"))
+	})
+
+	It("skips the stemcell version when the file does not exist", func() {
+		cfg.StemcellVersionPath = filepath.Join("testdata", "missing")
+		md := pmetric.NewMetrics()
+		md.ResourceMetrics().AppendEmpty()
+
+		out := processMetrics(md)
+
+		Expect(out.ResourceMetrics().At(0).Resource().Attributes().AsRaw()).NotTo(HaveKey("bosh.stemcell.version"))
+	})
+
+	It("reads the spec from the file of OTELCOL_BOSH_SPEC_FILE by default", func() {
+		GinkgoT().Setenv(boshinstance.EnvSpecFile, "")
+		Expect(boshresourceprocessor.NewFactory().CreateDefaultConfig().(*boshresourceprocessor.Config).SpecPath).To(Equal("/var/vcap/bosh/spec.json"))
+
+		GinkgoT().Setenv(boshinstance.EnvSpecFile, "/var/vcap/jobs/otel-collector/config/bosh/spec.json")
+		Expect(boshresourceprocessor.NewFactory().CreateDefaultConfig().(*boshresourceprocessor.Config).SpecPath).To(Equal("/var/vcap/jobs/otel-collector/config/bosh/spec.json"))
+	})
+
+	It("fails to start when the spec cannot be read", func() {
+		cfg.SpecPath = filepath.Join("testdata", "missing")
+		factory := boshresourceprocessor.NewFactory()
+		p, err := factory.CreateMetrics(context.Background(), processortest.NewNopSettings(factory.Type()), cfg, consumertest.NewNop())
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(p.Start(context.Background(), componenttest.NewNopHost())).To(MatchError(ContainSubstring("failed to read BOSH instance spec")))
+	})
+
+	It("adds the attributes to logs and traces", func() {
+		factory := boshresourceprocessor.NewFactory()
+
+		logsSink := new(consumertest.LogsSink)
+		lp, err := factory.CreateLogs(context.Background(), processortest.NewNopSettings(factory.Type()), cfg, logsSink)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(lp.Start(context.Background(), componenttest.NewNopHost())).To(Succeed())
+		ld := plog.NewLogs()
+		ld.ResourceLogs().AppendEmpty()
+		Expect(lp.ConsumeLogs(context.Background(), ld)).To(Succeed())
+		Expect(logsSink.AllLogs()[0].ResourceLogs().At(0).Resource().Attributes().AsRaw()).To(HaveKeyWithValue("bosh.id", "0c5b9b3a-4a4e-4a3c-8d7a-7c0a9e1f6b2d"))
+
+		tracesSink := new(consumertest.TracesSink)
+		tp, err := factory.CreateTraces(context.Background(), processortest.NewNopSettings(factory.Type()), cfg, tracesSink)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(tp.Start(context.Background(), componenttest.NewNopHost())).To(Succeed())
+		td := ptrace.NewTraces()
+		td.ResourceSpans().AppendEmpty()
+		Expect(tp.ConsumeTraces(context.Background(), td)).To(Succeed())
+		Expect(tracesSink.AllTraces()[0].ResourceSpans().At(0).Resource().Attributes().AsRaw()).To(HaveKeyWithValue("bosh.az", "z1"))
+	})
+})
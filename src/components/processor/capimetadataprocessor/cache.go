@@ -0,0 +1,77 @@
+package capimetadataprocessor
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// appMetadata is the cached result of looking up an app. Apps CAPI does not
+// know about are cached with no attributes so that they are not looked up on
+// every record.
+type appMetadata struct {
+	attributes map[string]string
+}
+
+type cacheEntry struct {
+	guid    string
+	value   appMetadata
+	expires time.Time
+}
+
+// lruCache is a size bounded cache that evicts the least recently used app.
+// Entries past their TTL are still returned, flagged as stale, so that callers
+// can keep using them while they are refreshed.
+type lruCache struct {
+	size int
+	ttl  time.Duration
+
+	mu    sync.Mutex
+	order *list.List
+	items map[string]*list.Element
+}
+
+func newLRUCache(size int, ttl time.Duration) *lruCache {
+	return &lruCache{
+		size:  size,
+		ttl:   ttl,
+		order: list.New(),
+		items: make(map[string]*list.Element, size),
+	}
+}
+
+// get returns the metadata for an app, whether it is still fresh, and
+// whether the app is cached at all.
+func (c *lruCache) get(guid string) (appMetadata, bool, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[guid]
+	if !ok {
+		return appMetadata{}, false, false
+	}
+	c.order.MoveToFront(el)
+	entry := el.Value.(*cacheEntry)
+	return entry.value, time.Now().Before(entry.expires), true
+}
+
+func (c *lruCache) put(guid string, value appMetadata) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expires := time.Now().Add(c.ttl)
+	if el, ok := c.items[guid]; ok {
+		c.order.MoveToFront(el)
+		entry := el.Value.(*cacheEntry)
+		entry.value = value
+		entry.expires = expires
+		return
+	}
+
+	c.items[guid] = c.order.PushFront(&cacheEntry{guid: guid, value: value, expires: expires})
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*cacheEntry).guid)
+	}
+}
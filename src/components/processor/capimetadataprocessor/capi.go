@@ -0,0 +1,203 @@
+package capimetadataprocessor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// tokenExpiryMargin is how long before its expiry a UAA token is replaced, so
+// that requests in flight do not fail with an expired token.
+const tokenExpiryMargin = 30 * time.Second
+
+var errUnauthorized = errors.New("unauthorized")
+
+// app is the subset of a CAPI v3 app and its space and organization the
+// processor adds to records.
+type app struct {
+	GUID        string
+	Name        string
+	SpaceGUID   string
+	SpaceName   string
+	OrgGUID     string
+	OrgName     string
+	Labels      map[string]string
+	Annotations map[string]string
+}
+
+// capiClient looks up apps in CAPI v3 with a UAA client credentials token.
+type capiClient struct {
+	capiURL      string
+	uaaURL       string
+	clientID     string
+	clientSecret string
+	httpClient   *http.Client
+
+	mu          sync.Mutex
+	token       string
+	tokenExpiry time.Time
+}
+
+// apps looks up a batch of apps, including their spaces and organizations,
+// in a single request. Apps CAPI does not return are omitted from the result.
+func (c *capiClient) apps(ctx context.Context, guids []string) (map[string]app, error) {
+	apps, err := c.listApps(ctx, guids)
+	if errors.Is(err, errUnauthorized) {
+		c.clearToken()
+		apps, err = c.listApps(ctx, guids)
+	}
+	return apps, err
+}
+
+type capiAppsResponse struct {
+	Resources []struct {
+		GUID          string `json:"guid"`
+		Name          string `json:"name"`
+		Relationships struct {
+			Space struct {
+				Data struct {
+					GUID string `json:"guid"`
+				} `json:"data"`
+			} `json:"space"`
+		} `json:"relationships"`
+		Metadata struct {
+			Labels      map[string]string `json:"labels"`
+			Annotations map[string]string `json:"annotations"`
+		} `json:"metadata"`
+	} `json:"resources"`
+	Included struct {
+		Spaces []struct {
+			GUID          string `json:"guid"`
+			Name          string `json:"name"`
+			Relationships struct {
+				Organization struct {
+					Data struct {
+						GUID string `json:"guid"`
+					} `json:"data"`
+				} `json:"organization"`
+			} `json:"relationships"`
+		} `json:"spaces"`
+		Organizations []struct {
+			GUID string `json:"guid"`
+			Name string `json:"name"`
+		} `json:"organizations"`
+	} `json:"included"`
+}
+
+func (c *capiClient) listApps(ctx context.Context, guids []string) (map[string]app, error) {
+	token, err := c.accessToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query := url.Values{
+		"guids":    {strings.Join(guids, ",")},
+		"include":  {"space.organization"},
+		"per_page": {fmt.Sprint(len(guids))},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.capiURL+"/v3/apps?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "bearer "+token)
+	req.Header.Set("Accept", "application/json")
+
+	var body capiAppsResponse
+	if err := c.do(req, &body); err != nil {
+		return nil, fmt.Errorf("failed to list apps: %w", err)
+	}
+
+	orgs := make(map[string]string, len(body.Included.Organizations))
+	for _, o := range body.Included.Organizations {
+		orgs[o.GUID] = o.Name
+	}
+	type space struct{ name, orgGUID string }
+	spaces := make(map[string]space, len(body.Included.Spaces))
+	for _, s := range body.Included.Spaces {
+		spaces[s.GUID] = space{name: s.Name, orgGUID: s.Relationships.Organization.Data.GUID}
+	}
+
+	apps := make(map[string]app, len(body.Resources))
+	for _, r := range body.Resources {
+		s := spaces[r.Relationships.Space.Data.GUID]
+		apps[r.GUID] = app{
+			GUID:        r.GUID,
+			Name:        r.Name,
+			SpaceGUID:   r.Relationships.Space.Data.GUID,
+			SpaceName:   s.name,
+			OrgGUID:     s.orgGUID,
+			OrgName:     orgs[s.orgGUID],
+			Labels:      r.Metadata.Labels,
+			Annotations: r.Metadata.Annotations,
+		}
+	}
+	return apps, nil
+}
+
+type uaaTokenResponse struct {
+	AccessToken string `json:"access_token"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+// accessToken returns the cached UAA token, requesting a new one with the
+// client credentials grant when it is missing or about to expire.
+func (c *capiClient) accessToken(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.token != "" && time.Now().Before(c.tokenExpiry) {
+		return c.token, nil
+	}
+
+	form := url.Values{"grant_type": {"client_credentials"}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.uaaURL+"/oauth/token", strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.SetBasicAuth(url.QueryEscape(c.clientID), url.QueryEscape(c.clientSecret))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	var body uaaTokenResponse
+	if err := c.do(req, &body); err != nil {
+		return "", fmt.Errorf("failed to get UAA token: %w", err)
+	}
+	if body.AccessToken == "" {
+		return "", errors.New("failed to get UAA token: response has no access_token")
+	}
+
+	c.token = body.AccessToken
+	c.tokenExpiry = time.Now().Add(time.Duration(body.ExpiresIn)*time.Second - tokenExpiryMargin)
+	return c.token, nil
+}
+
+func (c *capiClient) clearToken() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.token = ""
+}
+
+func (c *capiClient) do(req *http.Request, v any) error {
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return errUnauthorized
+	}
+	if resp.StatusCode != http.StatusOK {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
@@ -0,0 +1,13 @@
+package capimetadataprocessor_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestCAPIMetadataProcessor(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "CAPI Metadata Processor Suite")
+}
@@ -0,0 +1,75 @@
+package capimetadataprocessor
+
+import (
+	"errors"
+	"time"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/config/configopaque"
+	"go.opentelemetry.io/collector/config/configtls"
+)
+
+// Config defines the configuration for the CAPI metadata processor.
+type Config struct {
+	// CAPIEndpoint is the Cloud Controller API, e.g. https://api.sys.example.com.
+	CAPIEndpoint string `mapstructure:"capi_endpoint"`
+
+	// UAA configures the client credentials used to authenticate to CAPI.
+	UAA UAAConfig `mapstructure:"uaa"`
+
+	// TLS configures the connections to CAPI and UAA.
+	TLS configtls.ClientConfig `mapstructure:"tls"`
+
+	// Timeout bounds every request to CAPI and UAA.
+	Timeout time.Duration `mapstructure:"timeout"`
+
+	// Cache configures how many apps are remembered and for how long.
+	Cache CacheConfig `mapstructure:"cache"`
+
+	// Labels and Annotations are the app metadata keys added as attributes.
+	Labels      []string `mapstructure:"labels"`
+	Annotations []string `mapstructure:"annotations"`
+}
+
+// UAAConfig defines the UAA client used to get CAPI tokens.
+type UAAConfig struct {
+	Endpoint     string              `mapstructure:"endpoint"`
+	ClientID     string              `mapstructure:"client_id"`
+	ClientSecret configopaque.String `mapstructure:"client_secret"`
+}
+
+// CacheConfig defines the app metadata cache.
+type CacheConfig struct {
+	// Size is the maximum number of apps kept, evicting the least recently
+	// used app when full.
+	Size int `mapstructure:"size"`
+
+	// TTL is how long an app is used before it is looked up again. Expired
+	// apps keep being used until the lookup succeeds.
+	TTL time.Duration `mapstructure:"ttl"`
+}
+
+var _ component.Config = (*Config)(nil)
+
+// Validate checks that CAPI and UAA are configured.
+func (cfg *Config) Validate() error {
+	if cfg.CAPIEndpoint == "" {
+		return errors.New(`requires a non-empty "capi_endpoint"`)
+	}
+	if cfg.UAA.Endpoint == "" {
+		return errors.New(`requires a non-empty "uaa.endpoint"`)
+	}
+	if cfg.UAA.ClientID == "" || cfg.UAA.ClientSecret == "" {
+		return errors.New(`requires "uaa.client_id" and "uaa.client_secret"`)
+	}
+	if cfg.Timeout <= 0 {
+		return errors.New(`"timeout" must be positive`)
+	}
+	if cfg.Cache.Size <= 0 {
+		return errors.New(`"cache.size" must be positive`)
+	}
+	if cfg.Cache.TTL <= 0 {
+		return errors.New(`"cache.ttl" must be positive`)
+	}
+	return nil
+}
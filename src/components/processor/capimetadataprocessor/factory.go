@@ -0,0 +1,117 @@
+// Package capimetadataprocessor implements a processor that adds app, space
+// and organization metadata from the Cloud Controller to records that carry
+// an app GUID.
+package capimetadataprocessor
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/processor"
+	"go.opentelemetry.io/collector/processor/processorhelper"
+	"go.uber.org/zap"
+)
+
+const stability = component.StabilityLevelAlpha
+
+var componentType = component.MustNewType("capimetadata")
+
+var processorCapabilities = consumer.Capabilities{MutatesData: true}
+
+// NewFactory creates a factory for the CAPI metadata processor.
+func NewFactory() processor.Factory {
+	return processor.NewFactory(
+		componentType,
+		createDefaultConfig,
+		processor.WithLogs(createLogs, stability),
+		processor.WithMetrics(createMetrics, stability),
+		processor.WithTraces(createTraces, stability),
+	)
+}
+
+func createDefaultConfig() component.Config {
+	return &Config{
+		Timeout: 10 * time.Second,
+		Cache: CacheConfig{
+			Size: 10000,
+			TTL:  5 * time.Minute,
+		},
+	}
+}
+
+func createLogs(ctx context.Context, set processor.Settings, cfg component.Config, next consumer.Logs) (processor.Logs, error) {
+	p := &capiMetadataProcessor{resolver: resolvers.acquire(cfg.(*Config), set.Logger)}
+	return processorhelper.NewLogs(ctx, set, cfg, next,
+		p.processLogs,
+		processorhelper.WithCapabilities(processorCapabilities),
+		processorhelper.WithStart(p.resolver.start),
+		processorhelper.WithShutdown(p.shutdown),
+	)
+}
+
+func createMetrics(ctx context.Context, set processor.Settings, cfg component.Config, next consumer.Metrics) (processor.Metrics, error) {
+	p := &capiMetadataProcessor{resolver: resolvers.acquire(cfg.(*Config), set.Logger)}
+	return processorhelper.NewMetrics(ctx, set, cfg, next,
+		p.processMetrics,
+		processorhelper.WithCapabilities(processorCapabilities),
+		processorhelper.WithStart(p.resolver.start),
+		processorhelper.WithShutdown(p.shutdown),
+	)
+}
+
+func createTraces(ctx context.Context, set processor.Settings, cfg component.Config, next consumer.Traces) (processor.Traces, error) {
+	p := &capiMetadataProcessor{resolver: resolvers.acquire(cfg.(*Config), set.Logger)}
+	return processorhelper.NewTraces(ctx, set, cfg, next,
+		p.processTraces,
+		processorhelper.WithCapabilities(processorCapabilities),
+		processorhelper.WithStart(p.resolver.start),
+		processorhelper.WithShutdown(p.shutdown),
+	)
+}
+
+// sharedResolvers ensures that a single cache and CAPI client are used per
+// processor configuration, no matter how many pipelines the processor is used
+// in. A resolver is stopped once the processors of every pipeline using it
+// are shut down.
+type sharedResolvers struct {
+	mu sync.Mutex
+	m  map[*Config]*sharedResolver
+}
+
+type sharedResolver struct {
+	resolver *resolver
+	refs     int
+}
+
+var resolvers = &sharedResolvers{m: map[*Config]*sharedResolver{}}
+
+func (s *sharedResolvers) acquire(cfg *Config, logger *zap.Logger) *resolver {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sr, ok := s.m[cfg]
+	if !ok {
+		sr = &sharedResolver{resolver: newResolver(cfg, logger)}
+		s.m[cfg] = sr
+	}
+	sr.refs++
+	return sr.resolver
+}
+
+// release stops the resolver of cfg when no other processor uses it.
+func (s *sharedResolvers) release(cfg *Config) {
+	s.mu.Lock()
+	sr := s.m[cfg]
+	sr.refs--
+	if sr.refs > 0 {
+		s.mu.Unlock()
+		return
+	}
+	delete(s.m, cfg)
+	s.mu.Unlock()
+
+	sr.resolver.stop()
+}
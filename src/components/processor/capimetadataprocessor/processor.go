@@ -0,0 +1,130 @@
+package capimetadataprocessor
+
+import (
+	"context"
+	"sync"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+const (
+	attributeAppID    = "app_id"
+	attributeSourceID = "source_id"
+)
+
+type capiMetadataProcessor struct {
+	resolver    *resolver
+	releaseOnce sync.Once
+}
+
+// shutdown releases the resolver, which is stopped once the processors of
+// every pipeline sharing it are shut down.
+func (p *capiMetadataProcessor) shutdown(context.Context) error {
+	p.releaseOnce.Do(func() {
+		resolvers.release(p.resolver.cfg)
+	})
+	return nil
+}
+
+func (p *capiMetadataProcessor) processLogs(_ context.Context, ld plog.Logs) (plog.Logs, error) {
+	for i := 0; i < ld.ResourceLogs().Len(); i++ {
+		rl := ld.ResourceLogs().At(i)
+		if p.enrich(rl.Resource().Attributes()) {
+			continue
+		}
+		for j := 0; j < rl.ScopeLogs().Len(); j++ {
+			records := rl.ScopeLogs().At(j).LogRecords()
+			for k := 0; k < records.Len(); k++ {
+				p.enrich(records.At(k).Attributes())
+			}
+		}
+	}
+	return ld, nil
+}
+
+func (p *capiMetadataProcessor) processTraces(_ context.Context, td ptrace.Traces) (ptrace.Traces, error) {
+	for i := 0; i < td.ResourceSpans().Len(); i++ {
+		rs := td.ResourceSpans().At(i)
+		if p.enrich(rs.Resource().Attributes()) {
+			continue
+		}
+		for j := 0; j < rs.ScopeSpans().Len(); j++ {
+			spans := rs.ScopeSpans().At(j).Spans()
+			for k := 0; k < spans.Len(); k++ {
+				p.enrich(spans.At(k).Attributes())
+			}
+		}
+	}
+	return td, nil
+}
+
+func (p *capiMetadataProcessor) processMetrics(_ context.Context, md pmetric.Metrics) (pmetric.Metrics, error) {
+	for i := 0; i < md.ResourceMetrics().Len(); i++ {
+		rm := md.ResourceMetrics().At(i)
+		if p.enrich(rm.Resource().Attributes()) {
+			continue
+		}
+		for j := 0; j < rm.ScopeMetrics().Len(); j++ {
+			metrics := rm.ScopeMetrics().At(j).Metrics()
+			for k := 0; k < metrics.Len(); k++ {
+				m := metrics.At(k)
+
+				switch m.Type() {
+				case pmetric.MetricTypeGauge:
+					dps := m.Gauge().DataPoints()
+					for l := 0; l < dps.Len(); l++ {
+						p.enrich(dps.At(l).Attributes())
+					}
+				case pmetric.MetricTypeSum:
+					dps := m.Sum().DataPoints()
+					for l := 0; l < dps.Len(); l++ {
+						p.enrich(dps.At(l).Attributes())
+					}
+				case pmetric.MetricTypeHistogram:
+					dps := m.Histogram().DataPoints()
+					for l := 0; l < dps.Len(); l++ {
+						p.enrich(dps.At(l).Attributes())
+					}
+				case pmetric.MetricTypeExponentialHistogram:
+					dps := m.ExponentialHistogram().DataPoints()
+					for l := 0; l < dps.Len(); l++ {
+						p.enrich(dps.At(l).Attributes())
+					}
+				case pmetric.MetricTypeSummary:
+					dps := m.Summary().DataPoints()
+					for l := 0; l < dps.Len(); l++ {
+						p.enrich(dps.At(l).Attributes())
+					}
+				}
+			}
+		}
+	}
+	return md, nil
+}
+
+// enrich adds the app metadata to attrs when they identify an app, without
+// replacing attributes that are already set. It reports whether attrs
+// identify an app, so that records under an enriched resource are skipped.
+func (p *capiMetadataProcessor) enrich(attrs pcommon.Map) bool {
+	guid, ok := attrs.Get(attributeAppID)
+	if !ok {
+		guid, ok = attrs.Get(attributeSourceID)
+	}
+	if !ok {
+		return false
+	}
+
+	metadata, ok := p.resolver.resolve(guid.AsString())
+	if !ok {
+		return false
+	}
+	for k, v := range metadata {
+		if _, exists := attrs.Get(k); !exists {
+			attrs.PutStr(k, v)
+		}
+	}
+	return true
+}
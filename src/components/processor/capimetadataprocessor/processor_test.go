@@ -0,0 +1,467 @@
+package capimetadataprocessor_test
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/otel-collector-release/src/components/processor/capimetadataprocessor"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.opentelemetry.io/collector/component/componenttest"
+	"go.opentelemetry.io/collector/consumer/consumertest"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/processor"
+	"go.opentelemetry.io/collector/processor/processortest"
+)
+
+const (
+	appGUID   = "64f96e84-e84d-4543-bf85-9cbfc567aa44"
+	otherGUID = "0c5b9b3a-4a4e-4a3c-8d7a-7c0a9e1f6b2d"
+	spaceGUID = "bc5ee0ca-97f1-40ce-adb6-b363b86f2e46"
+	orgGUID   = "6ac10a06-72ee-4f35-ab9b-a39f505b4ebf"
+)
+
+var _ = Describe("CAPI metadata processor", func() {
+	var (
+		fake *fakeCloudController
+		cfg  *capimetadataprocessor.Config
+	)
+
+	BeforeEach(func() {
+		fake = newFakeCloudController()
+		DeferCleanup(fake.server.Close)
+
+		cfg = capimetadataprocessor.NewFactory().CreateDefaultConfig().(*capimetadataprocessor.Config)
+		cfg.CAPIEndpoint = fake.server.URL
+		cfg.UAA.Endpoint = fake.server.URL + "/uaa"
+		cfg.UAA.ClientID = "otel-collector"
+		cfg.UAA.ClientSecret = "secret"
+		cfg.TLS.CAFile = fake.caFile
+		cfg.Labels = []string{"team"}
+		cfg.Annotations = []string{"contact"}
+		Expect(cfg.Validate()).To(Succeed())
+	})
+
+	startLogs := func() (processor.Logs, *consumertest.LogsSink) {
+		sink := new(consumertest.LogsSink)
+		factory := capimetadataprocessor.NewFactory()
+		p, err := factory.CreateLogs(context.Background(), processortest.NewNopSettings(factory.Type()), cfg, sink)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(p.Start(context.Background(), componenttest.NewNopHost())).To(Succeed())
+		DeferCleanup(p.Shutdown, context.Background())
+		return p, sink
+	}
+
+	// consumeUntilEnriched sends a log for the app until it comes out with the
+	// app name, since lookups happen in the background.
+	consumeUntilEnriched := func(p processor.Logs, sink *consumertest.LogsSink, guid string) map[string]any {
+		var attrs map[string]any
+		Eventually(func() map[string]any {
+			sink.Reset()
+			Expect(p.ConsumeLogs(context.Background(), appLog(guid))).To(Succeed())
+			attrs = sink.AllLogs()[0].ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0).Attributes().AsRaw()
+			return attrs
+		}).Should(HaveKey("app_name"))
+		return attrs
+	}
+
+	It("passes records through while the app is looked up", func() {
+		fake.block()
+		p, sink := startLogs()
+
+		Expect(p.ConsumeLogs(context.Background(), appLog(appGUID))).To(Succeed())
+		Expect(sink.AllLogs()).To(HaveLen(1))
+		Expect(sink.AllLogs()[0].ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0).Attributes().AsRaw()).To(Equal(map[string]any{
+			"app_id": appGUID,
+		}))
+		fake.unblock()
+	})
+
+	It("adds the app, space and org names and the selected metadata", func() {
+		p, sink := startLogs()
+
+		attrs := consumeUntilEnriched(p, sink, appGUID)
+
+		Expect(attrs).To(Equal(map[string]any{
+			"app_id":                 appGUID,
+			"app_name":               "tanzu-hub-sli-test-app",
+			"space_id":               spaceGUID,
+			"space_name":             "tanzu-hub-collector",
+			"organization_id":        orgGUID,
+			"organization_name":      "system",
+			"app_label.team":         "observability",
+			"app_annotation.contact": "team@example.com",
+		}))
+		Expect(fake.tokenRequests()).To(Equal(1))
+		Expect(fake.authorization()).To(Equal("bearer token-1"))
+	})
+
+	It("does not replace attributes that are already set", func() {
+		p, sink := startLogs()
+		consumeUntilEnriched(p, sink, appGUID)
+
+		sink.Reset()
+		ld := appLog(appGUID)
+		ld.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0).Attributes().PutStr("app_name", "renamed")
+		Expect(p.ConsumeLogs(context.Background(), ld)).To(Succeed())
+
+		Expect(sink.AllLogs()[0].ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0).Attributes().AsRaw()).To(HaveKeyWithValue("app_name", "renamed"))
+	})
+
+	It("uses the source_id and enriches resources", func() {
+		p, sink := startLogs()
+
+		Eventually(func() map[string]any {
+			sink.Reset()
+			ld := plog.NewLogs()
+			rl := ld.ResourceLogs().AppendEmpty()
+			rl.Resource().Attributes().PutStr("source_id", appGUID)
+			rl.ScopeLogs().AppendEmpty().LogRecords().AppendEmpty()
+			Expect(p.ConsumeLogs(context.Background(), ld)).To(Succeed())
+			return sink.AllLogs()[0].ResourceLogs().At(0).Resource().Attributes().AsRaw()
+		}).Should(HaveKeyWithValue("organization_name", "system"))
+	})
+
+	It("ignores source IDs that are not app GUIDs", func() {
+		p, sink := startLogs()
+
+		ld := plog.NewLogs()
+		ld.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords().AppendEmpty().Attributes().PutStr("source_id", "gorouter")
+		Expect(p.ConsumeLogs(context.Background(), ld)).To(Succeed())
+
+		Consistently(fake.appRequests, 200*time.Millisecond).Should(BeZero())
+		Expect(sink.LogRecordCount()).To(Equal(1))
+	})
+
+	It("batches lookups and skips apps that are already being looked up", func() {
+		fake.block()
+		p, sink := startLogs()
+
+		Expect(p.ConsumeLogs(context.Background(), appLog(appGUID))).To(Succeed())
+		Eventually(fake.appRequests).Should(Equal(1))
+		Expect(p.ConsumeLogs(context.Background(), appLog(otherGUID))).To(Succeed())
+		Expect(p.ConsumeLogs(context.Background(), appLog("11111111-2222-3333-4444-555555555555"))).To(Succeed())
+		Expect(p.ConsumeLogs(context.Background(), appLog(appGUID))).To(Succeed())
+		fake.unblock()
+
+		consumeUntilEnriched(p, sink, otherGUID)
+		consumeUntilEnriched(p, sink, appGUID)
+		Expect(fake.appRequests()).To(Equal(2))
+		Expect(fake.lastGUIDs()).To(Equal(otherGUID + ",11111111-2222-3333-4444-555555555555"))
+	})
+
+	It("remembers apps that CAPI does not know about", func() {
+		p, sink := startLogs()
+		unknown := "11111111-2222-3333-4444-555555555555"
+
+		Expect(p.ConsumeLogs(context.Background(), appLog(unknown))).To(Succeed())
+		Eventually(fake.appRequests).Should(Equal(1))
+		Consistently(func() int {
+			Expect(p.ConsumeLogs(context.Background(), appLog(unknown))).To(Succeed())
+			return fake.appRequests()
+		}, 200*time.Millisecond).Should(Equal(1))
+		Expect(sink.AllLogs()[len(sink.AllLogs())-1].ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0).Attributes().AsRaw()).NotTo(HaveKey("app_name"))
+	})
+
+	It("keeps using expired metadata while CAPI is unavailable", func() {
+		cfg.Cache.TTL = 50 * time.Millisecond
+		p, sink := startLogs()
+		consumeUntilEnriched(p, sink, appGUID)
+
+		fake.setAppsStatus(http.StatusServiceUnavailable)
+		requests := fake.appRequests()
+		Eventually(func() int {
+			Expect(p.ConsumeLogs(context.Background(), appLog(appGUID))).To(Succeed())
+			return fake.appRequests()
+		}).Should(BeNumerically(">", requests))
+
+		sink.Reset()
+		Expect(p.ConsumeLogs(context.Background(), appLog(appGUID))).To(Succeed())
+		Expect(sink.AllLogs()[0].ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0).Attributes().AsRaw()).To(HaveKeyWithValue("app_name", "tanzu-hub-sli-test-app"))
+	})
+
+	It("backs off looking up apps while CAPI is unavailable", func() {
+		fake.setAppsStatus(http.StatusServiceUnavailable)
+		p, _ := startLogs()
+
+		consume := func() int {
+			Expect(p.ConsumeLogs(context.Background(), appLog(appGUID))).To(Succeed())
+			return fake.appRequests()
+		}
+		Eventually(consume).Should(Equal(1))
+		Consistently(consume, 500*time.Millisecond).Should(Equal(1))
+		Eventually(consume, 2*time.Second).Should(Equal(2))
+	})
+
+	It("refreshes expired metadata", func() {
+		cfg.Cache.TTL = 50 * time.Millisecond
+		p, sink := startLogs()
+		consumeUntilEnriched(p, sink, appGUID)
+
+		fake.renameApp("renamed-app")
+		Eventually(func() map[string]any {
+			sink.Reset()
+			Expect(p.ConsumeLogs(context.Background(), appLog(appGUID))).To(Succeed())
+			return sink.AllLogs()[0].ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0).Attributes().AsRaw()
+		}).Should(HaveKeyWithValue("app_name", "renamed-app"))
+	})
+
+	It("evicts the least recently used app when the cache is full", func() {
+		cfg.Cache.Size = 1
+		p, sink := startLogs()
+		consumeUntilEnriched(p, sink, appGUID)
+		consumeUntilEnriched(p, sink, otherGUID)
+		requests := fake.appRequests()
+
+		consumeUntilEnriched(p, sink, appGUID)
+		Expect(fake.appRequests()).To(BeNumerically(">", requests))
+	})
+
+	It("requests a new token when CAPI rejects the current one", func() {
+		cfg.Cache.TTL = 50 * time.Millisecond
+		p, sink := startLogs()
+		consumeUntilEnriched(p, sink, appGUID)
+
+		fake.revokeTokens()
+		fake.renameApp("renamed-app")
+		Eventually(func() map[string]any {
+			sink.Reset()
+			Expect(p.ConsumeLogs(context.Background(), appLog(appGUID))).To(Succeed())
+			return sink.AllLogs()[0].ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0).Attributes().AsRaw()
+		}).Should(HaveKeyWithValue("app_name", "renamed-app"))
+		Expect(fake.tokenRequests()).To(Equal(2))
+	})
+
+	It("keeps looking up apps for the other pipelines when one is shut down", func() {
+		factory := capimetadataprocessor.NewFactory()
+		metrics, err := factory.CreateMetrics(context.Background(), processortest.NewNopSettings(factory.Type()), cfg, consumertest.NewNop())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(metrics.Start(context.Background(), componenttest.NewNopHost())).To(Succeed())
+		p, sink := startLogs()
+		consumeUntilEnriched(p, sink, appGUID)
+
+		Expect(metrics.Shutdown(context.Background())).To(Succeed())
+		Expect(metrics.Shutdown(context.Background())).To(Succeed())
+
+		consumeUntilEnriched(p, sink, otherGUID)
+		Expect(fake.appRequests()).To(Equal(2))
+	})
+
+	It("enriches metric datapoints", func() {
+		sink := new(consumertest.MetricsSink)
+		factory := capimetadataprocessor.NewFactory()
+		p, err := factory.CreateMetrics(context.Background(), processortest.NewNopSettings(factory.Type()), cfg, sink)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(p.Start(context.Background(), componenttest.NewNopHost())).To(Succeed())
+		DeferCleanup(p.Shutdown, context.Background())
+
+		Eventually(func() map[string]any {
+			sink.Reset()
+			md := pmetric.NewMetrics()
+			dp := md.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty().Metrics().AppendEmpty().SetEmptyGauge().DataPoints().AppendEmpty()
+			dp.Attributes().PutStr("source_id", appGUID)
+			Expect(p.ConsumeMetrics(context.Background(), md)).To(Succeed())
+			return sink.AllMetrics()[0].ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0).Gauge().DataPoints().At(0).Attributes().AsRaw()
+		}).Should(HaveKeyWithValue("space_name", "tanzu-hub-collector"))
+	})
+})
+
+var _ = Describe("Config", func() {
+	It("requires CAPI and UAA", func() {
+		cfg := capimetadataprocessor.NewFactory().CreateDefaultConfig().(*capimetadataprocessor.Config)
+		Expect(cfg.Validate()).To(MatchError(ContainSubstring("capi_endpoint")))
+
+		cfg.CAPIEndpoint = "https://api.sys.example.com"
+		Expect(cfg.Validate()).To(MatchError(ContainSubstring("uaa.endpoint")))
+
+		cfg.UAA.Endpoint = "https://uaa.sys.example.com"
+		Expect(cfg.Validate()).To(MatchError(ContainSubstring("uaa.client_id")))
+
+		cfg.UAA.ClientID = "otel-collector"
+		cfg.UAA.ClientSecret = "secret"
+		Expect(cfg.Validate()).To(Succeed())
+
+		cfg.Cache.Size = 0
+		Expect(cfg.Validate()).To(MatchError(ContainSubstring("cache.size")))
+	})
+})
+
+func appLog(guid string) plog.Logs {
+	ld := plog.NewLogs()
+	lr := ld.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords().AppendEmpty()
+	lr.Attributes().PutStr("app_id", guid)
+	return ld
+}
+
+// fakeCloudController serves the UAA token endpoint under /uaa and the CAPI
+// v3 apps endpoint.
+type fakeCloudController struct {
+	server *httptest.Server
+	caFile string
+
+	mu         sync.Mutex
+	tokens     int
+	validToken string
+	apps       int
+	lastAuth   string
+	guids      string
+	appsStatus int
+	appName    string
+	blocked    chan struct{}
+}
+
+func newFakeCloudController() *fakeCloudController {
+	f := &fakeCloudController{appsStatus: http.StatusOK, appName: "tanzu-hub-sli-test-app"}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /uaa/oauth/token", f.token)
+	mux.HandleFunc("GET /v3/apps", f.listApps)
+	f.server = httptest.NewTLSServer(mux)
+
+	f.caFile = filepath.Join(GinkgoT().TempDir(), "ca.crt")
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: f.server.Certificate().Raw})
+	Expect(os.WriteFile(f.caFile, caPEM, 0600)).To(Succeed())
+	return f
+}
+
+func (f *fakeCloudController) token(w http.ResponseWriter, r *http.Request) {
+	user, pass, ok := r.BasicAuth()
+	if !ok || user != "otel-collector" || pass != "secret" || r.FormValue("grant_type") != "client_credentials" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	f.mu.Lock()
+	f.tokens++
+	f.validToken = "token-" + string(rune('0'+f.tokens))
+	token := f.validToken
+	f.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"access_token": token,
+		"token_type":   "bearer",
+		"expires_in":   3600,
+	})
+}
+
+func (f *fakeCloudController) listApps(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	f.apps++
+	f.lastAuth = r.Header.Get("Authorization")
+	f.guids = r.URL.Query().Get("guids")
+	valid := f.lastAuth == "bearer "+f.validToken
+	status := f.appsStatus
+	name := f.appName
+	blocked := f.blocked
+	f.mu.Unlock()
+
+	if blocked != nil {
+		<-blocked
+	}
+	if !valid {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	if status != http.StatusOK {
+		w.WriteHeader(status)
+		return
+	}
+
+	var resources []map[string]any
+	for _, guid := range strings.Split(r.URL.Query().Get("guids"), ",") {
+		if guid != appGUID && guid != otherGUID {
+			continue
+		}
+		resources = append(resources, map[string]any{
+			"guid": guid,
+			"name": name,
+			"relationships": map[string]any{
+				"space": map[string]any{"data": map[string]any{"guid": spaceGUID}},
+			},
+			"metadata": map[string]any{
+				"labels":      map[string]any{"team": "observability", "tier": "1"},
+				"annotations": map[string]any{"contact": "team@example.com"},
+			},
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"resources": resources,
+		"included": map[string]any{
+			"spaces": []map[string]any{{
+				"guid": spaceGUID,
+				"name": "tanzu-hub-collector",
+				"relationships": map[string]any{
+					"organization": map[string]any{"data": map[string]any{"guid": orgGUID}},
+				},
+			}},
+			"organizations": []map[string]any{{"guid": orgGUID, "name": "system"}},
+		},
+	})
+}
+
+func (f *fakeCloudController) block() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.blocked = make(chan struct{})
+}
+
+func (f *fakeCloudController) unblock() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	close(f.blocked)
+	f.blocked = nil
+}
+
+func (f *fakeCloudController) tokenRequests() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.tokens
+}
+
+func (f *fakeCloudController) appRequests() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.apps
+}
+
+func (f *fakeCloudController) lastGUIDs() string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.guids
+}
+
+func (f *fakeCloudController) authorization() string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.lastAuth
+}
+
+func (f *fakeCloudController) setAppsStatus(status int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.appsStatus = status
+}
+
+func (f *fakeCloudController) renameApp(name string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.appName = name
+}
+
+func (f *fakeCloudController) revokeTokens() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.validToken = "revoked"
+}
@@ -0,0 +1,232 @@
+package capimetadataprocessor
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/collector/component"
+	"go.uber.org/zap"
+)
+
+const (
+	// maxBatchSize is the most apps looked up in a single CAPI request.
+	maxBatchSize = 50
+
+	// queueSize is the most lookups waiting for the worker. Lookups requested
+	// while the queue is full are dropped and requested again by the next
+	// record for the same app.
+	queueSize = 1000
+
+	// lookupRetryInitial and lookupRetryMax bound how long the worker waits
+	// after a failed lookup, doubling while lookups keep failing, so that an
+	// unavailable CAPI isn't sent a request for every batch of records.
+	lookupRetryInitial = time.Second
+	lookupRetryMax     = time.Minute
+
+	attributeAppName          = "app_name"
+	attributeSpaceID          = "space_id"
+	attributeSpaceName        = "space_name"
+	attributeOrganizationID   = "organization_id"
+	attributeOrganizationName = "organization_name"
+	attributeLabelPrefix      = "app_label."
+	attributeAnnotationPrefix = "app_annotation."
+)
+
+var guidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// resolver resolves app GUIDs from the cache and looks up missing and expired
+// apps in the background, so that records never wait on CAPI.
+type resolver struct {
+	cfg    *Config
+	logger *zap.Logger
+	cache  *lruCache
+	client *capiClient
+
+	queue chan string
+	done  chan struct{}
+	wg    sync.WaitGroup
+
+	pendingMu sync.Mutex
+	pending   map[string]struct{}
+
+	startOnce sync.Once
+}
+
+func newResolver(cfg *Config, logger *zap.Logger) *resolver {
+	return &resolver{
+		cfg:     cfg,
+		logger:  logger,
+		cache:   newLRUCache(cfg.Cache.Size, cfg.Cache.TTL),
+		queue:   make(chan string, queueSize),
+		done:    make(chan struct{}),
+		pending: map[string]struct{}{},
+	}
+}
+
+// start is shared by every pipeline the processor is used in, so only the
+// first call starts the worker.
+func (r *resolver) start(ctx context.Context, _ component.Host) error {
+	var err error
+	r.startOnce.Do(func() {
+		err = r.startWorker(ctx)
+	})
+	return err
+}
+
+func (r *resolver) startWorker(ctx context.Context) error {
+	tlsConfig, err := r.cfg.TLS.LoadTLSConfig(ctx)
+	if err != nil {
+		return err
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	r.client = &capiClient{
+		capiURL:      strings.TrimSuffix(r.cfg.CAPIEndpoint, "/"),
+		uaaURL:       strings.TrimSuffix(r.cfg.UAA.Endpoint, "/"),
+		clientID:     r.cfg.UAA.ClientID,
+		clientSecret: string(r.cfg.UAA.ClientSecret),
+		httpClient:   &http.Client{Transport: transport, Timeout: r.cfg.Timeout},
+	}
+
+	r.wg.Add(1)
+	go r.run()
+	return nil
+}
+
+// stop stops the worker, it may be called without start.
+func (r *resolver) stop() {
+	close(r.done)
+	r.wg.Wait()
+}
+
+// resolve returns the attributes for an app, or false when the app has not
+// been looked up yet. Expired apps are returned and refreshed in the
+// background, which also keeps them in use while CAPI is unavailable.
+func (r *resolver) resolve(guid string) (map[string]string, bool) {
+	if !guidPattern.MatchString(guid) {
+		return nil, false
+	}
+
+	metadata, fresh, ok := r.cache.get(guid)
+	if !fresh {
+		r.request(guid)
+	}
+	return metadata.attributes, ok
+}
+
+func (r *resolver) request(guid string) {
+	r.pendingMu.Lock()
+	defer r.pendingMu.Unlock()
+
+	if _, ok := r.pending[guid]; ok {
+		return
+	}
+	select {
+	case r.queue <- guid:
+		r.pending[guid] = struct{}{}
+	default:
+	}
+}
+
+func (r *resolver) run() {
+	defer r.wg.Done()
+
+	var retry time.Duration
+	for {
+		select {
+		case <-r.done:
+			return
+		case guid := <-r.queue:
+			batch := []string{guid}
+		drain:
+			for len(batch) < maxBatchSize {
+				select {
+				case guid := <-r.queue:
+					batch = append(batch, guid)
+				default:
+					break drain
+				}
+			}
+			if err := r.lookup(batch); err != nil {
+				retry = min(max(2*retry, lookupRetryInitial), lookupRetryMax)
+				r.logger.Warn("Failed to look up apps in CAPI, using cached metadata", zap.Int("apps", len(batch)), zap.Duration("retry_in", retry), zap.Error(err))
+				select {
+				case <-r.done:
+					return
+				case <-time.After(retry):
+				}
+				continue
+			}
+			retry = 0
+		}
+	}
+}
+
+// lookup looks up the apps and caches them. The apps are no longer pending
+// afterwards, so that they are requested again by their next record when the
+// lookup failed.
+func (r *resolver) lookup(guids []string) error {
+	defer func() {
+		r.pendingMu.Lock()
+		defer r.pendingMu.Unlock()
+		for _, guid := range guids {
+			delete(r.pending, guid)
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), r.cfg.Timeout)
+	defer cancel()
+	go func() {
+		select {
+		case <-r.done:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	apps, err := r.client.apps(ctx, guids)
+	if err != nil {
+		return err
+	}
+
+	for _, guid := range guids {
+		a, ok := apps[guid]
+		if !ok {
+			r.cache.put(guid, appMetadata{})
+			continue
+		}
+		r.cache.put(guid, appMetadata{attributes: r.attributes(a)})
+	}
+	return nil
+}
+
+func (r *resolver) attributes(a app) map[string]string {
+	attrs := map[string]string{
+		attributeAppName:          a.Name,
+		attributeSpaceID:          a.SpaceGUID,
+		attributeSpaceName:        a.SpaceName,
+		attributeOrganizationID:   a.OrgGUID,
+		attributeOrganizationName: a.OrgName,
+	}
+	for _, key := range r.cfg.Labels {
+		if v, ok := a.Labels[key]; ok {
+			attrs[attributeLabelPrefix+key] = v
+		}
+	}
+	for _, key := range r.cfg.Annotations {
+		if v, ok := a.Annotations[key]; ok {
+			attrs[attributeAnnotationPrefix+key] = v
+		}
+	}
+	for k, v := range attrs {
+		if v == "" {
+			delete(attrs, k)
+		}
+	}
+	return attrs
+}
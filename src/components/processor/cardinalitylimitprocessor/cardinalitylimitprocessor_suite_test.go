@@ -0,0 +1,13 @@
+package cardinalitylimitprocessor_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestCardinalityLimitProcessor(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Cardinality Limit Processor Suite")
+}
@@ -0,0 +1,66 @@
+package cardinalitylimitprocessor
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/collector/component"
+)
+
+const (
+	// actionDrop drops datapoints of new series over the limit.
+	actionDrop = "drop"
+
+	// actionOverflow folds datapoints of new series over the limit into a
+	// single series with the otel.overflow attribute.
+	actionOverflow = "overflow"
+)
+
+// Config defines the configuration for the cardinality limit processor.
+type Config struct {
+	// Limit is the number of series allowed per metric name within the
+	// window.
+	Limit int `mapstructure:"limit"`
+
+	// MetricLimits overrides the limit for specific metric names.
+	MetricLimits map[string]int `mapstructure:"metric_limits"`
+
+	// Window is how long a series counts towards the limit after its last
+	// datapoint.
+	Window time.Duration `mapstructure:"window"`
+
+	// Action is what happens to datapoints of series over the limit, drop or
+	// overflow.
+	Action string `mapstructure:"action"`
+}
+
+var _ component.Config = (*Config)(nil)
+
+// Validate checks the limits, the window and the action.
+func (cfg *Config) Validate() error {
+	if cfg.Limit <= 0 {
+		return errors.New(`"limit" must be positive`)
+	}
+	for name, limit := range cfg.MetricLimits {
+		if limit <= 0 {
+			return fmt.Errorf("metric_limits: limit for %q must be positive", name)
+		}
+	}
+	if cfg.Window <= 0 {
+		return errors.New(`"window" must be positive`)
+	}
+	switch cfg.Action {
+	case actionDrop, actionOverflow:
+	default:
+		return fmt.Errorf("unsupported action %q, must be %s or %s", cfg.Action, actionDrop, actionOverflow)
+	}
+	return nil
+}
+
+func (cfg *Config) limitFor(name string) int {
+	if limit, ok := cfg.MetricLimits[name]; ok {
+		return limit
+	}
+	return cfg.Limit
+}
@@ -0,0 +1,53 @@
+// Package cardinalitylimitprocessor implements a processor that limits the
+// number of series per metric name, so that unbounded attribute values do
+// not overload metric backends.
+package cardinalitylimitprocessor
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/processor"
+	"go.opentelemetry.io/collector/processor/processorhelper"
+)
+
+const stability = component.StabilityLevelAlpha
+
+var componentType = component.MustNewType("cardinalitylimit")
+
+var processorCapabilities = consumer.Capabilities{MutatesData: true}
+
+// NewFactory creates a factory for the cardinality limit processor.
+func NewFactory() processor.Factory {
+	return processor.NewFactory(
+		componentType,
+		createDefaultConfig,
+		processor.WithMetrics(createMetrics, stability),
+	)
+}
+
+func createDefaultConfig() component.Config {
+	return &Config{
+		Limit:  1000,
+		Window: 10 * time.Minute,
+		Action: actionOverflow,
+	}
+}
+
+func createMetrics(ctx context.Context, set processor.Settings, cfg component.Config, next consumer.Metrics) (processor.Metrics, error) {
+	p, err := newCardinalityLimitProcessor(cfg.(*Config), set.MeterProvider.Meter(scopeName), set.ID.String())
+	if err != nil {
+		return nil, err
+	}
+	return processorhelper.NewMetrics(ctx, set, cfg, next,
+		p.processMetrics,
+		processorhelper.WithCapabilities(processorCapabilities),
+		processorhelper.WithStart(func(context.Context, component.Host) error {
+			p.tracker.start()
+			return nil
+		}),
+		processorhelper.WithShutdown(p.shutdown),
+	)
+}
@@ -0,0 +1,256 @@
+package cardinalitylimitprocessor
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"code.cloudfoundry.org/otel-collector-release/src/components/internal/series"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/processor/processorhelper"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// attributeOverflow marks the series that datapoints over the limit are
+// folded into.
+const attributeOverflow = "otel.overflow"
+
+type outcome int
+
+const (
+	outcomeKeep outcome = iota
+	outcomeDrop
+	outcomeOverflow
+)
+
+type dataPoint[T any] interface {
+	Attributes() pcommon.Map
+	Timestamp() pcommon.Timestamp
+	CopyTo(T)
+}
+
+type dataPointSlice[T any] interface {
+	RemoveIf(func(T) bool)
+	Len() int
+}
+
+type cardinalityLimitProcessor struct {
+	cfg     *Config
+	tracker *tracker
+
+	limited      metric.Int64Counter
+	registration metric.Registration
+	attrs        []attribute.KeyValue
+}
+
+func newCardinalityLimitProcessor(cfg *Config, meter metric.Meter, processorID string) (*cardinalityLimitProcessor, error) {
+	p := &cardinalityLimitProcessor{
+		cfg:     cfg,
+		tracker: newTracker(cfg),
+		attrs:   []attribute.KeyValue{attribute.String("processor", processorID)},
+	}
+
+	var err error
+	p.limited, err = meter.Int64Counter(
+		"otelcol_processor_cardinalitylimit_limited_datapoints",
+		metric.WithDescription("Number of datapoints dropped or folded into the overflow series for exceeding the cardinality limit of their metric."),
+		metric.WithUnit("{datapoints}"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	series, err := meter.Int64ObservableGauge(
+		"otelcol_processor_cardinalitylimit_series",
+		metric.WithDescription("Number of series per metric name seen within the window."),
+		metric.WithUnit("{series}"),
+	)
+	if err != nil {
+		return nil, err
+	}
+	p.registration, err = meter.RegisterCallback(p.tracker.observeCardinality(series, p.attrs), series)
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (p *cardinalityLimitProcessor) shutdown(context.Context) error {
+	p.tracker.shutdown()
+	return p.registration.Unregister()
+}
+
+func (p *cardinalityLimitProcessor) processMetrics(ctx context.Context, md pmetric.Metrics) (pmetric.Metrics, error) {
+	h := series.NewHasher()
+	now := time.Now()
+
+	md.ResourceMetrics().RemoveIf(func(rm pmetric.ResourceMetrics) bool {
+		h.Reset()
+		h.WriteMap(rm.Resource().Attributes())
+		resource := h.Sum()
+
+		rm.ScopeMetrics().RemoveIf(func(sm pmetric.ScopeMetrics) bool {
+			sm.Metrics().RemoveIf(func(m pmetric.Metric) bool {
+				// Only gauges and deltas can be folded into the overflow
+				// series, folding cumulative datapoints of different series
+				// would make it go back and forth between their totals.
+				limit := func(foldable bool) func(pcommon.Map) outcome {
+					return func(attrs pcommon.Map) outcome {
+						return p.limit(ctx, h, resource, m.Name(), attrs, foldable, now)
+					}
+				}
+
+				switch m.Type() {
+				case pmetric.MetricTypeGauge:
+					return limitDataPoints(m.Gauge().DataPoints(), limit(true), keepLatest) == 0
+				case pmetric.MetricTypeSum:
+					return limitDataPoints(m.Sum().DataPoints(), limit(isDelta(m.Sum())), addNumbers) == 0
+				case pmetric.MetricTypeHistogram:
+					return limitDataPoints(m.Histogram().DataPoints(), limit(isDelta(m.Histogram())), addHistograms) == 0
+				case pmetric.MetricTypeExponentialHistogram:
+					return limitDataPoints(m.ExponentialHistogram().DataPoints(), limit(isDelta(m.ExponentialHistogram())), keepLatest) == 0
+				case pmetric.MetricTypeSummary:
+					return limitDataPoints(m.Summary().DataPoints(), limit(false), keepLatest) == 0
+				}
+				return false
+			})
+			return sm.Metrics().Len() == 0
+		})
+		return rm.ScopeMetrics().Len() == 0
+	})
+
+	if md.ResourceMetrics().Len() == 0 {
+		return md, processorhelper.ErrSkipProcessingData
+	}
+	return md, nil
+}
+
+// limit tracks the series of a datapoint and decides what happens to it.
+// Datapoints that can't be folded are dropped instead of overflowing.
+func (p *cardinalityLimitProcessor) limit(ctx context.Context, h *series.Hasher, resource seriesID, name string, attrs pcommon.Map, foldable bool, now time.Time) outcome {
+	h.Reset()
+	h.WriteID(resource)
+	h.WriteMap(attrs)
+	if p.tracker.observe(name, h.Sum(), now) {
+		return outcomeKeep
+	}
+
+	action := p.cfg.Action
+	if !foldable {
+		action = actionDrop
+	}
+	kvs := append(p.attrs[:len(p.attrs):len(p.attrs)],
+		attribute.String("metric_name", name),
+		attribute.String("action", action),
+	)
+	p.limited.Add(ctx, 1, metric.WithAttributes(kvs...))
+
+	if action == actionDrop {
+		return outcomeDrop
+	}
+	return outcomeOverflow
+}
+
+type aggregatedMetric interface {
+	AggregationTemporality() pmetric.AggregationTemporality
+}
+
+func isDelta(m aggregatedMetric) bool {
+	return m.AggregationTemporality() == pmetric.AggregationTemporalityDelta
+}
+
+// limitDataPoints removes the datapoints that are dropped and folds the
+// datapoints that overflow into the first of them using merge. It returns the
+// number of datapoints left.
+func limitDataPoints[T dataPoint[T]](dps dataPointSlice[T], limit func(pcommon.Map) outcome, merge func(into, from T)) int {
+	var overflow T
+	var hasOverflow bool
+
+	dps.RemoveIf(func(dp T) bool {
+		switch limit(dp.Attributes()) {
+		case outcomeKeep:
+			return false
+		case outcomeDrop:
+			return true
+		}
+
+		dp.Attributes().Clear()
+		dp.Attributes().PutBool(attributeOverflow, true)
+		if !hasOverflow {
+			overflow, hasOverflow = dp, true
+			return false
+		}
+		merge(overflow, dp)
+		return true
+	})
+	return dps.Len()
+}
+
+// keepLatest is used for datapoints that cannot be added up, keeping the
+// most recent one.
+func keepLatest[T dataPoint[T]](into, from T) {
+	if from.Timestamp() >= into.Timestamp() {
+		from.CopyTo(into)
+	}
+}
+
+func addNumbers(into, from pmetric.NumberDataPoint) {
+	switch {
+	case into.ValueType() == pmetric.NumberDataPointValueTypeInt && from.ValueType() == pmetric.NumberDataPointValueTypeInt:
+		into.SetIntValue(into.IntValue() + from.IntValue())
+	default:
+		into.SetDoubleValue(numberValue(into) + numberValue(from))
+	}
+	mergeTimes(into, from)
+}
+
+func numberValue(dp pmetric.NumberDataPoint) float64 {
+	if dp.ValueType() == pmetric.NumberDataPointValueTypeInt {
+		return float64(dp.IntValue())
+	}
+	return dp.DoubleValue()
+}
+
+// addHistograms adds up histograms with the same bucket boundaries and keeps
+// the most recent one otherwise.
+func addHistograms(into, from pmetric.HistogramDataPoint) {
+	if !slices.Equal(into.ExplicitBounds().AsRaw(), from.ExplicitBounds().AsRaw()) ||
+		into.BucketCounts().Len() != from.BucketCounts().Len() {
+		keepLatest(into, from)
+		return
+	}
+
+	into.SetCount(into.Count() + from.Count())
+	if into.HasSum() || from.HasSum() {
+		into.SetSum(into.Sum() + from.Sum())
+	}
+	if from.HasMin() && (!into.HasMin() || from.Min() < into.Min()) {
+		into.SetMin(from.Min())
+	}
+	if from.HasMax() && (!into.HasMax() || from.Max() > into.Max()) {
+		into.SetMax(from.Max())
+	}
+	for i := 0; i < into.BucketCounts().Len(); i++ {
+		into.BucketCounts().SetAt(i, into.BucketCounts().At(i)+from.BucketCounts().At(i))
+	}
+	mergeTimes(into, from)
+}
+
+type timedDataPoint interface {
+	StartTimestamp() pcommon.Timestamp
+	SetStartTimestamp(pcommon.Timestamp)
+	Timestamp() pcommon.Timestamp
+	SetTimestamp(pcommon.Timestamp)
+}
+
+// mergeTimes widens the time range of into to cover from.
+func mergeTimes(into, from timedDataPoint) {
+	if from.StartTimestamp() != 0 && (into.StartTimestamp() == 0 || from.StartTimestamp() < into.StartTimestamp()) {
+		into.SetStartTimestamp(from.StartTimestamp())
+	}
+	if from.Timestamp() > into.Timestamp() {
+		into.SetTimestamp(from.Timestamp())
+	}
+}
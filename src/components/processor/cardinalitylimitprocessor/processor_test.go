@@ -0,0 +1,325 @@
+package cardinalitylimitprocessor_test
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"code.cloudfoundry.org/otel-collector-release/src/components/processor/cardinalitylimitprocessor"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.opentelemetry.io/collector/component/componenttest"
+	"go.opentelemetry.io/collector/consumer/consumertest"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/processor"
+	"go.opentelemetry.io/collector/processor/processortest"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+var _ = Describe("Cardinality limit processor", func() {
+	var (
+		cfg       *cardinalitylimitprocessor.Config
+		telemetry *componenttest.Telemetry
+		sink      *consumertest.MetricsSink
+	)
+
+	BeforeEach(func() {
+		cfg = cardinalitylimitprocessor.NewFactory().CreateDefaultConfig().(*cardinalitylimitprocessor.Config)
+		cfg.Limit = 2
+
+		telemetry = componenttest.NewTelemetry()
+		DeferCleanup(telemetry.Shutdown, context.Background())
+		sink = new(consumertest.MetricsSink)
+	})
+
+	start := func() processor.Metrics {
+		Expect(cfg.Validate()).To(Succeed())
+		factory := cardinalitylimitprocessor.NewFactory()
+		settings := processortest.NewNopSettings(factory.Type())
+		settings.TelemetrySettings = telemetry.NewTelemetrySettings()
+		p, err := factory.CreateMetrics(context.Background(), settings, cfg, sink)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(p.Start(context.Background(), componenttest.NewNopHost())).To(Succeed())
+		DeferCleanup(p.Shutdown, context.Background())
+		return p
+	}
+
+	It("passes series within the limit", func() {
+		p := start()
+
+		Expect(p.ConsumeMetrics(context.Background(), sums("requests", "a", "b"))).To(Succeed())
+		Expect(p.ConsumeMetrics(context.Background(), sums("requests", "a", "b", "a"))).To(Succeed())
+
+		Expect(sink.DataPointCount()).To(Equal(5))
+	})
+
+	It("drops new series over the limit", func() {
+		cfg.Action = "drop"
+		p := start()
+
+		Expect(p.ConsumeMetrics(context.Background(), sums("requests", "a", "b", "c", "d"))).To(Succeed())
+		Expect(p.ConsumeMetrics(context.Background(), sums("requests", "a", "e"))).To(Succeed())
+
+		Expect(paths(sink)).To(Equal([]string{"a", "b", "a"}))
+	})
+
+	It("limits every metric name separately", func() {
+		cfg.Action = "drop"
+		cfg.MetricLimits = map[string]int{"latency": 3}
+		p := start()
+
+		Expect(p.ConsumeMetrics(context.Background(), sums("requests", "a", "b", "c", "d"))).To(Succeed())
+		Expect(p.ConsumeMetrics(context.Background(), sums("latency", "a", "b", "c", "d"))).To(Succeed())
+
+		Expect(sink.DataPointCount()).To(Equal(5))
+	})
+
+	It("counts resource attributes towards the series", func() {
+		cfg.Action = "drop"
+		p := start()
+
+		md := sums("requests", "a")
+		rm := md.ResourceMetrics().AppendEmpty()
+		md.ResourceMetrics().At(0).CopyTo(rm)
+		rm.Resource().Attributes().PutStr("app_id", "other-app")
+		Expect(p.ConsumeMetrics(context.Background(), md)).To(Succeed())
+		Expect(p.ConsumeMetrics(context.Background(), sums("requests", "b"))).To(Succeed())
+
+		Expect(sink.DataPointCount()).To(Equal(2))
+	})
+
+	It("does not depend on the order of the attributes", func() {
+		cfg.Action = "drop"
+		p := start()
+
+		md := pmetric.NewMetrics()
+		dps := md.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty().Metrics().AppendEmpty().SetEmptyGauge().DataPoints()
+		for i := range 5 {
+			dp := dps.AppendEmpty()
+			if i%2 == 0 {
+				dp.Attributes().PutStr("method", "GET")
+				dp.Attributes().PutInt("status", 200)
+			} else {
+				dp.Attributes().PutInt("status", 200)
+				dp.Attributes().PutStr("method", "GET")
+			}
+		}
+		dps.At(4).Attributes().PutStr("status", "200")
+		Expect(p.ConsumeMetrics(context.Background(), md)).To(Succeed())
+
+		Expect(sink.DataPointCount()).To(Equal(5))
+	})
+
+	It("folds sums over the limit into the overflow series", func() {
+		p := start()
+
+		md := sums("requests", "a", "b", "c", "d", "e")
+		dps := md.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0).Sum().DataPoints()
+		for i := 0; i < dps.Len(); i++ {
+			dps.At(i).SetIntValue(int64(i + 1))
+			dps.At(i).SetTimestamp(pcommon.Timestamp(100 + i))
+		}
+		Expect(p.ConsumeMetrics(context.Background(), md)).To(Succeed())
+
+		out := sink.AllMetrics()[0].ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0).Sum().DataPoints()
+		Expect(out.Len()).To(Equal(3))
+		Expect(out.At(2).Attributes().AsRaw()).To(Equal(map[string]any{"otel.overflow": true}))
+		Expect(out.At(2).IntValue()).To(Equal(int64(3 + 4 + 5)))
+		Expect(out.At(2).Timestamp()).To(Equal(pcommon.Timestamp(104)))
+	})
+
+	It("folds histograms with the same buckets into the overflow series", func() {
+		p := start()
+
+		md := pmetric.NewMetrics()
+		m := md.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty().Metrics().AppendEmpty()
+		m.SetName("latency")
+		histogram := m.SetEmptyHistogram()
+		histogram.SetAggregationTemporality(pmetric.AggregationTemporalityDelta)
+		dps := histogram.DataPoints()
+		for i := range 4 {
+			dp := dps.AppendEmpty()
+			dp.Attributes().PutStr("path", fmt.Sprint(i))
+			dp.SetCount(2)
+			dp.SetSum(float64(i))
+			dp.ExplicitBounds().FromRaw([]float64{1})
+			dp.BucketCounts().FromRaw([]uint64{1, 1})
+		}
+		Expect(p.ConsumeMetrics(context.Background(), md)).To(Succeed())
+
+		out := sink.AllMetrics()[0].ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0).Histogram().DataPoints()
+		Expect(out.Len()).To(Equal(3))
+		Expect(out.At(2).Attributes().AsRaw()).To(Equal(map[string]any{"otel.overflow": true}))
+		Expect(out.At(2).Count()).To(Equal(uint64(4)))
+		Expect(out.At(2).Sum()).To(Equal(float64(2 + 3)))
+		Expect(out.At(2).BucketCounts().AsRaw()).To(Equal([]uint64{2, 2}))
+	})
+
+	It("drops cumulative sums over the limit instead of folding them", func() {
+		p := start()
+
+		md := sums("requests", "a", "b", "c", "d")
+		md.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0).Sum().SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
+		Expect(p.ConsumeMetrics(context.Background(), md)).To(Succeed())
+
+		Expect(paths(sink)).To(Equal([]string{"a", "b"}))
+
+		m, err := telemetry.GetMetric("otelcol_processor_cardinalitylimit_limited_datapoints")
+		Expect(err).NotTo(HaveOccurred())
+		dps := m.Data.(metricdata.Sum[int64]).DataPoints
+		Expect(dps).To(HaveLen(1))
+		Expect(dps[0].Value).To(Equal(int64(2)))
+		action, _ := dps[0].Attributes.Value(attribute.Key("action"))
+		Expect(action.AsString()).To(Equal("drop"))
+	})
+
+	It("drops cumulative histograms over the limit instead of folding them", func() {
+		p := start()
+
+		md := pmetric.NewMetrics()
+		m := md.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty().Metrics().AppendEmpty()
+		m.SetName("latency")
+		histogram := m.SetEmptyHistogram()
+		histogram.SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
+		for i := range 4 {
+			histogram.DataPoints().AppendEmpty().Attributes().PutStr("path", fmt.Sprint(i))
+		}
+		Expect(p.ConsumeMetrics(context.Background(), md)).To(Succeed())
+
+		out := sink.AllMetrics()[0].ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0).Histogram().DataPoints()
+		Expect(out.Len()).To(Equal(2))
+		for i := 0; i < out.Len(); i++ {
+			Expect(out.At(i).Attributes().AsRaw()).NotTo(HaveKey("otel.overflow"))
+		}
+	})
+
+	It("keeps the latest gauge in the overflow series", func() {
+		p := start()
+
+		md := pmetric.NewMetrics()
+		m := md.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty().Metrics().AppendEmpty()
+		m.SetName("cpu")
+		dps := m.SetEmptyGauge().DataPoints()
+		for i, ts := range []int{1, 2, 5, 3} {
+			dp := dps.AppendEmpty()
+			dp.Attributes().PutStr("instance", fmt.Sprint(i))
+			dp.SetTimestamp(pcommon.Timestamp(ts))
+			dp.SetDoubleValue(float64(ts))
+		}
+		Expect(p.ConsumeMetrics(context.Background(), md)).To(Succeed())
+
+		out := sink.AllMetrics()[0].ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0).Gauge().DataPoints()
+		Expect(out.Len()).To(Equal(3))
+		Expect(out.At(2).Attributes().AsRaw()).To(Equal(map[string]any{"otel.overflow": true}))
+		Expect(out.At(2).DoubleValue()).To(Equal(float64(5)))
+	})
+
+	It("lets new series in once old ones leave the window", func() {
+		cfg.Action = "drop"
+		cfg.Window = 100 * time.Millisecond
+		p := start()
+
+		Expect(p.ConsumeMetrics(context.Background(), sums("requests", "a", "b"))).To(Succeed())
+		Eventually(func() []string {
+			sink.Reset()
+			Expect(p.ConsumeMetrics(context.Background(), sums("requests", "c"))).To(Succeed())
+			return paths(sink)
+		}, 3*time.Second, 100*time.Millisecond).Should(Equal([]string{"c"}))
+	})
+
+	It("does not pass on batches where everything was dropped", func() {
+		cfg.Action = "drop"
+		p := start()
+
+		Expect(p.ConsumeMetrics(context.Background(), sums("requests", "a", "b"))).To(Succeed())
+		Expect(p.ConsumeMetrics(context.Background(), sums("requests", "c"))).To(Succeed())
+
+		Expect(sink.AllMetrics()).To(HaveLen(1))
+	})
+
+	It("reports cardinality and limited datapoints", func() {
+		p := start()
+
+		Expect(p.ConsumeMetrics(context.Background(), sums("requests", "a", "b", "c", "d"))).To(Succeed())
+		Expect(p.ConsumeMetrics(context.Background(), sums("latency", "a"))).To(Succeed())
+
+		m, err := telemetry.GetMetric("otelcol_processor_cardinalitylimit_series")
+		Expect(err).NotTo(HaveOccurred())
+		series := map[string]int64{}
+		for _, dp := range m.Data.(metricdata.Gauge[int64]).DataPoints {
+			name, _ := dp.Attributes.Value(attribute.Key("metric_name"))
+			series[name.AsString()] = dp.Value
+		}
+		Expect(series).To(Equal(map[string]int64{"requests": 2, "latency": 1}))
+
+		m, err = telemetry.GetMetric("otelcol_processor_cardinalitylimit_limited_datapoints")
+		Expect(err).NotTo(HaveOccurred())
+		dps := m.Data.(metricdata.Sum[int64]).DataPoints
+		Expect(dps).To(HaveLen(1))
+		Expect(dps[0].Value).To(Equal(int64(2)))
+		action, _ := dps[0].Attributes.Value(attribute.Key("action"))
+		Expect(action.AsString()).To(Equal("overflow"))
+	})
+})
+
+var _ = Describe("Config", func() {
+	var cfg *cardinalitylimitprocessor.Config
+
+	BeforeEach(func() {
+		cfg = cardinalitylimitprocessor.NewFactory().CreateDefaultConfig().(*cardinalitylimitprocessor.Config)
+	})
+
+	It("is valid by default", func() {
+		Expect(cfg.Validate()).To(Succeed())
+	})
+
+	It("requires positive limits and window", func() {
+		cfg.Limit = 0
+		Expect(cfg.Validate()).To(MatchError(`"limit" must be positive`))
+
+		cfg.Limit = 10
+		cfg.MetricLimits = map[string]int{"requests": -1}
+		Expect(cfg.Validate()).To(MatchError(`metric_limits: limit for "requests" must be positive`))
+
+		cfg.MetricLimits = nil
+		cfg.Window = 0
+		Expect(cfg.Validate()).To(MatchError(`"window" must be positive`))
+	})
+
+	It("requires a known action", func() {
+		cfg.Action = "sample"
+		Expect(cfg.Validate()).To(MatchError(ContainSubstring(`unsupported action "sample"`)))
+	})
+})
+
+// sums returns a sum metric with a datapoint per path attribute value.
+func sums(name string, paths ...string) pmetric.Metrics {
+	md := pmetric.NewMetrics()
+	rm := md.ResourceMetrics().AppendEmpty()
+	rm.Resource().Attributes().PutStr("app_id", "app")
+	m := rm.ScopeMetrics().AppendEmpty().Metrics().AppendEmpty()
+	m.SetName(name)
+	sum := m.SetEmptySum()
+	sum.SetAggregationTemporality(pmetric.AggregationTemporalityDelta)
+	dps := sum.DataPoints()
+	for _, path := range paths {
+		dp := dps.AppendEmpty()
+		dp.Attributes().PutStr("path", path)
+		dp.SetIntValue(1)
+	}
+	return md
+}
+
+func paths(sink *consumertest.MetricsSink) []string {
+	var paths []string
+	for _, md := range sink.AllMetrics() {
+		dps := md.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0).Sum().DataPoints()
+		for i := 0; i < dps.Len(); i++ {
+			path, _ := dps.At(i).Attributes().Get("path")
+			paths = append(paths, path.Str())
+		}
+	}
+	return paths
+}
@@ -0,0 +1,99 @@
+package cardinalitylimitprocessor
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/otel-collector-release/src/components/internal/series"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+const scopeName = "code.cloudfoundry.org/otel-collector-release/src/components/processor/cardinalitylimitprocessor"
+
+// seriesID identifies a series by a hash of its resource and datapoint
+// attributes.
+type seriesID = series.ID
+
+// tracker keeps the series seen per metric name with the time of their last
+// datapoint. Series whose last datapoint is older than the window are
+// removed, which lets new series in again.
+type tracker struct {
+	cfg *Config
+
+	mu     sync.Mutex
+	series map[string]map[seriesID]time.Time
+
+	sweeper *series.Sweeper
+}
+
+func newTracker(cfg *Config) *tracker {
+	t := &tracker{
+		cfg:    cfg,
+		series: map[string]map[seriesID]time.Time{},
+	}
+	t.sweeper = series.NewSweeper(cfg.Window, t.sweep)
+	return t
+}
+
+func (t *tracker) start() {
+	t.sweeper.Start()
+}
+
+func (t *tracker) shutdown() {
+	t.sweeper.Shutdown()
+}
+
+// observe records a datapoint for the series and reports whether the series
+// is within the limit of its metric.
+func (t *tracker) observe(name string, id seriesID, now time.Time) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	series, ok := t.series[name]
+	if !ok {
+		series = map[seriesID]time.Time{}
+		t.series[name] = series
+	}
+
+	if _, ok := series[id]; ok {
+		series[id] = now
+		return true
+	}
+	if len(series) >= t.cfg.limitFor(name) {
+		return false
+	}
+	series[id] = now
+	return true
+}
+
+func (t *tracker) sweep(now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for name, series := range t.series {
+		for id, lastSeen := range series {
+			if now.Sub(lastSeen) > t.cfg.Window {
+				delete(series, id)
+			}
+		}
+		if len(series) == 0 {
+			delete(t.series, name)
+		}
+	}
+}
+
+// observeCardinality reports the number of series per metric name.
+func (t *tracker) observeCardinality(gauge metric.Int64ObservableGauge, attrs []attribute.KeyValue) metric.Callback {
+	return func(_ context.Context, o metric.Observer) error {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+
+		for name, series := range t.series {
+			kvs := append(attrs[:len(attrs):len(attrs)], attribute.String("metric_name", name))
+			o.ObserveInt64(gauge, int64(len(series)), metric.WithAttributes(kvs...))
+		}
+		return nil
+	}
+}
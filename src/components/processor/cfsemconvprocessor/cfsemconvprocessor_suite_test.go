@@ -0,0 +1,13 @@
+package cfsemconvprocessor_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestCFSemconvProcessor(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "CF Semconv Processor Suite")
+}
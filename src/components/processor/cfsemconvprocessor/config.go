@@ -0,0 +1,37 @@
+package cfsemconvprocessor
+
+import (
+	"fmt"
+
+	"go.opentelemetry.io/collector/component"
+)
+
+const (
+	// modeToSemconv lifts Loggregator attributes into resource attributes
+	// with semantic convention names.
+	modeToSemconv = "to_semconv"
+
+	// modeToLoggregator pushes semantic convention resource attributes down
+	// onto every record with their Loggregator names.
+	modeToLoggregator = "to_loggregator"
+)
+
+// Config defines the configuration for the CF semantic conventions
+// processor.
+type Config struct {
+	// Mode is the direction of the mapping, either to_semconv or
+	// to_loggregator.
+	Mode string `mapstructure:"mode"`
+}
+
+var _ component.Config = (*Config)(nil)
+
+// Validate checks that the mode is supported.
+func (cfg *Config) Validate() error {
+	switch cfg.Mode {
+	case modeToSemconv, modeToLoggregator:
+		return nil
+	default:
+		return fmt.Errorf("unsupported mode %q, must be %s or %s", cfg.Mode, modeToSemconv, modeToLoggregator)
+	}
+}
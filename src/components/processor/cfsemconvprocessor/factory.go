@@ -0,0 +1,60 @@
+// Package cfsemconvprocessor implements a processor that maps the attributes
+// CF components emit with Loggregator names to and from OpenTelemetry
+// semantic convention resource attributes.
+package cfsemconvprocessor
+
+import (
+	"context"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/processor"
+	"go.opentelemetry.io/collector/processor/processorhelper"
+)
+
+const stability = component.StabilityLevelAlpha
+
+var componentType = component.MustNewType("cfsemconv")
+
+var processorCapabilities = consumer.Capabilities{MutatesData: true}
+
+// NewFactory creates a factory for the CF semantic conventions processor.
+func NewFactory() processor.Factory {
+	return processor.NewFactory(
+		componentType,
+		createDefaultConfig,
+		processor.WithLogs(createLogs, stability),
+		processor.WithMetrics(createMetrics, stability),
+		processor.WithTraces(createTraces, stability),
+	)
+}
+
+func createDefaultConfig() component.Config {
+	return &Config{
+		Mode: modeToSemconv,
+	}
+}
+
+func createLogs(ctx context.Context, set processor.Settings, cfg component.Config, next consumer.Logs) (processor.Logs, error) {
+	p := newCFSemconvProcessor(cfg.(*Config))
+	return processorhelper.NewLogs(ctx, set, cfg, next,
+		p.processLogs,
+		processorhelper.WithCapabilities(processorCapabilities),
+	)
+}
+
+func createMetrics(ctx context.Context, set processor.Settings, cfg component.Config, next consumer.Metrics) (processor.Metrics, error) {
+	p := newCFSemconvProcessor(cfg.(*Config))
+	return processorhelper.NewMetrics(ctx, set, cfg, next,
+		p.processMetrics,
+		processorhelper.WithCapabilities(processorCapabilities),
+	)
+}
+
+func createTraces(ctx context.Context, set processor.Settings, cfg component.Config, next consumer.Traces) (processor.Traces, error) {
+	p := newCFSemconvProcessor(cfg.(*Config))
+	return processorhelper.NewTraces(ctx, set, cfg, next,
+		p.processTraces,
+		processorhelper.WithCapabilities(processorCapabilities),
+	)
+}
@@ -0,0 +1,93 @@
+package cfsemconvprocessor
+
+import (
+	"strings"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+)
+
+// attributeMapping pairs a Loggregator attribute with its semantic
+// convention name.
+type attributeMapping struct {
+	loggregator string
+	semconv     string
+}
+
+// mappings lists the attributes CF components emit and the resource
+// attributes they describe. The order is used for grouping keys, so entries
+// must only be appended.
+var mappings = []attributeMapping{
+	{"source_id", "service.name"},
+	{"instance_id", "service.instance.id"},
+	{"app_id", "cloudfoundry.app.id"},
+	{"app_name", "cloudfoundry.app.name"},
+	{"organization_id", "cloudfoundry.org.id"},
+	{"organization_name", "cloudfoundry.org.name"},
+	{"space_id", "cloudfoundry.space.id"},
+	{"space_name", "cloudfoundry.space.name"},
+	{"process_id", "cloudfoundry.process.id"},
+	{"process_type", "cloudfoundry.process.type"},
+	{"origin", "cloudfoundry.system.id"},
+	{"index", "cloudfoundry.system.instance.id"},
+	{"deployment", "bosh.deployment"},
+	{"job", "bosh.instance_group"},
+	{"ip", "host.ip"},
+}
+
+// lift removes the Loggregator attributes from attrs and returns them with
+// their semantic convention names, together with a key identifying the
+// lifted values.
+func lift(attrs pcommon.Map) (pcommon.Map, string) {
+	lifted := pcommon.NewMap()
+	var key strings.Builder
+
+	for _, m := range mappings {
+		v, ok := attrs.Get(m.loggregator)
+		if !ok {
+			continue
+		}
+		key.WriteString(m.semconv)
+		key.WriteByte('=')
+		key.WriteString(v.AsString())
+		key.WriteByte(0)
+
+		v.CopyTo(lifted.PutEmpty(m.semconv))
+		attrs.Remove(m.loggregator)
+	}
+
+	return lifted, key.String()
+}
+
+// renameToSemconv renames the Loggregator attributes on a resource in place.
+func renameToSemconv(resource pcommon.Map) {
+	for _, m := range mappings {
+		if v, ok := resource.Get(m.loggregator); ok {
+			v.CopyTo(resource.PutEmpty(m.semconv))
+			resource.Remove(m.loggregator)
+		}
+	}
+}
+
+// lower removes the semantic convention attributes from a resource and
+// returns them with their Loggregator names.
+func lower(resource pcommon.Map) pcommon.Map {
+	lowered := pcommon.NewMap()
+	for _, m := range mappings {
+		if v, ok := resource.Get(m.semconv); ok {
+			v.CopyTo(lowered.PutEmpty(m.loggregator))
+			resource.Remove(m.semconv)
+		}
+	}
+	return lowered
+}
+
+// putMissing copies every attribute from src that is not already set in
+// dest, so that record level values win over resource level ones.
+func putMissing(dest, src pcommon.Map) {
+	src.Range(func(k string, v pcommon.Value) bool {
+		if _, ok := dest.Get(k); !ok {
+			v.CopyTo(dest.PutEmpty(k))
+		}
+		return true
+	})
+}
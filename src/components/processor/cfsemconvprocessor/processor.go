@@ -0,0 +1,336 @@
+package cfsemconvprocessor
+
+import (
+	"context"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+type cfSemconvProcessor struct {
+	cfg *Config
+}
+
+func newCFSemconvProcessor(cfg *Config) *cfSemconvProcessor {
+	return &cfSemconvProcessor{cfg: cfg}
+}
+
+func (p *cfSemconvProcessor) processLogs(_ context.Context, ld plog.Logs) (plog.Logs, error) {
+	if p.cfg.Mode == modeToLoggregator {
+		lowerLogs(ld)
+		return ld, nil
+	}
+	return liftLogs(ld), nil
+}
+
+func (p *cfSemconvProcessor) processMetrics(_ context.Context, md pmetric.Metrics) (pmetric.Metrics, error) {
+	if p.cfg.Mode == modeToLoggregator {
+		lowerMetrics(md)
+		return md, nil
+	}
+	return liftMetrics(md), nil
+}
+
+func (p *cfSemconvProcessor) processTraces(_ context.Context, td ptrace.Traces) (ptrace.Traces, error) {
+	if p.cfg.Mode == modeToLoggregator {
+		lowerTraces(td)
+		return td, nil
+	}
+	return liftTraces(td), nil
+}
+
+// logsGroup is a resource created for one set of lifted attributes, with the
+// scopes of the original resource created on demand.
+type logsGroup struct {
+	rl     plog.ResourceLogs
+	scopes map[int]plog.ScopeLogs
+}
+
+// liftLogs moves the Loggregator attributes of every log record onto its
+// resource and regroups the records so that each resource only holds records
+// that share the same lifted values.
+func liftLogs(ld plog.Logs) plog.Logs {
+	out := plog.NewLogs()
+
+	for i := 0; i < ld.ResourceLogs().Len(); i++ {
+		rl := ld.ResourceLogs().At(i)
+		renameToSemconv(rl.Resource().Attributes())
+		groups := map[string]*logsGroup{}
+
+		for j := 0; j < rl.ScopeLogs().Len(); j++ {
+			sl := rl.ScopeLogs().At(j)
+			for k := 0; k < sl.LogRecords().Len(); k++ {
+				lr := sl.LogRecords().At(k)
+				lifted, key := lift(lr.Attributes())
+
+				g, ok := groups[key]
+				if !ok {
+					g = &logsGroup{rl: out.ResourceLogs().AppendEmpty(), scopes: map[int]plog.ScopeLogs{}}
+					rl.Resource().CopyTo(g.rl.Resource())
+					g.rl.SetSchemaUrl(rl.SchemaUrl())
+					overlay(g.rl.Resource().Attributes(), lifted)
+					groups[key] = g
+				}
+
+				ns, ok := g.scopes[j]
+				if !ok {
+					ns = g.rl.ScopeLogs().AppendEmpty()
+					sl.Scope().CopyTo(ns.Scope())
+					ns.SetSchemaUrl(sl.SchemaUrl())
+					g.scopes[j] = ns
+				}
+				lr.MoveTo(ns.LogRecords().AppendEmpty())
+			}
+		}
+	}
+
+	return out
+}
+
+type tracesGroup struct {
+	rs     ptrace.ResourceSpans
+	scopes map[int]ptrace.ScopeSpans
+}
+
+// liftTraces moves the Loggregator attributes of every span onto its
+// resource, regrouping spans the same way as liftLogs.
+func liftTraces(td ptrace.Traces) ptrace.Traces {
+	out := ptrace.NewTraces()
+
+	for i := 0; i < td.ResourceSpans().Len(); i++ {
+		rs := td.ResourceSpans().At(i)
+		renameToSemconv(rs.Resource().Attributes())
+		groups := map[string]*tracesGroup{}
+
+		for j := 0; j < rs.ScopeSpans().Len(); j++ {
+			ss := rs.ScopeSpans().At(j)
+			for k := 0; k < ss.Spans().Len(); k++ {
+				span := ss.Spans().At(k)
+				lifted, key := lift(span.Attributes())
+
+				g, ok := groups[key]
+				if !ok {
+					g = &tracesGroup{rs: out.ResourceSpans().AppendEmpty(), scopes: map[int]ptrace.ScopeSpans{}}
+					rs.Resource().CopyTo(g.rs.Resource())
+					g.rs.SetSchemaUrl(rs.SchemaUrl())
+					overlay(g.rs.Resource().Attributes(), lifted)
+					groups[key] = g
+				}
+
+				ns, ok := g.scopes[j]
+				if !ok {
+					ns = g.rs.ScopeSpans().AppendEmpty()
+					ss.Scope().CopyTo(ns.Scope())
+					ns.SetSchemaUrl(ss.SchemaUrl())
+					g.scopes[j] = ns
+				}
+				span.MoveTo(ns.Spans().AppendEmpty())
+			}
+		}
+	}
+
+	return out
+}
+
+// metricsGroup additionally tracks the metrics created for each original
+// metric, keyed by scope and metric index.
+type metricsGroup struct {
+	rm      pmetric.ResourceMetrics
+	scopes  map[int]pmetric.ScopeMetrics
+	metrics map[[2]int]pmetric.Metric
+}
+
+// liftMetrics moves the Loggregator attributes of every datapoint onto its
+// resource, regrouping datapoints the same way as liftLogs. A metric whose
+// datapoints end up in several resources is copied into each of them.
+func liftMetrics(md pmetric.Metrics) pmetric.Metrics {
+	out := pmetric.NewMetrics()
+
+	for i := 0; i < md.ResourceMetrics().Len(); i++ {
+		rm := md.ResourceMetrics().At(i)
+		renameToSemconv(rm.Resource().Attributes())
+		groups := map[string]*metricsGroup{}
+
+		metricFor := func(attrs pcommon.Map, j, k int) pmetric.Metric {
+			lifted, key := lift(attrs)
+
+			g, ok := groups[key]
+			if !ok {
+				g = &metricsGroup{
+					rm:      out.ResourceMetrics().AppendEmpty(),
+					scopes:  map[int]pmetric.ScopeMetrics{},
+					metrics: map[[2]int]pmetric.Metric{},
+				}
+				rm.Resource().CopyTo(g.rm.Resource())
+				g.rm.SetSchemaUrl(rm.SchemaUrl())
+				overlay(g.rm.Resource().Attributes(), lifted)
+				groups[key] = g
+			}
+
+			sm := rm.ScopeMetrics().At(j)
+			ns, ok := g.scopes[j]
+			if !ok {
+				ns = g.rm.ScopeMetrics().AppendEmpty()
+				sm.Scope().CopyTo(ns.Scope())
+				ns.SetSchemaUrl(sm.SchemaUrl())
+				g.scopes[j] = ns
+			}
+
+			nm, ok := g.metrics[[2]int{j, k}]
+			if !ok {
+				nm = ns.Metrics().AppendEmpty()
+				copyMetricDescriptor(sm.Metrics().At(k), nm)
+				g.metrics[[2]int{j, k}] = nm
+			}
+			return nm
+		}
+
+		for j := 0; j < rm.ScopeMetrics().Len(); j++ {
+			metrics := rm.ScopeMetrics().At(j).Metrics()
+			for k := 0; k < metrics.Len(); k++ {
+				m := metrics.At(k)
+
+				switch m.Type() {
+				case pmetric.MetricTypeGauge:
+					dps := m.Gauge().DataPoints()
+					for l := 0; l < dps.Len(); l++ {
+						nm := metricFor(dps.At(l).Attributes(), j, k)
+						dps.At(l).MoveTo(nm.Gauge().DataPoints().AppendEmpty())
+					}
+				case pmetric.MetricTypeSum:
+					dps := m.Sum().DataPoints()
+					for l := 0; l < dps.Len(); l++ {
+						nm := metricFor(dps.At(l).Attributes(), j, k)
+						dps.At(l).MoveTo(nm.Sum().DataPoints().AppendEmpty())
+					}
+				case pmetric.MetricTypeHistogram:
+					dps := m.Histogram().DataPoints()
+					for l := 0; l < dps.Len(); l++ {
+						nm := metricFor(dps.At(l).Attributes(), j, k)
+						dps.At(l).MoveTo(nm.Histogram().DataPoints().AppendEmpty())
+					}
+				case pmetric.MetricTypeExponentialHistogram:
+					dps := m.ExponentialHistogram().DataPoints()
+					for l := 0; l < dps.Len(); l++ {
+						nm := metricFor(dps.At(l).Attributes(), j, k)
+						dps.At(l).MoveTo(nm.ExponentialHistogram().DataPoints().AppendEmpty())
+					}
+				case pmetric.MetricTypeSummary:
+					dps := m.Summary().DataPoints()
+					for l := 0; l < dps.Len(); l++ {
+						nm := metricFor(dps.At(l).Attributes(), j, k)
+						dps.At(l).MoveTo(nm.Summary().DataPoints().AppendEmpty())
+					}
+				}
+			}
+		}
+	}
+
+	return out
+}
+
+// copyMetricDescriptor copies everything but the datapoints of a metric.
+func copyMetricDescriptor(from, to pmetric.Metric) {
+	to.SetName(from.Name())
+	to.SetDescription(from.Description())
+	to.SetUnit(from.Unit())
+	from.Metadata().CopyTo(to.Metadata())
+
+	switch from.Type() {
+	case pmetric.MetricTypeGauge:
+		to.SetEmptyGauge()
+	case pmetric.MetricTypeSum:
+		to.SetEmptySum().SetAggregationTemporality(from.Sum().AggregationTemporality())
+		to.Sum().SetIsMonotonic(from.Sum().IsMonotonic())
+	case pmetric.MetricTypeHistogram:
+		to.SetEmptyHistogram().SetAggregationTemporality(from.Histogram().AggregationTemporality())
+	case pmetric.MetricTypeExponentialHistogram:
+		to.SetEmptyExponentialHistogram().SetAggregationTemporality(from.ExponentialHistogram().AggregationTemporality())
+	case pmetric.MetricTypeSummary:
+		to.SetEmptySummary()
+	}
+}
+
+// overlay copies every attribute from src into dest, replacing existing
+// values.
+func overlay(dest, src pcommon.Map) {
+	src.Range(func(k string, v pcommon.Value) bool {
+		v.CopyTo(dest.PutEmpty(k))
+		return true
+	})
+}
+
+// lowerLogs moves the semantic convention resource attributes onto every log
+// record with their Loggregator names.
+func lowerLogs(ld plog.Logs) {
+	for i := 0; i < ld.ResourceLogs().Len(); i++ {
+		rl := ld.ResourceLogs().At(i)
+		lowered := lower(rl.Resource().Attributes())
+		for j := 0; j < rl.ScopeLogs().Len(); j++ {
+			records := rl.ScopeLogs().At(j).LogRecords()
+			for k := 0; k < records.Len(); k++ {
+				putMissing(records.At(k).Attributes(), lowered)
+			}
+		}
+	}
+}
+
+// lowerTraces moves the semantic convention resource attributes onto every
+// span with their Loggregator names.
+func lowerTraces(td ptrace.Traces) {
+	for i := 0; i < td.ResourceSpans().Len(); i++ {
+		rs := td.ResourceSpans().At(i)
+		lowered := lower(rs.Resource().Attributes())
+		for j := 0; j < rs.ScopeSpans().Len(); j++ {
+			spans := rs.ScopeSpans().At(j).Spans()
+			for k := 0; k < spans.Len(); k++ {
+				putMissing(spans.At(k).Attributes(), lowered)
+			}
+		}
+	}
+}
+
+// lowerMetrics moves the semantic convention resource attributes onto every
+// datapoint with their Loggregator names.
+func lowerMetrics(md pmetric.Metrics) {
+	for i := 0; i < md.ResourceMetrics().Len(); i++ {
+		rm := md.ResourceMetrics().At(i)
+		lowered := lower(rm.Resource().Attributes())
+		for j := 0; j < rm.ScopeMetrics().Len(); j++ {
+			metrics := rm.ScopeMetrics().At(j).Metrics()
+			for k := 0; k < metrics.Len(); k++ {
+				m := metrics.At(k)
+
+				switch m.Type() {
+				case pmetric.MetricTypeGauge:
+					dps := m.Gauge().DataPoints()
+					for l := 0; l < dps.Len(); l++ {
+						putMissing(dps.At(l).Attributes(), lowered)
+					}
+				case pmetric.MetricTypeSum:
+					dps := m.Sum().DataPoints()
+					for l := 0; l < dps.Len(); l++ {
+						putMissing(dps.At(l).Attributes(), lowered)
+					}
+				case pmetric.MetricTypeHistogram:
+					dps := m.Histogram().DataPoints()
+					for l := 0; l < dps.Len(); l++ {
+						putMissing(dps.At(l).Attributes(), lowered)
+					}
+				case pmetric.MetricTypeExponentialHistogram:
+					dps := m.ExponentialHistogram().DataPoints()
+					for l := 0; l < dps.Len(); l++ {
+						putMissing(dps.At(l).Attributes(), lowered)
+					}
+				case pmetric.MetricTypeSummary:
+					dps := m.Summary().DataPoints()
+					for l := 0; l < dps.Len(); l++ {
+						putMissing(dps.At(l).Attributes(), lowered)
+					}
+				}
+			}
+		}
+	}
+}
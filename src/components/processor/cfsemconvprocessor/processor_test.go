@@ -0,0 +1,247 @@
+package cfsemconvprocessor_test
+
+import (
+	"context"
+
+	"code.cloudfoundry.org/otel-collector-release/src/components/processor/cfsemconvprocessor"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.opentelemetry.io/collector/component/componenttest"
+	"go.opentelemetry.io/collector/consumer/consumertest"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.opentelemetry.io/collector/processor/processortest"
+)
+
+var _ = Describe("CF semconv processor", func() {
+	var cfg *cfsemconvprocessor.Config
+
+	BeforeEach(func() {
+		cfg = cfsemconvprocessor.NewFactory().CreateDefaultConfig().(*cfsemconvprocessor.Config)
+	})
+
+	processLogs := func(ld plog.Logs) plog.Logs {
+		sink := new(consumertest.LogsSink)
+		factory := cfsemconvprocessor.NewFactory()
+		p, err := factory.CreateLogs(context.Background(), processortest.NewNopSettings(factory.Type()), cfg, sink)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(p.Start(context.Background(), componenttest.NewNopHost())).To(Succeed())
+		DeferCleanup(p.Shutdown, context.Background())
+
+		Expect(p.ConsumeLogs(context.Background(), ld)).To(Succeed())
+		Expect(sink.AllLogs()).To(HaveLen(1))
+		return sink.AllLogs()[0]
+	}
+
+	processMetrics := func(md pmetric.Metrics) pmetric.Metrics {
+		sink := new(consumertest.MetricsSink)
+		factory := cfsemconvprocessor.NewFactory()
+		p, err := factory.CreateMetrics(context.Background(), processortest.NewNopSettings(factory.Type()), cfg, sink)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(p.Start(context.Background(), componenttest.NewNopHost())).To(Succeed())
+		DeferCleanup(p.Shutdown, context.Background())
+
+		Expect(p.ConsumeMetrics(context.Background(), md)).To(Succeed())
+		Expect(sink.AllMetrics()).To(HaveLen(1))
+		return sink.AllMetrics()[0]
+	}
+
+	processTraces := func(td ptrace.Traces) ptrace.Traces {
+		sink := new(consumertest.TracesSink)
+		factory := cfsemconvprocessor.NewFactory()
+		p, err := factory.CreateTraces(context.Background(), processortest.NewNopSettings(factory.Type()), cfg, sink)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(p.Start(context.Background(), componenttest.NewNopHost())).To(Succeed())
+		DeferCleanup(p.Shutdown, context.Background())
+
+		Expect(p.ConsumeTraces(context.Background(), td)).To(Succeed())
+		Expect(sink.AllTraces()).To(HaveLen(1))
+		return sink.AllTraces()[0]
+	}
+
+	Describe("to_semconv", func() {
+		It("lifts log record attributes into resource attributes", func() {
+			ld := plog.NewLogs()
+			rl := ld.ResourceLogs().AppendEmpty()
+			rl.Resource().Attributes().PutStr("deployment", "cf")
+			sl := rl.ScopeLogs().AppendEmpty()
+			sl.Scope().SetName("loggregator")
+			lr := sl.LogRecords().AppendEmpty()
+			lr.Body().SetStr("Added process: \"web\"")
+			lr.Attributes().PutStr("source_id", "64f96e84-e84d-4543-bf85-9cbfc567aa44")
+			lr.Attributes().PutStr("instance_id", "0")
+			lr.Attributes().PutStr("app_id", "64f96e84-e84d-4543-bf85-9cbfc567aa44")
+			lr.Attributes().PutStr("app_name", "tanzu-hub-sli-test-app")
+			lr.Attributes().PutStr("organization_name", "system")
+			lr.Attributes().PutStr("space_name", "tanzu-hub-collector")
+			lr.Attributes().PutStr("origin", "cloud_controller")
+			lr.Attributes().PutStr("source_type", "API")
+
+			out := processLogs(ld)
+
+			Expect(out.ResourceLogs().Len()).To(Equal(1))
+			Expect(out.ResourceLogs().At(0).Resource().Attributes().AsRaw()).To(Equal(map[string]any{
+				"service.name":            "64f96e84-e84d-4543-bf85-9cbfc567aa44",
+				"service.instance.id":     "0",
+				"cloudfoundry.app.id":     "64f96e84-e84d-4543-bf85-9cbfc567aa44",
+				"cloudfoundry.app.name":   "tanzu-hub-sli-test-app",
+				"cloudfoundry.org.name":   "system",
+				"cloudfoundry.space.name": "tanzu-hub-collector",
+				"cloudfoundry.system.id":  "cloud_controller",
+				"bosh.deployment":         "cf",
+			}))
+
+			scope := out.ResourceLogs().At(0).ScopeLogs().At(0)
+			Expect(scope.Scope().Name()).To(Equal("loggregator"))
+			record := scope.LogRecords().At(0)
+			Expect(record.Body().Str()).To(Equal("Added process: \"web\""))
+			Expect(record.Attributes().AsRaw()).To(Equal(map[string]any{"source_type": "API"}))
+		})
+
+		It("regroups records by their lifted attributes", func() {
+			ld := plog.NewLogs()
+			records := ld.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords()
+			for _, sourceID := range []string{"gorouter", "uaa", "gorouter", ""} {
+				lr := records.AppendEmpty()
+				lr.Body().SetStr(sourceID)
+				if sourceID != "" {
+					lr.Attributes().PutStr("source_id", sourceID)
+				}
+			}
+
+			out := processLogs(ld)
+
+			Expect(out.ResourceLogs().Len()).To(Equal(3))
+			Expect(out.ResourceLogs().At(0).Resource().Attributes().AsRaw()).To(Equal(map[string]any{"service.name": "gorouter"}))
+			Expect(out.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().Len()).To(Equal(2))
+			Expect(out.ResourceLogs().At(1).Resource().Attributes().AsRaw()).To(Equal(map[string]any{"service.name": "uaa"}))
+			Expect(out.ResourceLogs().At(2).Resource().Attributes().AsRaw()).To(BeEmpty())
+			Expect(out.LogRecordCount()).To(Equal(4))
+		})
+
+		It("regroups datapoints and keeps the metric descriptors", func() {
+			md := pmetric.NewMetrics()
+			m := md.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty().Metrics().AppendEmpty()
+			m.SetName("total_requests")
+			m.SetUnit("1")
+			m.SetEmptySum().SetIsMonotonic(true)
+			m.Sum().SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
+			for _, index := range []string{"a", "b", "a"} {
+				dp := m.Sum().DataPoints().AppendEmpty()
+				dp.SetIntValue(1)
+				dp.Attributes().PutStr("source_id", "gorouter")
+				dp.Attributes().PutStr("index", index)
+				dp.Attributes().PutStr("route", "/")
+			}
+
+			out := processMetrics(md)
+
+			Expect(out.ResourceMetrics().Len()).To(Equal(2))
+			for i, index := range []string{"a", "b"} {
+				rm := out.ResourceMetrics().At(i)
+				Expect(rm.Resource().Attributes().AsRaw()).To(Equal(map[string]any{
+					"service.name":                    "gorouter",
+					"cloudfoundry.system.instance.id": index,
+				}))
+				metric := rm.ScopeMetrics().At(0).Metrics().At(0)
+				Expect(metric.Name()).To(Equal("total_requests"))
+				Expect(metric.Unit()).To(Equal("1"))
+				Expect(metric.Sum().IsMonotonic()).To(BeTrue())
+				Expect(metric.Sum().AggregationTemporality()).To(Equal(pmetric.AggregationTemporalityCumulative))
+				Expect(metric.Sum().DataPoints().At(0).Attributes().AsRaw()).To(Equal(map[string]any{"route": "/"}))
+			}
+			Expect(out.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0).Sum().DataPoints().Len()).To(Equal(2))
+			Expect(out.DataPointCount()).To(Equal(3))
+		})
+
+		It("lifts span attributes", func() {
+			td := ptrace.NewTraces()
+			span := td.ResourceSpans().AppendEmpty().ScopeSpans().AppendEmpty().Spans().AppendEmpty()
+			span.SetName("/")
+			span.Attributes().PutStr("source_id", "gorouter")
+			span.Attributes().PutStr("ip", "10.0.4.7")
+
+			out := processTraces(td)
+
+			Expect(out.ResourceSpans().At(0).Resource().Attributes().AsRaw()).To(Equal(map[string]any{
+				"service.name": "gorouter",
+				"host.ip":      "10.0.4.7",
+			}))
+			Expect(out.ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0).Attributes().Len()).To(BeZero())
+		})
+	})
+
+	Describe("to_loggregator", func() {
+		BeforeEach(func() {
+			cfg.Mode = "to_loggregator"
+		})
+
+		It("pushes resource attributes down onto records with their Loggregator names", func() {
+			ld := plog.NewLogs()
+			rl := ld.ResourceLogs().AppendEmpty()
+			rl.Resource().Attributes().PutStr("service.name", "64f96e84-e84d-4543-bf85-9cbfc567aa44")
+			rl.Resource().Attributes().PutStr("cloudfoundry.org.name", "system")
+			rl.Resource().Attributes().PutStr("host.name", "control-0")
+			records := rl.ScopeLogs().AppendEmpty().LogRecords()
+			records.AppendEmpty().Body().SetStr("first")
+			second := records.AppendEmpty()
+			second.Attributes().PutStr("organization_name", "other")
+
+			out := processLogs(ld)
+
+			Expect(out.ResourceLogs().At(0).Resource().Attributes().AsRaw()).To(Equal(map[string]any{"host.name": "control-0"}))
+			got := out.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords()
+			Expect(got.At(0).Attributes().AsRaw()).To(Equal(map[string]any{
+				"source_id":         "64f96e84-e84d-4543-bf85-9cbfc567aa44",
+				"organization_name": "system",
+			}))
+			Expect(got.At(1).Attributes().AsRaw()).To(HaveKeyWithValue("organization_name", "other"))
+		})
+
+		It("pushes resource attributes down onto datapoints", func() {
+			md := pmetric.NewMetrics()
+			rm := md.ResourceMetrics().AppendEmpty()
+			rm.Resource().Attributes().PutStr("service.name", "system_metrics_agent")
+			rm.Resource().Attributes().PutStr("bosh.deployment", "cf")
+			rm.ScopeMetrics().AppendEmpty().Metrics().AppendEmpty().SetEmptyGauge().DataPoints().AppendEmpty().SetDoubleValue(1)
+
+			out := processMetrics(md)
+
+			dp := out.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0).Gauge().DataPoints().At(0)
+			Expect(dp.Attributes().AsRaw()).To(Equal(map[string]any{
+				"source_id":  "system_metrics_agent",
+				"deployment": "cf",
+			}))
+		})
+
+		It("round trips with to_semconv", func() {
+			ld := plog.NewLogs()
+			lr := ld.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords().AppendEmpty()
+			lr.Attributes().PutStr("source_id", "uaa")
+			lr.Attributes().PutStr("instance_id", "1")
+			lr.Attributes().PutStr("source_type", "UAA")
+			expected := plog.NewLogs()
+			ld.CopyTo(expected)
+
+			cfg.Mode = "to_semconv"
+			lifted := processLogs(ld)
+			cfg.Mode = "to_loggregator"
+			out := processLogs(lifted)
+
+			Expect(out.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0).Attributes().AsRaw()).To(Equal(
+				expected.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0).Attributes().AsRaw(),
+			))
+		})
+	})
+})
+
+var _ = Describe("Config", func() {
+	It("rejects unknown modes", func() {
+		cfg := cfsemconvprocessor.NewFactory().CreateDefaultConfig().(*cfsemconvprocessor.Config)
+		Expect(cfg.Validate()).To(Succeed())
+
+		cfg.Mode = "sideways"
+		Expect(cfg.Validate()).To(MatchError(ContainSubstring(`unsupported mode "sideways"`)))
+	})
+})
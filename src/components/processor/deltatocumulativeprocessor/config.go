@@ -0,0 +1,33 @@
+package deltatocumulativeprocessor
+
+import (
+	"errors"
+	"time"
+
+	"go.opentelemetry.io/collector/component"
+)
+
+// Config defines the configuration for the delta to cumulative processor.
+type Config struct {
+	// MaxStale is how long a series is kept after its last datapoint. A
+	// series that receives a datapoint after it was evicted starts again
+	// from zero.
+	MaxStale time.Duration `mapstructure:"max_stale"`
+
+	// MaxStreams is the most series kept at once. Datapoints of new series
+	// are dropped while the limit is reached.
+	MaxStreams int `mapstructure:"max_streams"`
+}
+
+var _ component.Config = (*Config)(nil)
+
+// Validate checks that the staleness and the memory cap are positive.
+func (cfg *Config) Validate() error {
+	if cfg.MaxStale <= 0 {
+		return errors.New(`"max_stale" must be positive`)
+	}
+	if cfg.MaxStreams <= 0 {
+		return errors.New(`"max_streams" must be positive`)
+	}
+	return nil
+}
@@ -0,0 +1,13 @@
+package deltatocumulativeprocessor_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestDeltaToCumulativeProcessor(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Delta To Cumulative Processor Suite")
+}
@@ -0,0 +1,161 @@
+package deltatocumulativeprocessor
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"code.cloudfoundry.org/otel-collector-release/src/components/internal/series"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/processor/processorhelper"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+const (
+	// reasonLimit is the reason for dropping datapoints of new series while
+	// max_streams series are kept.
+	reasonLimit = "limit"
+
+	// reasonOutOfOrder is the reason for dropping datapoints that are not
+	// newer than the previous datapoint of their series.
+	reasonOutOfOrder = "out_of_order"
+
+	// reasonOverlap is the reason for dropping datapoints that start before
+	// the previous datapoint of their series ended, as part of what they
+	// count was added already.
+	reasonOverlap = "overlap"
+
+	scopeName = "code.cloudfoundry.org/otel-collector-release/src/components/processor/deltatocumulativeprocessor"
+)
+
+type deltaToCumulativeProcessor struct {
+	streams *streams
+
+	dropped      metric.Int64Counter
+	registration metric.Registration
+	attrs        []attribute.KeyValue
+}
+
+func newDeltaToCumulativeProcessor(cfg *Config, meter metric.Meter, processorID string) (*deltaToCumulativeProcessor, error) {
+	p := &deltaToCumulativeProcessor{
+		streams: newStreams(cfg),
+		attrs:   []attribute.KeyValue{attribute.String("processor", processorID)},
+	}
+
+	var err error
+	p.dropped, err = meter.Int64Counter(
+		"otelcol_processor_deltatocumulative_dropped_datapoints",
+		metric.WithDescription("Number of delta datapoints dropped because max_streams was reached, or they were out of order or overlapped the previous one."),
+		metric.WithUnit("{datapoints}"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	gauge, err := meter.Int64ObservableGauge(
+		"otelcol_processor_deltatocumulative_streams",
+		metric.WithDescription("Number of series whose running totals are kept."),
+		metric.WithUnit("{streams}"),
+	)
+	if err != nil {
+		return nil, err
+	}
+	p.registration, err = meter.RegisterCallback(p.streams.observeStreams(gauge, metric.WithAttributes(p.attrs...)), gauge)
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (p *deltaToCumulativeProcessor) shutdown(context.Context) error {
+	p.streams.shutdown()
+	return p.registration.Unregister()
+}
+
+func (p *deltaToCumulativeProcessor) processMetrics(ctx context.Context, md pmetric.Metrics) (pmetric.Metrics, error) {
+	h := series.NewHasher()
+	now := time.Now()
+	dropped := map[string]int64{}
+
+	md.ResourceMetrics().RemoveIf(func(rm pmetric.ResourceMetrics) bool {
+		rm.ScopeMetrics().RemoveIf(func(sm pmetric.ScopeMetrics) bool {
+			sm.Metrics().RemoveIf(func(m pmetric.Metric) bool {
+				if !isDelta(m) {
+					return false
+				}
+
+				h.Reset()
+				h.WriteMap(rm.Resource().Attributes())
+				h.WriteString(sm.Scope().Name())
+				h.WriteString(sm.Scope().Version())
+				h.WriteMap(sm.Scope().Attributes())
+				h.WriteString(m.Name())
+				h.WriteString(m.Unit())
+				h.WriteString(m.Type().String())
+				if m.Type() == pmetric.MetricTypeSum {
+					h.WriteString(strconv.FormatBool(m.Sum().IsMonotonic()))
+				}
+				prefix := h.Sum()
+
+				id := func(dp interface{ Attributes() pcommon.Map }) streamID {
+					h.Reset()
+					h.WriteID(prefix)
+					h.WriteMap(dp.Attributes())
+					return h.Sum()
+				}
+
+				switch m.Type() {
+				case pmetric.MetricTypeSum:
+					m.Sum().SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
+					dps := m.Sum().DataPoints()
+					dps.RemoveIf(func(dp pmetric.NumberDataPoint) bool {
+						return p.drop(p.streams.accumulateNumber(id(dp), dp, now), dropped)
+					})
+					return dps.Len() == 0
+				case pmetric.MetricTypeHistogram:
+					m.Histogram().SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
+					dps := m.Histogram().DataPoints()
+					dps.RemoveIf(func(dp pmetric.HistogramDataPoint) bool {
+						return p.drop(p.streams.accumulateHistogram(id(dp), dp, now), dropped)
+					})
+					return dps.Len() == 0
+				}
+				return false
+			})
+			return sm.Metrics().Len() == 0
+		})
+		return rm.ScopeMetrics().Len() == 0
+	})
+
+	for reason, n := range dropped {
+		kvs := append(p.attrs[:len(p.attrs):len(p.attrs)], attribute.String("reason", reason))
+		p.dropped.Add(ctx, n, metric.WithAttributes(kvs...))
+	}
+
+	if md.ResourceMetrics().Len() == 0 {
+		return md, processorhelper.ErrSkipProcessingData
+	}
+	return md, nil
+}
+
+func (p *deltaToCumulativeProcessor) drop(reason string, dropped map[string]int64) bool {
+	if reason == "" {
+		return false
+	}
+	dropped[reason]++
+	return true
+}
+
+// isDelta reports whether m is converted. Exponential histograms and
+// summaries are passed on unchanged.
+func isDelta(m pmetric.Metric) bool {
+	switch m.Type() {
+	case pmetric.MetricTypeSum:
+		return m.Sum().AggregationTemporality() == pmetric.AggregationTemporalityDelta
+	case pmetric.MetricTypeHistogram:
+		return m.Histogram().AggregationTemporality() == pmetric.AggregationTemporalityDelta
+	}
+	return false
+}
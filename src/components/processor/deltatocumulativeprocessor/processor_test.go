@@ -0,0 +1,301 @@
+package deltatocumulativeprocessor_test
+
+import (
+	"context"
+	"time"
+
+	"code.cloudfoundry.org/otel-collector-release/src/components/processor/deltatocumulativeprocessor"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.opentelemetry.io/collector/component/componenttest"
+	"go.opentelemetry.io/collector/consumer/consumertest"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/processor"
+	"go.opentelemetry.io/collector/processor/processortest"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+var _ = Describe("Delta to cumulative processor", func() {
+	var (
+		cfg       *deltatocumulativeprocessor.Config
+		telemetry *componenttest.Telemetry
+		sink      *consumertest.MetricsSink
+		p         processor.Metrics
+	)
+
+	BeforeEach(func() {
+		cfg = deltatocumulativeprocessor.NewFactory().CreateDefaultConfig().(*deltatocumulativeprocessor.Config)
+		telemetry = componenttest.NewTelemetry()
+		DeferCleanup(telemetry.Shutdown, context.Background())
+		sink = new(consumertest.MetricsSink)
+	})
+
+	JustBeforeEach(func() {
+		Expect(cfg.Validate()).To(Succeed())
+		factory := deltatocumulativeprocessor.NewFactory()
+		settings := processortest.NewNopSettings(factory.Type())
+		settings.TelemetrySettings = telemetry.NewTelemetrySettings()
+
+		var err error
+		p, err = factory.CreateMetrics(context.Background(), settings, cfg, sink)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(p.Start(context.Background(), componenttest.NewNopHost())).To(Succeed())
+		DeferCleanup(p.Shutdown, context.Background())
+	})
+
+	// consume sends the metrics and returns the sums that come out.
+	consume := func(md pmetric.Metrics) []pmetric.NumberDataPoint {
+		sink.Reset()
+		Expect(p.ConsumeMetrics(context.Background(), md)).To(Succeed())
+		var dps []pmetric.NumberDataPoint
+		for _, out := range sink.AllMetrics() {
+			for i := 0; i < out.ResourceMetrics().Len(); i++ {
+				m := out.ResourceMetrics().At(i).ScopeMetrics().At(0).Metrics().At(0)
+				Expect(m.Sum().AggregationTemporality()).To(Equal(pmetric.AggregationTemporalityCumulative))
+				for j := 0; j < m.Sum().DataPoints().Len(); j++ {
+					dps = append(dps, m.Sum().DataPoints().At(j))
+				}
+			}
+		}
+		return dps
+	}
+
+	It("adds up delta sums, starting with zero at the first datapoint without a start time", func() {
+		dps := consume(deltaSum("dropsonde_ingress", "app-a", 0, 10, 5))
+		Expect(dps).To(HaveLen(1))
+		Expect(dps[0].IntValue()).To(Equal(int64(0)))
+		Expect(dps[0].StartTimestamp()).To(Equal(pcommon.Timestamp(10)))
+		Expect(dps[0].Timestamp()).To(Equal(pcommon.Timestamp(10)))
+
+		dps = consume(deltaSum("dropsonde_ingress", "app-a", 0, 20, 3))
+		Expect(dps[0].IntValue()).To(Equal(int64(3)))
+		Expect(dps[0].StartTimestamp()).To(Equal(pcommon.Timestamp(10)))
+		Expect(dps[0].Timestamp()).To(Equal(pcommon.Timestamp(20)))
+
+		dps = consume(deltaSum("dropsonde_ingress", "app-a", 0, 30, 2))
+		Expect(dps[0].IntValue()).To(Equal(int64(5)))
+	})
+
+	It("keeps the start time of the first datapoint when it is set", func() {
+		dps := consume(deltaSum("requests", "app-a", 5, 10, 1))
+		Expect(dps[0].IntValue()).To(Equal(int64(1)))
+		Expect(dps[0].StartTimestamp()).To(Equal(pcommon.Timestamp(5)))
+
+		dps = consume(deltaSum("requests", "app-a", 10, 20, 1))
+		Expect(dps[0].IntValue()).To(Equal(int64(2)))
+		Expect(dps[0].StartTimestamp()).To(Equal(pcommon.Timestamp(5)))
+	})
+
+	It("keeps a total per series", func() {
+		consume(deltaSum("requests", "app-a", 5, 10, 1))
+		consume(deltaSum("requests", "app-b", 5, 10, 10))
+		consume(deltaSum("errors", "app-a", 5, 10, 100))
+
+		Expect(consume(deltaSum("requests", "app-a", 10, 20, 1))[0].IntValue()).To(Equal(int64(2)))
+		Expect(consume(deltaSum("requests", "app-b", 10, 20, 1))[0].IntValue()).To(Equal(int64(11)))
+
+		md := deltaSum("requests", "app-a", 20, 30, 1)
+		md.ResourceMetrics().At(0).Resource().Attributes().PutStr("instance_id", "1")
+		Expect(consume(md)[0].IntValue()).To(Equal(int64(1)))
+	})
+
+	It("switches to doubles when a double is added", func() {
+		consume(deltaSum("requests", "app-a", 5, 10, 1))
+		md := deltaSum("requests", "app-a", 10, 20, 0)
+		md.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0).Sum().DataPoints().At(0).SetDoubleValue(0.5)
+
+		dps := consume(md)
+		Expect(dps[0].ValueType()).To(Equal(pmetric.NumberDataPointValueTypeDouble))
+		Expect(dps[0].DoubleValue()).To(Equal(1.5))
+	})
+
+	It("drops datapoints that are not newer than the previous one", func() {
+		consume(deltaSum("requests", "app-a", 0, 20, 1))
+		Expect(consume(deltaSum("requests", "app-a", 0, 20, 1))).To(BeEmpty())
+		Expect(consume(deltaSum("requests", "app-a", 0, 10, 1))).To(BeEmpty())
+		Expect(sink.AllMetrics()).To(BeEmpty())
+
+		Expect(consume(deltaSum("requests", "app-a", 0, 30, 1))[0].IntValue()).To(Equal(int64(1)))
+
+		m, err := telemetry.GetMetric("otelcol_processor_deltatocumulative_dropped_datapoints")
+		Expect(err).NotTo(HaveOccurred())
+		dps := m.Data.(metricdata.Sum[int64]).DataPoints
+		Expect(dps).To(HaveLen(1))
+		Expect(dps[0].Value).To(Equal(int64(2)))
+		reason, _ := dps[0].Attributes.Value(attribute.Key("reason"))
+		Expect(reason.AsString()).To(Equal("out_of_order"))
+	})
+
+	It("drops datapoints that overlap the previous one", func() {
+		consume(deltaSum("requests", "app-a", 10, 20, 5))
+		consume(deltaSum("requests", "app-a", 20, 30, 5))
+
+		Expect(consume(deltaSum("requests", "app-a", 25, 40, 1))).To(BeEmpty())
+		Expect(consume(deltaSum("requests", "app-a", 30, 40, 1))[0].IntValue()).To(Equal(int64(11)))
+
+		m, err := telemetry.GetMetric("otelcol_processor_deltatocumulative_dropped_datapoints")
+		Expect(err).NotTo(HaveOccurred())
+		reason, _ := m.Data.(metricdata.Sum[int64]).DataPoints[0].Attributes.Value(attribute.Key("reason"))
+		Expect(reason.AsString()).To(Equal("overlap"))
+	})
+
+	It("starts again after a gap, such as when the sender restarted", func() {
+		consume(deltaSum("requests", "app-a", 10, 20, 5))
+
+		dps := consume(deltaSum("requests", "app-a", 50, 60, 1))
+		Expect(dps[0].IntValue()).To(Equal(int64(1)))
+		Expect(dps[0].StartTimestamp()).To(Equal(pcommon.Timestamp(50)))
+
+		dps = consume(deltaSum("requests", "app-a", 60, 70, 1))
+		Expect(dps[0].IntValue()).To(Equal(int64(2)))
+		Expect(dps[0].StartTimestamp()).To(Equal(pcommon.Timestamp(50)))
+	})
+
+	It("passes cumulative sums and gauges on unchanged", func() {
+		md := deltaSum("requests", "app-a", 0, 10, 7)
+		md.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0).Sum().SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
+		gauge := md.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().AppendEmpty()
+		gauge.SetName("cpu")
+		gauge.SetEmptyGauge().DataPoints().AppendEmpty().SetDoubleValue(0.5)
+		expected := pmetric.NewMetrics()
+		md.CopyTo(expected)
+
+		Expect(p.ConsumeMetrics(context.Background(), md)).To(Succeed())
+		Expect(sink.AllMetrics()).To(Equal([]pmetric.Metrics{expected}))
+	})
+
+	It("adds up delta histograms", func() {
+		Expect(p.ConsumeMetrics(context.Background(), deltaHistogram(5, 10, []float64{1, 2}, []uint64{1, 2, 3}, 4))).To(Succeed())
+		Expect(p.ConsumeMetrics(context.Background(), deltaHistogram(10, 20, []float64{1, 2}, []uint64{1, 0, 1}, 0.5))).To(Succeed())
+
+		m := sink.AllMetrics()[1].ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0)
+		Expect(m.Histogram().AggregationTemporality()).To(Equal(pmetric.AggregationTemporalityCumulative))
+		dp := m.Histogram().DataPoints().At(0)
+		Expect(dp.StartTimestamp()).To(Equal(pcommon.Timestamp(5)))
+		Expect(dp.BucketCounts().AsRaw()).To(Equal([]uint64{2, 2, 4}))
+		Expect(dp.Count()).To(Equal(uint64(8)))
+		Expect(dp.Sum()).To(Equal(4.5))
+		Expect(dp.Min()).To(Equal(0.5))
+		Expect(dp.Max()).To(Equal(4.0))
+	})
+
+	It("starts histograms without a start time with empty buckets", func() {
+		Expect(p.ConsumeMetrics(context.Background(), deltaHistogram(0, 10, []float64{1, 2}, []uint64{1, 2, 3}, 4))).To(Succeed())
+		Expect(p.ConsumeMetrics(context.Background(), deltaHistogram(0, 20, []float64{1, 2}, []uint64{1, 0, 1}, 0.5))).To(Succeed())
+
+		dp := sink.AllMetrics()[0].ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0).Histogram().DataPoints().At(0)
+		Expect(dp.StartTimestamp()).To(Equal(pcommon.Timestamp(10)))
+		Expect(dp.BucketCounts().AsRaw()).To(Equal([]uint64{0, 0, 0}))
+		Expect(dp.Count()).To(BeZero())
+		Expect(dp.Sum()).To(BeZero())
+		Expect(dp.HasMin()).To(BeFalse())
+		Expect(dp.HasMax()).To(BeFalse())
+
+		dp = sink.AllMetrics()[1].ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0).Histogram().DataPoints().At(0)
+		Expect(dp.BucketCounts().AsRaw()).To(Equal([]uint64{1, 0, 1}))
+		Expect(dp.Count()).To(Equal(uint64(2)))
+		Expect(dp.Min()).To(Equal(0.5))
+	})
+
+	It("starts histograms again when their buckets change", func() {
+		Expect(p.ConsumeMetrics(context.Background(), deltaHistogram(5, 10, []float64{1, 2}, []uint64{1, 2, 3}, 4))).To(Succeed())
+		Expect(p.ConsumeMetrics(context.Background(), deltaHistogram(10, 20, []float64{5}, []uint64{1, 1}, 1))).To(Succeed())
+
+		dp := sink.AllMetrics()[1].ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0).Histogram().DataPoints().At(0)
+		Expect(dp.StartTimestamp()).To(Equal(pcommon.Timestamp(10)))
+		Expect(dp.BucketCounts().AsRaw()).To(Equal([]uint64{1, 1}))
+		Expect(dp.Count()).To(Equal(uint64(2)))
+	})
+
+	Context("with a short max_stale", func() {
+		BeforeEach(func() {
+			cfg.MaxStale = 100 * time.Millisecond
+		})
+
+		It("starts series again after they were evicted", func() {
+			consume(deltaSum("requests", "app-a", 0, 10, 5))
+
+			ts := pcommon.Timestamp(10)
+			Eventually(func() int64 {
+				ts += 10
+				return consume(deltaSum("requests", "app-a", 0, ts, 1))[0].IntValue()
+			}, 3*time.Second, 200*time.Millisecond).Should(Equal(int64(0)))
+		})
+	})
+
+	Context("with a memory cap", func() {
+		BeforeEach(func() {
+			cfg.MaxStreams = 2
+		})
+
+		It("drops datapoints of new series while the cap is reached", func() {
+			consume(deltaSum("requests", "app-a", 0, 10, 1))
+			consume(deltaSum("requests", "app-b", 0, 10, 1))
+			Expect(consume(deltaSum("requests", "app-c", 0, 10, 1))).To(BeEmpty())
+			Expect(consume(deltaSum("requests", "app-a", 0, 20, 1))[0].IntValue()).To(Equal(int64(1)))
+
+			m, err := telemetry.GetMetric("otelcol_processor_deltatocumulative_dropped_datapoints")
+			Expect(err).NotTo(HaveOccurred())
+			reason, _ := m.Data.(metricdata.Sum[int64]).DataPoints[0].Attributes.Value(attribute.Key("reason"))
+			Expect(reason.AsString()).To(Equal("limit"))
+
+			m, err = telemetry.GetMetric("otelcol_processor_deltatocumulative_streams")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(m.Data.(metricdata.Gauge[int64]).DataPoints[0].Value).To(Equal(int64(2)))
+		})
+	})
+})
+
+var _ = Describe("Config", func() {
+	It("requires positive staleness and memory cap", func() {
+		cfg := deltatocumulativeprocessor.NewFactory().CreateDefaultConfig().(*deltatocumulativeprocessor.Config)
+		Expect(cfg.Validate()).To(Succeed())
+
+		cfg.MaxStale = 0
+		Expect(cfg.Validate()).To(MatchError(`"max_stale" must be positive`))
+
+		cfg.MaxStale = time.Minute
+		cfg.MaxStreams = 0
+		Expect(cfg.Validate()).To(MatchError(`"max_streams" must be positive`))
+	})
+})
+
+func deltaSum(name, sourceID string, start, ts pcommon.Timestamp, value int64) pmetric.Metrics {
+	md := pmetric.NewMetrics()
+	rm := md.ResourceMetrics().AppendEmpty()
+	rm.Resource().Attributes().PutStr("source_id", sourceID)
+	m := rm.ScopeMetrics().AppendEmpty().Metrics().AppendEmpty()
+	m.SetName(name)
+	sum := m.SetEmptySum()
+	sum.SetIsMonotonic(true)
+	sum.SetAggregationTemporality(pmetric.AggregationTemporalityDelta)
+	dp := sum.DataPoints().AppendEmpty()
+	dp.SetStartTimestamp(start)
+	dp.SetTimestamp(ts)
+	dp.SetIntValue(value)
+	return md
+}
+
+func deltaHistogram(start, ts pcommon.Timestamp, bounds []float64, counts []uint64, value float64) pmetric.Metrics {
+	md := pmetric.NewMetrics()
+	m := md.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty().Metrics().AppendEmpty()
+	m.SetName("latency")
+	h := m.SetEmptyHistogram()
+	h.SetAggregationTemporality(pmetric.AggregationTemporalityDelta)
+	dp := h.DataPoints().AppendEmpty()
+	dp.SetStartTimestamp(start)
+	dp.SetTimestamp(ts)
+	dp.ExplicitBounds().FromRaw(bounds)
+	dp.BucketCounts().FromRaw(counts)
+	var count uint64
+	for _, c := range counts {
+		count += c
+	}
+	dp.SetCount(count)
+	dp.SetSum(value)
+	dp.SetMin(value)
+	dp.SetMax(value)
+	return md
+}
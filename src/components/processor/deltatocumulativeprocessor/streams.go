@@ -0,0 +1,231 @@
+package deltatocumulativeprocessor
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/otel-collector-release/src/components/internal/series"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// streamID identifies a series by a hash of its resource, scope, metric and
+// datapoint attributes.
+type streamID = series.ID
+
+// stream is the running total of a series.
+type stream struct {
+	start    pcommon.Timestamp
+	last     pcommon.Timestamp
+	lastSeen time.Time
+
+	// marksStart is whether the next datapoint only marks the start of the
+	// stream, see reset.
+	marksStart bool
+
+	intValue    int64
+	doubleValue float64
+	isDouble    bool
+
+	bounds []float64
+	counts []uint64
+	count  uint64
+	sum    float64
+	min    float64
+	max    float64
+	hasMin bool
+	hasMax bool
+}
+
+// streams keeps the running totals of every series that has received a
+// delta datapoint within max_stale.
+type streams struct {
+	cfg *Config
+
+	mu sync.Mutex
+	m  map[streamID]*stream
+
+	sweeper *series.Sweeper
+}
+
+func newStreams(cfg *Config) *streams {
+	s := &streams{
+		cfg: cfg,
+		m:   map[streamID]*stream{},
+	}
+	s.sweeper = series.NewSweeper(cfg.MaxStale, s.evict)
+	return s
+}
+
+func (s *streams) start() {
+	s.sweeper.Start()
+}
+
+func (s *streams) shutdown() {
+	s.sweeper.Shutdown()
+}
+
+func (s *streams) evict(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, st := range s.m {
+		if now.Sub(st.lastSeen) > s.cfg.MaxStale {
+			delete(s.m, id)
+		}
+	}
+}
+
+// observeStreams reports the number of series kept.
+func (s *streams) observeStreams(gauge metric.Int64ObservableGauge, opts ...metric.ObserveOption) metric.Callback {
+	return func(_ context.Context, o metric.Observer) error {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		o.ObserveInt64(gauge, int64(len(s.m)), opts...)
+		return nil
+	}
+}
+
+// lookup returns the stream for a datapoint, creating it when there is room,
+// or the reason the datapoint is dropped. A datapoint continues the stream
+// when it starts where the previous one ended, or has no start time. One
+// starting earlier overlaps what was already added and is dropped. One
+// starting later leaves a gap the total can't account for, which is also
+// what a restarted sender looks like, so the stream starts again from it.
+func (s *streams) lookup(id streamID, start, ts pcommon.Timestamp, now time.Time) (*stream, string) {
+	st, ok := s.m[id]
+	switch {
+	case !ok:
+		if len(s.m) >= s.cfg.MaxStreams {
+			return nil, reasonLimit
+		}
+		st = &stream{}
+		st.reset(start, ts)
+		s.m[id] = st
+	case ts <= st.last:
+		return nil, reasonOutOfOrder
+	case start != 0 && start < st.last:
+		return nil, reasonOverlap
+	case start > st.last:
+		st.reset(start, ts)
+	}
+	st.last = ts
+	st.lastSeen = now
+	return st, ""
+}
+
+// reset starts the stream again from zero at start. Senders that do not set
+// a start time, such as Loggregator, don't say which interval the datapoint
+// starting the stream covers, so it only marks the start: the stream starts
+// at its timestamp with a total of zero, and its value is not added.
+func (st *stream) reset(start, ts pcommon.Timestamp) {
+	if start == 0 {
+		*st = stream{start: ts, marksStart: true}
+		return
+	}
+	*st = stream{start: start}
+}
+
+// takeStart reports whether the datapoint only marks the start of the
+// stream, which the next one no longer does.
+func (st *stream) takeStart() bool {
+	marksStart := st.marksStart
+	st.marksStart = false
+	return marksStart
+}
+
+// accumulateNumber adds a delta datapoint to the stream and replaces it
+// with the running total.
+func (s *streams) accumulateNumber(id streamID, dp pmetric.NumberDataPoint, now time.Time) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st, reason := s.lookup(id, dp.StartTimestamp(), dp.Timestamp(), now)
+	if st == nil {
+		return reason
+	}
+
+	switch {
+	case st.takeStart():
+		st.isDouble = dp.ValueType() == pmetric.NumberDataPointValueTypeDouble
+	case dp.ValueType() == pmetric.NumberDataPointValueTypeDouble && !st.isDouble:
+		st.isDouble = true
+		st.doubleValue = float64(st.intValue) + dp.DoubleValue()
+	case st.isDouble:
+		if dp.ValueType() == pmetric.NumberDataPointValueTypeInt {
+			st.doubleValue += float64(dp.IntValue())
+		} else {
+			st.doubleValue += dp.DoubleValue()
+		}
+	default:
+		st.intValue += dp.IntValue()
+	}
+
+	dp.SetStartTimestamp(st.start)
+	if st.isDouble {
+		dp.SetDoubleValue(st.doubleValue)
+	} else {
+		dp.SetIntValue(st.intValue)
+	}
+	return ""
+}
+
+// accumulateHistogram adds a delta datapoint to the stream and replaces it
+// with the running total. The stream is reset when the bucket boundaries
+// change.
+func (s *streams) accumulateHistogram(id streamID, dp pmetric.HistogramDataPoint, now time.Time) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st, reason := s.lookup(id, dp.StartTimestamp(), dp.Timestamp(), now)
+	if st == nil {
+		return reason
+	}
+
+	bounds := dp.ExplicitBounds().AsRaw()
+	if st.counts == nil || !slices.Equal(st.bounds, bounds) || len(st.counts) != dp.BucketCounts().Len() {
+		if st.counts != nil {
+			st.reset(dp.StartTimestamp(), dp.Timestamp())
+			st.last = dp.Timestamp()
+			st.lastSeen = now
+		}
+		st.bounds = bounds
+		st.counts = make([]uint64, dp.BucketCounts().Len())
+	}
+
+	if !st.takeStart() {
+		for i := range st.counts {
+			st.counts[i] += dp.BucketCounts().At(i)
+		}
+		st.count += dp.Count()
+		st.sum += dp.Sum()
+		if dp.HasMin() && (!st.hasMin || dp.Min() < st.min) {
+			st.min, st.hasMin = dp.Min(), true
+		}
+		if dp.HasMax() && (!st.hasMax || dp.Max() > st.max) {
+			st.max, st.hasMax = dp.Max(), true
+		}
+	}
+
+	dp.SetStartTimestamp(st.start)
+	dp.BucketCounts().FromRaw(st.counts)
+	dp.SetCount(st.count)
+	if dp.HasSum() {
+		dp.SetSum(st.sum)
+	}
+	if st.hasMin {
+		dp.SetMin(st.min)
+	} else {
+		dp.RemoveMin()
+	}
+	if st.hasMax {
+		dp.SetMax(st.max)
+	} else {
+		dp.RemoveMax()
+	}
+	return ""
+}
@@ -0,0 +1,60 @@
+package multilineprocessor
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+
+	"go.opentelemetry.io/collector/component"
+)
+
+// Config defines the configuration for the multiline processor.
+type Config struct {
+	// StreamAttributes are the attributes whose values identify a stream of
+	// lines. Record attributes are used before resource attributes. Records
+	// without any of them are passed on unchanged.
+	StreamAttributes []string `mapstructure:"stream_attributes"`
+
+	// StartPattern matches lines that start a new entry. When no
+	// continuation pattern is set, every other line continues the entry.
+	StartPattern string `mapstructure:"start_pattern"`
+
+	// ContinuationPattern matches lines that continue the current entry.
+	// Lines matching the start pattern always start a new entry.
+	ContinuationPattern string `mapstructure:"continuation_pattern"`
+
+	// FlushTimeout is how long an entry waits for another line before it is
+	// passed on.
+	FlushTimeout time.Duration `mapstructure:"flush_timeout"`
+
+	// MaxSize is the most bytes joined into a single entry. A line that would
+	// make the entry larger starts a new entry.
+	MaxSize int `mapstructure:"max_size"`
+}
+
+var _ component.Config = (*Config)(nil)
+
+// Validate checks that there are stream attributes, that at least one
+// pattern is set and compiles, and the limits.
+func (cfg *Config) Validate() error {
+	if len(cfg.StreamAttributes) == 0 {
+		return errors.New(`requires at least one "stream_attributes" entry`)
+	}
+	if cfg.StartPattern == "" && cfg.ContinuationPattern == "" {
+		return errors.New(`requires a "start_pattern" or a "continuation_pattern"`)
+	}
+	if _, err := regexp.Compile(cfg.StartPattern); err != nil {
+		return fmt.Errorf("invalid start_pattern: %w", err)
+	}
+	if _, err := regexp.Compile(cfg.ContinuationPattern); err != nil {
+		return fmt.Errorf("invalid continuation_pattern: %w", err)
+	}
+	if cfg.FlushTimeout <= 0 {
+		return errors.New(`"flush_timeout" must be positive`)
+	}
+	if cfg.MaxSize <= 0 {
+		return errors.New(`"max_size" must be positive`)
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+// Package multilineprocessor implements a processor that joins log records
+// that belong together, such as the lines of a stack trace, into a single
+// record per app instance.
+package multilineprocessor
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/processor"
+	"go.opentelemetry.io/collector/processor/processorhelper"
+)
+
+const stability = component.StabilityLevelAlpha
+
+var componentType = component.MustNewType("multiline")
+
+var processorCapabilities = consumer.Capabilities{MutatesData: true}
+
+// NewFactory creates a factory for the multiline processor.
+func NewFactory() processor.Factory {
+	return processor.NewFactory(
+		componentType,
+		createDefaultConfig,
+		processor.WithLogs(createLogs, stability),
+	)
+}
+
+func createDefaultConfig() component.Config {
+	return &Config{
+		StreamAttributes: []string{"source_id", "instance_id", "source_type"},
+		// Indented lines, such as "\tat com.example.App.main(App.java:10)"
+		// in Java or "\tfrom app.rb:10:in `<main>'" in Ruby, and the lines
+		// Java adds between nested exceptions.
+		ContinuationPattern: `^(\s|Caused by:|\.\.\. \d+ more)`,
+		FlushTimeout:        time.Second,
+		MaxSize:             64 * 1024,
+	}
+}
+
+func createLogs(ctx context.Context, set processor.Settings, cfg component.Config, next consumer.Logs) (processor.Logs, error) {
+	p := newMultilineProcessor(cfg.(*Config), set.Logger, next)
+	return processorhelper.NewLogs(ctx, set, cfg, next,
+		p.processLogs,
+		processorhelper.WithCapabilities(processorCapabilities),
+		processorhelper.WithStart(p.startFlushing),
+		processorhelper.WithShutdown(p.shutdown),
+	)
+}
@@ -0,0 +1,13 @@
+package multilineprocessor_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestMultilineProcessor(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Multiline Processor Suite")
+}
@@ -0,0 +1,211 @@
+package multilineprocessor
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/processor/processorhelper"
+	"go.uber.org/zap"
+)
+
+// flushChecksPerTimeout is the number of times per flush timeout that
+// entries are checked, which bounds how much later than the timeout an entry
+// is passed on.
+const flushChecksPerTimeout = 4
+
+// entry is a log record whose body is being joined from several lines. It is
+// kept in its own plog.Logs with a copy of its resource and scope, so that it
+// can be passed on after the batch it arrived in.
+type entry struct {
+	logs     plog.Logs
+	record   plog.LogRecord
+	body     strings.Builder
+	lastLine time.Time
+}
+
+func newEntry(resource pcommon.Resource, scope pcommon.InstrumentationScope, lr plog.LogRecord, now time.Time) *entry {
+	logs := plog.NewLogs()
+	rl := logs.ResourceLogs().AppendEmpty()
+	resource.CopyTo(rl.Resource())
+	sl := rl.ScopeLogs().AppendEmpty()
+	scope.CopyTo(sl.Scope())
+	record := sl.LogRecords().AppendEmpty()
+	lr.CopyTo(record)
+
+	e := &entry{logs: logs, record: record, lastLine: now}
+	e.body.WriteString(lr.Body().Str())
+	return e
+}
+
+func (e *entry) append(line string, now time.Time) {
+	e.body.WriteByte('\n')
+	e.body.WriteString(line)
+	e.lastLine = now
+}
+
+// finish sets the joined body and moves the entry into ld.
+func (e *entry) finish(ld plog.Logs) {
+	e.record.Body().SetStr(e.body.String())
+	e.logs.ResourceLogs().MoveAndAppendTo(ld.ResourceLogs())
+}
+
+type multilineProcessor struct {
+	cfg          *Config
+	logger       *zap.Logger
+	next         consumer.Logs
+	start        *regexp.Regexp
+	continuation *regexp.Regexp
+
+	mu      sync.Mutex
+	entries map[string]*entry
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+func newMultilineProcessor(cfg *Config, logger *zap.Logger, next consumer.Logs) *multilineProcessor {
+	p := &multilineProcessor{
+		cfg:     cfg,
+		logger:  logger,
+		next:    next,
+		entries: map[string]*entry{},
+		done:    make(chan struct{}),
+	}
+	if cfg.StartPattern != "" {
+		p.start = regexp.MustCompile(cfg.StartPattern)
+	}
+	if cfg.ContinuationPattern != "" {
+		p.continuation = regexp.MustCompile(cfg.ContinuationPattern)
+	}
+	return p
+}
+
+func (p *multilineProcessor) startFlushing(context.Context, component.Host) error {
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+
+		ticker := time.NewTicker(p.cfg.FlushTimeout / flushChecksPerTimeout)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-p.done:
+				return
+			case now := <-ticker.C:
+				p.flush(context.Background(), func(e *entry) bool {
+					return now.Sub(e.lastLine) >= p.cfg.FlushTimeout
+				})
+			}
+		}
+	}()
+	return nil
+}
+
+// shutdown passes on every buffered entry. Processors are shut down before
+// the exporters they feed, so the entries are still exported.
+func (p *multilineProcessor) shutdown(ctx context.Context) error {
+	close(p.done)
+	p.wg.Wait()
+	return p.flush(ctx, func(*entry) bool { return true })
+}
+
+func (p *multilineProcessor) flush(ctx context.Context, expired func(*entry) bool) error {
+	ld := plog.NewLogs()
+
+	p.mu.Lock()
+	for key, e := range p.entries {
+		if expired(e) {
+			e.finish(ld)
+			delete(p.entries, key)
+		}
+	}
+	p.mu.Unlock()
+
+	if ld.ResourceLogs().Len() == 0 {
+		return nil
+	}
+	err := p.next.ConsumeLogs(ctx, ld)
+	if err != nil {
+		p.logger.Error("Failed to pass on joined log records", zap.Int("records", ld.LogRecordCount()), zap.Error(err))
+	}
+	return err
+}
+
+// processLogs buffers the records of every stream and passes on the
+// entries that are complete because a new entry started in their stream.
+func (p *multilineProcessor) processLogs(_ context.Context, ld plog.Logs) (plog.Logs, error) {
+	now := time.Now()
+	var completed []*entry
+
+	p.mu.Lock()
+	ld.ResourceLogs().RemoveIf(func(rl plog.ResourceLogs) bool {
+		rl.ScopeLogs().RemoveIf(func(sl plog.ScopeLogs) bool {
+			sl.LogRecords().RemoveIf(func(lr plog.LogRecord) bool {
+				key, ok := p.streamKey(lr.Attributes(), rl.Resource().Attributes())
+				if !ok || lr.Body().Type() != pcommon.ValueTypeStr {
+					return false
+				}
+
+				line := lr.Body().Str()
+				e := p.entries[key]
+				if e != nil && p.isContinuation(line) && e.body.Len()+1+len(line) <= p.cfg.MaxSize {
+					e.append(line, now)
+					return true
+				}
+				if e != nil {
+					completed = append(completed, e)
+				}
+				p.entries[key] = newEntry(rl.Resource(), sl.Scope(), lr, now)
+				return true
+			})
+			return sl.LogRecords().Len() == 0
+		})
+		return rl.ScopeLogs().Len() == 0
+	})
+	p.mu.Unlock()
+
+	for _, e := range completed {
+		e.finish(ld)
+	}
+	if ld.ResourceLogs().Len() == 0 {
+		return ld, processorhelper.ErrSkipProcessingData
+	}
+	return ld, nil
+}
+
+// isContinuation reports whether line continues the current entry of its
+// stream.
+func (p *multilineProcessor) isContinuation(line string) bool {
+	if p.start != nil && p.start.MatchString(line) {
+		return false
+	}
+	if p.continuation != nil {
+		return p.continuation.MatchString(line)
+	}
+	return true
+}
+
+// streamKey returns the values of the stream attributes, or false when the
+// record has none of them.
+func (p *multilineProcessor) streamKey(attrs, resource pcommon.Map) (string, bool) {
+	values := make([]string, len(p.cfg.StreamAttributes))
+	found := false
+	for i, name := range p.cfg.StreamAttributes {
+		v, ok := attrs.Get(name)
+		if !ok {
+			v, ok = resource.Get(name)
+		}
+		if ok {
+			values[i] = v.AsString()
+			found = true
+		}
+	}
+	return strings.Join(values, "\x00"), found
+}
@@ -0,0 +1,239 @@
+package multilineprocessor_test
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/otel-collector-release/src/components/processor/multilineprocessor"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.opentelemetry.io/collector/component/componenttest"
+	"go.opentelemetry.io/collector/consumer/consumertest"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/processor"
+	"go.opentelemetry.io/collector/processor/processortest"
+)
+
+var javaTrace = []string{
+	"Exception in thread \"main\" java.lang.IllegalStateException: boom",
+	"\tat com.example.App.run(App.java:12)",
+	"\tat com.example.App.main(App.java:5)",
+	"Caused by: java.io.IOException: disk full",
+	"\tat com.example.Store.write(Store.java:40)",
+	"\t... 2 more",
+}
+
+var _ = Describe("Multiline processor", func() {
+	var (
+		cfg  *multilineprocessor.Config
+		sink *consumertest.LogsSink
+		p    processor.Logs
+	)
+
+	BeforeEach(func() {
+		cfg = multilineprocessor.NewFactory().CreateDefaultConfig().(*multilineprocessor.Config)
+		cfg.FlushTimeout = time.Hour
+		sink = new(consumertest.LogsSink)
+	})
+
+	JustBeforeEach(func() {
+		Expect(cfg.Validate()).To(Succeed())
+		factory := multilineprocessor.NewFactory()
+
+		var err error
+		p, err = factory.CreateLogs(context.Background(), processortest.NewNopSettings(factory.Type()), cfg, sink)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(p.Start(context.Background(), componenttest.NewNopHost())).To(Succeed())
+	})
+
+	shutdown := func() {
+		Expect(p.Shutdown(context.Background())).To(Succeed())
+	}
+
+	It("joins a stack trace into a single record once the next entry starts", func() {
+		defer shutdown()
+
+		lines := append([]string{}, javaTrace...)
+		lines = append(lines, "Shutting down")
+		Expect(p.ConsumeLogs(context.Background(), appLogs("app-a", "0", lines...))).To(Succeed())
+
+		Expect(bodies(sink)).To(Equal([]string{strings.Join(javaTrace, "\n")}))
+	})
+
+	It("keeps the attributes and timestamp of the first line", func() {
+		defer shutdown()
+
+		ld := appLogs("app-a", "0", javaTrace[0], javaTrace[1], "next")
+		records := ld.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords()
+		records.At(0).SetTimestamp(100)
+		records.At(0).Attributes().PutStr("log_type", "ERR")
+		records.At(1).SetTimestamp(200)
+		Expect(p.ConsumeLogs(context.Background(), ld)).To(Succeed())
+
+		rl := sink.AllLogs()[0].ResourceLogs().At(0)
+		Expect(rl.Resource().Attributes().AsRaw()).To(HaveKeyWithValue("source_id", "app-a"))
+		lr := rl.ScopeLogs().At(0).LogRecords().At(0)
+		Expect(lr.Timestamp()).To(Equal(pcommon.Timestamp(100)))
+		Expect(lr.Attributes().AsRaw()).To(HaveKeyWithValue("log_type", "ERR"))
+	})
+
+	It("joins lines that arrive in separate batches", func() {
+		defer shutdown()
+
+		for _, line := range javaTrace {
+			Expect(p.ConsumeLogs(context.Background(), appLogs("app-a", "0", line))).To(Succeed())
+		}
+		Expect(sink.AllLogs()).To(BeEmpty())
+
+		Expect(p.ConsumeLogs(context.Background(), appLogs("app-a", "0", "next"))).To(Succeed())
+		Expect(bodies(sink)).To(Equal([]string{strings.Join(javaTrace, "\n")}))
+	})
+
+	It("keeps the streams of app instances apart", func() {
+		defer shutdown()
+
+		Expect(p.ConsumeLogs(context.Background(), appLogs("app-a", "0", "error in 0"))).To(Succeed())
+		Expect(p.ConsumeLogs(context.Background(), appLogs("app-a", "1", "error in 1"))).To(Succeed())
+		Expect(p.ConsumeLogs(context.Background(), appLogs("app-a", "0", "\tat instance 0"))).To(Succeed())
+		Expect(p.ConsumeLogs(context.Background(), appLogs("app-a", "1", "\tat instance 1"))).To(Succeed())
+		Expect(p.ConsumeLogs(context.Background(), appLogs("app-a", "0", "next"))).To(Succeed())
+		Expect(p.ConsumeLogs(context.Background(), appLogs("app-a", "1", "next"))).To(Succeed())
+
+		Expect(bodies(sink)).To(Equal([]string{"error in 0\n\tat instance 0", "error in 1\n\tat instance 1"}))
+	})
+
+	It("passes on records without stream attributes or string bodies unchanged", func() {
+		defer shutdown()
+
+		ld := plog.NewLogs()
+		records := ld.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords()
+		records.AppendEmpty().Body().SetStr("\tplatform log")
+		Expect(p.ConsumeLogs(context.Background(), ld)).To(Succeed())
+
+		ld = appLogs("app-a", "0")
+		ld.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().AppendEmpty().Body().SetEmptyMap().PutStr("msg", "structured")
+		Expect(p.ConsumeLogs(context.Background(), ld)).To(Succeed())
+
+		Expect(sink.LogRecordCount()).To(Equal(2))
+	})
+
+	Context("with a short flush timeout", func() {
+		BeforeEach(func() {
+			cfg.FlushTimeout = 50 * time.Millisecond
+		})
+
+		It("flushes entries when no line arrives within it", func() {
+			defer shutdown()
+
+			Expect(p.ConsumeLogs(context.Background(), appLogs("app-a", "0", javaTrace...))).To(Succeed())
+
+			Eventually(func() []string { return bodies(sink) }).Should(Equal([]string{strings.Join(javaTrace, "\n")}))
+		})
+	})
+
+	Context("with a maximum size", func() {
+		BeforeEach(func() {
+			cfg.MaxSize = 21
+		})
+
+		It("starts a new entry when the entry would exceed it", func() {
+			defer shutdown()
+
+			Expect(p.ConsumeLogs(context.Background(), appLogs("app-a", "0", "error", "\tat one", "\tat two", "\tat three", "next"))).To(Succeed())
+
+			Expect(bodies(sink)).To(Equal([]string{"error\n\tat one\n\tat two", "\tat three"}))
+		})
+	})
+
+	It("flushes every entry on shutdown", func() {
+		Expect(p.ConsumeLogs(context.Background(), appLogs("app-a", "0", "error a", "\tat a"))).To(Succeed())
+		Expect(p.ConsumeLogs(context.Background(), appLogs("app-b", "0", "error b"))).To(Succeed())
+		Expect(sink.AllLogs()).To(BeEmpty())
+
+		shutdown()
+
+		Expect(bodies(sink)).To(ConsistOf("error a\n\tat a", "error b"))
+	})
+
+	Context("with a start pattern", func() {
+		BeforeEach(func() {
+			cfg.StartPattern = `^\d{4}-\d{2}-\d{2} `
+			cfg.ContinuationPattern = ""
+		})
+
+		It("continues the entry with every line that does not match it", func() {
+			defer shutdown()
+
+			Expect(p.ConsumeLogs(context.Background(), appLogs("app-a", "0",
+				"2024-01-02 ERROR failed",
+				"Traceback (most recent call last):",
+				`  File "app.py", line 3`,
+				"2024-01-02 INFO retrying",
+			))).To(Succeed())
+
+			Expect(bodies(sink)).To(Equal([]string{"2024-01-02 ERROR failed\nTraceback (most recent call last):\n  File \"app.py\", line 3"}))
+		})
+	})
+})
+
+var _ = Describe("Config", func() {
+	var cfg *multilineprocessor.Config
+
+	BeforeEach(func() {
+		cfg = multilineprocessor.NewFactory().CreateDefaultConfig().(*multilineprocessor.Config)
+	})
+
+	It("is valid by default", func() {
+		Expect(cfg.Validate()).To(Succeed())
+	})
+
+	It("requires a pattern that compiles", func() {
+		cfg.ContinuationPattern = ""
+		Expect(cfg.Validate()).To(MatchError(`requires a "start_pattern" or a "continuation_pattern"`))
+
+		cfg.StartPattern = "("
+		Expect(cfg.Validate()).To(MatchError(ContainSubstring("invalid start_pattern")))
+	})
+
+	It("requires stream attributes and positive limits", func() {
+		cfg.StreamAttributes = nil
+		Expect(cfg.Validate()).To(MatchError(ContainSubstring(`"stream_attributes"`)))
+
+		cfg.StreamAttributes = []string{"source_id"}
+		cfg.FlushTimeout = 0
+		Expect(cfg.Validate()).To(MatchError(`"flush_timeout" must be positive`))
+
+		cfg.FlushTimeout = time.Second
+		cfg.MaxSize = 0
+		Expect(cfg.Validate()).To(MatchError(`"max_size" must be positive`))
+	})
+})
+
+func appLogs(sourceID, instanceID string, lines ...string) plog.Logs {
+	ld := plog.NewLogs()
+	rl := ld.ResourceLogs().AppendEmpty()
+	rl.Resource().Attributes().PutStr("source_id", sourceID)
+	rl.Resource().Attributes().PutStr("source_type", "APP/PROC/WEB")
+	records := rl.ScopeLogs().AppendEmpty().LogRecords()
+	for _, line := range lines {
+		lr := records.AppendEmpty()
+		lr.Attributes().PutStr("instance_id", instanceID)
+		lr.Body().SetStr(line)
+	}
+	return ld
+}
+
+func bodies(sink *consumertest.LogsSink) []string {
+	var bodies []string
+	for _, ld := range sink.AllLogs() {
+		for i := 0; i < ld.ResourceLogs().Len(); i++ {
+			records := ld.ResourceLogs().At(i).ScopeLogs().At(0).LogRecords()
+			for j := 0; j < records.Len(); j++ {
+				bodies = append(bodies, records.At(j).Body().AsString())
+			}
+		}
+	}
+	return bodies
+}
@@ -0,0 +1,131 @@
+package ratelimitprocessor
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/collector/component"
+)
+
+const (
+	// actionDrop drops every item over the limit.
+	actionDrop = "drop"
+
+	// actionSample keeps a percentage of the items over the limit.
+	actionSample = "sample"
+)
+
+// Config defines the configuration for the rate limit processor.
+type Config struct {
+	// KeyAttributes are the attributes whose values identify a limit, for
+	// example organization_id and space_id. Record attributes are used
+	// before resource attributes.
+	KeyAttributes []string `mapstructure:"key_attributes"`
+
+	// Default is the limit for keys that match no override.
+	Default LimitConfig `mapstructure:"default"`
+
+	// Overrides are limits for specific keys. The first matching override
+	// is used.
+	Overrides []OverrideConfig `mapstructure:"overrides"`
+
+	// Action is what happens to items over the limit, drop or sample.
+	Action string `mapstructure:"action"`
+
+	// SamplingPercentage is the percentage of items over the limit that are
+	// kept when the action is sample.
+	SamplingPercentage float64 `mapstructure:"sampling_percentage"`
+
+	// Summary configures a periodic summary of the dropped items.
+	Summary SummaryConfig `mapstructure:"summary"`
+}
+
+// LimitConfig defines a token bucket.
+type LimitConfig struct {
+	// Rate is the number of log records, spans or datapoints allowed per
+	// second.
+	Rate float64 `mapstructure:"rate"`
+
+	// Burst is the number of items allowed at once. Defaults to the rate.
+	Burst int `mapstructure:"burst"`
+}
+
+// OverrideConfig defines the limit for keys whose attributes match.
+type OverrideConfig struct {
+	LimitConfig `mapstructure:",squash"`
+
+	// Match are the key attribute values the override applies to.
+	Match map[string]string `mapstructure:"match"`
+}
+
+// SummaryConfig defines the summary of the dropped items. Logs pipelines
+// pass it on as a log record, other pipelines write it to the collector's
+// own log.
+type SummaryConfig struct {
+	Enabled  bool          `mapstructure:"enabled"`
+	Interval time.Duration `mapstructure:"interval"`
+}
+
+var _ component.Config = (*Config)(nil)
+
+// Validate checks the limits, the action and that overrides only match on
+// key attributes.
+func (cfg *Config) Validate() error {
+	if len(cfg.KeyAttributes) == 0 {
+		return errors.New(`requires at least one "key_attributes" entry`)
+	}
+	if err := cfg.Default.validate(); err != nil {
+		return fmt.Errorf("default: %w", err)
+	}
+
+	keys := map[string]bool{}
+	for _, k := range cfg.KeyAttributes {
+		keys[k] = true
+	}
+	for i, o := range cfg.Overrides {
+		if err := o.validate(); err != nil {
+			return fmt.Errorf("overrides[%d]: %w", i, err)
+		}
+		if len(o.Match) == 0 {
+			return fmt.Errorf(`overrides[%d]: requires a non-empty "match"`, i)
+		}
+		for k := range o.Match {
+			if !keys[k] {
+				return fmt.Errorf("overrides[%d]: %q is not one of the key attributes", i, k)
+			}
+		}
+	}
+
+	switch cfg.Action {
+	case actionDrop:
+	case actionSample:
+		if cfg.SamplingPercentage <= 0 || cfg.SamplingPercentage > 100 {
+			return errors.New(`"sampling_percentage" must be greater than 0 and at most 100`)
+		}
+	default:
+		return fmt.Errorf("unsupported action %q, must be %s or %s", cfg.Action, actionDrop, actionSample)
+	}
+
+	if cfg.Summary.Enabled && cfg.Summary.Interval <= 0 {
+		return errors.New(`"summary.interval" must be positive`)
+	}
+	return nil
+}
+
+func (l LimitConfig) validate() error {
+	if l.Rate <= 0 {
+		return errors.New(`"rate" must be positive`)
+	}
+	if l.Burst < 0 {
+		return errors.New(`"burst" must not be negative`)
+	}
+	return nil
+}
+
+func (l LimitConfig) burst() int {
+	if l.Burst == 0 {
+		return max(1, int(l.Rate))
+	}
+	return l.Burst
+}
@@ -0,0 +1,97 @@
+// Package ratelimitprocessor implements a processor that limits the rate of
+// log records, spans and datapoints per organization, space or any other set
+// of attributes.
+package ratelimitprocessor
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/processor"
+	"go.opentelemetry.io/collector/processor/processorhelper"
+)
+
+const stability = component.StabilityLevelAlpha
+
+var componentType = component.MustNewType("ratelimit")
+
+var processorCapabilities = consumer.Capabilities{MutatesData: true}
+
+// NewFactory creates a factory for the rate limit processor.
+func NewFactory() processor.Factory {
+	return processor.NewFactory(
+		componentType,
+		createDefaultConfig,
+		processor.WithLogs(createLogs, stability),
+		processor.WithMetrics(createMetrics, stability),
+		processor.WithTraces(createTraces, stability),
+	)
+}
+
+func createDefaultConfig() component.Config {
+	return &Config{
+		KeyAttributes: []string{"organization_id", "space_id"},
+		Default: LimitConfig{
+			Rate: 1000,
+		},
+		Action: actionDrop,
+		Summary: SummaryConfig{
+			Interval: time.Minute,
+		},
+	}
+}
+
+func createLogs(ctx context.Context, set processor.Settings, cfg component.Config, next consumer.Logs) (processor.Logs, error) {
+	p, err := newRateLimitProcessor(set, cfg.(*Config), "logs")
+	if err != nil {
+		return nil, err
+	}
+	p.limiter.report = p.passSummary(next)
+	return processorhelper.NewLogs(ctx, set, cfg, next,
+		p.processLogs,
+		processorhelper.WithCapabilities(processorCapabilities),
+		processorhelper.WithStart(p.start),
+		processorhelper.WithShutdown(p.limiter.shutdown),
+	)
+}
+
+func createMetrics(ctx context.Context, set processor.Settings, cfg component.Config, next consumer.Metrics) (processor.Metrics, error) {
+	p, err := newRateLimitProcessor(set, cfg.(*Config), "metrics")
+	if err != nil {
+		return nil, err
+	}
+	return processorhelper.NewMetrics(ctx, set, cfg, next,
+		p.processMetrics,
+		processorhelper.WithCapabilities(processorCapabilities),
+		processorhelper.WithStart(p.start),
+		processorhelper.WithShutdown(p.limiter.shutdown),
+	)
+}
+
+func createTraces(ctx context.Context, set processor.Settings, cfg component.Config, next consumer.Traces) (processor.Traces, error) {
+	p, err := newRateLimitProcessor(set, cfg.(*Config), "traces")
+	if err != nil {
+		return nil, err
+	}
+	return processorhelper.NewTraces(ctx, set, cfg, next,
+		p.processTraces,
+		processorhelper.WithCapabilities(processorCapabilities),
+		processorhelper.WithStart(p.start),
+		processorhelper.WithShutdown(p.limiter.shutdown),
+	)
+}
+
+func newRateLimitProcessor(set processor.Settings, cfg *Config, signal string) (*rateLimitProcessor, error) {
+	l, err := newLimiter(cfg, set.Logger, set.MeterProvider.Meter(scopeName), set.ID.String(), signal)
+	if err != nil {
+		return nil, err
+	}
+	return &rateLimitProcessor{cfg: cfg, id: set.ID, logger: set.Logger, limiter: l}, nil
+}
+
+func (p *rateLimitProcessor) start(ctx context.Context, _ component.Host) error {
+	p.limiter.start(ctx)
+	return nil
+}
@@ -0,0 +1,258 @@
+package ratelimitprocessor
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.uber.org/zap"
+	"golang.org/x/time/rate"
+)
+
+const (
+	// evictionInterval is how often idle buckets are removed when the summary
+	// is disabled.
+	evictionInterval = time.Minute
+
+	scopeName = "code.cloudfoundry.org/otel-collector-release/src/components/processor/ratelimitprocessor"
+
+	summaryMessage = "Dropped items exceeding the rate limit"
+)
+
+// bucket is the token bucket and drop count for a single key.
+type bucket struct {
+	limiter  *rate.Limiter
+	values   []string
+	lastSeen time.Time
+
+	// sampled accumulates the sampling percentage for every item over the
+	// limit, so that exactly that percentage of them is kept.
+	sampled float64
+
+	// dropped is the number of items dropped since the last summary.
+	dropped int64
+}
+
+// limiter keeps a token bucket per key. Buckets are created on first use and
+// removed once they have been idle long enough to refill, at which point a new
+// bucket behaves the same.
+type limiter struct {
+	cfg    *Config
+	logger *zap.Logger
+	signal string
+
+	dropped metric.Int64Counter
+	attrs   []attribute.KeyValue
+
+	// report is given the summary of the items dropped per key. It writes
+	// the summary to the collector's own log unless the pipeline can carry
+	// it as a log record.
+	report func(dropped int64, keys []string)
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+func newLimiter(cfg *Config, logger *zap.Logger, meter metric.Meter, processorID, signal string) (*limiter, error) {
+	dropped, err := meter.Int64Counter(
+		"otelcol_processor_ratelimit_dropped_items",
+		metric.WithDescription("Number of items dropped for exceeding the rate limit of their key."),
+		metric.WithUnit("{items}"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	l := &limiter{
+		cfg:     cfg,
+		logger:  logger,
+		signal:  signal,
+		dropped: dropped,
+		attrs: []attribute.KeyValue{
+			attribute.String("processor", processorID),
+			attribute.String("signal", signal),
+		},
+		buckets: map[string]*bucket{},
+		done:    make(chan struct{}),
+	}
+	l.report = l.logSummary
+	return l, nil
+}
+
+func (l *limiter) start(context.Context) {
+	interval := evictionInterval
+	if l.cfg.Summary.Enabled {
+		interval = l.cfg.Summary.Interval
+	}
+
+	l.wg.Add(1)
+	go func() {
+		defer l.wg.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-l.done:
+				return
+			case now := <-ticker.C:
+				l.tick(now)
+			}
+		}
+	}()
+}
+
+func (l *limiter) shutdown(context.Context) error {
+	close(l.done)
+	l.wg.Wait()
+	if l.cfg.Summary.Enabled {
+		l.summarize()
+	}
+	return nil
+}
+
+func (l *limiter) tick(now time.Time) {
+	if l.cfg.Summary.Enabled {
+		l.summarize()
+	}
+	l.evict(now)
+}
+
+// allow reports whether an item with the given key values is kept, counting
+// it as dropped otherwise.
+func (l *limiter) allow(ctx context.Context, values []string) bool {
+	key := strings.Join(values, "\x00")
+	now := time.Now()
+
+	l.mu.Lock()
+	b, ok := l.buckets[key]
+	if !ok {
+		limit := l.limitFor(values)
+		b = &bucket{
+			limiter: rate.NewLimiter(rate.Limit(limit.Rate), limit.burst()),
+			values:  values,
+		}
+		l.buckets[key] = b
+	}
+	b.lastSeen = now
+
+	allowed := b.limiter.AllowN(now, 1)
+	if !allowed && l.cfg.Action == actionSample {
+		b.sampled += l.cfg.SamplingPercentage
+		if b.sampled >= 100 {
+			b.sampled -= 100
+			allowed = true
+		}
+	}
+	if !allowed {
+		b.dropped++
+	}
+	l.mu.Unlock()
+
+	if !allowed {
+		l.dropped.Add(ctx, 1, metric.WithAttributes(l.keyAttributes(values)...))
+	}
+	return allowed
+}
+
+// limitFor returns the limit of the first override matching the key values,
+// or the default limit.
+func (l *limiter) limitFor(values []string) LimitConfig {
+	for _, o := range l.cfg.Overrides {
+		if l.matches(o, values) {
+			return o.LimitConfig
+		}
+	}
+	return l.cfg.Default
+}
+
+func (l *limiter) matches(o OverrideConfig, values []string) bool {
+	for i, name := range l.cfg.KeyAttributes {
+		if want, ok := o.Match[name]; ok && want != values[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (l *limiter) keyAttributes(values []string) []attribute.KeyValue {
+	attrs := make([]attribute.KeyValue, 0, len(l.attrs)+len(values))
+	attrs = append(attrs, l.attrs...)
+	for i, name := range l.cfg.KeyAttributes {
+		attrs = append(attrs, attribute.String(name, values[i]))
+	}
+	return attrs
+}
+
+// summarize reports the items dropped per key since the previous summary.
+// Nothing is reported when nothing was dropped.
+func (l *limiter) summarize() {
+	l.mu.Lock()
+	dropped := map[string]int64{}
+	var total int64
+	for _, b := range l.buckets {
+		if b.dropped == 0 {
+			continue
+		}
+		dropped[l.describe(b.values)] = b.dropped
+		total += b.dropped
+		b.dropped = 0
+	}
+	l.mu.Unlock()
+
+	if total == 0 {
+		return
+	}
+
+	keys := make([]string, 0, len(dropped))
+	for k := range dropped {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	fields := make([]string, 0, len(keys))
+	for _, k := range keys {
+		fields = append(fields, fmt.Sprintf("%s: %d", k, dropped[k]))
+	}
+	l.report(total, fields)
+}
+
+func (l *limiter) logSummary(dropped int64, keys []string) {
+	l.logger.Info(summaryMessage,
+		zap.String("signal", l.signal),
+		zap.Int64("dropped", dropped),
+		zap.Strings("keys", keys),
+	)
+}
+
+func (l *limiter) describe(values []string) string {
+	parts := make([]string, len(values))
+	for i, name := range l.cfg.KeyAttributes {
+		parts[i] = name + "=" + values[i]
+	}
+	return strings.Join(parts, ",")
+}
+
+// evict removes buckets that have been idle long enough to be full again and
+// have no drops waiting to be summarized.
+func (l *limiter) evict(now time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for key, b := range l.buckets {
+		if b.dropped > 0 {
+			continue
+		}
+		refill := time.Duration(float64(b.limiter.Burst()) / float64(b.limiter.Limit()) * float64(time.Second))
+		if now.Sub(b.lastSeen) > refill {
+			delete(l.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package ratelimitprocessor
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.opentelemetry.io/collector/processor/processorhelper"
+	"go.uber.org/zap"
+)
+
+type rateLimitProcessor struct {
+	cfg     *Config
+	id      component.ID
+	logger  *zap.Logger
+	limiter *limiter
+}
+
+// passSummary returns a report that passes the summary of a logs pipeline on
+// as a log record. The record isn't subject to the limit it reports on.
+func (p *rateLimitProcessor) passSummary(next consumer.Logs) func(dropped int64, keys []string) {
+	return func(dropped int64, keys []string) {
+		if err := next.ConsumeLogs(context.Background(), p.summaryLogs(time.Now(), dropped, keys)); err != nil {
+			p.logger.Warn("Failed to pass on the rate limit summary", zap.Error(err))
+		}
+	}
+}
+
+func (p *rateLimitProcessor) summaryLogs(now time.Time, dropped int64, keys []string) plog.Logs {
+	ld := plog.NewLogs()
+	sl := ld.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty()
+	sl.Scope().SetName(scopeName)
+
+	lr := sl.LogRecords().AppendEmpty()
+	lr.SetTimestamp(pcommon.NewTimestampFromTime(now))
+	lr.SetObservedTimestamp(pcommon.NewTimestampFromTime(now))
+	lr.SetSeverityNumber(plog.SeverityNumberInfo)
+	lr.SetSeverityText("INFO")
+	lr.Body().SetStr(summaryMessage)
+	lr.Attributes().PutStr("processor", p.id.String())
+	lr.Attributes().PutInt("dropped", dropped)
+	values := lr.Attributes().PutEmptySlice("keys")
+	for _, k := range keys {
+		values.AppendEmpty().SetStr(k)
+	}
+	return ld
+}
+
+func (p *rateLimitProcessor) processLogs(ctx context.Context, ld plog.Logs) (plog.Logs, error) {
+	ld.ResourceLogs().RemoveIf(func(rl plog.ResourceLogs) bool {
+		resource := rl.Resource().Attributes()
+		rl.ScopeLogs().RemoveIf(func(sl plog.ScopeLogs) bool {
+			sl.LogRecords().RemoveIf(func(lr plog.LogRecord) bool {
+				return !p.allow(ctx, lr.Attributes(), resource)
+			})
+			return sl.LogRecords().Len() == 0
+		})
+		return rl.ScopeLogs().Len() == 0
+	})
+	if ld.ResourceLogs().Len() == 0 {
+		return ld, processorhelper.ErrSkipProcessingData
+	}
+	return ld, nil
+}
+
+func (p *rateLimitProcessor) processTraces(ctx context.Context, td ptrace.Traces) (ptrace.Traces, error) {
+	td.ResourceSpans().RemoveIf(func(rs ptrace.ResourceSpans) bool {
+		resource := rs.Resource().Attributes()
+		rs.ScopeSpans().RemoveIf(func(ss ptrace.ScopeSpans) bool {
+			ss.Spans().RemoveIf(func(s ptrace.Span) bool {
+				return !p.allow(ctx, s.Attributes(), resource)
+			})
+			return ss.Spans().Len() == 0
+		})
+		return rs.ScopeSpans().Len() == 0
+	})
+	if td.ResourceSpans().Len() == 0 {
+		return td, processorhelper.ErrSkipProcessingData
+	}
+	return td, nil
+}
+
+func (p *rateLimitProcessor) processMetrics(ctx context.Context, md pmetric.Metrics) (pmetric.Metrics, error) {
+	md.ResourceMetrics().RemoveIf(func(rm pmetric.ResourceMetrics) bool {
+		resource := rm.Resource().Attributes()
+		rm.ScopeMetrics().RemoveIf(func(sm pmetric.ScopeMetrics) bool {
+			sm.Metrics().RemoveIf(func(m pmetric.Metric) bool {
+				return p.limitDataPoints(ctx, m, resource) == 0
+			})
+			return sm.Metrics().Len() == 0
+		})
+		return rm.ScopeMetrics().Len() == 0
+	})
+	if md.ResourceMetrics().Len() == 0 {
+		return md, processorhelper.ErrSkipProcessingData
+	}
+	return md, nil
+}
+
+// limitDataPoints removes the datapoints over the limit and returns how many
+// are left.
+func (p *rateLimitProcessor) limitDataPoints(ctx context.Context, m pmetric.Metric, resource pcommon.Map) int {
+	switch m.Type() {
+	case pmetric.MetricTypeGauge:
+		dps := m.Gauge().DataPoints()
+		dps.RemoveIf(func(dp pmetric.NumberDataPoint) bool {
+			return !p.allow(ctx, dp.Attributes(), resource)
+		})
+		return dps.Len()
+	case pmetric.MetricTypeSum:
+		dps := m.Sum().DataPoints()
+		dps.RemoveIf(func(dp pmetric.NumberDataPoint) bool {
+			return !p.allow(ctx, dp.Attributes(), resource)
+		})
+		return dps.Len()
+	case pmetric.MetricTypeHistogram:
+		dps := m.Histogram().DataPoints()
+		dps.RemoveIf(func(dp pmetric.HistogramDataPoint) bool {
+			return !p.allow(ctx, dp.Attributes(), resource)
+		})
+		return dps.Len()
+	case pmetric.MetricTypeExponentialHistogram:
+		dps := m.ExponentialHistogram().DataPoints()
+		dps.RemoveIf(func(dp pmetric.ExponentialHistogramDataPoint) bool {
+			return !p.allow(ctx, dp.Attributes(), resource)
+		})
+		return dps.Len()
+	case pmetric.MetricTypeSummary:
+		dps := m.Summary().DataPoints()
+		dps.RemoveIf(func(dp pmetric.SummaryDataPoint) bool {
+			return !p.allow(ctx, dp.Attributes(), resource)
+		})
+		return dps.Len()
+	}
+	return 1
+}
+
+// allow looks up the key values on the record first and on its resource
+// otherwise. Missing attributes are treated as empty values, so records
+// without them share a single limit.
+func (p *rateLimitProcessor) allow(ctx context.Context, attrs, resource pcommon.Map) bool {
+	values := make([]string, len(p.cfg.KeyAttributes))
+	for i, name := range p.cfg.KeyAttributes {
+		if v, ok := attrs.Get(name); ok {
+			values[i] = v.AsString()
+		} else if v, ok := resource.Get(name); ok {
+			values[i] = v.AsString()
+		}
+	}
+	return p.limiter.allow(ctx, values)
+}
@@ -0,0 +1,327 @@
+package ratelimitprocessor_test
+
+import (
+	"context"
+
+	"code.cloudfoundry.org/otel-collector-release/src/components/processor/ratelimitprocessor"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.opentelemetry.io/collector/component/componenttest"
+	"go.opentelemetry.io/collector/consumer/consumertest"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.opentelemetry.io/collector/processor"
+	"go.opentelemetry.io/collector/processor/processortest"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// slowRate refills a single token every 1000 seconds, so that nothing is
+// refilled while a test runs.
+const slowRate = 0.001
+
+var _ = Describe("Rate limit processor", func() {
+	var (
+		cfg       *ratelimitprocessor.Config
+		telemetry *componenttest.Telemetry
+		logs      *observer.ObservedLogs
+		settings  processor.Settings
+	)
+
+	BeforeEach(func() {
+		cfg = ratelimitprocessor.NewFactory().CreateDefaultConfig().(*ratelimitprocessor.Config)
+		cfg.Default = ratelimitprocessor.LimitConfig{Rate: slowRate, Burst: 2}
+
+		telemetry = componenttest.NewTelemetry()
+		DeferCleanup(telemetry.Shutdown, context.Background())
+
+		var core zapcore.Core
+		core, logs = observer.New(zapcore.InfoLevel)
+		settings = processortest.NewNopSettings(ratelimitprocessor.NewFactory().Type())
+		settings.TelemetrySettings = telemetry.NewTelemetrySettings()
+		settings.Logger = zap.New(core)
+	})
+
+	startLogs := func() (processor.Logs, *consumertest.LogsSink) {
+		Expect(cfg.Validate()).To(Succeed())
+		sink := new(consumertest.LogsSink)
+		p, err := ratelimitprocessor.NewFactory().CreateLogs(context.Background(), settings, cfg, sink)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(p.Start(context.Background(), componenttest.NewNopHost())).To(Succeed())
+		return p, sink
+	}
+
+	It("drops log records over the limit of their org and space", func() {
+		p, sink := startLogs()
+		DeferCleanup(p.Shutdown, context.Background())
+
+		Expect(p.ConsumeLogs(context.Background(), orgLogs("org-a", "space-a", 3))).To(Succeed())
+		Expect(p.ConsumeLogs(context.Background(), orgLogs("org-b", "space-b", 1))).To(Succeed())
+		Expect(p.ConsumeLogs(context.Background(), orgLogs("org-a", "space-a", 1))).To(Succeed())
+
+		Expect(sink.AllLogs()).To(HaveLen(2))
+		Expect(sink.LogRecordCount()).To(Equal(3))
+		Expect(sink.AllLogs()[0].ResourceLogs().At(0).Resource().Attributes().AsRaw()).To(HaveKeyWithValue("organization_id", "org-a"))
+		Expect(sink.AllLogs()[1].ResourceLogs().At(0).Resource().Attributes().AsRaw()).To(HaveKeyWithValue("organization_id", "org-b"))
+	})
+
+	It("limits spaces separately", func() {
+		p, sink := startLogs()
+		DeferCleanup(p.Shutdown, context.Background())
+
+		Expect(p.ConsumeLogs(context.Background(), orgLogs("org-a", "space-a", 3))).To(Succeed())
+		Expect(p.ConsumeLogs(context.Background(), orgLogs("org-a", "space-b", 3))).To(Succeed())
+
+		Expect(sink.LogRecordCount()).To(Equal(4))
+	})
+
+	It("prefers record attributes over resource attributes", func() {
+		p, sink := startLogs()
+		DeferCleanup(p.Shutdown, context.Background())
+
+		ld := orgLogs("org-a", "space-a", 4)
+		records := ld.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords()
+		records.At(2).Attributes().PutStr("space_id", "space-b")
+		records.At(3).Attributes().PutStr("space_id", "space-b")
+		Expect(p.ConsumeLogs(context.Background(), ld)).To(Succeed())
+
+		Expect(sink.LogRecordCount()).To(Equal(4))
+	})
+
+	It("uses the first matching override", func() {
+		cfg.Overrides = []ratelimitprocessor.OverrideConfig{
+			{
+				Match:       map[string]string{"organization_id": "org-a", "space_id": "space-a"},
+				LimitConfig: ratelimitprocessor.LimitConfig{Rate: slowRate, Burst: 5},
+			},
+			{
+				Match:       map[string]string{"organization_id": "org-a"},
+				LimitConfig: ratelimitprocessor.LimitConfig{Rate: slowRate, Burst: 1},
+			},
+		}
+		p, sink := startLogs()
+		DeferCleanup(p.Shutdown, context.Background())
+
+		Expect(p.ConsumeLogs(context.Background(), orgLogs("org-a", "space-a", 10))).To(Succeed())
+		Expect(sink.LogRecordCount()).To(Equal(5))
+
+		sink.Reset()
+		Expect(p.ConsumeLogs(context.Background(), orgLogs("org-a", "space-b", 10))).To(Succeed())
+		Expect(sink.LogRecordCount()).To(Equal(1))
+
+		sink.Reset()
+		Expect(p.ConsumeLogs(context.Background(), orgLogs("org-b", "space-c", 10))).To(Succeed())
+		Expect(sink.LogRecordCount()).To(Equal(2))
+	})
+
+	It("keeps the sampling percentage of items over the limit", func() {
+		cfg.Action = "sample"
+		cfg.SamplingPercentage = 25
+		p, sink := startLogs()
+		DeferCleanup(p.Shutdown, context.Background())
+
+		Expect(p.ConsumeLogs(context.Background(), orgLogs("org-a", "space-a", 42))).To(Succeed())
+
+		Expect(sink.LogRecordCount()).To(Equal(2 + 10))
+	})
+
+	It("does not pass on batches where everything was dropped", func() {
+		p, sink := startLogs()
+		DeferCleanup(p.Shutdown, context.Background())
+
+		Expect(p.ConsumeLogs(context.Background(), orgLogs("org-a", "space-a", 2))).To(Succeed())
+		Expect(p.ConsumeLogs(context.Background(), orgLogs("org-a", "space-a", 2))).To(Succeed())
+
+		Expect(sink.AllLogs()).To(HaveLen(1))
+	})
+
+	It("counts dropped items per key", func() {
+		p, _ := startLogs()
+		DeferCleanup(p.Shutdown, context.Background())
+
+		Expect(p.ConsumeLogs(context.Background(), orgLogs("org-a", "space-a", 5))).To(Succeed())
+		Expect(p.ConsumeLogs(context.Background(), orgLogs("org-b", "space-b", 3))).To(Succeed())
+
+		m, err := telemetry.GetMetric("otelcol_processor_ratelimit_dropped_items")
+		Expect(err).NotTo(HaveOccurred())
+		sum := m.Data.(metricdata.Sum[int64])
+		dropped := map[string]int64{}
+		for _, dp := range sum.DataPoints {
+			org, _ := dp.Attributes.Value(attribute.Key("organization_id"))
+			signal, _ := dp.Attributes.Value(attribute.Key("signal"))
+			Expect(signal.AsString()).To(Equal("logs"))
+			dropped[org.AsString()] = dp.Value
+		}
+		Expect(dropped).To(Equal(map[string]int64{"org-a": 3, "org-b": 1}))
+	})
+
+	It("passes on a summary record of dropped items", func() {
+		cfg.Summary.Enabled = true
+		p, sink := startLogs()
+
+		Expect(p.ConsumeLogs(context.Background(), orgLogs("org-a", "space-a", 5))).To(Succeed())
+		Expect(p.ConsumeLogs(context.Background(), orgLogs("org-b", "space-b", 3))).To(Succeed())
+		Expect(p.Shutdown(context.Background())).To(Succeed())
+
+		Expect(sink.AllLogs()).To(HaveLen(3))
+		summary := sink.AllLogs()[2].ResourceLogs().At(0).ScopeLogs().At(0).LogRecords()
+		Expect(summary.Len()).To(Equal(1))
+		Expect(summary.At(0).Body().Str()).To(Equal("Dropped items exceeding the rate limit"))
+		Expect(summary.At(0).Attributes().AsRaw()).To(Equal(map[string]any{
+			"processor": "ratelimit",
+			"dropped":   int64(4),
+			"keys": []any{
+				"organization_id=org-a,space_id=space-a: 3",
+				"organization_id=org-b,space_id=space-b: 1",
+			},
+		}))
+		Expect(logs.Len()).To(BeZero())
+	})
+
+	It("logs the summary in pipelines that cannot carry log records", func() {
+		cfg.Summary.Enabled = true
+		p, err := ratelimitprocessor.NewFactory().CreateTraces(context.Background(), settings, cfg, consumertest.NewNop())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(p.Start(context.Background(), componenttest.NewNopHost())).To(Succeed())
+
+		td := ptrace.NewTraces()
+		rs := td.ResourceSpans().AppendEmpty()
+		rs.Resource().Attributes().PutStr("organization_id", "org-a")
+		rs.Resource().Attributes().PutStr("space_id", "space-a")
+		for range 3 {
+			rs.ScopeSpans().AppendEmpty().Spans().AppendEmpty().SetName("span")
+		}
+		Expect(p.ConsumeTraces(context.Background(), td)).To(Succeed())
+		Expect(p.Shutdown(context.Background())).To(Succeed())
+
+		entries := logs.FilterMessage("Dropped items exceeding the rate limit").All()
+		Expect(entries).To(HaveLen(1))
+		Expect(entries[0].ContextMap()).To(HaveKeyWithValue("signal", "traces"))
+		Expect(entries[0].ContextMap()).To(HaveKeyWithValue("dropped", int64(1)))
+		Expect(entries[0].ContextMap()).To(HaveKeyWithValue("keys", []any{
+			"organization_id=org-a,space_id=space-a: 1",
+		}))
+	})
+
+	It("does not summarize when the summary is disabled", func() {
+		p, sink := startLogs()
+
+		Expect(p.ConsumeLogs(context.Background(), orgLogs("org-a", "space-a", 5))).To(Succeed())
+		Expect(p.Shutdown(context.Background())).To(Succeed())
+
+		Expect(sink.AllLogs()).To(HaveLen(1))
+		Expect(logs.Len()).To(BeZero())
+	})
+
+	It("limits spans", func() {
+		sink := new(consumertest.TracesSink)
+		p, err := ratelimitprocessor.NewFactory().CreateTraces(context.Background(), settings, cfg, sink)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(p.Start(context.Background(), componenttest.NewNopHost())).To(Succeed())
+		DeferCleanup(p.Shutdown, context.Background())
+
+		td := ptrace.NewTraces()
+		rs := td.ResourceSpans().AppendEmpty()
+		rs.Resource().Attributes().PutStr("organization_id", "org-a")
+		rs.Resource().Attributes().PutStr("space_id", "space-a")
+		spans := rs.ScopeSpans().AppendEmpty().Spans()
+		for range 3 {
+			spans.AppendEmpty().SetName("span")
+		}
+		Expect(p.ConsumeTraces(context.Background(), td)).To(Succeed())
+
+		Expect(sink.SpanCount()).To(Equal(2))
+	})
+
+	It("limits datapoints and removes empty metrics", func() {
+		sink := new(consumertest.MetricsSink)
+		p, err := ratelimitprocessor.NewFactory().CreateMetrics(context.Background(), settings, cfg, sink)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(p.Start(context.Background(), componenttest.NewNopHost())).To(Succeed())
+		DeferCleanup(p.Shutdown, context.Background())
+
+		md := pmetric.NewMetrics()
+		rm := md.ResourceMetrics().AppendEmpty()
+		rm.Resource().Attributes().PutStr("organization_id", "org-a")
+		rm.Resource().Attributes().PutStr("space_id", "space-a")
+		metrics := rm.ScopeMetrics().AppendEmpty().Metrics()
+		gauge := metrics.AppendEmpty()
+		gauge.SetName("cpu")
+		gauge.SetEmptyGauge().DataPoints().AppendEmpty().SetDoubleValue(1)
+		gauge.Gauge().DataPoints().AppendEmpty().SetDoubleValue(2)
+		sum := metrics.AppendEmpty()
+		sum.SetName("requests")
+		sum.SetEmptySum().DataPoints().AppendEmpty().SetIntValue(3)
+		Expect(p.ConsumeMetrics(context.Background(), md)).To(Succeed())
+
+		Expect(sink.DataPointCount()).To(Equal(2))
+		Expect(sink.AllMetrics()[0].MetricCount()).To(Equal(1))
+		Expect(sink.AllMetrics()[0].ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0).Name()).To(Equal("cpu"))
+	})
+})
+
+var _ = Describe("Config", func() {
+	var cfg *ratelimitprocessor.Config
+
+	BeforeEach(func() {
+		cfg = ratelimitprocessor.NewFactory().CreateDefaultConfig().(*ratelimitprocessor.Config)
+	})
+
+	It("is valid by default", func() {
+		Expect(cfg.Validate()).To(Succeed())
+	})
+
+	It("requires key attributes", func() {
+		cfg.KeyAttributes = nil
+		Expect(cfg.Validate()).To(MatchError(ContainSubstring(`"key_attributes"`)))
+	})
+
+	It("requires a positive rate", func() {
+		cfg.Default.Rate = 0
+		Expect(cfg.Validate()).To(MatchError(`default: "rate" must be positive`))
+	})
+
+	It("requires overrides to match on key attributes", func() {
+		cfg.Overrides = []ratelimitprocessor.OverrideConfig{{
+			Match:       map[string]string{"app_id": "app"},
+			LimitConfig: ratelimitprocessor.LimitConfig{Rate: 10},
+		}}
+		Expect(cfg.Validate()).To(MatchError(`overrides[0]: "app_id" is not one of the key attributes`))
+
+		cfg.Overrides[0].Match = nil
+		Expect(cfg.Validate()).To(MatchError(`overrides[0]: requires a non-empty "match"`))
+	})
+
+	It("requires a known action and a sampling percentage when sampling", func() {
+		cfg.Action = "queue"
+		Expect(cfg.Validate()).To(MatchError(ContainSubstring(`unsupported action "queue"`)))
+
+		cfg.Action = "sample"
+		Expect(cfg.Validate()).To(MatchError(ContainSubstring(`"sampling_percentage"`)))
+
+		cfg.SamplingPercentage = 10
+		Expect(cfg.Validate()).To(Succeed())
+	})
+
+	It("requires a summary interval when the summary is enabled", func() {
+		cfg.Summary.Enabled = true
+		cfg.Summary.Interval = 0
+		Expect(cfg.Validate()).To(MatchError(`"summary.interval" must be positive`))
+	})
+})
+
+func orgLogs(org, space string, n int) plog.Logs {
+	ld := plog.NewLogs()
+	rl := ld.ResourceLogs().AppendEmpty()
+	rl.Resource().Attributes().PutStr("organization_id", org)
+	rl.Resource().Attributes().PutStr("space_id", space)
+	records := rl.ScopeLogs().AppendEmpty().LogRecords()
+	for range n {
+		records.AppendEmpty().Body().SetStr("log line")
+	}
+	return ld
+}
@@ -0,0 +1,94 @@
+package redactionprocessor
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/config/configopaque"
+)
+
+const (
+	// actionMask replaces sensitive values with a fixed mask.
+	actionMask = "mask"
+
+	// actionHash replaces sensitive values with a hash, so that the same
+	// value can still be correlated across records.
+	actionHash = "hash"
+)
+
+// Config defines the configuration for the redaction processor.
+type Config struct {
+	// Detectors are the built-in detectors to use. Defaults to all of them.
+	Detectors []string `mapstructure:"detectors"`
+
+	// Custom are additional detectors defined by regular expressions.
+	Custom []CustomDetectorConfig `mapstructure:"custom"`
+
+	// Action is how sensitive values are redacted, mask or hash.
+	Action string `mapstructure:"action"`
+
+	// HashKey is the key used to hash sensitive values with HMAC-SHA256.
+	// Without a key values are hashed with SHA-256, which allows short values
+	// such as card numbers to be recovered by brute force.
+	HashKey configopaque.String `mapstructure:"hash_key"`
+}
+
+// CustomDetectorConfig defines a detector by a regular expression. When the
+// expression has a group named "secret", only that group is redacted.
+type CustomDetectorConfig struct {
+	Name    string `mapstructure:"name"`
+	Pattern string `mapstructure:"pattern"`
+}
+
+var _ component.Config = (*Config)(nil)
+
+// Validate checks that the detectors exist, that custom detectors compile
+// and have unique names, and the action.
+func (cfg *Config) Validate() error {
+	for _, name := range cfg.Detectors {
+		if _, ok := builtinDetectors[name]; !ok {
+			return fmt.Errorf("unknown detector %q, must be one of %v", name, builtinDetectorNames())
+		}
+	}
+
+	names := map[string]bool{}
+	for i, c := range cfg.Custom {
+		if c.Name == "" {
+			return fmt.Errorf(`custom[%d]: requires a non-empty "name"`, i)
+		}
+		if _, ok := builtinDetectors[c.Name]; ok || names[c.Name] {
+			return fmt.Errorf("custom[%d]: detector %q is already defined", i, c.Name)
+		}
+		names[c.Name] = true
+
+		if c.Pattern == "" {
+			return fmt.Errorf(`custom[%d]: requires a non-empty "pattern"`, i)
+		}
+		if _, err := regexp.Compile(c.Pattern); err != nil {
+			return fmt.Errorf("custom[%d]: invalid pattern: %w", i, err)
+		}
+	}
+
+	switch cfg.Action {
+	case actionMask, actionHash:
+	default:
+		return fmt.Errorf("unsupported action %q, must be %s or %s", cfg.Action, actionMask, actionHash)
+	}
+
+	if len(cfg.Detectors) == 0 && len(cfg.Custom) == 0 {
+		return errors.New("requires at least one detector")
+	}
+	return nil
+}
+
+func builtinDetectorNames() []string {
+	names := make([]string, 0, len(builtinDetectors))
+	for name := range builtinDetectors {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
@@ -0,0 +1,111 @@
+package redactionprocessor
+
+import (
+	"regexp"
+	"strings"
+)
+
+const (
+	detectorPrivateKey          = "private_key"
+	detectorAuthorizationHeader = "authorization_header"
+	detectorJWT                 = "jwt"
+	detectorAWSKey              = "aws_key"
+	detectorCreditCard          = "credit_card"
+
+	// secretGroup is the name of the group that is redacted when a pattern
+	// has one, so that the surrounding context stays readable.
+	secretGroup = "secret"
+)
+
+// detector finds sensitive values in strings.
+type detector struct {
+	name     string
+	patterns []*regexp.Regexp
+
+	// valid filters out matches that look sensitive but are not, when set.
+	valid func(string) bool
+}
+
+// builtinDetectors are the detectors that can be enabled by name.
+var builtinDetectors = map[string]detector{
+	detectorPrivateKey: {
+		name: detectorPrivateKey,
+		patterns: []*regexp.Regexp{
+			regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?(?:-----END [A-Z ]*PRIVATE KEY-----|$)`),
+		},
+	},
+	detectorAuthorizationHeader: {
+		name: detectorAuthorizationHeader,
+		patterns: []*regexp.Regexp{
+			regexp.MustCompile(`(?i)\b(?:proxy-)?authorization\b["']?\s*[:=]\s*["']?(?:(?:bearer|basic|token|digest)\s+)?(?P<secret>[^\s"',;]+)`),
+		},
+	},
+	detectorJWT: {
+		name: detectorJWT,
+		patterns: []*regexp.Regexp{
+			regexp.MustCompile(`\beyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`),
+		},
+	},
+	detectorAWSKey: {
+		name: detectorAWSKey,
+		patterns: []*regexp.Regexp{
+			regexp.MustCompile(`\b(?:AKIA|ASIA|AGPA|AIDA|AROA|AIPA|ANPA|ANVA)[A-Z0-9]{16}\b`),
+			regexp.MustCompile(`(?i)\baws_?secret_?(?:access_?)?key\b["']?\s*[:=]\s*["']?(?P<secret>[A-Za-z0-9/+=]{40})\b`),
+		},
+	},
+	detectorCreditCard: {
+		name: detectorCreditCard,
+		patterns: []*regexp.Regexp{
+			regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`),
+		},
+		valid: luhnValid,
+	},
+}
+
+// detectorOrder is the order built-in detectors run in. Detectors for
+// values that contain other sensitive values run first, so that a JWT in an
+// Authorization header is counted once.
+var detectorOrder = []string{
+	detectorPrivateKey,
+	detectorAuthorizationHeader,
+	detectorJWT,
+	detectorAWSKey,
+	detectorCreditCard,
+}
+
+// luhnValid reports whether the digits in s pass the Luhn checksum used by
+// card numbers, which rules out most other long numbers such as timestamps.
+func luhnValid(s string) bool {
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, s)
+	if len(digits) < 13 || len(digits) > 19 {
+		return false
+	}
+
+	sum := 0
+	double := false
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := int(digits[i] - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+// isAuthorizationKey reports whether an attribute holds an Authorization
+// header, such as http.request.header.authorization, in which case its whole
+// value is sensitive.
+func isAuthorizationKey(key string) bool {
+	key = strings.ToLower(key)
+	return strings.HasSuffix(key, "authorization")
+}
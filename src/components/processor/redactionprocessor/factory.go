@@ -0,0 +1,46 @@
+// Package redactionprocessor implements a processor that masks or hashes
+// credentials, card numbers and other sensitive values in log bodies and
+// attributes.
+package redactionprocessor
+
+import (
+	"context"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/processor"
+	"go.opentelemetry.io/collector/processor/processorhelper"
+)
+
+const stability = component.StabilityLevelAlpha
+
+var componentType = component.MustNewType("redaction")
+
+var processorCapabilities = consumer.Capabilities{MutatesData: true}
+
+// NewFactory creates a factory for the redaction processor.
+func NewFactory() processor.Factory {
+	return processor.NewFactory(
+		componentType,
+		createDefaultConfig,
+		processor.WithLogs(createLogs, stability),
+	)
+}
+
+func createDefaultConfig() component.Config {
+	return &Config{
+		Detectors: append([]string(nil), detectorOrder...),
+		Action:    actionMask,
+	}
+}
+
+func createLogs(ctx context.Context, set processor.Settings, cfg component.Config, next consumer.Logs) (processor.Logs, error) {
+	p, err := newRedactionProcessor(cfg.(*Config), set.MeterProvider.Meter(scopeName), set.ID.String())
+	if err != nil {
+		return nil, err
+	}
+	return processorhelper.NewLogs(ctx, set, cfg, next,
+		p.processLogs,
+		processorhelper.WithCapabilities(processorCapabilities),
+	)
+}
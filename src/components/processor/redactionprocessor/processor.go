@@ -0,0 +1,54 @@
+package redactionprocessor
+
+import (
+	"context"
+
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+const scopeName = "code.cloudfoundry.org/otel-collector-release/src/components/processor/redactionprocessor"
+
+type redactionProcessor struct {
+	redactor *redactor
+	redacted metric.Int64Counter
+	attrs    []attribute.KeyValue
+}
+
+func newRedactionProcessor(cfg *Config, meter metric.Meter, processorID string) (*redactionProcessor, error) {
+	redacted, err := meter.Int64Counter(
+		"otelcol_processor_redaction_redacted_values",
+		metric.WithDescription("Number of sensitive values redacted, per detector."),
+		metric.WithUnit("{values}"),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &redactionProcessor{
+		redactor: newRedactor(cfg),
+		redacted: redacted,
+		attrs:    []attribute.KeyValue{attribute.String("processor", processorID)},
+	}, nil
+}
+
+func (p *redactionProcessor) processLogs(ctx context.Context, ld plog.Logs) (plog.Logs, error) {
+	counts := map[string]int64{}
+	for i := 0; i < ld.ResourceLogs().Len(); i++ {
+		rl := ld.ResourceLogs().At(i)
+		for j := 0; j < rl.ScopeLogs().Len(); j++ {
+			records := rl.ScopeLogs().At(j).LogRecords()
+			for k := 0; k < records.Len(); k++ {
+				lr := records.At(k)
+				p.redactor.redactValue(lr.Body(), counts)
+				p.redactor.redactMap(lr.Attributes(), counts)
+			}
+		}
+	}
+
+	for name, n := range counts {
+		kvs := append(p.attrs[:len(p.attrs):len(p.attrs)], attribute.String("detector", name))
+		p.redacted.Add(ctx, n, metric.WithAttributes(kvs...))
+	}
+	return ld, nil
+}
@@ -0,0 +1,13 @@
+package redactionprocessor_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestRedactionProcessor(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Redaction Processor Suite")
+}
@@ -0,0 +1,144 @@
+package redactionprocessor
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"regexp"
+	"strings"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+)
+
+const (
+	// mask replaces sensitive values when the action is mask.
+	mask = "****"
+
+	// hashLength is the number of hex characters of the hash kept when the
+	// action is hash.
+	hashLength = 16
+)
+
+// redactor replaces the values found by its detectors and counts them per
+// detector.
+type redactor struct {
+	detectors     []detector
+	authorization bool
+	newHash       func() hash.Hash
+	hashing       bool
+}
+
+func newRedactor(cfg *Config) *redactor {
+	r := &redactor{hashing: cfg.Action == actionHash}
+
+	for _, name := range detectorOrder {
+		for _, enabled := range cfg.Detectors {
+			if enabled == name {
+				r.detectors = append(r.detectors, builtinDetectors[name])
+			}
+		}
+	}
+	for _, c := range cfg.Custom {
+		r.detectors = append(r.detectors, detector{
+			name:     c.Name,
+			patterns: []*regexp.Regexp{regexp.MustCompile(c.Pattern)},
+		})
+	}
+	for _, d := range r.detectors {
+		if d.name == detectorAuthorizationHeader {
+			r.authorization = true
+		}
+	}
+
+	r.newHash = sha256.New
+	if cfg.HashKey != "" {
+		key := []byte(cfg.HashKey)
+		r.newHash = func() hash.Hash { return hmac.New(sha256.New, key) }
+	}
+	return r
+}
+
+// redactString returns s with every sensitive value replaced.
+func (r *redactor) redactString(s string, counts map[string]int64) string {
+	for _, d := range r.detectors {
+		for _, re := range d.patterns {
+			s = r.replace(s, d, re, counts)
+		}
+	}
+	return s
+}
+
+func (r *redactor) replace(s string, d detector, re *regexp.Regexp, counts map[string]int64) string {
+	matches := re.FindAllStringSubmatchIndex(s, -1)
+	if matches == nil {
+		return s
+	}
+
+	group := re.SubexpIndex(secretGroup)
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		start, end := m[0], m[1]
+		if group >= 0 && m[2*group] >= 0 {
+			start, end = m[2*group], m[2*group+1]
+		}
+
+		value := s[start:end]
+		if value == "" || value == mask || (d.valid != nil && !d.valid(value)) {
+			continue
+		}
+
+		b.WriteString(s[last:start])
+		b.WriteString(r.replacement(value))
+		last = end
+		counts[d.name]++
+	}
+	if last == 0 {
+		return s
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
+
+func (r *redactor) replacement(value string) string {
+	if !r.hashing {
+		return mask
+	}
+	h := r.newHash()
+	h.Write([]byte(value))
+	return hex.EncodeToString(h.Sum(nil))[:hashLength]
+}
+
+// redactValue redacts strings in v, including those nested in maps and
+// slices.
+func (r *redactor) redactValue(v pcommon.Value, counts map[string]int64) {
+	switch v.Type() {
+	case pcommon.ValueTypeStr:
+		if redacted := r.redactString(v.Str(), counts); redacted != v.Str() {
+			v.SetStr(redacted)
+		}
+	case pcommon.ValueTypeMap:
+		r.redactMap(v.Map(), counts)
+	case pcommon.ValueTypeSlice:
+		s := v.Slice()
+		for i := 0; i < s.Len(); i++ {
+			r.redactValue(s.At(i), counts)
+		}
+	}
+}
+
+// redactMap redacts the values of m. String values of Authorization
+// attributes are redacted as a whole.
+func (r *redactor) redactMap(m pcommon.Map, counts map[string]int64) {
+	for k, v := range m.All() {
+		if r.authorization && v.Type() == pcommon.ValueTypeStr && isAuthorizationKey(k) && v.Str() != "" {
+			if v.Str() != mask {
+				v.SetStr(r.replacement(v.Str()))
+				counts[detectorAuthorizationHeader]++
+			}
+			continue
+		}
+		r.redactValue(v, counts)
+	}
+}
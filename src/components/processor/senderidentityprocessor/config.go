@@ -0,0 +1,26 @@
+package senderidentityprocessor
+
+import (
+	"fmt"
+
+	"go.opentelemetry.io/collector/component"
+)
+
+// Config defines the configuration for the sender identity processor.
+type Config struct {
+	// SourceIDs are the source_id values each sender identity may send.
+	// Senders that are not listed may send any source_id.
+	SourceIDs map[string][]string `mapstructure:"source_ids"`
+}
+
+var _ component.Config = (*Config)(nil)
+
+// Validate checks that every listed sender may send a source_id.
+func (cfg *Config) Validate() error {
+	for identity, sourceIDs := range cfg.SourceIDs {
+		if len(sourceIDs) == 0 {
+			return fmt.Errorf(`"source_ids" of %q must not be empty`, identity)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,228 @@
+package senderidentityprocessor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"go.opentelemetry.io/collector/client"
+	"go.opentelemetry.io/collector/consumer/consumererror"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+const (
+	// authIdentity is the auth data attribute auth extensions like
+	// clientcertauth expose the sender identity as.
+	authIdentity = "identity"
+
+	// The auth data attributes auth extensions like instanceidentityauth
+	// expose for senders that are app instances.
+	authAppGUID          = "app_guid"
+	authSpaceGUID        = "space_guid"
+	authOrganizationGUID = "organization_guid"
+
+	attributeSenderIdentity = "cf.sender.identity"
+	attributeSourceID       = "source_id"
+)
+
+var errNoIdentity = errors.New("the request has no sender identity, the receiver must use an auth extension that exposes one")
+
+type senderIdentityProcessor struct {
+	cfg *Config
+}
+
+func newSenderIdentityProcessor(cfg *Config) *senderIdentityProcessor {
+	return &senderIdentityProcessor{cfg: cfg}
+}
+
+func (p *senderIdentityProcessor) processLogs(ctx context.Context, ld plog.Logs) (plog.Logs, error) {
+	s, err := p.sender(ctx)
+	if err != nil {
+		return ld, err
+	}
+	for i := 0; i < ld.ResourceLogs().Len(); i++ {
+		rl := ld.ResourceLogs().At(i)
+		if err := s.checkAndForce(rl.Resource().Attributes()); err != nil {
+			return ld, err
+		}
+		for j := 0; j < rl.ScopeLogs().Len(); j++ {
+			records := rl.ScopeLogs().At(j).LogRecords()
+			for k := 0; k < records.Len(); k++ {
+				if err := s.checkAndForce(records.At(k).Attributes()); err != nil {
+					return ld, err
+				}
+			}
+		}
+		s.stamp(rl.Resource().Attributes())
+	}
+	return ld, nil
+}
+
+func (p *senderIdentityProcessor) processTraces(ctx context.Context, td ptrace.Traces) (ptrace.Traces, error) {
+	s, err := p.sender(ctx)
+	if err != nil {
+		return td, err
+	}
+	for i := 0; i < td.ResourceSpans().Len(); i++ {
+		rs := td.ResourceSpans().At(i)
+		if err := s.checkAndForce(rs.Resource().Attributes()); err != nil {
+			return td, err
+		}
+		for j := 0; j < rs.ScopeSpans().Len(); j++ {
+			spans := rs.ScopeSpans().At(j).Spans()
+			for k := 0; k < spans.Len(); k++ {
+				if err := s.checkAndForce(spans.At(k).Attributes()); err != nil {
+					return td, err
+				}
+			}
+		}
+		s.stamp(rs.Resource().Attributes())
+	}
+	return td, nil
+}
+
+func (p *senderIdentityProcessor) processMetrics(ctx context.Context, md pmetric.Metrics) (pmetric.Metrics, error) {
+	s, err := p.sender(ctx)
+	if err != nil {
+		return md, err
+	}
+	for i := 0; i < md.ResourceMetrics().Len(); i++ {
+		rm := md.ResourceMetrics().At(i)
+		if err := s.checkAndForce(rm.Resource().Attributes()); err != nil {
+			return md, err
+		}
+		for j := 0; j < rm.ScopeMetrics().Len(); j++ {
+			metrics := rm.ScopeMetrics().At(j).Metrics()
+			for k := 0; k < metrics.Len(); k++ {
+				if err := s.checkAndForceMetric(metrics.At(k)); err != nil {
+					return md, err
+				}
+			}
+		}
+		s.stamp(rm.Resource().Attributes())
+	}
+	return md, nil
+}
+
+// sender is the authenticated sender of a request.
+type sender struct {
+	identity string
+	// sourceIDs are the source_id values the sender may send, any when nil.
+	sourceIDs []string
+	// tenant are the attributes of the app instance the sender is, which are
+	// forced onto everything it sends so that apps can't pose as each other.
+	// Empty for senders that are not app instances.
+	tenant map[string]string
+}
+
+func (p *senderIdentityProcessor) sender(ctx context.Context) (sender, error) {
+	auth := client.FromContext(ctx).Auth
+	if auth == nil {
+		return sender{}, consumererror.NewPermanent(errNoIdentity)
+	}
+	identity, _ := auth.GetAttribute(authIdentity).(string)
+	if identity == "" {
+		return sender{}, consumererror.NewPermanent(errNoIdentity)
+	}
+	return sender{
+		identity:  identity,
+		sourceIDs: p.cfg.SourceIDs[identity],
+		tenant:    tenantAttributes(auth),
+	}, nil
+}
+
+// tenantAttributes are the Loggregator and semantic convention attributes of
+// the app, space and organization the auth data describes.
+func tenantAttributes(auth client.AuthData) map[string]string {
+	appGUID, _ := auth.GetAttribute(authAppGUID).(string)
+	if appGUID == "" {
+		return nil
+	}
+	spaceGUID, _ := auth.GetAttribute(authSpaceGUID).(string)
+	organizationGUID, _ := auth.GetAttribute(authOrganizationGUID).(string)
+	return map[string]string{
+		attributeSourceID:       appGUID,
+		"app_id":                appGUID,
+		"space_id":              spaceGUID,
+		"organization_id":       organizationGUID,
+		"cloudfoundry.app.id":   appGUID,
+		"cloudfoundry.space.id": spaceGUID,
+		"cloudfoundry.org.id":   organizationGUID,
+	}
+}
+
+// checkAndForce rejects a source_id the sender may not send, and replaces the
+// tenant attributes an app instance set with its own.
+func (s sender) checkAndForce(attrs pcommon.Map) error {
+	if err := s.check(attrs); err != nil {
+		return err
+	}
+	for k, v := range s.tenant {
+		if _, ok := attrs.Get(k); ok {
+			attrs.PutStr(k, v)
+		}
+	}
+	return nil
+}
+
+// check rejects a source_id the sender may not send.
+func (s sender) check(attrs pcommon.Map) error {
+	if s.sourceIDs == nil {
+		return nil
+	}
+	v, ok := attrs.Get(attributeSourceID)
+	if !ok || slices.Contains(s.sourceIDs, v.AsString()) {
+		return nil
+	}
+	return consumererror.NewPermanent(fmt.Errorf("sender %q may not send source_id %q", s.identity, v.AsString()))
+}
+
+func (s sender) checkAndForceMetric(m pmetric.Metric) error {
+	var attrs []pcommon.Map
+	switch m.Type() {
+	case pmetric.MetricTypeGauge:
+		dps := m.Gauge().DataPoints()
+		for l := 0; l < dps.Len(); l++ {
+			attrs = append(attrs, dps.At(l).Attributes())
+		}
+	case pmetric.MetricTypeSum:
+		dps := m.Sum().DataPoints()
+		for l := 0; l < dps.Len(); l++ {
+			attrs = append(attrs, dps.At(l).Attributes())
+		}
+	case pmetric.MetricTypeHistogram:
+		dps := m.Histogram().DataPoints()
+		for l := 0; l < dps.Len(); l++ {
+			attrs = append(attrs, dps.At(l).Attributes())
+		}
+	case pmetric.MetricTypeExponentialHistogram:
+		dps := m.ExponentialHistogram().DataPoints()
+		for l := 0; l < dps.Len(); l++ {
+			attrs = append(attrs, dps.At(l).Attributes())
+		}
+	case pmetric.MetricTypeSummary:
+		dps := m.Summary().DataPoints()
+		for l := 0; l < dps.Len(); l++ {
+			attrs = append(attrs, dps.At(l).Attributes())
+		}
+	}
+	for _, a := range attrs {
+		if err := s.checkAndForce(a); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// stamp sets the sender identity and tenant on a resource, replacing any the
+// sender set itself.
+func (s sender) stamp(resource pcommon.Map) {
+	resource.PutStr(attributeSenderIdentity, s.identity)
+	for k, v := range s.tenant {
+		resource.PutStr(k, v)
+	}
+}
@@ -0,0 +1,215 @@
+package senderidentityprocessor_test
+
+import (
+	"context"
+
+	"code.cloudfoundry.org/otel-collector-release/src/components/processor/senderidentityprocessor"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.opentelemetry.io/collector/client"
+	"go.opentelemetry.io/collector/component/componenttest"
+	"go.opentelemetry.io/collector/consumer/consumererror"
+	"go.opentelemetry.io/collector/consumer/consumertest"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.opentelemetry.io/collector/processor/processortest"
+)
+
+var _ = Describe("Sender identity processor", func() {
+	var (
+		cfg *senderidentityprocessor.Config
+		ctx context.Context
+	)
+
+	BeforeEach(func() {
+		cfg = senderidentityprocessor.NewFactory().CreateDefaultConfig().(*senderidentityprocessor.Config)
+		cfg.SourceIDs = map[string][]string{"forwarder-agent": {"gorouter", "uaa"}}
+		ctx = withIdentity("loggregator-agent")
+	})
+
+	consumeLogs := func(ld plog.Logs) (*consumertest.LogsSink, error) {
+		sink := new(consumertest.LogsSink)
+		factory := senderidentityprocessor.NewFactory()
+		p, err := factory.CreateLogs(context.Background(), processortest.NewNopSettings(factory.Type()), cfg, sink)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(p.Start(context.Background(), componenttest.NewNopHost())).To(Succeed())
+		DeferCleanup(p.Shutdown, context.Background())
+		return sink, p.ConsumeLogs(ctx, ld)
+	}
+
+	It("adds the sender identity to every resource", func() {
+		ld := plog.NewLogs()
+		ld.ResourceLogs().AppendEmpty().Resource().Attributes().PutStr("source_id", "gorouter")
+		ld.ResourceLogs().AppendEmpty().Resource().Attributes().PutStr("cf.sender.identity", "spoofed")
+
+		sink, err := consumeLogs(ld)
+
+		Expect(err).NotTo(HaveOccurred())
+		out := sink.AllLogs()[0].ResourceLogs()
+		Expect(out.At(0).Resource().Attributes().AsRaw()).To(HaveKeyWithValue("cf.sender.identity", "loggregator-agent"))
+		Expect(out.At(1).Resource().Attributes().AsRaw()).To(HaveKeyWithValue("cf.sender.identity", "loggregator-agent"))
+	})
+
+	It("rejects requests without a sender identity", func() {
+		ctx = context.Background()
+		ld := plog.NewLogs()
+		ld.ResourceLogs().AppendEmpty()
+
+		sink, err := consumeLogs(ld)
+
+		Expect(err).To(MatchError(ContainSubstring("no sender identity")))
+		Expect(consumererror.IsPermanent(err)).To(BeTrue())
+		Expect(sink.AllLogs()).To(BeEmpty())
+	})
+
+	Context("when the source_id values of the sender are limited", func() {
+		BeforeEach(func() {
+			ctx = withIdentity("forwarder-agent")
+		})
+
+		It("accepts the source_id values the sender may send", func() {
+			ld := plog.NewLogs()
+			rl := ld.ResourceLogs().AppendEmpty()
+			rl.Resource().Attributes().PutStr("source_id", "gorouter")
+			rl.ScopeLogs().AppendEmpty().LogRecords().AppendEmpty().Attributes().PutStr("source_id", "uaa")
+
+			sink, err := consumeLogs(ld)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(sink.AllLogs()).To(HaveLen(1))
+		})
+
+		It("rejects a resource with another source_id", func() {
+			ld := plog.NewLogs()
+			ld.ResourceLogs().AppendEmpty().Resource().Attributes().PutStr("source_id", "cloud_controller")
+
+			sink, err := consumeLogs(ld)
+
+			Expect(err).To(MatchError(ContainSubstring(`sender "forwarder-agent" may not send source_id "cloud_controller"`)))
+			Expect(consumererror.IsPermanent(err)).To(BeTrue())
+			Expect(sink.AllLogs()).To(BeEmpty())
+		})
+
+		It("rejects a log record with another source_id", func() {
+			ld := plog.NewLogs()
+			ld.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords().AppendEmpty().Attributes().PutStr("source_id", "cloud_controller")
+
+			_, err := consumeLogs(ld)
+
+			Expect(err).To(MatchError(ContainSubstring(`may not send source_id "cloud_controller"`)))
+		})
+
+		It("rejects a span with another source_id", func() {
+			td := ptrace.NewTraces()
+			td.ResourceSpans().AppendEmpty().ScopeSpans().AppendEmpty().Spans().AppendEmpty().Attributes().PutStr("source_id", "cloud_controller")
+
+			sink := new(consumertest.TracesSink)
+			factory := senderidentityprocessor.NewFactory()
+			p, err := factory.CreateTraces(context.Background(), processortest.NewNopSettings(factory.Type()), cfg, sink)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(p.ConsumeTraces(ctx, td)).To(MatchError(ContainSubstring(`may not send source_id "cloud_controller"`)))
+			Expect(sink.AllTraces()).To(BeEmpty())
+		})
+
+		It("checks the data points of metrics", func() {
+			md := pmetric.NewMetrics()
+			rm := md.ResourceMetrics().AppendEmpty()
+			metrics := rm.ScopeMetrics().AppendEmpty().Metrics()
+			metrics.AppendEmpty().SetEmptyGauge().DataPoints().AppendEmpty().Attributes().PutStr("source_id", "gorouter")
+			metrics.AppendEmpty().SetEmptyHistogram().DataPoints().AppendEmpty().Attributes().PutStr("source_id", "cloud_controller")
+
+			sink := new(consumertest.MetricsSink)
+			factory := senderidentityprocessor.NewFactory()
+			p, err := factory.CreateMetrics(context.Background(), processortest.NewNopSettings(factory.Type()), cfg, sink)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(p.ConsumeMetrics(ctx, md)).To(MatchError(ContainSubstring(`may not send source_id "cloud_controller"`)))
+
+			metrics.At(1).Histogram().DataPoints().At(0).Attributes().PutStr("source_id", "uaa")
+			Expect(p.ConsumeMetrics(ctx, md)).To(Succeed())
+			Expect(sink.AllMetrics()[0].ResourceMetrics().At(0).Resource().Attributes().AsRaw()).To(HaveKeyWithValue("cf.sender.identity", "forwarder-agent"))
+		})
+	})
+
+	Context("when the sender is an app instance", func() {
+		BeforeEach(func() {
+			ctx = client.NewContext(context.Background(), client.Info{Auth: authData{
+				"identity":          "instance-guid",
+				"app_guid":          "app-guid",
+				"space_guid":        "space-guid",
+				"organization_guid": "organization-guid",
+			}})
+		})
+
+		It("forces its app, space and organization onto every resource", func() {
+			ld := plog.NewLogs()
+			attrs := ld.ResourceLogs().AppendEmpty().Resource().Attributes()
+			attrs.PutStr("source_id", "other-app-guid")
+			attrs.PutStr("cloudfoundry.space.id", "other-space-guid")
+
+			sink, err := consumeLogs(ld)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(sink.AllLogs()[0].ResourceLogs().At(0).Resource().Attributes().AsRaw()).To(Equal(map[string]any{
+				"cf.sender.identity":    "instance-guid",
+				"source_id":             "app-guid",
+				"app_id":                "app-guid",
+				"space_id":              "space-guid",
+				"organization_id":       "organization-guid",
+				"cloudfoundry.app.id":   "app-guid",
+				"cloudfoundry.space.id": "space-guid",
+				"cloudfoundry.org.id":   "organization-guid",
+			}))
+		})
+
+		It("replaces the tenant attributes of records", func() {
+			ld := plog.NewLogs()
+			attrs := ld.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords().AppendEmpty().Attributes()
+			attrs.PutStr("app_id", "other-app-guid")
+			attrs.PutStr("organization_id", "other-organization-guid")
+			attrs.PutStr("message_type", "OUT")
+
+			sink, err := consumeLogs(ld)
+
+			Expect(err).NotTo(HaveOccurred())
+			record := sink.AllLogs()[0].ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0)
+			Expect(record.Attributes().AsRaw()).To(Equal(map[string]any{
+				"app_id":          "app-guid",
+				"organization_id": "organization-guid",
+				"message_type":    "OUT",
+			}))
+		})
+	})
+
+	Describe("Config", func() {
+		It("requires source_id values for listed senders", func() {
+			cfg := senderidentityprocessor.NewFactory().CreateDefaultConfig().(*senderidentityprocessor.Config)
+			Expect(cfg.Validate()).To(Succeed())
+
+			cfg.SourceIDs = map[string][]string{"forwarder-agent": {}}
+			Expect(cfg.Validate()).To(MatchError(`"source_ids" of "forwarder-agent" must not be empty`))
+		})
+	})
+})
+
+// withIdentity is the context of a request an auth extension authenticated
+// as identity.
+func withIdentity(identity string) context.Context {
+	return client.NewContext(context.Background(), client.Info{Auth: authData{"identity": identity}})
+}
+
+type authData map[string]any
+
+func (d authData) GetAttribute(name string) any {
+	return d[name]
+}
+
+func (d authData) GetAttributeNames() []string {
+	names := make([]string, 0, len(d))
+	for name := range d {
+		names = append(names, name)
+	}
+	return names
+}
@@ -0,0 +1,13 @@
+package senderidentityprocessor_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestSenderIdentityProcessor(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Sender Identity Processor Suite")
+}
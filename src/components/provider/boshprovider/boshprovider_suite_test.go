@@ -0,0 +1,13 @@
+package boshprovider_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestBOSHProvider(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "BOSH Provider Suite")
+}
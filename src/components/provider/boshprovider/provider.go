@@ -0,0 +1,105 @@
+// Package boshprovider implements a provider for the bosh scheme, which
+// resolves references to facts about the BOSH instance the collector runs
+// on, like ${bosh:deployment}, from the files the BOSH agent writes on every
+// VM.
+package boshprovider
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"code.cloudfoundry.org/otel-collector-release/src/components/internal/boshinstance"
+	"go.opentelemetry.io/collector/confmap"
+)
+
+const schemeName = "bosh"
+
+// keys are the facts about the instance that can be referenced.
+var keys = []string{"az", "deployment", "instance_group", "ip"}
+
+// Option configures the provider.
+type Option func(*provider)
+
+// WithSpecPath sets the instance spec the BOSH agent writes, which defaults to
+// the one of OTELCOL_BOSH_SPEC_FILE when set.
+func WithSpecPath(path string) Option {
+	return func(p *provider) {
+		p.specPath = path
+	}
+}
+
+// WithSettingsPath sets the agent settings the BOSH agent writes, which the
+// IP is read from when the instance spec has none.
+func WithSettingsPath(path string) Option {
+	return func(p *provider) {
+		p.settingsPath = path
+	}
+}
+
+type provider struct {
+	specPath     string
+	settingsPath string
+}
+
+// NewFactory creates a factory for the BOSH provider.
+func NewFactory(opts ...Option) confmap.ProviderFactory {
+	return confmap.NewProviderFactory(func(confmap.ProviderSettings) confmap.Provider {
+		p := &provider{
+			specPath:     boshinstance.DefaultSpecPath(),
+			settingsPath: boshinstance.DefaultSettingsPath,
+		}
+		for _, opt := range opts {
+			opt(p)
+		}
+		return p
+	})
+}
+
+func (p *provider) Retrieve(_ context.Context, uri string, _ confmap.WatcherFunc) (*confmap.Retrieved, error) {
+	if !strings.HasPrefix(uri, schemeName+":") {
+		return nil, fmt.Errorf("%q uri is not supported by %q provider", uri, schemeName)
+	}
+	key := uri[len(schemeName)+1:]
+	if !slices.Contains(keys, key) {
+		return nil, fmt.Errorf("unknown BOSH instance fact %q, must be one of %s", key, strings.Join(keys, ", "))
+	}
+
+	facts, err := p.facts()
+	if err != nil {
+		return nil, err
+	}
+	if facts[key] == "" {
+		return nil, fmt.Errorf("the BOSH instance has no %s", key)
+	}
+	return confmap.NewRetrieved(facts[key])
+}
+
+func (*provider) Scheme() string {
+	return schemeName
+}
+
+func (*provider) Shutdown(context.Context) error {
+	return nil
+}
+
+// facts reads the BOSH agent files and returns the facts about the instance
+// by key.
+func (p *provider) facts() (map[string]string, error) {
+	spec, err := boshinstance.ReadSpec(p.specPath)
+	if err != nil {
+		return nil, err
+	}
+	ip, err := spec.InstanceIP(p.settingsPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]string{
+		"az":             spec.AZ,
+		"deployment":     spec.Deployment,
+		"instance_group": spec.Name,
+		"ip":             ip,
+	}, nil
+}
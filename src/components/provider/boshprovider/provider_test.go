@@ -0,0 +1,122 @@
+package boshprovider_test
+
+import (
+	"context"
+	"path/filepath"
+
+	"code.cloudfoundry.org/otel-collector-release/src/components/provider/boshprovider"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.opentelemetry.io/collector/confmap"
+	"go.opentelemetry.io/collector/confmap/provider/envprovider"
+)
+
+// The BOSH instance files are kept next to the internal boshinstance package,
+// which reads them.
+var boshTestdata = filepath.Join("..", "..", "internal", "boshinstance", "testdata")
+
+var _ = Describe("BOSH provider", func() {
+	var (
+		specPath     string
+		settingsPath string
+	)
+
+	BeforeEach(func() {
+		specPath = filepath.Join(boshTestdata, "spec.json")
+		settingsPath = filepath.Join(boshTestdata, "settings.json")
+	})
+
+	retrieve := func(uri string) (any, error) {
+		provider := boshprovider.NewFactory(
+			boshprovider.WithSpecPath(specPath),
+			boshprovider.WithSettingsPath(settingsPath),
+		).Create(confmap.ProviderSettings{})
+		DeferCleanup(provider.Shutdown, context.Background())
+
+		retrieved, err := provider.Retrieve(context.Background(), uri, nil)
+		if err != nil {
+			return nil, err
+		}
+		return retrieved.AsRaw()
+	}
+
+	It("has the bosh scheme", func() {
+		Expect(boshprovider.NewFactory().Create(confmap.ProviderSettings{}).Scheme()).To(Equal("bosh"))
+	})
+
+	DescribeTable("resolves facts about the instance",
+		func(uri, expected string) {
+			Expect(retrieve(uri)).To(Equal(expected))
+		},
+		Entry("deployment", "bosh:deployment", "cf"),
+		Entry("az", "bosh:az", "z1"),
+		Entry("instance_group", "bosh:instance_group", "router"),
+		Entry("ip", "bosh:ip", "10.0.1.12"),
+	)
+
+	It("uses the IP of the network with the default gateway when the spec has no IP", func() {
+		specPath = filepath.Join(boshTestdata, "spec_without_ip.json")
+		Expect(retrieve("bosh:ip")).To(Equal("10.0.16.7"))
+	})
+
+	It("uses the IP of the agent settings when the spec has no networks", func() {
+		specPath = filepath.Join(boshTestdata, "spec_without_networks.json")
+		Expect(retrieve("bosh:ip")).To(Equal("10.0.1.12"))
+	})
+
+	It("reads the spec of OTELCOL_BOSH_SPEC_FILE by default", func() {
+		GinkgoT().Setenv("OTELCOL_BOSH_SPEC_FILE", filepath.Join(boshTestdata, "spec_without_ip.json"))
+		provider := boshprovider.NewFactory().Create(confmap.ProviderSettings{})
+
+		retrieved, err := provider.Retrieve(context.Background(), "bosh:instance_group", nil)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(retrieved.AsRaw()).To(Equal("diego-cell"))
+	})
+
+	It("fails for unknown facts", func() {
+		_, err := retrieve("bosh:index")
+		Expect(err).To(MatchError(`unknown BOSH instance fact "index", must be one of az, deployment, instance_group, ip`))
+	})
+
+	It("fails for facts the instance doesn't have", func() {
+		specPath = filepath.Join(boshTestdata, "spec_without_networks.json")
+		_, err := retrieve("bosh:az")
+		Expect(err).To(MatchError("the BOSH instance has no az"))
+	})
+
+	It("fails when the spec cannot be read", func() {
+		specPath = filepath.Join(boshTestdata, "missing.json")
+		_, err := retrieve("bosh:deployment")
+		Expect(err).To(MatchError(ContainSubstring("failed to read BOSH instance spec")))
+	})
+
+	It("ignores missing agent settings", func() {
+		specPath = filepath.Join(boshTestdata, "spec_without_networks.json")
+		settingsPath = filepath.Join(boshTestdata, "missing.json")
+		_, err := retrieve("bosh:ip")
+		Expect(err).To(MatchError("the BOSH instance has no ip"))
+	})
+
+	It("rejects other schemes", func() {
+		_, err := retrieve("env:deployment")
+		Expect(err).To(MatchError(`"env:deployment" uri is not supported by "bosh" provider`))
+	})
+
+	It("can be used in the configuration", func() {
+		GinkgoT().Setenv("OTELCOL_CONFIG", "exporters:\n  file:\n    path: /var/vcap/data/${bosh:deployment}/${bosh:instance_group}.json\n")
+		resolver, err := confmap.NewResolver(confmap.ResolverSettings{
+			URIs: []string{"env:OTELCOL_CONFIG"},
+			ProviderFactories: []confmap.ProviderFactory{
+				boshprovider.NewFactory(boshprovider.WithSpecPath(specPath), boshprovider.WithSettingsPath(settingsPath)),
+				envprovider.NewFactory(),
+			},
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		conf, err := resolver.Resolve(context.Background())
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(conf.Get("exporters::file::path")).To(Equal("/var/vcap/data/cf/router.json"))
+	})
+})
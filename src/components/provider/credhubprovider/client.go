@@ -0,0 +1,237 @@
+package credhubprovider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/collector/config/configtls"
+)
+
+const requestTimeout = 30 * time.Second
+
+// client gets credentials from the CredHub API.
+type client struct {
+	server     string
+	httpClient *http.Client
+
+	// uaaClient and uaaSecret are the UAA client the client gets tokens for,
+	// empty when it authenticates with a client certificate.
+	uaaClient string
+	uaaSecret string
+
+	mu     sync.Mutex
+	token  string
+	expiry time.Time
+}
+
+func newClientFromEnv() (*client, error) {
+	server := os.Getenv(EnvServer)
+	if server == "" {
+		return nil, fmt.Errorf("%s must be set to resolve CredHub references", EnvServer)
+	}
+
+	tlsSettings := configtls.ClientConfig{Config: configtls.Config{
+		CAFile:   os.Getenv(EnvCACert),
+		CertFile: os.Getenv(EnvClientCert),
+		KeyFile:  os.Getenv(EnvClientKey),
+	}}
+	c := &client{server: strings.TrimSuffix(server, "/")}
+	if tlsSettings.CertFile == "" && tlsSettings.KeyFile == "" {
+		c.uaaClient, c.uaaSecret = os.Getenv(EnvClient), os.Getenv(EnvSecret)
+		if c.uaaClient == "" || c.uaaSecret == "" {
+			return nil, fmt.Errorf("either %s and %s or %s and %s must be set to authenticate with CredHub", EnvClientCert, EnvClientKey, EnvClient, EnvSecret)
+		}
+	}
+
+	tlsConfig, err := tlsSettings.LoadTLSConfig(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the TLS settings for CredHub: %w", err)
+	}
+	c.httpClient = &http.Client{
+		Timeout: requestTimeout,
+		Transport: &http.Transport{
+			Proxy:           http.ProxyFromEnvironment,
+			TLSClientConfig: tlsConfig,
+		},
+	}
+	return c, nil
+}
+
+// credential is the current version of a CredHub credential.
+type credential struct {
+	Name  string          `json:"name"`
+	Type  string          `json:"type"`
+	Value json.RawMessage `json:"value"`
+}
+
+func (c *client) credential(ctx context.Context, name string) (*credential, error) {
+	query := url.Values{"name": {name}, "current": {"true"}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.server+"/api/v1/data?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.uaaClient != "" {
+		token, err := c.getToken(ctx)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get credential %q: %w", name, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to get credential %q: %w", name, responseError(resp))
+	}
+
+	var body struct {
+		Data []credential `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to get credential %q: %w", name, err)
+	}
+	if len(body.Data) == 0 {
+		return nil, fmt.Errorf("credential %q does not exist", name)
+	}
+	cred := body.Data[0]
+	cred.Name = name
+	return &cred, nil
+}
+
+// field returns the value of a value or password credential, or a field of
+// a certificate credential.
+func (cred *credential) field(field string) (string, error) {
+	switch cred.Type {
+	case "value", "password":
+		if field != "" {
+			return "", fmt.Errorf("credential %q of type %q has no field %q", cred.Name, cred.Type, field)
+		}
+		var value string
+		if err := json.Unmarshal(cred.Value, &value); err != nil {
+			return "", fmt.Errorf("credential %q of type %q is not a string", cred.Name, cred.Type)
+		}
+		return value, nil
+	case "certificate":
+		if field == "" {
+			return "", fmt.Errorf("credential %q is a certificate, select its ca, certificate or private_key like ${credhub:%s:certificate}", cred.Name, cred.Name)
+		}
+		var cert map[string]any
+		if err := json.Unmarshal(cred.Value, &cert); err != nil {
+			return "", fmt.Errorf("credential %q of type %q is not an object", cred.Name, cred.Type)
+		}
+		value, ok := cert[field].(string)
+		if !ok || value == "" {
+			return "", fmt.Errorf("certificate %q has no %q", cred.Name, field)
+		}
+		return value, nil
+	default:
+		return "", fmt.Errorf("credential %q has the unsupported type %q, supported are value, password and certificate", cred.Name, cred.Type)
+	}
+}
+
+// getToken returns a token for the UAA client, requesting a new one when the
+// last one is close to expiring.
+func (c *client) getToken(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.token != "" && time.Now().Before(c.expiry) {
+		return c.token, nil
+	}
+
+	tokenURL, err := c.tokenURL(ctx)
+	if err != nil {
+		return "", err
+	}
+	form := url.Values{"grant_type": {"client_credentials"}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.SetBasicAuth(url.QueryEscape(c.uaaClient), url.QueryEscape(c.uaaSecret))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to get UAA token: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return "", fmt.Errorf("failed to get UAA token: unexpected status %s", resp.Status)
+	}
+
+	var body struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("failed to get UAA token: %w", err)
+	}
+	if body.AccessToken == "" {
+		return "", errors.New("failed to get UAA token: response has no access_token")
+	}
+
+	// The token is replaced once most of its lifetime has passed, so that it
+	// doesn't expire between getting it and CredHub checking it.
+	c.token = body.AccessToken
+	c.expiry = time.Now().Add(time.Duration(body.ExpiresIn) * time.Second * 9 / 10)
+	return c.token, nil
+}
+
+// tokenURL is the token endpoint of the UAA CredHub trusts, as CredHub
+// advertises it.
+func (c *client) tokenURL(ctx context.Context) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.server+"/info", nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to get the CredHub auth server: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to get the CredHub auth server: %w", responseError(resp))
+	}
+
+	var body struct {
+		AuthServer struct {
+			URL string `json:"url"`
+		} `json:"auth-server"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("failed to get the CredHub auth server: %w", err)
+	}
+	if body.AuthServer.URL == "" {
+		return "", errors.New("failed to get the CredHub auth server: response has no auth-server url")
+	}
+	return strings.TrimSuffix(body.AuthServer.URL, "/") + "/oauth/token", nil
+}
+
+// responseError describes a failed CredHub response by its status and the
+// error CredHub gives.
+func responseError(resp *http.Response) error {
+	var body struct {
+		Error string `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil || body.Error == "" {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return fmt.Errorf("unexpected status %s: %s", resp.Status, body.Error)
+}
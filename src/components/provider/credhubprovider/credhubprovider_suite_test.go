@@ -0,0 +1,13 @@
+package credhubprovider_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestCredHubProvider(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "CredHub Provider Suite")
+}
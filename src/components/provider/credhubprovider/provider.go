@@ -0,0 +1,116 @@
+// Package credhubprovider implements a provider for the credhub scheme that
+// resolves references to CredHub credentials, like
+// ${credhub:/deployment/splunk_token}, when the collector loads its
+// configuration, so that secrets are never written to the configuration file.
+package credhubprovider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"go.opentelemetry.io/collector/confmap"
+)
+
+const (
+	schemeName = "credhub"
+
+	// redacted replaces the values of credentials in configuration dumps.
+	redacted = "[REDACTED]"
+)
+
+// The environment variables that configure the connection to CredHub. The
+// provider authenticates with the client certificate when one is set, and
+// with a token for the UAA client otherwise.
+const (
+	EnvServer     = "CREDHUB_SERVER"
+	EnvCACert     = "CREDHUB_CA_CERT"
+	EnvClientCert = "CREDHUB_CLIENT_CERT"
+	EnvClientKey  = "CREDHUB_CLIENT_KEY"
+	EnvClient     = "CREDHUB_CLIENT"
+	EnvSecret     = "CREDHUB_SECRET"
+)
+
+// Option configures the provider.
+type Option func(*provider)
+
+// WithRedaction sets whether the provider resolves references to a
+// placeholder instead of the credential, for the commands that print the
+// configuration. The collector sets it for print-initial-config.
+func WithRedaction(redact bool) Option {
+	return func(p *provider) {
+		p.redact = redact
+	}
+}
+
+type provider struct {
+	redact bool
+
+	mu     sync.Mutex
+	client *client
+}
+
+// NewFactory creates a factory for the CredHub provider.
+func NewFactory(opts ...Option) confmap.ProviderFactory {
+	return confmap.NewProviderFactory(func(confmap.ProviderSettings) confmap.Provider {
+		p := &provider{}
+		for _, opt := range opts {
+			opt(p)
+		}
+		return p
+	})
+}
+
+func (p *provider) Retrieve(ctx context.Context, uri string, _ confmap.WatcherFunc) (*confmap.Retrieved, error) {
+	if !strings.HasPrefix(uri, schemeName+":") {
+		return nil, fmt.Errorf("%q uri is not supported by %q provider", uri, schemeName)
+	}
+
+	name, field, _ := strings.Cut(uri[len(schemeName)+1:], ":")
+	if name == "" {
+		return nil, fmt.Errorf("%q uri has no credential name", uri)
+	}
+	if p.redact {
+		return confmap.NewRetrieved(redacted)
+	}
+
+	c, err := p.getClient()
+	if err != nil {
+		return nil, err
+	}
+	cred, err := c.credential(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	value, err := cred.field(field)
+	if err != nil {
+		return nil, err
+	}
+	return confmap.NewRetrieved(value)
+}
+
+func (*provider) Scheme() string {
+	return schemeName
+}
+
+func (*provider) Shutdown(context.Context) error {
+	return nil
+}
+
+// getClient creates the CredHub client on first use, so that the collector
+// only needs the environment variables when its configuration references
+// CredHub.
+func (p *provider) getClient() (*client, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.client != nil {
+		return p.client, nil
+	}
+	c, err := newClientFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	p.client = c
+	return c, nil
+}
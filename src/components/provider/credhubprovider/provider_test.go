@@ -0,0 +1,238 @@
+package credhubprovider_test
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+
+	"code.cloudfoundry.org/otel-collector-release/src/components/provider/credhubprovider"
+	"code.cloudfoundry.org/tlsconfig/certtest"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.opentelemetry.io/collector/confmap"
+)
+
+var _ = Describe("CredHub provider", func() {
+	var (
+		credhub  *fakeCredHub
+		provider confmap.Provider
+	)
+
+	BeforeEach(func() {
+		credhub = newFakeCredHub(map[string]any{
+			"/deployment/splunk_token": map[string]any{"type": "value", "value": "hec-token"},
+			"/deployment/password":     map[string]any{"type": "password", "value": "s3cr3t"},
+			"/deployment/syslog_tls": map[string]any{"type": "certificate", "value": map[string]any{
+				"ca":          "ca-pem",
+				"certificate": "cert-pem",
+				"private_key": "key-pem",
+			}},
+			"/deployment/user": map[string]any{"type": "user", "value": map[string]any{"username": "admin"}},
+		})
+
+		GinkgoT().Setenv(credhubprovider.EnvServer, credhub.URL)
+		GinkgoT().Setenv(credhubprovider.EnvCACert, credhub.caFile)
+		GinkgoT().Setenv(credhubprovider.EnvClientCert, credhub.clientCertFile)
+		GinkgoT().Setenv(credhubprovider.EnvClientKey, credhub.clientKeyFile)
+		GinkgoT().Setenv(credhubprovider.EnvClient, "")
+		GinkgoT().Setenv(credhubprovider.EnvSecret, "")
+	})
+
+	JustBeforeEach(func() {
+		provider = credhubprovider.NewFactory(credhubprovider.WithRedaction(false)).Create(confmap.ProviderSettings{})
+		DeferCleanup(provider.Shutdown, context.Background())
+	})
+
+	resolve := func(uri string) (any, error) {
+		retrieved, err := provider.Retrieve(context.Background(), uri, nil)
+		if err != nil {
+			return nil, err
+		}
+		return retrieved.AsRaw()
+	}
+
+	It("resolves value and password credentials with a client certificate", func() {
+		Expect(resolve("credhub:/deployment/splunk_token")).To(Equal("hec-token"))
+		Expect(resolve("credhub:/deployment/password")).To(Equal("s3cr3t"))
+		Expect(credhub.certRequests.Load()).To(BeEquivalentTo(2))
+		Expect(provider.Scheme()).To(Equal("credhub"))
+	})
+
+	It("resolves the fields of certificate credentials", func() {
+		Expect(resolve("credhub:/deployment/syslog_tls:ca")).To(Equal("ca-pem"))
+		Expect(resolve("credhub:/deployment/syslog_tls:certificate")).To(Equal("cert-pem"))
+		Expect(resolve("credhub:/deployment/syslog_tls:private_key")).To(Equal("key-pem"))
+	})
+
+	It("rejects unknown fields and unsupported credentials", func() {
+		_, err := resolve("credhub:/deployment/syslog_tls")
+		Expect(err).To(MatchError(ContainSubstring(`credential "/deployment/syslog_tls" is a certificate, select its ca, certificate or private_key like ${credhub:/deployment/syslog_tls:certificate}`)))
+
+		_, err = resolve("credhub:/deployment/syslog_tls:public_key")
+		Expect(err).To(MatchError(`certificate "/deployment/syslog_tls" has no "public_key"`))
+
+		_, err = resolve("credhub:/deployment/splunk_token:value")
+		Expect(err).To(MatchError(`credential "/deployment/splunk_token" of type "value" has no field "value"`))
+
+		_, err = resolve("credhub:/deployment/user")
+		Expect(err).To(MatchError(`credential "/deployment/user" has the unsupported type "user", supported are value, password and certificate`))
+	})
+
+	It("reports the CredHub error for missing credentials", func() {
+		_, err := resolve("credhub:/deployment/missing")
+		Expect(err).To(MatchError(ContainSubstring(`failed to get credential "/deployment/missing": unexpected status 404 Not Found: The request could not be completed because the credential does not exist or you do not have sufficient authorization.`)))
+	})
+
+	It("rejects other schemes and empty names", func() {
+		_, err := resolve("env:SPLUNK_TOKEN")
+		Expect(err).To(MatchError(`"env:SPLUNK_TOKEN" uri is not supported by "credhub" provider`))
+
+		_, err = resolve("credhub:")
+		Expect(err).To(MatchError(`"credhub:" uri has no credential name`))
+	})
+
+	Context("when authenticating with a UAA client", func() {
+		BeforeEach(func() {
+			GinkgoT().Setenv(credhubprovider.EnvClientCert, "")
+			GinkgoT().Setenv(credhubprovider.EnvClientKey, "")
+			GinkgoT().Setenv(credhubprovider.EnvClient, "otel-collector")
+			GinkgoT().Setenv(credhubprovider.EnvSecret, "uaa-secret")
+		})
+
+		It("gets a token from the UAA CredHub advertises and reuses it", func() {
+			Expect(resolve("credhub:/deployment/splunk_token")).To(Equal("hec-token"))
+			Expect(resolve("credhub:/deployment/syslog_tls:certificate")).To(Equal("cert-pem"))
+
+			Expect(credhub.tokenRequests.Load()).To(BeEquivalentTo(1))
+			Expect(credhub.certRequests.Load()).To(BeZero())
+		})
+
+		It("fails when UAA rejects the client", func() {
+			GinkgoT().Setenv(credhubprovider.EnvSecret, "wrong")
+
+			_, err := resolve("credhub:/deployment/splunk_token")
+			Expect(err).To(MatchError("failed to get UAA token: unexpected status 401 Unauthorized"))
+		})
+	})
+
+	It("resolves references to a placeholder with redaction", func() {
+		provider = credhubprovider.NewFactory(credhubprovider.WithRedaction(true)).Create(confmap.ProviderSettings{})
+
+		Expect(resolve("credhub:/deployment/splunk_token")).To(Equal("[REDACTED]"))
+		Expect(resolve("credhub:/deployment/syslog_tls:private_key")).To(Equal("[REDACTED]"))
+		Expect(credhub.certRequests.Load()).To(BeZero())
+	})
+
+	It("requires the connection to CredHub to be configured", func() {
+		GinkgoT().Setenv(credhubprovider.EnvServer, "")
+		_, err := resolve("credhub:/deployment/splunk_token")
+		Expect(err).To(MatchError("CREDHUB_SERVER must be set to resolve CredHub references"))
+
+		provider = credhubprovider.NewFactory(credhubprovider.WithRedaction(false)).Create(confmap.ProviderSettings{})
+		GinkgoT().Setenv(credhubprovider.EnvServer, credhub.URL)
+		GinkgoT().Setenv(credhubprovider.EnvClientCert, "")
+		GinkgoT().Setenv(credhubprovider.EnvClientKey, "")
+		_, err = resolve("credhub:/deployment/splunk_token")
+		Expect(err).To(MatchError(ContainSubstring("either CREDHUB_CLIENT_CERT and CREDHUB_CLIENT_KEY or CREDHUB_CLIENT and CREDHUB_SECRET must be set")))
+	})
+})
+
+// fakeCredHub serves credentials to clients with a certificate of its CA or
+// a token of its UAA, which it serves under /uaa.
+type fakeCredHub struct {
+	*httptest.Server
+	caFile         string
+	clientCertFile string
+	clientKeyFile  string
+
+	certRequests  atomic.Int32
+	tokenRequests atomic.Int32
+}
+
+func newFakeCredHub(credentials map[string]any) *fakeCredHub {
+	dir := GinkgoT().TempDir()
+	ca, err := certtest.BuildCA("credhub")
+	Expect(err).NotTo(HaveOccurred())
+	caPEM, err := ca.CertificatePEM()
+	Expect(err).NotTo(HaveOccurred())
+	pool, err := ca.CertPool()
+	Expect(err).NotTo(HaveOccurred())
+
+	serverCert, err := ca.BuildSignedCertificate("credhub", certtest.WithIPs(net.ParseIP("127.0.0.1")))
+	Expect(err).NotTo(HaveOccurred())
+	serverTLSCert, err := serverCert.TLSCertificate()
+	Expect(err).NotTo(HaveOccurred())
+	clientCert, err := ca.BuildSignedCertificate("otel-collector")
+	Expect(err).NotTo(HaveOccurred())
+	clientCertPEM, clientKeyPEM, err := clientCert.CertificatePEMAndPrivateKey()
+	Expect(err).NotTo(HaveOccurred())
+
+	f := &fakeCredHub{
+		caFile:         filepath.Join(dir, "credhub-ca.crt"),
+		clientCertFile: filepath.Join(dir, "credhub-client.crt"),
+		clientKeyFile:  filepath.Join(dir, "credhub-client.key"),
+	}
+	Expect(os.WriteFile(f.caFile, caPEM, 0o600)).To(Succeed())
+	Expect(os.WriteFile(f.clientCertFile, clientCertPEM, 0o600)).To(Succeed())
+	Expect(os.WriteFile(f.clientKeyFile, clientKeyPEM, 0o600)).To(Succeed())
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /info", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]any{"auth-server": map[string]any{"url": f.URL + "/uaa"}})
+	})
+	mux.HandleFunc("POST /uaa/oauth/token", func(w http.ResponseWriter, r *http.Request) {
+		f.tokenRequests.Add(1)
+		id, secret, ok := r.BasicAuth()
+		if !ok || id != "otel-collector" || secret != "uaa-secret" || r.FormValue("grant_type") != "client_credentials" {
+			writeJSON(w, http.StatusUnauthorized, map[string]any{"error": "unauthorized"})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"access_token": "uaa-token", "token_type": "bearer", "expires_in": 3600})
+	})
+	mux.HandleFunc("GET /api/v1/data", func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case len(r.TLS.VerifiedChains) > 0:
+			f.certRequests.Add(1)
+		case r.Header.Get("Authorization") == "Bearer uaa-token":
+		default:
+			writeJSON(w, http.StatusUnauthorized, map[string]any{"error": "Full authentication is required to access this resource"})
+			return
+		}
+		name := r.URL.Query().Get("name")
+		cred, ok := credentials[name]
+		if !ok || r.URL.Query().Get("current") != "true" {
+			writeJSON(w, http.StatusNotFound, map[string]any{"error": "The request could not be completed because the credential does not exist or you do not have sufficient authorization."})
+			return
+		}
+		c := cred.(map[string]any)
+		writeJSON(w, http.StatusOK, map[string]any{"data": []any{map[string]any{
+			"type":               c["type"],
+			"version_created_at": "2026-10-01T12:00:00Z",
+			"id":                 "a6b3c1d2-0000-4000-8000-000000000000",
+			"name":               name,
+			"value":              c["value"],
+		}}})
+	})
+
+	f.Server = httptest.NewUnstartedServer(mux)
+	f.TLS = &tls.Config{
+		Certificates: []tls.Certificate{serverTLSCert},
+		ClientCAs:    pool,
+		ClientAuth:   tls.VerifyClientCertIfGiven,
+	}
+	f.StartTLS()
+	DeferCleanup(f.Close)
+	return f
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
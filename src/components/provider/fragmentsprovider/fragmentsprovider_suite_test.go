@@ -0,0 +1,13 @@
+package fragmentsprovider_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestFragmentsProvider(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Fragments Provider Suite")
+}
@@ -0,0 +1,256 @@
+// Package fragmentsprovider implements a provider for the fragments scheme,
+// which merges the configuration fragments that co-located BOSH jobs place in
+// a directory, so that they can contribute their own components and
+// pipelines to the collector.
+package fragmentsprovider
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+
+	"go.opentelemetry.io/collector/confmap"
+	"go.yaml.in/yaml/v3"
+)
+
+const schemeName = "fragments"
+
+// DefinitionsKey is the key of the configuration under which the components
+// and pipelines of the fragments are listed with their merged configuration,
+// so that the Cloud Foundry converter can reject configurations that follow
+// the fragments and set their fields to different values. The converter
+// removes it from the configuration.
+const DefinitionsKey = "cf_fragments"
+
+// componentKinds are the sections of the configuration fragments may define
+// components in. Fragments can't define receivers, their pipelines receive
+// from the receivers of the BOSH jobs, which authenticate the senders.
+var componentKinds = []string{"processors", "exporters", "connectors"}
+
+type provider struct{}
+
+// NewFactory creates a factory for the fragments provider.
+func NewFactory() confmap.ProviderFactory {
+	return confmap.NewProviderFactory(func(confmap.ProviderSettings) confmap.Provider {
+		return provider{}
+	})
+}
+
+// Retrieve merges the fragments matching the glob pattern of the uri, in the
+// lexical order of their paths. Fragments without a match are not an error,
+// the configuration is empty then.
+func (provider) Retrieve(_ context.Context, uri string, _ confmap.WatcherFunc) (*confmap.Retrieved, error) {
+	if !strings.HasPrefix(uri, schemeName+":") {
+		return nil, fmt.Errorf("%q uri is not supported by %q provider", uri, schemeName)
+	}
+	pattern := filepath.Clean(uri[len(schemeName)+1:])
+	paths, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+	}
+	slices.Sort(paths)
+
+	m := newMerger()
+	for _, path := range paths {
+		f, err := readFragment(pattern, path)
+		if err != nil {
+			return nil, err
+		}
+		if err := m.add(f); err != nil {
+			return nil, err
+		}
+	}
+	if definitions := m.definitions(); len(definitions) > 0 {
+		m.cfg[DefinitionsKey] = definitions
+	}
+	return confmap.NewRetrieved(m.cfg)
+}
+
+func (provider) Scheme() string {
+	return schemeName
+}
+
+func (provider) Shutdown(context.Context) error {
+	return nil
+}
+
+// fragment is the configuration a job placed in a file.
+type fragment struct {
+	path string
+	// job is the job that owns the fragment, which its pipelines are
+	// namespaced by.
+	job string
+	cfg map[string]any
+}
+
+func readFragment(pattern, path string) (fragment, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fragment{}, fmt.Errorf("unable to read the fragment %v: %w", path, err)
+	}
+	f := fragment{path: path, job: owner(pattern, path)}
+	if err := yaml.Unmarshal(content, &f.cfg); err != nil {
+		return fragment{}, fmt.Errorf("unable to parse the fragment %v: %w", path, err)
+	}
+	for key, v := range f.cfg {
+		switch {
+		case slices.Contains(componentKinds, key):
+		case key == "service":
+			service, _ := v.(map[string]any)
+			for key := range service {
+				if key != "pipelines" {
+					return fragment{}, fmt.Errorf("fragment %v may not configure service::%s, only pipelines", path, key)
+				}
+			}
+		default:
+			return fragment{}, fmt.Errorf("fragment %v may not configure %s, only %s and pipelines", path, key, strings.Join(componentKinds, ", "))
+		}
+	}
+	return f, nil
+}
+
+// owner is the job that owns a fragment, the path segment the first wildcard
+// of the pattern matched, like gorouter for
+// /var/vcap/jobs/gorouter/config/otel-collector.d/logs.yml. When only the
+// file name has wildcards, it is the file name without its extension.
+func owner(pattern, path string) string {
+	patternSegments := strings.Split(filepath.ToSlash(pattern), "/")
+	pathSegments := strings.Split(filepath.ToSlash(path), "/")
+	for i, segment := range patternSegments[:len(patternSegments)-1] {
+		if strings.ContainsAny(segment, "*?[") && i < len(pathSegments) {
+			return pathSegments[i]
+		}
+	}
+	return strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+}
+
+// merger deep-merges fragments, remembering which fragment defined each
+// component and pipeline to report conflicts.
+type merger struct {
+	cfg     map[string]any
+	definer map[string]string
+	// defined are the components and pipelines, in the order they were
+	// defined.
+	defined []definition
+}
+
+// definition locates a component or pipeline in the configuration.
+type definition struct {
+	path string
+	id   string
+}
+
+func newMerger() *merger {
+	return &merger{cfg: map[string]any{}, definer: map[string]string{}}
+}
+
+// definitions lists the components and pipelines of the fragments for the
+// DefinitionsKey, each with its path, like exporters, its ID, the fragment
+// that first defined it and its merged configuration.
+func (m *merger) definitions() []any {
+	definitions := make([]any, 0, len(m.defined))
+	for _, d := range m.defined {
+		parent := m.cfg
+		for _, key := range strings.Split(d.path, "::") {
+			parent = parent[key].(map[string]any)
+		}
+		definitions = append(definitions, map[string]any{
+			"path":     d.path,
+			"id":       d.id,
+			"fragment": m.definer[d.path+"::"+d.id],
+			"config":   parent[d.id],
+		})
+	}
+	return definitions
+}
+
+func (m *merger) add(f fragment) error {
+	for _, kind := range componentKinds {
+		components, _ := f.cfg[kind].(map[string]any)
+		for _, id := range slices.Sorted(maps.Keys(components)) {
+			if err := m.merge(f, kind, id, id, components[id]); err != nil {
+				return err
+			}
+		}
+	}
+	service, _ := f.cfg["service"].(map[string]any)
+	pipelines, _ := service["pipelines"].(map[string]any)
+	for _, id := range slices.Sorted(maps.Keys(pipelines)) {
+		if err := m.merge(f, "service::pipelines", id, namespaced(id, f.job), pipelines[id]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// namespaced is the ID of a pipeline of a fragment owned by job.
+func namespaced(id, job string) string {
+	if strings.Contains(id, "/") {
+		return id + "-" + job
+	}
+	return id + "/" + job
+}
+
+// merge merges the configuration of a component or pipeline of a fragment
+// into the section at path, failing if another fragment set any of its
+// fields to a different value.
+func (m *merger) merge(f fragment, path, id, mergedID string, cfg any) error {
+	section := m.cfg
+	for _, key := range strings.Split(path, "::") {
+		next, ok := section[key].(map[string]any)
+		if !ok {
+			next = map[string]any{}
+			section[key] = next
+		}
+		section = next
+	}
+
+	key := path + "::" + mergedID
+	existing, defined := section[mergedID]
+	if !defined {
+		section[mergedID] = cfg
+		m.definer[key] = f.path
+		m.defined = append(m.defined, definition{path: path, id: mergedID})
+		return nil
+	}
+	merged, ok := deepMerge(existing, cfg)
+	if !ok {
+		return fmt.Errorf("%s %q of fragment %v conflicts with the one of fragment %v", path, id, f.path, m.definer[key])
+	}
+	section[mergedID] = merged
+	return nil
+}
+
+// deepMerge merges two values, maps key by key. Any other values, including
+// lists, have to be equal.
+func deepMerge(a, b any) (any, bool) {
+	am, aIsMap := a.(map[string]any)
+	bm, bIsMap := b.(map[string]any)
+	if !aIsMap || !bIsMap {
+		if a == nil {
+			return b, true
+		}
+		if b == nil {
+			return a, true
+		}
+		return a, reflect.DeepEqual(a, b)
+	}
+	for k, bv := range bm {
+		av, ok := am[k]
+		if !ok {
+			am[k] = bv
+			continue
+		}
+		merged, ok := deepMerge(av, bv)
+		if !ok {
+			return nil, false
+		}
+		am[k] = merged
+	}
+	return am, true
+}
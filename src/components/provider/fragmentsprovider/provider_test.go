@@ -0,0 +1,255 @@
+package fragmentsprovider_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	"code.cloudfoundry.org/otel-collector-release/src/components/provider/fragmentsprovider"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.opentelemetry.io/collector/confmap"
+)
+
+var _ = Describe("Fragments provider", func() {
+	var (
+		jobsDir  string
+		uri      string
+		provider confmap.Provider
+	)
+
+	BeforeEach(func() {
+		jobsDir = GinkgoT().TempDir()
+		uri = "fragments:" + filepath.Join(jobsDir, "*", "config", "otel-collector.d", "*.yml")
+		provider = fragmentsprovider.NewFactory().Create(confmap.ProviderSettings{})
+		DeferCleanup(provider.Shutdown, context.Background())
+	})
+
+	writeFragment := func(job, name, content string) {
+		dir := filepath.Join(jobsDir, job, "config", "otel-collector.d")
+		Expect(os.MkdirAll(dir, 0o700)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600)).To(Succeed())
+	}
+
+	retrieve := func() (map[string]any, error) {
+		retrieved, err := provider.Retrieve(context.Background(), uri, nil)
+		if err != nil {
+			return nil, err
+		}
+		conf, err := retrieved.AsConf()
+		if err != nil {
+			return nil, err
+		}
+		return conf.ToStringMap(), nil
+	}
+
+	It("has the fragments scheme", func() {
+		Expect(provider.Scheme()).To(Equal("fragments"))
+	})
+
+	It("rejects other schemes", func() {
+		_, err := provider.Retrieve(context.Background(), "file:/config.yml", nil)
+		Expect(err).To(MatchError(ContainSubstring(`"file:/config.yml" uri is not supported by "fragments" provider`)))
+	})
+
+	It("is empty without fragments", func() {
+		Expect(retrieve()).To(BeEmpty())
+	})
+
+	It("namespaces the pipelines of fragments by the job that owns them", func() {
+		writeFragment("gorouter", "logs.yml", `
+processors:
+  filter/gorouter:
+    error_mode: ignore
+service:
+  pipelines:
+    logs:
+      receivers: [otlp/cf-internal-local]
+      processors: [filter/gorouter]
+      exporters: [otlp]
+    logs/access:
+      receivers: [otlp/cf-internal-local]
+      exporters: [otlp]
+`)
+		writeFragment("uaa", "logs.yml", `
+service:
+  pipelines:
+    logs:
+      receivers: [otlp/cf-internal-local]
+      exporters: [otlp]
+`)
+
+		cfg, err := retrieve()
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cfg).To(HaveKeyWithValue("processors", HaveKey("filter/gorouter")))
+		Expect(cfg["service"]).To(HaveKeyWithValue("pipelines", SatisfyAll(
+			HaveLen(3),
+			HaveKeyWithValue("logs/gorouter", HaveKeyWithValue("processors", ConsistOf("filter/gorouter"))),
+			HaveKey("logs/access-gorouter"),
+			HaveKey("logs/uaa"),
+		)))
+	})
+
+	It("deep-merges the components of fragments", func() {
+		writeFragment("gorouter", "batch.yml", `
+processors:
+  batch:
+    timeout: 1s
+`)
+		writeFragment("uaa", "batch.yml", `
+processors:
+  batch:
+    timeout: 1s
+    send_batch_size: 100
+`)
+
+		Expect(retrieve()).To(HaveKeyWithValue("processors", Equal(map[string]any{
+			"batch": map[string]any{"timeout": "1s", "send_batch_size": 100},
+		})))
+	})
+
+	It("merges the fragments of a job", func() {
+		writeFragment("gorouter", "a.yml", `
+service:
+  pipelines:
+    logs:
+      receivers: [otlp/cf-internal-local]
+`)
+		writeFragment("gorouter", "b.yml", `
+service:
+  pipelines:
+    logs:
+      exporters: [otlp]
+`)
+
+		Expect(retrieve()).To(HaveKeyWithValue("service", HaveKeyWithValue("pipelines", Equal(map[string]any{
+			"logs/gorouter": map[string]any{
+				"receivers": []any{"otlp/cf-internal-local"},
+				"exporters": []any{"otlp"},
+			},
+		}))))
+	})
+
+	It("lists the components and pipelines of fragments for the converter", func() {
+		writeFragment("gorouter", "logs.yml", `
+processors:
+  batch:
+    timeout: 1s
+service:
+  pipelines:
+    logs:
+      receivers: [otlp/cf-internal-local]
+      exporters: [otlp]
+`)
+		writeFragment("uaa", "logs.yml", `
+processors:
+  batch:
+    send_batch_size: 100
+`)
+		gorouter := filepath.Join(jobsDir, "gorouter", "config", "otel-collector.d", "logs.yml")
+
+		Expect(retrieve()).To(HaveKeyWithValue(fragmentsprovider.DefinitionsKey, Equal([]any{
+			map[string]any{
+				"path":     "processors",
+				"id":       "batch",
+				"fragment": gorouter,
+				"config":   map[string]any{"timeout": "1s", "send_batch_size": 100},
+			},
+			map[string]any{
+				"path":     "service::pipelines",
+				"id":       "logs/gorouter",
+				"fragment": gorouter,
+				"config": map[string]any{
+					"receivers": []any{"otlp/cf-internal-local"},
+					"exporters": []any{"otlp"},
+				},
+			},
+		})))
+	})
+
+	It("rejects components that fragments configure differently", func() {
+		writeFragment("gorouter", "batch.yml", `
+processors:
+  batch:
+    timeout: 1s
+`)
+		writeFragment("uaa", "batch.yml", `
+processors:
+  batch:
+    timeout: 5s
+`)
+
+		_, err := retrieve()
+
+		Expect(err).To(MatchError(MatchRegexp(`processors "batch" of fragment .*/uaa/config/otel-collector.d/batch.yml conflicts with the one of fragment .*/gorouter/config/otel-collector.d/batch.yml`)))
+	})
+
+	It("rejects pipelines of a job that fragments configure differently", func() {
+		writeFragment("gorouter", "a.yml", `
+service:
+  pipelines:
+    logs:
+      exporters: [otlp]
+`)
+		writeFragment("gorouter", "b.yml", `
+service:
+  pipelines:
+    logs:
+      exporters: [otlp/other]
+`)
+
+		_, err := retrieve()
+
+		Expect(err).To(MatchError(MatchRegexp(`service::pipelines "logs" of fragment .*/b.yml conflicts with the one of fragment .*/a.yml`)))
+	})
+
+	It("rejects fragments configuring anything but components and pipelines", func() {
+		writeFragment("gorouter", "extensions.yml", `
+extensions:
+  pprof:
+`)
+		_, err := retrieve()
+		Expect(err).To(MatchError(ContainSubstring("may not configure extensions, only processors, exporters, connectors and pipelines")))
+	})
+
+	It("rejects fragments configuring receivers", func() {
+		writeFragment("gorouter", "receivers.yml", `
+receivers:
+  otlp:
+    protocols:
+      grpc:
+`)
+		_, err := retrieve()
+		Expect(err).To(MatchError(ContainSubstring("may not configure receivers, only processors, exporters, connectors and pipelines")))
+	})
+
+	It("rejects fragments configuring the service", func() {
+		writeFragment("gorouter", "telemetry.yml", `
+service:
+  telemetry:
+    logs:
+      level: debug
+`)
+		_, err := retrieve()
+		Expect(err).To(MatchError(ContainSubstring("may not configure service::telemetry, only pipelines")))
+	})
+
+	It("rejects invalid fragments", func() {
+		writeFragment("gorouter", "invalid.yml", "processors: [")
+		_, err := retrieve()
+		Expect(err).To(MatchError(ContainSubstring("unable to parse the fragment")))
+	})
+
+	It("namespaces pipelines by the file name when only it has wildcards", func() {
+		writeFragment("gorouter", "routing.yml", `
+service:
+  pipelines:
+    logs:
+      exporters: [otlp]
+`)
+		uri = "fragments:" + filepath.Join(jobsDir, "gorouter", "config", "otel-collector.d", "*.yml")
+
+		Expect(retrieve()).To(HaveKeyWithValue("service", HaveKeyWithValue("pipelines", HaveKey("logs/routing"))))
+	})
+})
@@ -0,0 +1,128 @@
+// Package watchfileprovider implements a provider for the file scheme that,
+// unlike the upstream file provider, watches the configuration file and the
+// TLS files it references, and has the collector reload its configuration
+// once a change has been validated.
+package watchfileprovider
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/collector/confmap"
+	"go.uber.org/zap"
+)
+
+const (
+	schemeName = "file"
+
+	defaultPollInterval = 5 * time.Second
+
+	validateTimeout = time.Minute
+)
+
+// Option configures the provider.
+type Option func(*provider)
+
+// WithPollInterval sets how often the files are checked for changes.
+func WithPollInterval(interval time.Duration) Option {
+	return func(p *provider) {
+		p.pollInterval = interval
+	}
+}
+
+// WithValidator replaces how a changed configuration is validated before the
+// collector reloads it, which is by running the validate command of the
+// collector with the arguments it was started with.
+func WithValidator(validate func(context.Context) error) Option {
+	return func(p *provider) {
+		p.validate = validate
+	}
+}
+
+type provider struct {
+	// logger is used without deriving loggers With fields, which would not
+	// see the collector swap its core for the configured one once the
+	// configuration is retrieved.
+	logger       *zap.Logger
+	pollInterval time.Duration
+	validate     func(context.Context) error
+}
+
+// NewFactory creates a factory for the watching file provider.
+func NewFactory(opts ...Option) confmap.ProviderFactory {
+	return confmap.NewProviderFactory(func(set confmap.ProviderSettings) confmap.Provider {
+		p := &provider{
+			logger:       set.Logger,
+			pollInterval: defaultPollInterval,
+			validate:     validateWithCollector,
+		}
+		if p.logger == nil {
+			p.logger = zap.NewNop()
+		}
+		for _, opt := range opts {
+			opt(p)
+		}
+		return p
+	})
+}
+
+func (p *provider) Retrieve(_ context.Context, uri string, watcher confmap.WatcherFunc) (*confmap.Retrieved, error) {
+	if !strings.HasPrefix(uri, schemeName+":") {
+		return nil, fmt.Errorf("%q uri is not supported by %q provider", uri, schemeName)
+	}
+
+	path := filepath.Clean(uri[len(schemeName)+1:])
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the file %v: %w", uri, err)
+	}
+	if watcher == nil {
+		return confmap.NewRetrievedFromYAML(content)
+	}
+
+	w := &watch{
+		provider: p,
+		path:     path,
+		blocks:   tlsBlocksOf(content),
+		onChange: watcher,
+	}
+	w.current = w.read()
+
+	done := make(chan struct{})
+	go w.run(done)
+
+	var once sync.Once
+	return confmap.NewRetrievedFromYAML(content, confmap.WithRetrievedClose(func(context.Context) error {
+		once.Do(func() { close(done) })
+		return nil
+	}))
+}
+
+func (*provider) Scheme() string {
+	return schemeName
+}
+
+func (*provider) Shutdown(context.Context) error {
+	return nil
+}
+
+// validateWithCollector runs the validate command of the running collector
+// with its arguments, which loads the configuration the way a reload would.
+func validateWithCollector(ctx context.Context) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	out, err := exec.CommandContext(ctx, exe, append([]string{"validate"}, os.Args[1:]...)...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%w: %s", err, bytes.TrimSpace(out))
+	}
+	return nil
+}
@@ -0,0 +1,239 @@
+package watchfileprovider_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	"code.cloudfoundry.org/otel-collector-release/src/components/provider/watchfileprovider"
+	"code.cloudfoundry.org/tlsconfig/certtest"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.opentelemetry.io/collector/confmap"
+	"go.uber.org/zap"
+)
+
+var _ = Describe("Watch file provider", func() {
+	var (
+		dir        string
+		configFile string
+		invalid    atomic.Bool
+		validated  atomic.Int32
+		changes    chan *confmap.ChangeEvent
+		provider   confmap.Provider
+		retrieved  *confmap.Retrieved
+	)
+
+	BeforeEach(func() {
+		dir = GinkgoT().TempDir()
+		configFile = filepath.Join(dir, "config.yml")
+		invalid.Store(false)
+		validated.Store(0)
+		changes = make(chan *confmap.ChangeEvent, 10)
+
+		provider = watchfileprovider.NewFactory(
+			watchfileprovider.WithPollInterval(10*time.Millisecond),
+			watchfileprovider.WithValidator(func(context.Context) error {
+				validated.Add(1)
+				if invalid.Load() {
+					return errors.New("receivers must be configured")
+				}
+				return nil
+			}),
+		).Create(confmap.ProviderSettings{Logger: zap.NewNop()})
+		DeferCleanup(provider.Shutdown, context.Background())
+	})
+
+	write := func(file, content string) {
+		Expect(os.WriteFile(file, []byte(content), 0o600)).To(Succeed())
+	}
+
+	// retrieve retrieves the configuration file, watching it for changes.
+	retrieve := func() {
+		var err error
+		retrieved, err = provider.Retrieve(context.Background(), "file:"+configFile, func(ev *confmap.ChangeEvent) {
+			changes <- ev
+		})
+		Expect(err).NotTo(HaveOccurred())
+		DeferCleanup(retrieved.Close, context.Background())
+	}
+
+	It("reads the configuration like the file provider", func() {
+		write(configFile, "receivers:\n  otlp:\n")
+		retrieve()
+
+		raw, err := retrieved.AsRaw()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(raw).To(Equal(map[string]any{"receivers": map[string]any{"otlp": nil}}))
+		Expect(provider.Scheme()).To(Equal("file"))
+	})
+
+	It("rejects other schemes and missing files", func() {
+		_, err := provider.Retrieve(context.Background(), "env:CONFIG", nil)
+		Expect(err).To(MatchError(`"env:CONFIG" uri is not supported by "file" provider`))
+
+		_, err = provider.Retrieve(context.Background(), "file:"+filepath.Join(dir, "missing.yml"), nil)
+		Expect(err).To(MatchError(ContainSubstring("unable to read the file")))
+	})
+
+	Describe("configuration changes", func() {
+		BeforeEach(func() {
+			write(configFile, "receivers:\n  otlp:\n")
+		})
+
+		JustBeforeEach(func() {
+			retrieve()
+		})
+
+		It("reloads once a change is validated", func() {
+			write(configFile, "receivers:\n  otlp/other:\n")
+
+			Eventually(changes).Should(Receive())
+			Expect(validated.Load()).To(BeEquivalentTo(1))
+		})
+
+		It("does not reload while the configuration is invalid", func() {
+			invalid.Store(true)
+			write(configFile, "receivers: {}\n")
+
+			Eventually(validated.Load).Should(BeEquivalentTo(1))
+			Consistently(changes, 100*time.Millisecond).ShouldNot(Receive())
+			Expect(validated.Load()).To(BeEquivalentTo(1), "an invalid change is only validated once")
+
+			invalid.Store(false)
+			write(configFile, "receivers:\n  otlp/fixed:\n")
+			Eventually(changes).Should(Receive())
+		})
+
+		It("does not reload a file that is not YAML", func() {
+			write(configFile, "receivers: [")
+
+			Consistently(changes, 100*time.Millisecond).ShouldNot(Receive())
+			Expect(validated.Load()).To(BeZero())
+		})
+
+		It("stops watching once the configuration is closed", func() {
+			Expect(retrieved.Close(context.Background())).To(Succeed())
+			write(configFile, "receivers:\n  otlp/other:\n")
+
+			Consistently(changes, 100*time.Millisecond).ShouldNot(Receive())
+		})
+
+		It("stops watching after a change", func() {
+			write(configFile, "receivers:\n  otlp/other:\n")
+			Eventually(changes).Should(Receive())
+
+			write(configFile, "receivers:\n  otlp/again:\n")
+			Consistently(changes, 100*time.Millisecond).ShouldNot(Receive())
+		})
+	})
+
+	Describe("TLS changes", func() {
+		var (
+			ca                        *certtest.Authority
+			caFile, certFile, keyFile string
+			tlsSettings               string
+		)
+
+		// rotate replaces the certificate and key with new ones.
+		rotate := func() {
+			cert, err := ca.BuildSignedCertificate("otel-collector")
+			Expect(err).NotTo(HaveOccurred())
+			certPEM, keyPEM, err := cert.CertificatePEMAndPrivateKey()
+			Expect(err).NotTo(HaveOccurred())
+			write(certFile, string(certPEM))
+			write(keyFile, string(keyPEM))
+		}
+
+		BeforeEach(func() {
+			var err error
+			ca, err = certtest.BuildCA("otel-collector")
+			Expect(err).NotTo(HaveOccurred())
+			caPEM, err := ca.CertificatePEM()
+			Expect(err).NotTo(HaveOccurred())
+
+			caFile = filepath.Join(dir, "ca.crt")
+			certFile = filepath.Join(dir, "otel-collector.crt")
+			keyFile = filepath.Join(dir, "otel-collector.key")
+			write(caFile, string(caPEM))
+			rotate()
+			tlsSettings = ""
+		})
+
+		JustBeforeEach(func() {
+			write(configFile, `
+receivers:
+  otlp:
+    protocols:
+      grpc:
+        tls:
+          client_ca_file: `+caFile+`
+          cert_file: `+certFile+`
+          key_file: `+keyFile+`
+`+tlsSettings)
+			retrieve()
+		})
+
+		It("reloads the configuration when the certificate and key are rotated", func() {
+			rotate()
+
+			Eventually(changes).Should(Receive())
+			Expect(validated.Load()).To(BeZero())
+		})
+
+		It("waits for the certificate and key to match", func() {
+			cert, err := ca.BuildSignedCertificate("otel-collector")
+			Expect(err).NotTo(HaveOccurred())
+			certPEM, keyPEM, err := cert.CertificatePEMAndPrivateKey()
+			Expect(err).NotTo(HaveOccurred())
+
+			write(certFile, string(certPEM))
+			Consistently(changes, 100*time.Millisecond).ShouldNot(Receive())
+
+			write(keyFile, string(keyPEM))
+			Eventually(changes).Should(Receive())
+		})
+
+		It("does not reload a CA without certificates", func() {
+			write(caFile, "not a certificate")
+
+			Consistently(changes, 100*time.Millisecond).ShouldNot(Receive())
+		})
+
+		Context("when the components reload the files themselves", func() {
+			BeforeEach(func() {
+				tlsSettings = `
+          reload_interval: 1m
+          client_ca_file_reload: true
+`
+			})
+
+			It("leaves the certificate and key to them", func() {
+				rotate()
+
+				Consistently(changes, 100*time.Millisecond).ShouldNot(Receive())
+			})
+
+			It("leaves the client CA to them", func() {
+				otherCA, err := certtest.BuildCA("other")
+				Expect(err).NotTo(HaveOccurred())
+				caPEM, err := otherCA.CertificatePEM()
+				Expect(err).NotTo(HaveOccurred())
+				write(caFile, string(caPEM))
+
+				Consistently(changes, 100*time.Millisecond).ShouldNot(Receive())
+			})
+
+			It("still reloads when the configuration changes", func() {
+				rotate()
+				Consistently(changes, 50*time.Millisecond).ShouldNot(Receive())
+
+				write(configFile, "receivers:\n  otlp/other:\n")
+				Eventually(changes).Should(Receive())
+			})
+		})
+	})
+})
@@ -0,0 +1,244 @@
+package watchfileprovider
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/collector/confmap"
+	"go.uber.org/zap"
+	"go.yaml.in/yaml/v3"
+)
+
+// snapshot is the content of the configuration file and of the TLS files it
+// references, nil for the files that cannot be read.
+type snapshot struct {
+	config []byte
+	tls    map[string][]byte
+}
+
+func (s snapshot) equal(other snapshot) bool {
+	return bytes.Equal(s.config, other.config) && maps.EqualFunc(s.tls, other.tls, bytes.Equal)
+}
+
+// watch follows the files of one retrieved configuration until it changes
+// or is closed.
+type watch struct {
+	*provider
+	path     string
+	blocks   []tlsBlock
+	onChange confmap.WatcherFunc
+
+	// current is what the running configuration was loaded from.
+	current snapshot
+}
+
+func (w *watch) read() snapshot {
+	s := snapshot{tls: map[string][]byte{}}
+	s.config, _ = os.ReadFile(w.path)
+	for _, b := range w.blocks {
+		for _, file := range b.files {
+			s.tls[file], _ = os.ReadFile(file)
+		}
+	}
+	return s
+}
+
+func (w *watch) run(done <-chan struct{}) {
+	ticker := time.NewTicker(w.pollInterval)
+	defer ticker.Stop()
+
+	// rejected is the last change that was found invalid, which is not
+	// checked again until the files change once more.
+	var rejected *snapshot
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+
+		s := w.read()
+		if s.equal(w.current) || (rejected != nil && s.equal(*rejected)) {
+			continue
+		}
+
+		reload, err := w.check(s)
+		if err != nil {
+			w.logger.Error("Not reloading the configuration, the changed files are not valid", zap.String("file", w.path), zap.Error(err))
+			rejected = &s
+			continue
+		}
+		if !reload {
+			w.current = s
+			continue
+		}
+
+		select {
+		case <-done:
+		default:
+			w.onChange(&confmap.ChangeEvent{})
+		}
+		return
+	}
+}
+
+// check decides whether a change needs the configuration to be reloaded, or
+// only TLS files that the components reload themselves changed.
+func (w *watch) check(s snapshot) (bool, error) {
+	if !bytes.Equal(s.config, w.current.config) {
+		// The collector takes a file that is not YAML for a string, and only
+		// fails once it has shut the running pipelines down.
+		var conf map[string]any
+		if err := yaml.Unmarshal(s.config, &conf); err != nil {
+			return false, fmt.Errorf("unable to unmarshal the configuration: %w", err)
+		}
+		for _, b := range tlsBlocksOf(s.config) {
+			if err := b.check(); err != nil {
+				return false, err
+			}
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), validateTimeout)
+		defer cancel()
+		if err := w.validate(ctx); err != nil {
+			return false, err
+		}
+		w.logger.Info("Configuration changed, reloading", zap.String("file", w.path))
+		return true, nil
+	}
+
+	var changed []string
+	inPlace := true
+	for _, b := range w.blocks {
+		blockChanged := false
+		for key, file := range b.files {
+			if !bytes.Equal(s.tls[file], w.current.tls[file]) {
+				blockChanged = true
+				changed = append(changed, file)
+				inPlace = inPlace && b.reloadsInPlace(key)
+			}
+		}
+		if blockChanged {
+			// A certificate and key are rarely replaced at once, wait until
+			// they match.
+			if err := b.check(); err != nil {
+				return false, err
+			}
+		}
+	}
+	slices.Sort(changed)
+	changed = slices.Compact(changed)
+
+	if inPlace {
+		w.logger.Info("TLS files changed, the components reload them in place", zap.String("file", w.path), zap.Strings("tls_files", changed))
+		return false, nil
+	}
+	w.logger.Info("TLS files changed, reloading the configuration", zap.String("file", w.path), zap.Strings("tls_files", changed))
+	return true, nil
+}
+
+// tlsBlock is a TLS setting of the configuration that references files.
+type tlsBlock struct {
+	// files are the paths of the files by the key referencing them.
+	files map[string]string
+
+	reloadInterval     bool
+	reloadClientCAFile bool
+}
+
+// tlsFileKeys are the configtls settings that reference files.
+var tlsFileKeys = []string{"ca_file", "cert_file", "key_file", "client_ca_file"}
+
+// tlsBlocksOf finds the TLS settings in a configuration, skipping the files
+// that are only known once the configuration is resolved.
+func tlsBlocksOf(content []byte) []tlsBlock {
+	retrieved, err := confmap.NewRetrievedFromYAML(content)
+	if err != nil {
+		return nil
+	}
+	raw, err := retrieved.AsRaw()
+	if err != nil {
+		return nil
+	}
+
+	var blocks []tlsBlock
+	var walk func(v any)
+	walk = func(v any) {
+		switch v := v.(type) {
+		case map[string]any:
+			b := tlsBlock{files: map[string]string{}}
+			for _, key := range tlsFileKeys {
+				if file, ok := v[key].(string); ok && file != "" && !strings.Contains(file, "${") {
+					b.files[key] = file
+				}
+			}
+			if len(b.files) > 0 {
+				if interval, ok := v["reload_interval"].(string); ok {
+					d, err := time.ParseDuration(interval)
+					b.reloadInterval = err == nil && d > 0
+				}
+				b.reloadClientCAFile, _ = v["client_ca_file_reload"].(bool)
+				blocks = append(blocks, b)
+			}
+			for _, child := range v {
+				walk(child)
+			}
+		case []any:
+			for _, child := range v {
+				walk(child)
+			}
+		}
+	}
+	walk(raw)
+	return blocks
+}
+
+// reloadsInPlace is whether the components reload the file of key
+// themselves, as configtls does for certificates and keys with a
+// reload_interval and for client CAs with client_ca_file_reload.
+func (b tlsBlock) reloadsInPlace(key string) bool {
+	switch key {
+	case "cert_file", "key_file":
+		return b.reloadInterval
+	case "client_ca_file":
+		return b.reloadClientCAFile
+	default:
+		return false
+	}
+}
+
+// check loads the files the way the components will.
+func (b tlsBlock) check() error {
+	certFile, hasCert := b.files["cert_file"]
+	keyFile, hasKey := b.files["key_file"]
+	if hasCert && hasKey {
+		if _, err := tls.LoadX509KeyPair(certFile, keyFile); err != nil {
+			return fmt.Errorf("failed to load %s and %s: %w", certFile, keyFile, err)
+		}
+	}
+	for _, key := range []string{"ca_file", "client_ca_file"} {
+		file, ok := b.files[key]
+		if !ok {
+			continue
+		}
+		pem, err := os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("failed to load %s: %w", file, err)
+		}
+		if !x509.NewCertPool().AppendCertsFromPEM(pem) {
+			return fmt.Errorf("failed to load %s: %w", file, errNoCertificates)
+		}
+	}
+	return nil
+}
+
+var errNoCertificates = errors.New("no certificates found")
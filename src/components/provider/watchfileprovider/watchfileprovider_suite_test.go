@@ -0,0 +1,13 @@
+package watchfileprovider_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestWatchFileProvider(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Watch File Provider Suite")
+}
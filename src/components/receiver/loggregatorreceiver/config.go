@@ -0,0 +1,30 @@
+package loggregatorreceiver
+
+import (
+	"errors"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/config/configgrpc"
+)
+
+// Config defines the configuration for the Loggregator v2 Ingress receiver.
+type Config struct {
+	configgrpc.ServerConfig `mapstructure:",squash"`
+}
+
+var _ component.Config = (*Config)(nil)
+
+// Validate checks that the receiver is configured for mutual TLS, which is
+// what every Loggregator v2 client expects when dialing the Ingress API.
+func (cfg *Config) Validate() error {
+	if cfg.NetAddr.Endpoint == "" {
+		return errors.New("endpoint must be specified")
+	}
+	if cfg.TLS == nil {
+		return errors.New("tls must be configured")
+	}
+	if cfg.TLS.ClientCAFile == "" {
+		return errors.New("tls.client_ca_file must be specified to require client certificates")
+	}
+	return nil
+}
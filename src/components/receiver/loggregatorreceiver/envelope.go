@@ -0,0 +1,228 @@
+package loggregatorreceiver
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sort"
+	"strconv"
+	"time"
+
+	"code.cloudfoundry.org/go-loggregator/v10/rpc/loggregator_v2"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+const (
+	attributeSourceID   = "source_id"
+	attributeInstanceID = "instance_id"
+
+	tagTraceID    = "trace_id"
+	tagSpanID     = "span_id"
+	tagPeerType   = "peer_type"
+	tagStatusCode = "status_code"
+)
+
+// convertEnvelopes converts a batch of envelopes into logs, metrics and
+// traces. Log and event envelopes become log records, counter and gauge
+// envelopes become metrics, and timer envelopes become spans. Timers without
+// valid trace_id or span_id tags, like many HTTP timers of the gorouter, get
+// random IDs.
+func convertEnvelopes(envelopes []*loggregator_v2.Envelope) (plog.Logs, pmetric.Metrics, ptrace.Traces) {
+	logs := plog.NewLogs()
+	scopeLogs := logs.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty()
+
+	metrics := pmetric.NewMetrics()
+	scopeMetrics := metrics.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty()
+
+	traces := ptrace.NewTraces()
+	scopeSpans := traces.ResourceSpans().AppendEmpty().ScopeSpans().AppendEmpty()
+
+	observed := pcommon.NewTimestampFromTime(time.Now())
+	for _, e := range envelopes {
+		switch e.GetMessage().(type) {
+		case *loggregator_v2.Envelope_Log:
+			convertLog(e, scopeLogs.LogRecords().AppendEmpty(), observed)
+		case *loggregator_v2.Envelope_Event:
+			convertEvent(e, scopeLogs.LogRecords().AppendEmpty(), observed)
+		case *loggregator_v2.Envelope_Counter:
+			convertCounter(e, scopeMetrics.Metrics(), observed)
+		case *loggregator_v2.Envelope_Gauge:
+			convertGauge(e, scopeMetrics.Metrics(), observed)
+		case *loggregator_v2.Envelope_Timer:
+			convertTimer(e, scopeSpans.Spans())
+		}
+	}
+
+	return logs, metrics, traces
+}
+
+func convertLog(e *loggregator_v2.Envelope, lr plog.LogRecord, observed pcommon.Timestamp) {
+	lr.SetTimestamp(timestamp(e, observed))
+	lr.SetObservedTimestamp(observed)
+	lr.Body().SetStr(string(e.GetLog().GetPayload()))
+
+	switch e.GetLog().GetType() {
+	case loggregator_v2.Log_ERR:
+		lr.SetSeverityNumber(plog.SeverityNumberError)
+		lr.SetSeverityText("ERROR")
+	default:
+		lr.SetSeverityNumber(plog.SeverityNumberInfo)
+		lr.SetSeverityText("INFO")
+	}
+
+	putAttributes(lr.Attributes(), e, nil)
+}
+
+func convertEvent(e *loggregator_v2.Envelope, lr plog.LogRecord, observed pcommon.Timestamp) {
+	lr.SetTimestamp(timestamp(e, observed))
+	lr.SetObservedTimestamp(observed)
+
+	body := lr.Body().SetEmptyMap()
+	body.PutStr("title", e.GetEvent().GetTitle())
+	body.PutStr("body", e.GetEvent().GetBody())
+
+	putAttributes(lr.Attributes(), e, nil)
+}
+
+func convertCounter(e *loggregator_v2.Envelope, ms pmetric.MetricSlice, observed pcommon.Timestamp) {
+	c := e.GetCounter()
+
+	m := ms.AppendEmpty()
+	m.SetName(c.GetName())
+	sum := m.SetEmptySum()
+	sum.SetIsMonotonic(true)
+
+	dp := sum.DataPoints().AppendEmpty()
+	dp.SetTimestamp(timestamp(e, observed))
+	// Emitters that only send deltas leave the total unset.
+	if c.GetTotal() == 0 && c.GetDelta() != 0 {
+		sum.SetAggregationTemporality(pmetric.AggregationTemporalityDelta)
+		dp.SetIntValue(int64(c.GetDelta()))
+	} else {
+		sum.SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
+		dp.SetIntValue(int64(c.GetTotal()))
+	}
+
+	putAttributes(dp.Attributes(), e, nil)
+}
+
+func convertGauge(e *loggregator_v2.Envelope, ms pmetric.MetricSlice, observed pcommon.Timestamp) {
+	gaugeMetrics := e.GetGauge().GetMetrics()
+
+	for _, name := range sortedKeys(gaugeMetrics) {
+		v := gaugeMetrics[name]
+
+		m := ms.AppendEmpty()
+		m.SetName(name)
+		m.SetUnit(v.GetUnit())
+
+		dp := m.SetEmptyGauge().DataPoints().AppendEmpty()
+		dp.SetTimestamp(timestamp(e, observed))
+		dp.SetDoubleValue(v.GetValue())
+
+		putAttributes(dp.Attributes(), e, nil)
+	}
+}
+
+func convertTimer(e *loggregator_v2.Envelope, spans ptrace.SpanSlice) {
+	traceID, ok := decodeTraceID(e.GetTags()[tagTraceID])
+	if !ok {
+		_, _ = rand.Read(traceID[:])
+	}
+	spanID, ok := decodeSpanID(e.GetTags()[tagSpanID])
+	if !ok {
+		_, _ = rand.Read(spanID[:])
+	}
+
+	t := e.GetTimer()
+
+	span := spans.AppendEmpty()
+	span.SetTraceID(traceID)
+	span.SetSpanID(spanID)
+	span.SetName(t.GetName())
+	span.SetStartTimestamp(pcommon.Timestamp(t.GetStart()))
+	span.SetEndTimestamp(pcommon.Timestamp(t.GetStop()))
+
+	switch e.GetTags()[tagPeerType] {
+	case "Server":
+		span.SetKind(ptrace.SpanKindServer)
+	case "Client":
+		span.SetKind(ptrace.SpanKindClient)
+	}
+
+	if code, err := strconv.Atoi(e.GetTags()[tagStatusCode]); err == nil && code >= 500 {
+		span.Status().SetCode(ptrace.StatusCodeError)
+	}
+
+	putAttributes(span.Attributes(), e, map[string]bool{tagTraceID: true, tagSpanID: true})
+}
+
+// putAttributes copies the envelope's source and instance IDs and its tags
+// into attrs. Keys are written in sorted order so that the output is stable.
+func putAttributes(attrs pcommon.Map, e *loggregator_v2.Envelope, skip map[string]bool) {
+	deprecatedTags := e.GetDeprecatedTags()
+	tags := e.GetTags()
+	attrs.EnsureCapacity(len(deprecatedTags) + len(tags) + 2)
+
+	for _, k := range sortedKeys(deprecatedTags) {
+		if skip[k] {
+			continue
+		}
+		switch v := deprecatedTags[k].GetData().(type) {
+		case *loggregator_v2.Value_Text:
+			attrs.PutStr(k, v.Text)
+		case *loggregator_v2.Value_Integer:
+			attrs.PutInt(k, v.Integer)
+		case *loggregator_v2.Value_Decimal:
+			attrs.PutDouble(k, v.Decimal)
+		}
+	}
+
+	for _, k := range sortedKeys(tags) {
+		if skip[k] {
+			continue
+		}
+		attrs.PutStr(k, tags[k])
+	}
+
+	attrs.PutStr(attributeSourceID, e.GetSourceId())
+	attrs.PutStr(attributeInstanceID, e.GetInstanceId())
+}
+
+func timestamp(e *loggregator_v2.Envelope, fallback pcommon.Timestamp) pcommon.Timestamp {
+	if e.GetTimestamp() == 0 {
+		return fallback
+	}
+	return pcommon.Timestamp(e.GetTimestamp())
+}
+
+func decodeTraceID(s string) (pcommon.TraceID, bool) {
+	var id pcommon.TraceID
+	b, err := hex.DecodeString(s)
+	if err != nil || len(b) != len(id) {
+		return id, false
+	}
+	copy(id[:], b)
+	return id, !id.IsEmpty()
+}
+
+func decodeSpanID(s string) (pcommon.SpanID, bool) {
+	var id pcommon.SpanID
+	b, err := hex.DecodeString(s)
+	if err != nil || len(b) != len(id) {
+		return id, false
+	}
+	copy(id[:], b)
+	return id, !id.IsEmpty()
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
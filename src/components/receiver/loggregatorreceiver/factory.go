@@ -0,0 +1,98 @@
+// Package loggregatorreceiver implements a receiver that serves the
+// Loggregator v2 Ingress API and converts envelopes into pdata.
+package loggregatorreceiver
+
+import (
+	"context"
+	"sync"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/config/configgrpc"
+	"go.opentelemetry.io/collector/config/confignet"
+	"go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/receiver"
+)
+
+const stability = component.StabilityLevelAlpha
+
+var componentType = component.MustNewType("loggregator")
+
+// NewFactory creates a factory for the Loggregator v2 Ingress receiver.
+func NewFactory() receiver.Factory {
+	return receiver.NewFactory(
+		componentType,
+		createDefaultConfig,
+		receiver.WithLogs(createLogs, stability),
+		receiver.WithMetrics(createMetrics, stability),
+		receiver.WithTraces(createTraces, stability),
+	)
+}
+
+func createDefaultConfig() component.Config {
+	grpcCfg := configgrpc.NewDefaultServerConfig()
+	grpcCfg.NetAddr = confignet.NewDefaultAddrConfig()
+	grpcCfg.NetAddr.Endpoint = "localhost:3458"
+	grpcCfg.NetAddr.Transport = confignet.TransportTypeTCP
+
+	return &Config{
+		ServerConfig: grpcCfg,
+	}
+}
+
+func createLogs(_ context.Context, set receiver.Settings, cfg component.Config, next consumer.Logs) (receiver.Logs, error) {
+	r, err := receivers.loadOrStore(cfg.(*Config), set)
+	if err != nil {
+		return nil, err
+	}
+	r.nextLogs = next
+	return r, nil
+}
+
+func createMetrics(_ context.Context, set receiver.Settings, cfg component.Config, next consumer.Metrics) (receiver.Metrics, error) {
+	r, err := receivers.loadOrStore(cfg.(*Config), set)
+	if err != nil {
+		return nil, err
+	}
+	r.nextMetrics = next
+	return r, nil
+}
+
+func createTraces(_ context.Context, set receiver.Settings, cfg component.Config, next consumer.Traces) (receiver.Traces, error) {
+	r, err := receivers.loadOrStore(cfg.(*Config), set)
+	if err != nil {
+		return nil, err
+	}
+	r.nextTraces = next
+	return r, nil
+}
+
+// sharedReceivers ensures that a single gRPC server is started per receiver
+// configuration, no matter how many pipelines the receiver is used in.
+type sharedReceivers struct {
+	mu sync.Mutex
+	m  map[*Config]*loggregatorReceiver
+}
+
+var receivers = &sharedReceivers{m: map[*Config]*loggregatorReceiver{}}
+
+func (s *sharedReceivers) loadOrStore(cfg *Config, set receiver.Settings) (*loggregatorReceiver, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if r, ok := s.m[cfg]; ok {
+		return r, nil
+	}
+
+	r, err := newLoggregatorReceiver(cfg, set, func() { s.remove(cfg) })
+	if err != nil {
+		return nil, err
+	}
+	s.m[cfg] = r
+	return r, nil
+}
+
+func (s *sharedReceivers) remove(cfg *Config) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.m, cfg)
+}
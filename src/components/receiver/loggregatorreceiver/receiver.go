@@ -0,0 +1,172 @@
+package loggregatorreceiver
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+
+	"code.cloudfoundry.org/go-loggregator/v10/rpc/loggregator_v2"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/component/componentstatus"
+	"go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/receiver"
+	"go.opentelemetry.io/collector/receiver/receiverhelper"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	transport  = "grpc"
+	dataFormat = "loggregator_v2"
+)
+
+type loggregatorReceiver struct {
+	loggregator_v2.UnimplementedIngressServer
+
+	cfg      *Config
+	settings receiver.Settings
+	obsrecv  *receiverhelper.ObsReport
+	onStop   func()
+
+	nextLogs    consumer.Logs
+	nextMetrics consumer.Metrics
+	nextTraces  consumer.Traces
+
+	server       *grpc.Server
+	serverWG     sync.WaitGroup
+	startOnce    sync.Once
+	shutdownOnce sync.Once
+}
+
+func newLoggregatorReceiver(cfg *Config, set receiver.Settings, onStop func()) (*loggregatorReceiver, error) {
+	obsrecv, err := receiverhelper.NewObsReport(receiverhelper.ObsReportSettings{
+		ReceiverID:             set.ID,
+		Transport:              transport,
+		ReceiverCreateSettings: set,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &loggregatorReceiver{
+		cfg:      cfg,
+		settings: set,
+		obsrecv:  obsrecv,
+		onStop:   onStop,
+	}, nil
+}
+
+func (r *loggregatorReceiver) Start(ctx context.Context, host component.Host) error {
+	var err error
+	r.startOnce.Do(func() {
+		err = r.start(ctx, host)
+	})
+	return err
+}
+
+func (r *loggregatorReceiver) start(ctx context.Context, host component.Host) error {
+	server, err := r.cfg.ToServer(ctx, host, r.settings.TelemetrySettings)
+	if err != nil {
+		return err
+	}
+
+	lis, err := r.cfg.NetAddr.Listen(ctx)
+	if err != nil {
+		return err
+	}
+
+	loggregator_v2.RegisterIngressServer(server, r)
+	r.server = server
+
+	r.settings.Logger.Info("Starting Loggregator v2 Ingress server", zap.String("endpoint", r.cfg.NetAddr.Endpoint))
+	r.serverWG.Add(1)
+	go func() {
+		defer r.serverWG.Done()
+		if errGrpc := server.Serve(lis); errGrpc != nil && !errors.Is(errGrpc, grpc.ErrServerStopped) {
+			componentstatus.ReportStatus(host, componentstatus.NewFatalErrorEvent(errGrpc))
+		}
+	}()
+
+	return nil
+}
+
+func (r *loggregatorReceiver) Shutdown(context.Context) error {
+	r.shutdownOnce.Do(func() {
+		if r.server != nil {
+			r.server.GracefulStop()
+		}
+		r.serverWG.Wait()
+		r.onStop()
+	})
+	return nil
+}
+
+// Sender receives a stream of individual envelopes.
+func (r *loggregatorReceiver) Sender(s loggregator_v2.Ingress_SenderServer) error {
+	for {
+		e, err := s.Recv()
+		if errors.Is(err, io.EOF) {
+			return s.SendAndClose(&loggregator_v2.IngressResponse{})
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := r.consume(s.Context(), []*loggregator_v2.Envelope{e}); err != nil {
+			r.settings.Logger.Debug("failed to consume envelope", zap.Error(err))
+		}
+	}
+}
+
+// BatchSender receives a stream of envelope batches.
+func (r *loggregatorReceiver) BatchSender(s loggregator_v2.Ingress_BatchSenderServer) error {
+	for {
+		b, err := s.Recv()
+		if errors.Is(err, io.EOF) {
+			return s.SendAndClose(&loggregator_v2.BatchSenderResponse{})
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := r.consume(s.Context(), b.GetBatch()); err != nil {
+			r.settings.Logger.Debug("failed to consume envelope batch", zap.Error(err))
+		}
+	}
+}
+
+// Send receives a single envelope batch.
+func (r *loggregatorReceiver) Send(ctx context.Context, b *loggregator_v2.EnvelopeBatch) (*loggregator_v2.SendResponse, error) {
+	if err := r.consume(ctx, b.GetBatch()); err != nil {
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+	return &loggregator_v2.SendResponse{}, nil
+}
+
+func (r *loggregatorReceiver) consume(ctx context.Context, envelopes []*loggregator_v2.Envelope) error {
+	logs, metrics, traces := convertEnvelopes(envelopes)
+
+	var errs error
+	if n := logs.LogRecordCount(); n > 0 && r.nextLogs != nil {
+		ctx := r.obsrecv.StartLogsOp(ctx)
+		err := r.nextLogs.ConsumeLogs(ctx, logs)
+		r.obsrecv.EndLogsOp(ctx, dataFormat, n, err)
+		errs = errors.Join(errs, err)
+	}
+	if n := metrics.DataPointCount(); n > 0 && r.nextMetrics != nil {
+		ctx := r.obsrecv.StartMetricsOp(ctx)
+		err := r.nextMetrics.ConsumeMetrics(ctx, metrics)
+		r.obsrecv.EndMetricsOp(ctx, dataFormat, n, err)
+		errs = errors.Join(errs, err)
+	}
+	if n := traces.SpanCount(); n > 0 && r.nextTraces != nil {
+		ctx := r.obsrecv.StartTracesOp(ctx)
+		err := r.nextTraces.ConsumeTraces(ctx, traces)
+		r.obsrecv.EndTracesOp(ctx, dataFormat, n, err)
+		errs = errors.Join(errs, err)
+	}
+	return errs
+}
@@ -0,0 +1,337 @@
+package loggregatorreceiver_test
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+
+	"code.cloudfoundry.org/go-loggregator/v10/rpc/loggregator_v2"
+	"code.cloudfoundry.org/otel-collector-release/src/components/receiver/loggregatorreceiver"
+	"code.cloudfoundry.org/tlsconfig/certtest"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/component/componenttest"
+	"go.opentelemetry.io/collector/config/configtls"
+	"go.opentelemetry.io/collector/consumer/consumertest"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.opentelemetry.io/collector/receiver"
+	"go.opentelemetry.io/collector/receiver/receivertest"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+var _ = Describe("Loggregator receiver", func() {
+	var (
+		cfg          *loggregatorreceiver.Config
+		logsSink     *consumertest.LogsSink
+		metricsSink  *consumertest.MetricsSink
+		tracesSink   *consumertest.TracesSink
+		receivers    []component.Component
+		ca           *certtest.Authority
+		ingress      loggregator_v2.IngressClient
+		clientConfig *tls.Config
+	)
+
+	BeforeEach(func() {
+		var err error
+		ca, err = certtest.BuildCA("loggregator")
+		Expect(err).NotTo(HaveOccurred())
+
+		dir := GinkgoT().TempDir()
+		caFile, certFile, keyFile := writeCerts(ca, dir, "metron")
+
+		cfg = loggregatorreceiver.NewFactory().CreateDefaultConfig().(*loggregatorreceiver.Config)
+		cfg.NetAddr.Endpoint = fmt.Sprintf("127.0.0.1:%d", freePort())
+		cfg.TLS = &configtls.ServerConfig{
+			Config: configtls.Config{
+				CertFile: certFile,
+				KeyFile:  keyFile,
+			},
+			ClientCAFile: caFile,
+		}
+		Expect(cfg.Validate()).To(Succeed())
+
+		logsSink = new(consumertest.LogsSink)
+		metricsSink = new(consumertest.MetricsSink)
+		tracesSink = new(consumertest.TracesSink)
+
+		clientConfig = clientTLSConfig(ca, "client")
+	})
+
+	JustBeforeEach(func() {
+		f := loggregatorreceiver.NewFactory()
+		set := receivertest.NewNopSettings(f.Type())
+
+		lr, err := f.CreateLogs(context.Background(), set, cfg, logsSink)
+		Expect(err).NotTo(HaveOccurred())
+		mr, err := f.CreateMetrics(context.Background(), set, cfg, metricsSink)
+		Expect(err).NotTo(HaveOccurred())
+		tr, err := f.CreateTraces(context.Background(), set, cfg, tracesSink)
+		Expect(err).NotTo(HaveOccurred())
+		receivers = []component.Component{lr, mr, tr}
+
+		for _, r := range receivers {
+			Expect(r.Start(context.Background(), componenttest.NewNopHost())).To(Succeed())
+		}
+
+		conn, err := grpc.NewClient(cfg.NetAddr.Endpoint, grpc.WithTransportCredentials(credentials.NewTLS(clientConfig)))
+		Expect(err).NotTo(HaveOccurred())
+		DeferCleanup(conn.Close)
+		ingress = loggregator_v2.NewIngressClient(conn)
+	})
+
+	AfterEach(func() {
+		for _, r := range receivers {
+			Expect(r.Shutdown(context.Background())).To(Succeed())
+		}
+	})
+
+	It("shares a single server between signals", func() {
+		Expect(receivers[0]).To(BeIdenticalTo(receivers[1]))
+		Expect(receivers[1]).To(BeIdenticalTo(receivers[2]))
+	})
+
+	It("converts log envelopes into log records", func() {
+		_, err := ingress.Send(context.Background(), &loggregator_v2.EnvelopeBatch{
+			Batch: []*loggregator_v2.Envelope{
+				{
+					Timestamp:  1741217715635087516,
+					SourceId:   "64f96e84-e84d-4543-bf85-9cbfc567aa44",
+					InstanceId: "0",
+					Tags:       map[string]string{"source_type": "APP/PROC/WEB", "app_name": "dora"},
+					Message: &loggregator_v2.Envelope_Log{
+						Log: &loggregator_v2.Log{Payload: []byte("hello"), Type: loggregator_v2.Log_OUT},
+					},
+				},
+				{
+					SourceId: "64f96e84-e84d-4543-bf85-9cbfc567aa44",
+					Message: &loggregator_v2.Envelope_Log{
+						Log: &loggregator_v2.Log{Payload: []byte("oops"), Type: loggregator_v2.Log_ERR},
+					},
+				},
+			},
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		Eventually(logsSink.AllLogs).Should(HaveLen(1))
+		records := logsSink.AllLogs()[0].ResourceLogs().At(0).ScopeLogs().At(0).LogRecords()
+		Expect(records.Len()).To(Equal(2))
+
+		out := records.At(0)
+		Expect(out.Body().Str()).To(Equal("hello"))
+		Expect(out.Timestamp()).To(Equal(pcommon.Timestamp(1741217715635087516)))
+		Expect(out.SeverityNumber()).To(Equal(plog.SeverityNumberInfo))
+		Expect(out.Attributes().AsRaw()).To(Equal(map[string]any{
+			"source_id":   "64f96e84-e84d-4543-bf85-9cbfc567aa44",
+			"instance_id": "0",
+			"source_type": "APP/PROC/WEB",
+			"app_name":    "dora",
+		}))
+
+		errLog := records.At(1)
+		Expect(errLog.Body().Str()).To(Equal("oops"))
+		Expect(errLog.SeverityNumber()).To(Equal(plog.SeverityNumberError))
+		Expect(errLog.Timestamp()).NotTo(BeZero())
+	})
+
+	It("converts event envelopes into log records", func() {
+		_, err := ingress.Send(context.Background(), &loggregator_v2.EnvelopeBatch{
+			Batch: []*loggregator_v2.Envelope{{
+				SourceId: "bosh-system-metrics",
+				Message: &loggregator_v2.Envelope_Event{
+					Event: &loggregator_v2.Event{Title: "vm alert", Body: "disk full"},
+				},
+			}},
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		Eventually(logsSink.AllLogs).Should(HaveLen(1))
+		record := logsSink.AllLogs()[0].ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0)
+		Expect(record.Body().Map().AsRaw()).To(Equal(map[string]any{"title": "vm alert", "body": "disk full"}))
+	})
+
+	It("converts counter and gauge envelopes into metrics", func() {
+		stream, err := ingress.BatchSender(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(stream.Send(&loggregator_v2.EnvelopeBatch{
+			Batch: []*loggregator_v2.Envelope{
+				{
+					Timestamp: 1741217715419374304,
+					SourceId:  "gorouter",
+					Message: &loggregator_v2.Envelope_Counter{
+						Counter: &loggregator_v2.Counter{Name: "total_requests", Total: 42},
+					},
+				},
+				{
+					SourceId: "gorouter",
+					Message: &loggregator_v2.Envelope_Counter{
+						Counter: &loggregator_v2.Counter{Name: "bad_gateways", Delta: 3},
+					},
+				},
+				{
+					SourceId: "system_metrics_agent",
+					DeprecatedTags: map[string]*loggregator_v2.Value{
+						"index": {Data: &loggregator_v2.Value_Integer{Integer: 2}},
+					},
+					Message: &loggregator_v2.Envelope_Gauge{
+						Gauge: &loggregator_v2.Gauge{Metrics: map[string]*loggregator_v2.GaugeValue{
+							"system_mem_percent": {Unit: "Percent", Value: 12.5},
+							"system_cpu_user":    {Unit: "Percent", Value: 1.5},
+						}},
+					},
+				},
+			},
+		})).To(Succeed())
+		_, err = stream.CloseAndRecv()
+		Expect(err).NotTo(HaveOccurred())
+
+		Eventually(metricsSink.AllMetrics).Should(HaveLen(1))
+		ms := metricsSink.AllMetrics()[0].ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics()
+		Expect(ms.Len()).To(Equal(4))
+
+		total := ms.At(0)
+		Expect(total.Name()).To(Equal("total_requests"))
+		Expect(total.Sum().IsMonotonic()).To(BeTrue())
+		Expect(total.Sum().AggregationTemporality()).To(Equal(pmetric.AggregationTemporalityCumulative))
+		Expect(total.Sum().DataPoints().At(0).IntValue()).To(Equal(int64(42)))
+		Expect(total.Sum().DataPoints().At(0).Attributes().AsRaw()).To(Equal(map[string]any{
+			"source_id":   "gorouter",
+			"instance_id": "",
+		}))
+
+		delta := ms.At(1)
+		Expect(delta.Name()).To(Equal("bad_gateways"))
+		Expect(delta.Sum().AggregationTemporality()).To(Equal(pmetric.AggregationTemporalityDelta))
+		Expect(delta.Sum().DataPoints().At(0).IntValue()).To(Equal(int64(3)))
+
+		cpu := ms.At(2)
+		Expect(cpu.Name()).To(Equal("system_cpu_user"))
+		Expect(cpu.Unit()).To(Equal("Percent"))
+		Expect(cpu.Gauge().DataPoints().At(0).DoubleValue()).To(Equal(1.5))
+		Expect(cpu.Gauge().DataPoints().At(0).Attributes().AsRaw()).To(HaveKeyWithValue("index", int64(2)))
+		Expect(ms.At(3).Name()).To(Equal("system_mem_percent"))
+	})
+
+	It("converts timer envelopes into spans", func() {
+		stream, err := ingress.Sender(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(stream.Send(&loggregator_v2.Envelope{
+			SourceId:   "gorouter",
+			InstanceId: "0",
+			Tags: map[string]string{
+				"trace_id":    "f0db4801b9634b85636b69c94e622b26",
+				"span_id":     "636b69c94e622b26",
+				"peer_type":   "Server",
+				"status_code": "502",
+				"method":      "GET",
+			},
+			Message: &loggregator_v2.Envelope_Timer{
+				Timer: &loggregator_v2.Timer{Name: "http", Start: 1741216325739358994, Stop: 1741216325759969237},
+			},
+		})).To(Succeed())
+		Expect(stream.Send(&loggregator_v2.Envelope{
+			SourceId: "gorouter",
+			Message: &loggregator_v2.Envelope_Timer{
+				Timer: &loggregator_v2.Timer{Name: "http", Start: 1, Stop: 2},
+			},
+		})).To(Succeed())
+		_, err = stream.CloseAndRecv()
+		Expect(err).NotTo(HaveOccurred())
+
+		Eventually(tracesSink.SpanCount).Should(Equal(2))
+		span := tracesSink.AllTraces()[0].ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0)
+		Expect(span.TraceID().String()).To(Equal("f0db4801b9634b85636b69c94e622b26"))
+		Expect(span.SpanID().String()).To(Equal("636b69c94e622b26"))
+		Expect(span.Name()).To(Equal("http"))
+		Expect(span.Kind()).To(Equal(ptrace.SpanKindServer))
+		Expect(span.Status().Code()).To(Equal(ptrace.StatusCodeError))
+		Expect(span.StartTimestamp()).To(Equal(pcommon.Timestamp(1741216325739358994)))
+		Expect(span.EndTimestamp()).To(Equal(pcommon.Timestamp(1741216325759969237)))
+		Expect(span.Attributes().AsRaw()).To(Equal(map[string]any{
+			"source_id":   "gorouter",
+			"instance_id": "0",
+			"peer_type":   "Server",
+			"status_code": "502",
+			"method":      "GET",
+		}))
+
+		untraced := tracesSink.AllTraces()[1].ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0)
+		Expect(untraced.TraceID().IsEmpty()).To(BeFalse())
+		Expect(untraced.SpanID().IsEmpty()).To(BeFalse())
+		Expect(untraced.TraceID()).NotTo(Equal(span.TraceID()))
+		Expect(untraced.Name()).To(Equal("http"))
+	})
+
+	Context("when the client does not present a certificate", func() {
+		BeforeEach(func() {
+			clientConfig = clientTLSConfig(ca, "")
+		})
+
+		It("rejects the connection", func() {
+			_, err := ingress.Send(context.Background(), &loggregator_v2.EnvelopeBatch{})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
+
+var _ = Describe("Config", func() {
+	It("requires client certificate verification", func() {
+		cfg := loggregatorreceiver.NewFactory().CreateDefaultConfig().(*loggregatorreceiver.Config)
+		Expect(cfg.Validate()).To(MatchError("tls must be configured"))
+
+		cfg.TLS = &configtls.ServerConfig{}
+		Expect(cfg.Validate()).To(MatchError(ContainSubstring("tls.client_ca_file")))
+	})
+
+	It("has a loggregator type", func() {
+		Expect(loggregatorreceiver.NewFactory().Type().String()).To(Equal("loggregator"))
+		var _ receiver.Factory = loggregatorreceiver.NewFactory()
+	})
+})
+
+func writeCerts(ca *certtest.Authority, dir, name string) (string, string, string) {
+	caPEM, err := ca.CertificatePEM()
+	Expect(err).NotTo(HaveOccurred())
+	cert, err := ca.BuildSignedCertificate(name)
+	Expect(err).NotTo(HaveOccurred())
+	certPEM, keyPEM, err := cert.CertificatePEMAndPrivateKey()
+	Expect(err).NotTo(HaveOccurred())
+
+	caFile := filepath.Join(dir, "ca.crt")
+	certFile := filepath.Join(dir, name+".crt")
+	keyFile := filepath.Join(dir, name+".key")
+	Expect(os.WriteFile(caFile, caPEM, 0600)).To(Succeed())
+	Expect(os.WriteFile(certFile, certPEM, 0600)).To(Succeed())
+	Expect(os.WriteFile(keyFile, keyPEM, 0600)).To(Succeed())
+	return caFile, certFile, keyFile
+}
+
+func clientTLSConfig(ca *certtest.Authority, name string) *tls.Config {
+	pool, err := ca.CertPool()
+	Expect(err).NotTo(HaveOccurred())
+	cfg := &tls.Config{RootCAs: pool, ServerName: "localhost"}
+	if name != "" {
+		cert, err := ca.BuildSignedCertificate(name)
+		Expect(err).NotTo(HaveOccurred())
+		tlsCert, err := cert.TLSCertificate()
+		Expect(err).NotTo(HaveOccurred())
+		cfg.Certificates = []tls.Certificate{tlsCert}
+	}
+	return cfg
+}
+
+func freePort() int {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	Expect(err).NotTo(HaveOccurred())
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port
+}
@@ -5,8 +5,11 @@ import (
 	"crypto/tls"
 	"crypto/x509"
 	"encoding/hex"
+	"os"
+	"path/filepath"
 	"strings"
 
+	"code.cloudfoundry.org/go-loggregator/v10/rpc/loggregator_v2"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	v11 "go.opentelemetry.io/proto/otlp/common/v1"
@@ -339,13 +342,34 @@ func (s FakeMetricsServiceServer) Export(ctx context.Context, emsr *colmetricspb
 	return &colmetricspb.ExportMetricsServiceResponse{}, nil
 }
 
+type FakeIngressServer struct {
+	loggregator_v2.IngressServer
+	EnvelopeBatches chan *loggregator_v2.EnvelopeBatch
+}
+
+func NewFakeIngressServer() FakeIngressServer {
+	return FakeIngressServer{
+		EnvelopeBatches: make(chan *loggregator_v2.EnvelopeBatch, 10),
+	}
+}
+
+func (f FakeIngressServer) Send(ctx context.Context, b *loggregator_v2.EnvelopeBatch) (*loggregator_v2.SendResponse, error) {
+	f.EnvelopeBatches <- b
+	return &loggregator_v2.SendResponse{}, nil
+}
+
 type OTelConfigVars struct {
-	IngressOTLPPort int
-	EgressOTLPPort  int
-	MetricsPort     int
-	Port            int
-	CA              *certtest.Authority
-	Cert            *certtest.Certificate
+	IngressOTLPPort        int
+	EgressOTLPPort         int
+	MetricsPort            int
+	Port                   int
+	IngressLoggregatorPort int
+	EgressLoggregatorPort  int
+	EgressSyslogPort       int
+	StorageDir             string
+	CA                     *certtest.Authority
+	Cert                   *certtest.Certificate
+	CaFile                 string
 }
 
 func NewOTELConfigVars() OTelConfigVars {
@@ -353,19 +377,32 @@ func NewOTELConfigVars() OTelConfigVars {
 	egressOTLPPort := 5000 + GinkgoParallelProcess()*100 + 1
 	metricsPort := 5000 + GinkgoParallelProcess()*100 + 2
 	port := 5000 + GinkgoParallelProcess()*100 + 3
+	ingressLoggregatorPort := 5000 + GinkgoParallelProcess()*100 + 4
+	egressLoggregatorPort := 5000 + GinkgoParallelProcess()*100 + 5
+	egressSyslogPort := 5000 + GinkgoParallelProcess()*100 + 6
 
 	ca, err := certtest.BuildCA("otel")
 	Expect(err).NotTo(HaveOccurred())
 	cert, err := ca.BuildSignedCertificate("egress")
 	Expect(err).NotTo(HaveOccurred())
 
+	caPem, err := ca.CertificatePEM()
+	Expect(err).NotTo(HaveOccurred())
+	caFile := filepath.Join(GinkgoT().TempDir(), "ca.crt")
+	Expect(os.WriteFile(caFile, caPem, 0600)).To(Succeed())
+
 	return OTelConfigVars{
-		IngressOTLPPort: ingressOTLPPort,
-		EgressOTLPPort:  egressOTLPPort,
-		MetricsPort:     metricsPort,
-		Port:            port,
-		Cert:            cert,
-		CA:              ca,
+		IngressOTLPPort:        ingressOTLPPort,
+		EgressOTLPPort:         egressOTLPPort,
+		MetricsPort:            metricsPort,
+		Port:                   port,
+		IngressLoggregatorPort: ingressLoggregatorPort,
+		EgressLoggregatorPort:  egressLoggregatorPort,
+		EgressSyslogPort:       egressSyslogPort,
+		StorageDir:             filepath.Join(GinkgoT().TempDir(), "otel-collector"),
+		Cert:                   cert,
+		CA:                     ca,
+		CaFile:                 caFile,
 	}
 
 }
@@ -390,6 +427,19 @@ func (o *OTelConfigVars) TLSCert() (tls.Certificate, error) {
 	return tlsCert, err
 }
 
+func (o OTelConfigVars) MutualTLSConfig() (*tls.Config, error) {
+	tlsConfig, err := o.CaAsTLSConfig()
+	if err != nil {
+		return tlsConfig, err
+	}
+	tlsCert, err := o.Cert.TLSCertificate()
+	if err != nil {
+		return tlsConfig, err
+	}
+	tlsConfig.Certificates = []tls.Certificate{tlsCert}
+	return tlsConfig, nil
+}
+
 func (o OTelConfigVars) CaAsTLSConfig() (*tls.Config, error) {
 	certPool := x509.NewCertPool()
 	caPem, err := o.CA.CertificatePEM()
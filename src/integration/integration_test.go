@@ -1,8 +1,10 @@
 package integration_test
 
 import (
+	"bufio"
 	"bytes"
 	"context"
+	"crypto/tls"
 	"embed"
 	"fmt"
 	"io"
@@ -14,6 +16,7 @@ import (
 	"text/template"
 	"time"
 
+	"code.cloudfoundry.org/go-loggregator/v10/rpc/loggregator_v2"
 	"github.com/google/go-cmp/cmp"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -45,10 +48,9 @@ var _ = Describe("OTel Collector", func() {
 	var lsc collogspb.LogsServiceClient
 	var otelConfigVars OTelConfigVars
 	var otelConfigPath string
+	var otelConfigFile string
 
-	BeforeEach(func() {
-		otelConfigVars = NewOTELConfigVars()
-
+	startOtelReceiver := func() {
 		lis, err := net.Listen("tcp", fmt.Sprintf(":%d", otelConfigVars.EgressOTLPPort))
 		Expect(err).NotTo(HaveOccurred())
 
@@ -56,15 +58,27 @@ var _ = Describe("OTel Collector", func() {
 		Expect(err).NotTo(HaveOccurred())
 		testOtelReceiver = grpc.NewServer(grpc.Creds(credentials.NewServerTLSFromCert(&tlsCert)))
 
-		fakeMetricsServiceServer = NewFakeMetricsServiceServer()
 		colmetricspb.RegisterMetricsServiceServer(testOtelReceiver, fakeMetricsServiceServer)
-
-		fakeTracesServiceServer = NewFakeTracesServiceServer()
 		coltracepb.RegisterTraceServiceServer(testOtelReceiver, fakeTracesServiceServer)
-
-		fakeLogsServiceServer = NewFakeLogsServiceServer()
 		collogspb.RegisterLogsServiceServer(testOtelReceiver, fakeLogsServiceServer)
 		go testOtelReceiver.Serve(lis)
+	}
+
+	startCollector := func() {
+		cmd := exec.Command(componentPaths.Collector, fmt.Sprintf("--config=file:%s", otelConfigFile))
+		var err error
+		otelCollectorSession, err = gexec.Start(cmd, GinkgoWriter, GinkgoWriter)
+		Expect(err).NotTo(HaveOccurred())
+		Eventually(otelCollectorSession.Err, 10*time.Second).Should(gbytes.Say(`Everything is ready. Begin running and processing data.`))
+	}
+
+	BeforeEach(func() {
+		otelConfigVars = NewOTELConfigVars()
+
+		fakeMetricsServiceServer = NewFakeMetricsServiceServer()
+		fakeTracesServiceServer = NewFakeTracesServiceServer()
+		fakeLogsServiceServer = NewFakeLogsServiceServer()
+		startOtelReceiver()
 
 		ca, err := otelConfigVars.CaAsTLSConfig()
 		Expect(err).NotTo(HaveOccurred())
@@ -86,17 +100,14 @@ var _ = Describe("OTel Collector", func() {
 		dir := fmt.Sprintf("./tmp-%d", GinkgoParallelProcess())
 		os.MkdirAll(dir, 0700)
 		DeferCleanup(os.RemoveAll, dir)
-		configPath := filepath.Join(dir, "config.yml")
+		otelConfigFile = filepath.Join(dir, "config.yml")
 
 		buf := new(bytes.Buffer)
 		err = t.Execute(buf, otelConfigVars)
 		Expect(err).NotTo(HaveOccurred())
-		os.WriteFile(configPath, buf.Bytes(), 0660)
+		os.WriteFile(otelConfigFile, buf.Bytes(), 0660)
 
-		cmd := exec.Command(componentPaths.Collector, fmt.Sprintf("--config=file:%s", configPath))
-		otelCollectorSession, err = gexec.Start(cmd, GinkgoWriter, GinkgoWriter)
-		Expect(err).NotTo(HaveOccurred())
-		Eventually(otelCollectorSession.Err, 10*time.Second).Should(gbytes.Say(`Everything is ready. Begin running and processing data.`))
+		startCollector()
 	})
 
 	AfterEach(func() {
@@ -168,6 +179,68 @@ var _ = Describe("OTel Collector", func() {
 		})
 	})
 
+	Describe("persistent sending queue", func() {
+		BeforeEach(func() {
+			otelConfigPath = "persistent_queue.yml"
+		})
+
+		It("redelivers logs queued on disk when the collector is killed", func() {
+			testOtelReceiver.Stop()
+
+			sl := NewSimpleLog()
+			_, err := lsc.Export(context.Background(), &collogspb.ExportLogsServiceRequest{
+				ResourceLogs: []*logspb.ResourceLogs{&sl},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Eventually(otelCollectorSession.Err, 5).Should(gbytes.Say(`Exporting failed`))
+
+			otelCollectorSession.Kill()
+			Eventually(otelCollectorSession, 5).Should(gexec.Exit())
+			Expect(fakeLogsServiceServer.ExportLogsServiceRequest).NotTo(Receive())
+
+			startOtelReceiver()
+			startCollector()
+
+			var elsr *collogspb.ExportLogsServiceRequest
+			Eventually(fakeLogsServiceServer.ExportLogsServiceRequest, 10).Should(Receive(&elsr))
+			Expect(cmp.Diff(elsr.GetResourceLogs()[0], &sl, protocmp.Transform())).To(BeEmpty())
+		})
+	})
+
+	Describe("configuration reload", func() {
+		BeforeEach(func() {
+			otelConfigPath = "reload.yml"
+		})
+
+		exportLog := func() {
+			sl := NewSimpleLog()
+			_, err := lsc.Export(context.Background(), &collogspb.ExportLogsServiceRequest{
+				ResourceLogs: []*logspb.ResourceLogs{&sl},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			var elsr *collogspb.ExportLogsServiceRequest
+			Eventually(fakeLogsServiceServer.ExportLogsServiceRequest, 5).Should(Receive(&elsr))
+			Expect(cmp.Diff(elsr.GetResourceLogs()[0], &sl, protocmp.Transform())).To(BeEmpty())
+		}
+
+		It("keeps running an invalid configuration change and reloads a valid one", func() {
+			config, err := os.ReadFile(otelConfigFile)
+			Expect(err).NotTo(HaveOccurred())
+
+			invalid := bytes.ReplaceAll(config, []byte("exporters: [otlp, file]"), []byte("exporters: [otlp, missing]"))
+			Expect(os.WriteFile(otelConfigFile, invalid, 0660)).To(Succeed())
+			Eventually(otelCollectorSession.Err, 15).Should(gbytes.Say(`Not reloading the configuration`))
+			Expect(otelCollectorSession).NotTo(gexec.Exit())
+			exportLog()
+
+			valid := bytes.ReplaceAll(config, []byte("exporters: [otlp, file]"), []byte("exporters: [otlp]"))
+			Expect(os.WriteFile(otelConfigFile, valid, 0660)).To(Succeed())
+			Eventually(otelCollectorSession.Err, 15).Should(gbytes.Say(`Configuration changed, reloading`))
+			Eventually(otelCollectorSession.Err, 10).Should(gbytes.Say(`Everything is ready. Begin running and processing data.`))
+			exportLog()
+		})
+	})
+
 	Describe("traces(spans)", func() {
 		BeforeEach(func() {
 			otelConfigPath = "simple.yml"
@@ -184,4 +257,167 @@ var _ = Describe("OTel Collector", func() {
 			Expect(cmp.Diff(etsr.GetResourceSpans()[0], &st, protocmp.Transform())).To(BeEmpty())
 		})
 	})
+
+	Describe("loggregator v2 ingress", func() {
+		var ingressClient loggregator_v2.IngressClient
+
+		BeforeEach(func() {
+			otelConfigPath = "loggregator_receiver.yml"
+
+			tlsConfig, err := otelConfigVars.MutualTLSConfig()
+			Expect(err).NotTo(HaveOccurred())
+			conn, err := grpc.NewClient(fmt.Sprintf("127.0.0.1:%d", otelConfigVars.IngressLoggregatorPort),
+				grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)),
+			)
+			Expect(err).NotTo(HaveOccurred())
+			ingressClient = loggregator_v2.NewIngressClient(conn)
+		})
+
+		It("forwards log envelopes as otlp logs", func() {
+			_, err := ingressClient.Send(context.Background(), &loggregator_v2.EnvelopeBatch{
+				Batch: []*loggregator_v2.Envelope{{
+					Timestamp:  1741217715635087516,
+					SourceId:   "64f96e84-e84d-4543-bf85-9cbfc567aa44",
+					InstanceId: "0",
+					Tags:       map[string]string{"source_type": "APP/PROC/WEB"},
+					Message: &loggregator_v2.Envelope_Log{
+						Log: &loggregator_v2.Log{Payload: []byte("Added process: \"web\"")},
+					},
+				}},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			var elsr *collogspb.ExportLogsServiceRequest
+			Eventually(fakeLogsServiceServer.ExportLogsServiceRequest, 5).Should(Receive(&elsr))
+			record := elsr.GetResourceLogs()[0].GetScopeLogs()[0].GetLogRecords()[0]
+			Expect(record.GetBody().GetStringValue()).To(Equal("Added process: \"web\""))
+			Expect(record.GetTimeUnixNano()).To(Equal(uint64(1741217715635087516)))
+			Expect(record.GetAttributes()).To(ContainElements(
+				HaveField("Key", "source_id"),
+				HaveField("Key", "instance_id"),
+				HaveField("Key", "source_type"),
+			))
+		})
+
+		It("forwards gauge envelopes as otlp metrics", func() {
+			_, err := ingressClient.Send(context.Background(), &loggregator_v2.EnvelopeBatch{
+				Batch: []*loggregator_v2.Envelope{{
+					SourceId: "system_metrics_agent",
+					Message: &loggregator_v2.Envelope_Gauge{
+						Gauge: &loggregator_v2.Gauge{Metrics: map[string]*loggregator_v2.GaugeValue{
+							"system_disk_persistent_read_bytes": {Unit: "Bytes", Value: 5436416},
+						}},
+					},
+				}},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			var emsr *colmetricspb.ExportMetricsServiceRequest
+			Eventually(fakeMetricsServiceServer.ExportMetricsServiceRequests, 5).Should(Receive(&emsr))
+			metric := emsr.GetResourceMetrics()[0].GetScopeMetrics()[0].GetMetrics()[0]
+			Expect(metric.GetName()).To(Equal("system_disk_persistent_read_bytes"))
+			Expect(metric.GetGauge().GetDataPoints()[0].GetAsDouble()).To(Equal(float64(5436416)))
+		})
+	})
+
+	Describe("loggregator v2 egress", func() {
+		var fakeIngressServer FakeIngressServer
+
+		BeforeEach(func() {
+			otelConfigPath = "loggregator_exporter.yml"
+
+			lis, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", otelConfigVars.EgressLoggregatorPort))
+			Expect(err).NotTo(HaveOccurred())
+
+			tlsConfig, err := otelConfigVars.MutualTLSConfig()
+			Expect(err).NotTo(HaveOccurred())
+			tlsConfig.ClientCAs = tlsConfig.RootCAs
+			tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+
+			ingressServer := grpc.NewServer(grpc.Creds(credentials.NewTLS(tlsConfig)))
+			fakeIngressServer = NewFakeIngressServer()
+			loggregator_v2.RegisterIngressServer(ingressServer, fakeIngressServer)
+			go ingressServer.Serve(lis)
+			DeferCleanup(ingressServer.Stop)
+		})
+
+		It("sends otlp logs as log envelopes", func() {
+			sl := NewSimpleLog()
+			_, err := lsc.Export(context.Background(), &collogspb.ExportLogsServiceRequest{
+				ResourceLogs: []*logspb.ResourceLogs{&sl},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			var batch *loggregator_v2.EnvelopeBatch
+			Eventually(fakeIngressServer.EnvelopeBatches, 5).Should(Receive(&batch))
+			envelope := batch.GetBatch()[0]
+			Expect(envelope.GetSourceId()).To(Equal("64f96e84-e84d-4543-bf85-9cbfc567aa44"))
+			Expect(envelope.GetInstanceId()).To(Equal("0"))
+			Expect(envelope.GetLog().GetPayload()).To(Equal([]byte("Added process: \"web\"")))
+			Expect(envelope.GetTags()).To(HaveKeyWithValue("space_name", "tanzu-hub-collector"))
+		})
+
+		It("sends otlp gauges as gauge envelopes", func() {
+			sm := NewSimpleResourceMetrics()
+			_, err := msc.Export(context.Background(), &colmetricspb.ExportMetricsServiceRequest{
+				ResourceMetrics: []*metricspb.ResourceMetrics{&sm},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			var batch *loggregator_v2.EnvelopeBatch
+			Eventually(fakeIngressServer.EnvelopeBatches, 5).Should(Receive(&batch))
+			name := sm.GetScopeMetrics()[0].GetMetrics()[0].GetName()
+			Expect(batch.GetBatch()[0].GetGauge().GetMetrics()).To(HaveKey(name))
+		})
+	})
+
+	Describe("syslog egress", func() {
+		var syslogMessages chan string
+
+		BeforeEach(func() {
+			otelConfigPath = "syslog_exporter.yml"
+
+			tlsCert, err := otelConfigVars.TLSCert()
+			Expect(err).NotTo(HaveOccurred())
+			lis, err := tls.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", otelConfigVars.EgressSyslogPort), &tls.Config{
+				Certificates: []tls.Certificate{tlsCert},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			DeferCleanup(lis.Close)
+
+			syslogMessages = make(chan string, 10)
+			go func() {
+				conn, err := lis.Accept()
+				if err != nil {
+					return
+				}
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+				for {
+					var length int
+					if _, err := fmt.Fscanf(r, "%d ", &length); err != nil {
+						return
+					}
+					msg := make([]byte, length)
+					if _, err := io.ReadFull(r, msg); err != nil {
+						return
+					}
+					syslogMessages <- string(msg)
+				}
+			}()
+		})
+
+		It("sends otlp logs as octet counted RFC 5424 messages", func() {
+			sl := NewSimpleLog()
+			_, err := lsc.Export(context.Background(), &collogspb.ExportLogsServiceRequest{
+				ResourceLogs: []*logspb.ResourceLogs{&sl},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			var msg string
+			Eventually(syslogMessages, 5).Should(Receive(&msg))
+			Expect(msg).To(MatchRegexp(`^<14>1 \S+ system.tanzu-hub-collector.tanzu-hub-sli-test-app 64f96e84-e84d-4543-bf85-9cbfc567aa44 \[API/0\] - \[tags@47450 .*\] Added process: "web"\n$`))
+			Expect(msg).To(ContainSubstring(`space_name="tanzu-hub-collector"`))
+		})
+	})
 })
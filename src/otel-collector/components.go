@@ -16,12 +16,32 @@ import (
 	prometheusexporter "github.com/open-telemetry/opentelemetry-collector-contrib/exporter/prometheusexporter"
 	prometheusremotewriteexporter "github.com/open-telemetry/opentelemetry-collector-contrib/exporter/prometheusremotewriteexporter"
 	splunkhecexporter "github.com/open-telemetry/opentelemetry-collector-contrib/exporter/splunkhecexporter"
+	loggregatorexporter "code.cloudfoundry.org/otel-collector-release/src/components/exporter/loggregatorexporter"
+	syslogexporter "code.cloudfoundry.org/otel-collector-release/src/components/exporter/syslogexporter"
 	pprofextension "github.com/open-telemetry/opentelemetry-collector-contrib/extension/pprofextension"
+	diskstorageextension "code.cloudfoundry.org/otel-collector-release/src/components/extension/diskstorageextension"
+	healthextension "code.cloudfoundry.org/otel-collector-release/src/components/extension/healthextension"
+	uaaauthextension "code.cloudfoundry.org/otel-collector-release/src/components/extension/uaaauthextension"
+	clientcertauthextension "code.cloudfoundry.org/otel-collector-release/src/components/extension/clientcertauthextension"
+	instanceidentityauthextension "code.cloudfoundry.org/otel-collector-release/src/components/extension/instanceidentityauthextension"
 	batchprocessor "go.opentelemetry.io/collector/processor/batchprocessor"
 	memorylimiterprocessor "go.opentelemetry.io/collector/processor/memorylimiterprocessor"
 	transformprocessor "github.com/open-telemetry/opentelemetry-collector-contrib/processor/transformprocessor"
 	filterprocessor "github.com/open-telemetry/opentelemetry-collector-contrib/processor/filterprocessor"
+	boshresourceprocessor "code.cloudfoundry.org/otel-collector-release/src/components/processor/boshresourceprocessor"
+	cfsemconvprocessor "code.cloudfoundry.org/otel-collector-release/src/components/processor/cfsemconvprocessor"
+	capimetadataprocessor "code.cloudfoundry.org/otel-collector-release/src/components/processor/capimetadataprocessor"
+	ratelimitprocessor "code.cloudfoundry.org/otel-collector-release/src/components/processor/ratelimitprocessor"
+	cardinalitylimitprocessor "code.cloudfoundry.org/otel-collector-release/src/components/processor/cardinalitylimitprocessor"
+	redactionprocessor "code.cloudfoundry.org/otel-collector-release/src/components/processor/redactionprocessor"
+	deltatocumulativeprocessor "code.cloudfoundry.org/otel-collector-release/src/components/processor/deltatocumulativeprocessor"
+	multilineprocessor "code.cloudfoundry.org/otel-collector-release/src/components/processor/multilineprocessor"
+	senderidentityprocessor "code.cloudfoundry.org/otel-collector-release/src/components/processor/senderidentityprocessor"
 	otlpreceiver "go.opentelemetry.io/collector/receiver/otlpreceiver"
+	loggregatorreceiver "code.cloudfoundry.org/otel-collector-release/src/components/receiver/loggregatorreceiver"
+	redmetricsconnector "code.cloudfoundry.org/otel-collector-release/src/components/connector/redmetricsconnector"
+	logcountconnector "code.cloudfoundry.org/otel-collector-release/src/components/connector/logcountconnector"
+	attributeroutingconnector "code.cloudfoundry.org/otel-collector-release/src/components/connector/attributeroutingconnector"
 )
 
 func components() (otelcol.Factories, error) {
@@ -30,21 +50,33 @@ func components() (otelcol.Factories, error) {
 
 	factories.Extensions, err = otelcol.MakeFactoryMap[extension.Factory](
 		pprofextension.NewFactory(),
+		diskstorageextension.NewFactory(),
+		healthextension.NewFactory(),
+		uaaauthextension.NewFactory(),
+		clientcertauthextension.NewFactory(),
+		instanceidentityauthextension.NewFactory(),
 	)
 	if err != nil {
 		return otelcol.Factories{}, err
 	}
 	factories.ExtensionModules = make(map[component.Type]string, len(factories.Extensions))
 	factories.ExtensionModules[pprofextension.NewFactory().Type()] = "github.com/open-telemetry/opentelemetry-collector-contrib/extension/pprofextension v0.129.0"
+	factories.ExtensionModules[diskstorageextension.NewFactory().Type()] = "code.cloudfoundry.org/otel-collector-release/src/components/extension/diskstorageextension v0.0.0"
+	factories.ExtensionModules[healthextension.NewFactory().Type()] = "code.cloudfoundry.org/otel-collector-release/src/components/extension/healthextension v0.0.0"
+	factories.ExtensionModules[uaaauthextension.NewFactory().Type()] = "code.cloudfoundry.org/otel-collector-release/src/components/extension/uaaauthextension v0.0.0"
+	factories.ExtensionModules[clientcertauthextension.NewFactory().Type()] = "code.cloudfoundry.org/otel-collector-release/src/components/extension/clientcertauthextension v0.0.0"
+	factories.ExtensionModules[instanceidentityauthextension.NewFactory().Type()] = "code.cloudfoundry.org/otel-collector-release/src/components/extension/instanceidentityauthextension v0.0.0"
 
 	factories.Receivers, err = otelcol.MakeFactoryMap[receiver.Factory](
 		otlpreceiver.NewFactory(),
+		loggregatorreceiver.NewFactory(),
 	)
 	if err != nil {
 		return otelcol.Factories{}, err
 	}
 	factories.ReceiverModules = make(map[component.Type]string, len(factories.Receivers))
 	factories.ReceiverModules[otlpreceiver.NewFactory().Type()] = "go.opentelemetry.io/collector/receiver/otlpreceiver v0.129.0"
+	factories.ReceiverModules[loggregatorreceiver.NewFactory().Type()] = "code.cloudfoundry.org/otel-collector-release/src/components/receiver/loggregatorreceiver v0.0.0"
 
 	factories.Exporters, err = otelcol.MakeFactoryMap[exporter.Factory](
 		otlpexporter.NewFactory(),
@@ -53,6 +85,8 @@ func components() (otelcol.Factories, error) {
 		prometheusexporter.NewFactory(),
 		prometheusremotewriteexporter.NewFactory(),
 		splunkhecexporter.NewFactory(),
+		loggregatorexporter.NewFactory(),
+		syslogexporter.NewFactory(),
 	)
 	if err != nil {
 		return otelcol.Factories{}, err
@@ -64,12 +98,23 @@ func components() (otelcol.Factories, error) {
 	factories.ExporterModules[prometheusexporter.NewFactory().Type()] = "github.com/open-telemetry/opentelemetry-collector-contrib/exporter/prometheusexporter v0.129.0"
 	factories.ExporterModules[prometheusremotewriteexporter.NewFactory().Type()] = "github.com/open-telemetry/opentelemetry-collector-contrib/exporter/prometheusremotewriteexporter v0.129.0"
 	factories.ExporterModules[splunkhecexporter.NewFactory().Type()] = "github.com/open-telemetry/opentelemetry-collector-contrib/exporter/splunkhecexporter v0.129.0"
+	factories.ExporterModules[loggregatorexporter.NewFactory().Type()] = "code.cloudfoundry.org/otel-collector-release/src/components/exporter/loggregatorexporter v0.0.0"
+	factories.ExporterModules[syslogexporter.NewFactory().Type()] = "code.cloudfoundry.org/otel-collector-release/src/components/exporter/syslogexporter v0.0.0"
 
 	factories.Processors, err = otelcol.MakeFactoryMap[processor.Factory](
 		batchprocessor.NewFactory(),
 		memorylimiterprocessor.NewFactory(),
 		transformprocessor.NewFactory(),
 		filterprocessor.NewFactory(),
+		boshresourceprocessor.NewFactory(),
+		cfsemconvprocessor.NewFactory(),
+		capimetadataprocessor.NewFactory(),
+		ratelimitprocessor.NewFactory(),
+		cardinalitylimitprocessor.NewFactory(),
+		redactionprocessor.NewFactory(),
+		deltatocumulativeprocessor.NewFactory(),
+		multilineprocessor.NewFactory(),
+		senderidentityprocessor.NewFactory(),
 	)
 	if err != nil {
 		return otelcol.Factories{}, err
@@ -79,13 +124,28 @@ func components() (otelcol.Factories, error) {
 	factories.ProcessorModules[memorylimiterprocessor.NewFactory().Type()] = "go.opentelemetry.io/collector/processor/memorylimiterprocessor v0.129.0"
 	factories.ProcessorModules[transformprocessor.NewFactory().Type()] = "github.com/open-telemetry/opentelemetry-collector-contrib/processor/transformprocessor v0.129.0"
 	factories.ProcessorModules[filterprocessor.NewFactory().Type()] = "github.com/open-telemetry/opentelemetry-collector-contrib/processor/filterprocessor v0.129.0"
+	factories.ProcessorModules[boshresourceprocessor.NewFactory().Type()] = "code.cloudfoundry.org/otel-collector-release/src/components/processor/boshresourceprocessor v0.0.0"
+	factories.ProcessorModules[cfsemconvprocessor.NewFactory().Type()] = "code.cloudfoundry.org/otel-collector-release/src/components/processor/cfsemconvprocessor v0.0.0"
+	factories.ProcessorModules[capimetadataprocessor.NewFactory().Type()] = "code.cloudfoundry.org/otel-collector-release/src/components/processor/capimetadataprocessor v0.0.0"
+	factories.ProcessorModules[ratelimitprocessor.NewFactory().Type()] = "code.cloudfoundry.org/otel-collector-release/src/components/processor/ratelimitprocessor v0.0.0"
+	factories.ProcessorModules[cardinalitylimitprocessor.NewFactory().Type()] = "code.cloudfoundry.org/otel-collector-release/src/components/processor/cardinalitylimitprocessor v0.0.0"
+	factories.ProcessorModules[redactionprocessor.NewFactory().Type()] = "code.cloudfoundry.org/otel-collector-release/src/components/processor/redactionprocessor v0.0.0"
+	factories.ProcessorModules[deltatocumulativeprocessor.NewFactory().Type()] = "code.cloudfoundry.org/otel-collector-release/src/components/processor/deltatocumulativeprocessor v0.0.0"
+	factories.ProcessorModules[multilineprocessor.NewFactory().Type()] = "code.cloudfoundry.org/otel-collector-release/src/components/processor/multilineprocessor v0.0.0"
+	factories.ProcessorModules[senderidentityprocessor.NewFactory().Type()] = "code.cloudfoundry.org/otel-collector-release/src/components/processor/senderidentityprocessor v0.0.0"
 
 	factories.Connectors, err = otelcol.MakeFactoryMap[connector.Factory](
+		redmetricsconnector.NewFactory(),
+		logcountconnector.NewFactory(),
+		attributeroutingconnector.NewFactory(),
 	)
 	if err != nil {
 		return otelcol.Factories{}, err
 	}
 	factories.ConnectorModules = make(map[component.Type]string, len(factories.Connectors))
+	factories.ConnectorModules[redmetricsconnector.NewFactory().Type()] = "code.cloudfoundry.org/otel-collector-release/src/components/connector/redmetricsconnector v0.0.0"
+	factories.ConnectorModules[logcountconnector.NewFactory().Type()] = "code.cloudfoundry.org/otel-collector-release/src/components/connector/logcountconnector v0.0.0"
+	factories.ConnectorModules[attributeroutingconnector.NewFactory().Type()] = "code.cloudfoundry.org/otel-collector-release/src/components/connector/attributeroutingconnector v0.0.0"
 
 	return factories, nil
 }
@@ -5,6 +5,32 @@ module code.cloudfoundry.org/otel-collector-release/src/otel-collector
 go 1.23.0
 
 require (
+	code.cloudfoundry.org/otel-collector-release/src/components/extension/instanceidentityauthextension v0.0.0
+	code.cloudfoundry.org/otel-collector-release/src/components/processor/senderidentityprocessor v0.0.0
+	code.cloudfoundry.org/otel-collector-release/src/components/extension/clientcertauthextension v0.0.0
+	code.cloudfoundry.org/otel-collector-release/src/components/extension/uaaauthextension v0.0.0
+	code.cloudfoundry.org/otel-collector-release/src/components/extension/healthextension v0.0.0
+	code.cloudfoundry.org/otel-collector-release/src/components/provider/watchfileprovider v0.0.0
+	code.cloudfoundry.org/otel-collector-release/src/components/provider/credhubprovider v0.0.0
+	code.cloudfoundry.org/otel-collector-release/src/components/provider/fragmentsprovider v0.0.0
+	code.cloudfoundry.org/otel-collector-release/src/components/provider/boshprovider v0.0.0
+	code.cloudfoundry.org/otel-collector-release/src/components/converter/cfconverter v0.0.0
+	code.cloudfoundry.org/otel-collector-release/src/components/command/validatecf v0.0.0
+	code.cloudfoundry.org/otel-collector-release/src/components/extension/diskstorageextension v0.0.0
+	code.cloudfoundry.org/otel-collector-release/src/components/connector/attributeroutingconnector v0.0.0
+	code.cloudfoundry.org/otel-collector-release/src/components/connector/logcountconnector v0.0.0
+	code.cloudfoundry.org/otel-collector-release/src/components/connector/redmetricsconnector v0.0.0
+	code.cloudfoundry.org/otel-collector-release/src/components/processor/multilineprocessor v0.0.0
+	code.cloudfoundry.org/otel-collector-release/src/components/processor/deltatocumulativeprocessor v0.0.0
+	code.cloudfoundry.org/otel-collector-release/src/components/processor/redactionprocessor v0.0.0
+	code.cloudfoundry.org/otel-collector-release/src/components/processor/cardinalitylimitprocessor v0.0.0
+	code.cloudfoundry.org/otel-collector-release/src/components/processor/ratelimitprocessor v0.0.0
+	code.cloudfoundry.org/otel-collector-release/src/components/processor/capimetadataprocessor v0.0.0
+	code.cloudfoundry.org/otel-collector-release/src/components/processor/cfsemconvprocessor v0.0.0
+	code.cloudfoundry.org/otel-collector-release/src/components/processor/boshresourceprocessor v0.0.0
+	code.cloudfoundry.org/otel-collector-release/src/components/exporter/syslogexporter v0.0.0
+	code.cloudfoundry.org/otel-collector-release/src/components/exporter/loggregatorexporter v0.0.0
+	code.cloudfoundry.org/otel-collector-release/src/components/receiver/loggregatorreceiver v0.0.0
 	github.com/open-telemetry/opentelemetry-collector-contrib/exporter/fileexporter v0.129.0
 	github.com/open-telemetry/opentelemetry-collector-contrib/exporter/prometheusexporter v0.129.0
 	github.com/open-telemetry/opentelemetry-collector-contrib/exporter/prometheusremotewriteexporter v0.129.0
@@ -34,6 +60,8 @@ require (
 	cloud.google.com/go/auth v0.16.0 // indirect
 	cloud.google.com/go/auth/oauth2adapt v0.2.8 // indirect
 	cloud.google.com/go/compute/metadata v0.6.0 // indirect
+	code.cloudfoundry.org/go-loggregator/v10 v10.2.0 // indirect
+	code.cloudfoundry.org/otel-collector-release/src/components/internal v0.0.0 // indirect
 	github.com/Azure/azure-sdk-for-go/sdk/azcore v1.18.0 // indirect
 	github.com/Azure/azure-sdk-for-go/sdk/azidentity v1.9.0 // indirect
 	github.com/Azure/azure-sdk-for-go/sdk/internal v1.11.1 // indirect
@@ -219,7 +247,7 @@ require (
 	gonum.org/v1/gonum v0.16.0 // indirect
 	google.golang.org/api v0.230.0 // indirect
 	google.golang.org/genproto/googleapis/api v0.0.0-20250519155744-55703ea1f237 // indirect
-	google.golang.org/genproto/googleapis/rpc v0.0.0-20250519155744-55703ea1f237 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20250603155806-513f23925822 // indirect
 	google.golang.org/grpc v1.73.0 // indirect
 	google.golang.org/protobuf v1.36.6 // indirect
 	gopkg.in/natefinch/lumberjack.v2 v2.2.1 // indirect
@@ -231,3 +259,57 @@ require (
 	k8s.io/utils v0.0.0-20241104100929-3ea5e8cea738 // indirect
 	sigs.k8s.io/yaml v1.4.0 // indirect
 )
+
+replace code.cloudfoundry.org/otel-collector-release/src/components/receiver/loggregatorreceiver => ../components/receiver/loggregatorreceiver
+
+replace code.cloudfoundry.org/otel-collector-release/src/components/exporter/loggregatorexporter => ../components/exporter/loggregatorexporter
+
+replace code.cloudfoundry.org/otel-collector-release/src/components/exporter/syslogexporter => ../components/exporter/syslogexporter
+
+replace code.cloudfoundry.org/otel-collector-release/src/components/processor/boshresourceprocessor => ../components/processor/boshresourceprocessor
+
+replace code.cloudfoundry.org/otel-collector-release/src/components/processor/cfsemconvprocessor => ../components/processor/cfsemconvprocessor
+
+replace code.cloudfoundry.org/otel-collector-release/src/components/processor/capimetadataprocessor => ../components/processor/capimetadataprocessor
+
+replace code.cloudfoundry.org/otel-collector-release/src/components/processor/ratelimitprocessor => ../components/processor/ratelimitprocessor
+
+replace code.cloudfoundry.org/otel-collector-release/src/components/processor/cardinalitylimitprocessor => ../components/processor/cardinalitylimitprocessor
+
+replace code.cloudfoundry.org/otel-collector-release/src/components/processor/redactionprocessor => ../components/processor/redactionprocessor
+
+replace code.cloudfoundry.org/otel-collector-release/src/components/processor/deltatocumulativeprocessor => ../components/processor/deltatocumulativeprocessor
+
+replace code.cloudfoundry.org/otel-collector-release/src/components/processor/multilineprocessor => ../components/processor/multilineprocessor
+
+replace code.cloudfoundry.org/otel-collector-release/src/components/connector/redmetricsconnector => ../components/connector/redmetricsconnector
+
+replace code.cloudfoundry.org/otel-collector-release/src/components/connector/logcountconnector => ../components/connector/logcountconnector
+
+replace code.cloudfoundry.org/otel-collector-release/src/components/connector/attributeroutingconnector => ../components/connector/attributeroutingconnector
+
+replace code.cloudfoundry.org/otel-collector-release/src/components/extension/diskstorageextension => ../components/extension/diskstorageextension
+
+replace code.cloudfoundry.org/otel-collector-release/src/components/extension/healthextension => ../components/extension/healthextension
+
+replace code.cloudfoundry.org/otel-collector-release/src/components/provider/watchfileprovider => ../components/provider/watchfileprovider
+
+replace code.cloudfoundry.org/otel-collector-release/src/components/provider/credhubprovider => ../components/provider/credhubprovider
+
+replace code.cloudfoundry.org/otel-collector-release/src/components/provider/fragmentsprovider => ../components/provider/fragmentsprovider
+
+replace code.cloudfoundry.org/otel-collector-release/src/components/provider/boshprovider => ../components/provider/boshprovider
+
+replace code.cloudfoundry.org/otel-collector-release/src/components/command/validatecf => ../components/command/validatecf
+
+replace code.cloudfoundry.org/otel-collector-release/src/components/converter/cfconverter => ../components/converter/cfconverter
+
+replace code.cloudfoundry.org/otel-collector-release/src/components/extension/uaaauthextension => ../components/extension/uaaauthextension
+
+replace code.cloudfoundry.org/otel-collector-release/src/components/extension/clientcertauthextension => ../components/extension/clientcertauthextension
+
+replace code.cloudfoundry.org/otel-collector-release/src/components/processor/senderidentityprocessor => ../components/processor/senderidentityprocessor
+
+replace code.cloudfoundry.org/otel-collector-release/src/components/extension/instanceidentityauthextension => ../components/extension/instanceidentityauthextension
+
+replace code.cloudfoundry.org/otel-collector-release/src/components/internal => ../components/internal
@@ -9,7 +9,12 @@ import (
 	"go.opentelemetry.io/collector/component"
 	"go.opentelemetry.io/collector/confmap"
 	envprovider "go.opentelemetry.io/collector/confmap/provider/envprovider"
-	fileprovider "go.opentelemetry.io/collector/confmap/provider/fileprovider"
+	watchfileprovider "code.cloudfoundry.org/otel-collector-release/src/components/provider/watchfileprovider"
+	credhubprovider "code.cloudfoundry.org/otel-collector-release/src/components/provider/credhubprovider"
+	fragmentsprovider "code.cloudfoundry.org/otel-collector-release/src/components/provider/fragmentsprovider"
+	boshprovider "code.cloudfoundry.org/otel-collector-release/src/components/provider/boshprovider"
+	cfconverter "code.cloudfoundry.org/otel-collector-release/src/components/converter/cfconverter"
+	validatecf "code.cloudfoundry.org/otel-collector-release/src/components/command/validatecf"
 	"go.opentelemetry.io/collector/otelcol"
 )
 
@@ -27,15 +32,25 @@ func main() {
 			ResolverSettings: confmap.ResolverSettings{
 				ProviderFactories: []confmap.ProviderFactory{
 					envprovider.NewFactory(),
-					fileprovider.NewFactory(),
+					watchfileprovider.NewFactory(),
+					credhubprovider.NewFactory(),
+					fragmentsprovider.NewFactory(),
+					boshprovider.NewFactory(),
+				},
+				ConverterFactories: []confmap.ConverterFactory{
+					cfconverter.NewFactory(),
 				},
 			},
 		},
 		ProviderModules: map[string]string{
 			envprovider.NewFactory().Create(confmap.ProviderSettings{}).Scheme(): "go.opentelemetry.io/collector/confmap/provider/envprovider v1.36.1",
-			fileprovider.NewFactory().Create(confmap.ProviderSettings{}).Scheme(): "go.opentelemetry.io/collector/confmap/provider/fileprovider v1.36.1",
+			watchfileprovider.NewFactory().Create(confmap.ProviderSettings{}).Scheme(): "code.cloudfoundry.org/otel-collector-release/src/components/provider/watchfileprovider v0.0.0",
+			credhubprovider.NewFactory().Create(confmap.ProviderSettings{}).Scheme(): "code.cloudfoundry.org/otel-collector-release/src/components/provider/credhubprovider v0.0.0",
+			fragmentsprovider.NewFactory().Create(confmap.ProviderSettings{}).Scheme(): "code.cloudfoundry.org/otel-collector-release/src/components/provider/fragmentsprovider v0.0.0",
+			boshprovider.NewFactory().Create(confmap.ProviderSettings{}).Scheme(): "code.cloudfoundry.org/otel-collector-release/src/components/provider/boshprovider v0.0.0",
     	},
 		ConverterModules: []string{
+			"code.cloudfoundry.org/otel-collector-release/src/components/converter/cfconverter v0.0.0",
 		},
 	}
 
@@ -46,6 +61,7 @@ func main() {
 
 func runInteractive(params otelcol.CollectorSettings) error {
 	cmd := otelcol.NewCommand(params)
+	validatecf.Extend(cmd, params)
 	if err := cmd.Execute(); err != nil {
 		log.Fatalf("collector server run finished with error: %v", err)
 	}